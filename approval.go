@@ -0,0 +1,86 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import "github.com/hashicorp/raft"
+
+// ApprovalDelegate may optionally be implemented by the ApplicationIntegration
+// to sign off on every destructive change before autopilot makes it. This lets
+// applications require approval from an operator or a policy engine while
+// autopilot continues to calculate what should be done. Changes which are not
+// approved are skipped and will be reconsidered in later rounds.
+type ApprovalDelegate interface {
+	// ApproveRemoval is called before the server is removed.
+	ApproveRemoval(*Server) bool
+
+	// ApproveDemotion is called before the server is demoted to a non-voter.
+	ApproveDemotion(*Server) bool
+
+	// ApproveLeadershipTransfer is called before leadership is transferred
+	// to the server.
+	ApproveLeadershipTransfer(*Server) bool
+}
+
+// approveRemoval returns whether the delegate approves of removing the server.
+// Removals are always approved when the delegate does not implement
+// ApprovalDelegate.
+func (a *Autopilot) approveRemoval(srv *Server) bool {
+	approver, ok := a.delegate.(ApprovalDelegate)
+	if !ok {
+		return true
+	}
+
+	a.countDelegateCall("ApproveRemoval")
+	if !approver.ApproveRemoval(srv) {
+		a.roundLogger().Info("application has not approved the removal of the server", "id", srv.ID)
+		return false
+	}
+	return true
+}
+
+// approveDemotion returns whether the delegate approves of demoting the
+// server. Demotions are always approved when the delegate does not implement
+// ApprovalDelegate.
+func (a *Autopilot) approveDemotion(srv *Server) bool {
+	approver, ok := a.delegate.(ApprovalDelegate)
+	if !ok {
+		return true
+	}
+
+	a.countDelegateCall("ApproveDemotion")
+	if !approver.ApproveDemotion(srv) {
+		a.roundLogger().Info("application has not approved the demotion of the server", "id", srv.ID)
+		return false
+	}
+	return true
+}
+
+// approveLeadershipTransfer returns whether the delegate approves of
+// transferring leadership to the server. Transfers are always approved when
+// the delegate does not implement ApprovalDelegate.
+func (a *Autopilot) approveLeadershipTransfer(srv *Server) bool {
+	approver, ok := a.delegate.(ApprovalDelegate)
+	if !ok {
+		return true
+	}
+
+	a.countDelegateCall("ApproveLeadershipTransfer")
+	if !approver.ApproveLeadershipTransfer(srv) {
+		a.subsystemRoundLogger(SubsystemTransfer).Info("application has not approved the leadership transfer", "id", srv.ID)
+		return false
+	}
+	return true
+}
+
+// knownServer returns the server with the given ID from the current state.
+// Only the ID is populated when the server is not part of the state.
+func (a *Autopilot) knownServer(id raft.ServerID) *Server {
+	if state := a.GetState(); state != nil {
+		if srv, ok := state.Servers[id]; ok {
+			result := srv.Server
+			return &result
+		}
+	}
+	return &Server{ID: id}
+}
@@ -0,0 +1,68 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+type approvalDelegate struct {
+	*MockApplicationIntegration
+	*MockApprovalDelegate
+}
+
+func TestApprovalDelegate(t *testing.T) {
+	state := &State{
+		Leader: "a",
+		Servers: map[raft.ServerID]*ServerState{
+			"a": {Server: Server{ID: "a", Address: "198.18.0.1:8300"}, State: RaftLeader, Health: ServerHealth{Healthy: true}},
+			"b": {Server: Server{ID: "b", Address: "198.18.0.2:8300"}, State: RaftVoter, Health: ServerHealth{Healthy: true}},
+			"c": {Server: Server{ID: "c", Address: "198.18.0.3:8300"}, State: RaftVoter, Health: ServerHealth{Healthy: true}},
+		},
+	}
+
+	mdel := &approvalDelegate{
+		MockApplicationIntegration: NewMockApplicationIntegration(t),
+		MockApprovalDelegate:       NewMockApprovalDelegate(t),
+	}
+	mraft := NewMockRaft(t)
+
+	a := &Autopilot{
+		logger:   hclog.NewNullLogger(),
+		raft:     mraft,
+		delegate: mdel,
+	}
+
+	t.Run("demotion", func(t *testing.T) {
+		changes := RaftChanges{Demotions: []raft.ServerID{"b", "c"}}
+
+		mdel.MockApprovalDelegate.On("ApproveDemotion", &state.Servers["b"].Server).Return(false).Once()
+		mdel.MockApprovalDelegate.On("ApproveDemotion", &state.Servers["c"].Server).Return(true).Once()
+		mraft.On("DemoteVoter", raft.ServerID("c"), uint64(0), time.Duration(0)).Return(&raftIndexFuture{}).Once()
+
+		// only the approved demotion is made
+		done, err := a.applyDemotions(context.Background(), nil, state, changes)
+		require.True(t, done)
+		require.NoError(t, err)
+	})
+
+	t.Run("removal", func(t *testing.T) {
+		failed := &Server{ID: "d", NodeStatus: NodeFailed}
+		mdel.MockApprovalDelegate.On("ApproveRemoval", failed).Return(false).Once()
+
+		a.removeFailedServers([]*Server{failed})
+	})
+
+	t.Run("leadership-transfer", func(t *testing.T) {
+		mdel.MockApprovalDelegate.On("ApproveLeadershipTransfer", &state.Servers["b"].Server).Return(false).Once()
+
+		require.NoError(t, a.applyLeadershipTransfer(context.Background(), state, RaftChanges{Leader: "b"}))
+	})
+}
@@ -0,0 +1,186 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hashicorp/raft"
+)
+
+// DecisionType is the kind of action a Decision records.
+type DecisionType string
+
+const (
+	// DecisionPromote records a server being promoted to a voter.
+	DecisionPromote DecisionType = "promote"
+
+	// DecisionDemote records a voter being demoted to a non-voter.
+	DecisionDemote DecisionType = "demote"
+
+	// DecisionRemove records a stale or failed server being removed.
+	DecisionRemove DecisionType = "remove"
+
+	// DecisionRemovalFailed records an attempt to remove a server which
+	// failed. Other removals are still attempted.
+	DecisionRemovalFailed DecisionType = "removal-failed"
+
+	// DecisionSkipRemoval records a failed or stale server being kept as its
+	// removal would put the cluster at risk.
+	DecisionSkipRemoval DecisionType = "skip-removal"
+
+	// DecisionTransferLeadership records leadership being transferred.
+	DecisionTransferLeadership DecisionType = "transfer-leadership"
+)
+
+// DecisionReason is a machine readable explanation of why a Decision was made.
+type DecisionReason string
+
+const (
+	// ReasonStableNonVoter is the reason for promoting a healthy non-voter
+	// which has been stable for long enough.
+	ReasonStableNonVoter DecisionReason = "stable-non-voter"
+
+	// ReasonDemotionRequested is the reason for demoting a voter which the
+	// promoter or one of autopilot's policies no longer want as a voter.
+	ReasonDemotionRequested DecisionReason = "demotion-requested"
+
+	// ReasonStaleServer is the reason for removing a server from the Raft
+	// configuration which the application no longer knows about.
+	ReasonStaleServer DecisionReason = "stale-server"
+
+	// ReasonFailedServer is the reason for removing a server which the
+	// application reports as failed or left.
+	ReasonFailedServer DecisionReason = "failed-server"
+
+	// ReasonServerReplaced is the reason for removing a server from the Raft
+	// configuration once it has been replaced with ReplaceServer.
+	ReasonServerReplaced DecisionReason = "server-replaced"
+
+	// ReasonBelowMinQuorum is the reason for skipping a removal which would
+	// leave fewer potential voters than the configured MinQuorum.
+	ReasonBelowMinQuorum DecisionReason = "below-min-quorum"
+
+	// ReasonQuorumRisk is the reason for skipping a removal of a voter which
+	// would remove a majority of the voters.
+	ReasonQuorumRisk DecisionReason = "quorum-risk"
+
+	// ReasonLeaderNominated is the reason for transferring leadership to a
+	// server nominated to become the leader.
+	ReasonLeaderNominated DecisionReason = "leader-nominated"
+
+	// ReasonCurrentLeader is the reason for skipping the removal of the
+	// current leader. Leadership is transferred away from it first.
+	ReasonCurrentLeader DecisionReason = "current-leader"
+
+	// ReasonRemovalLimit is the reason for skipping a removal once the
+	// MaxRemovalsPerRound have been made within the round.
+	ReasonRemovalLimit DecisionReason = "removal-limit"
+)
+
+// Decision records an action autopilot took, or decided against taking,
+// along with why.
+type Decision struct {
+	// Type is the kind of action.
+	Type DecisionType
+
+	// Reason is the machine readable explanation of the decision.
+	Reason DecisionReason
+
+	// Time is when the decision was made according to autopilot's
+	// TimeProvider.
+	Time time.Time
+
+	// Round is the ID of the round during which the decision was made.
+	Round string
+
+	// ServerID is the server the decision was about.
+	ServerID raft.ServerID
+
+	// Message is a human readable description of the decision.
+	Message string
+
+	// Values holds the configuration values and observations relevant to
+	// the decision, such as the MinQuorum which prevented a removal.
+	Values map[string]string
+}
+
+// DecisionRecorder may optionally be implemented by the ApplicationIntegration
+// to receive every Decision as it is made, for example to persist an audit
+// trail. It is called synchronously and therefore should not block.
+type DecisionRecorder interface {
+	RecordDecision(Decision)
+}
+
+// decisionLog is an append only log of the most recent decisions.
+type decisionLog struct {
+	lock sync.Mutex
+
+	// size is the number of decisions retained. Zero disables the log.
+	size int
+
+	decisions []Decision
+}
+
+// append adds the decision to the log, discarding the oldest decision once
+// the log is full.
+func (l *decisionLog) append(d Decision) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.size < 1 {
+		return
+	}
+
+	if len(l.decisions) >= l.size {
+		l.decisions = l.decisions[len(l.decisions)-l.size+1:]
+	}
+	l.decisions = append(l.decisions, d)
+}
+
+// enabled returns whether decisions are being retained.
+func (l *decisionLog) enabled() bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.size > 0
+}
+
+// Decisions returns the most recent decisions from oldest to newest. The
+// number retained is set with the WithDecisionLog option and no decisions are
+// retained without it.
+func (a *Autopilot) Decisions() []Decision {
+	a.decisions.lock.Lock()
+	defer a.decisions.lock.Unlock()
+
+	result := make([]Decision, len(a.decisions.decisions))
+	copy(result, a.decisions.decisions)
+	return result
+}
+
+// recordDecision appends a decision to the log and passes it to the delegate
+// when it implements DecisionRecorder. Nothing is done when neither is the
+// case.
+func (a *Autopilot) recordDecision(typ DecisionType, reason DecisionReason, id raft.ServerID, message string, values map[string]string) {
+	recorder, ok := a.delegate.(DecisionRecorder)
+	if !ok && !a.decisions.enabled() {
+		return
+	}
+
+	d := Decision{
+		Type:     typ,
+		Reason:   reason,
+		Time:     a.now(),
+		Round:    a.currentRound(),
+		ServerID: id,
+		Message:  message,
+		Values:   values,
+	}
+
+	a.decisions.append(d)
+	if ok {
+		a.countDelegateCall("RecordDecision")
+		recorder.RecordDecision(d)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+type decisionRecordingDelegate struct {
+	*MockApplicationIntegration
+	*MockDecisionRecorder
+}
+
+func TestDecisionLog(t *testing.T) {
+	a := &Autopilot{}
+	WithDecisionLog(2)(a)
+	a.decisions.append(Decision{ServerID: "a"})
+	a.decisions.append(Decision{ServerID: "b"})
+	a.decisions.append(Decision{ServerID: "c"})
+
+	require.Equal(t, []Decision{{ServerID: "b"}, {ServerID: "c"}}, a.Decisions())
+
+	// without a size nothing is retained
+	var disabled decisionLog
+	disabled.append(Decision{ServerID: "a"})
+	require.Empty(t, disabled.decisions)
+}
+
+func TestRecordDecisions(t *testing.T) {
+	now := time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)
+
+	mtime := NewMockTimeProvider(t)
+	mtime.On("Now").Return(now)
+	mdel := &decisionRecordingDelegate{
+		MockApplicationIntegration: NewMockApplicationIntegration(t),
+		MockDecisionRecorder:       NewMockDecisionRecorder(t),
+	}
+	mdel.MockApplicationIntegration.On("AutopilotConfig").Return(&Config{MinQuorum: 3})
+	mdel.MockApplicationIntegration.On("RemoveFailedServer", &Server{ID: "c", NodeStatus: NodeFailed}).Once()
+
+	a := &Autopilot{
+		logger:   hclog.NewNullLogger(),
+		time:     mtime,
+		delegate: mdel,
+	}
+	WithDecisionLog(10)(a)
+
+	vr := &voterRegistry{eligibility: map[raft.ServerID]*voterEligibility{
+		"a": {currentVoter: true, potentialVoter: true},
+		"b": {currentVoter: true, potentialVoter: true},
+		"c": {currentVoter: true, potentialVoter: true},
+	}}
+
+	skipped := Decision{
+		Type:     DecisionSkipRemoval,
+		Reason:   ReasonBelowMinQuorum,
+		Time:     now,
+		ServerID: "c",
+		Message:  "removal would leave less voters than the minimum number allowed",
+		Values:   map[string]string{"min_quorum": "3", "potential_voters": "3"},
+	}
+	mdel.MockDecisionRecorder.On("RecordDecision", skipped).Once()
+
+	// skipped removals are only recorded when they would have been applied
+	require.Empty(t, a.adjudicateRemoval([]raft.ServerID{"c"}, vr, false))
+	require.Empty(t, a.adjudicateRemoval([]raft.ServerID{"c"}, vr, true))
+
+	removed := Decision{
+		Type:     DecisionRemove,
+		Reason:   ReasonFailedServer,
+		Time:     now,
+		ServerID: "c",
+		Message:  `removed as its node status is "failed"`,
+		Values:   map[string]string{"node_status": "failed"},
+	}
+	mdel.MockDecisionRecorder.On("RecordDecision", removed).Once()
+	a.removeFailedServers([]*Server{{ID: "c", NodeStatus: NodeFailed}})
+
+	require.Equal(t, []Decision{skipped, removed}, a.Decisions())
+}
@@ -8,6 +8,7 @@ import (
 	"sync"
 	"time"
 
+	metrics "github.com/armon/go-metrics"
 	hclog "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/raft"
 )
@@ -78,6 +79,214 @@ func WithPromoter(promoter Promoter) Option {
 	}
 }
 
+// WithPromoterTimeout returns an option to limit how long the promoter may take
+// to calculate promotions and demotions. If the promoter has not finished within
+// the given duration the reconcile round will be skipped. A zero duration, the
+// default, places no limit on the promoter.
+func WithPromoterTimeout(t time.Duration) Option {
+	return func(a *Autopilot) {
+		a.promoterTimeout = t
+	}
+}
+
+// WithPromoterFallback returns an option to fall back to the default promoter
+// after the configured promoter fails by either panicking or exceeding its
+// timeout. The default promoter will be used for subsequent rounds until
+// ReinstatePromoter is called.
+func WithPromoterFallback() Option {
+	return func(a *Autopilot) {
+		a.promoterFallback = true
+	}
+}
+
+// WithOperationLockout returns an option to configure how autopilot backs off
+// from servers that it repeatedly fails to promote, demote or remove. After
+// threshold consecutive failures the server will be locked out for the base
+// duration and each further failure doubles the lockout up to the max
+// duration. Servers are never locked out without this option or with a
+// threshold of zero. DefaultLockoutThreshold, DefaultLockoutBase and
+// DefaultLockoutMax are suitable for most clusters.
+func WithOperationLockout(threshold int, base, max time.Duration) Option {
+	return func(a *Autopilot) {
+		a.lockouts.threshold = threshold
+		a.lockouts.base = base
+		a.lockouts.max = max
+	}
+}
+
+// WithLeadershipTransferRetries returns an option to verify that leadership
+// transfers move leadership to the intended server. A transfer which fails,
+// either by returning an error or by a later autopilot state showing a
+// different leader, is retried up to the given number of times. The first
+// retry waits for the backoff which is doubled for each further retry. Zero
+// retries disables the verification.
+func WithLeadershipTransferRetries(retries int, backoff time.Duration) Option {
+	return func(a *Autopilot) {
+		a.transferRetries.retries = retries
+		a.transferRetries.backoff = backoff
+	}
+}
+
+// WithRemovalQuarantine returns an option to quarantine servers after they
+// have been removed. A server with the ID or address of a server removed within
+// the window will not be promoted to a voter, which stops crash looping servers
+// from repeatedly joining, being promoted, failing and being removed. A zero
+// window, the default, disables the quarantine.
+func WithRemovalQuarantine(window time.Duration) Option {
+	return func(a *Autopilot) {
+		a.quarantine.window = window
+	}
+}
+
+// WithMembershipChangeBudget returns an option to limit the promotions,
+// demotions and removals autopilot makes to at most max within any window of
+// the given duration. Changes beyond the budget are deferred until a later
+// round. A max of zero, the default, places no limit on membership changes.
+func WithMembershipChangeBudget(max int, window time.Duration) Option {
+	return func(a *Autopilot) {
+		a.changeBudget.max = max
+		a.changeBudget.window = window
+	}
+}
+
+// WithInvariantChecks returns an option to check every set of membership
+// changes with an InvariantChecker before making them. Changes which would
+// leave fewer voters than MinQuorum or leave the healthy voters without a
+// majority are refused with an error and an EventInvariantViolation.
+func WithInvariantChecks() Option {
+	return func(a *Autopilot) {
+		a.invariantChecks = true
+	}
+}
+
+// WithEventHandler returns an option to register a function that will be called
+// with every event autopilot emits. This option may be given multiple times to
+// register multiple handlers.
+func WithEventHandler(handler EventHandler) Option {
+	return func(a *Autopilot) {
+		if handler != nil {
+			a.eventHandlers = append(a.eventHandlers, handler)
+		}
+	}
+}
+
+// WithNotifier returns an option to register a Notifier which will be told of
+// every promotion, demotion, removal and leadership transfer autopilot makes.
+// This option may be given multiple times to register multiple notifiers.
+func WithNotifier(notifier Notifier) Option {
+	return func(a *Autopilot) {
+		if notifier != nil {
+			a.notifiers = append(a.notifiers, notifier)
+		}
+	}
+}
+
+// WithKnownServersSettleTime returns an option to set how long demotions and
+// removals are held after the delegate's known servers regress sharply, such as
+// when the application restarts and briefly reports only itself. Such
+// regressions are not detected without this option or with a zero duration.
+// DefaultKnownServersSettleTime is suitable for most applications.
+func WithKnownServersSettleTime(t time.Duration) Option {
+	return func(a *Autopilot) {
+		a.knownServers.settleTime = t
+	}
+}
+
+// WithRaftFutureWatchdog returns an option to set how long autopilot waits
+// for a Raft membership change to resolve before considering it stuck. While
+// an operation is stuck no further membership changes are made. Without this
+// option or with a zero duration futures are waited on until they resolve or
+// autopilot is stopped. DefaultRaftFutureWatchdog is suitable for most
+// clusters.
+func WithRaftFutureWatchdog(t time.Duration) Option {
+	return func(a *Autopilot) {
+		a.watchdog.timeout = t
+	}
+}
+
+// WithChangeQueueSize returns an option to set how many Raft membership
+// changes may wait to be made, one at a time, before further changes are
+// refused with ErrChangeQueueFull. DefaultChangeQueueSize is used when zero.
+func WithChangeQueueSize(size int) Option {
+	return func(a *Autopilot) {
+		a.changes.size = size
+	}
+}
+
+// WithRaftOperationTimeouts returns an option to set the timeouts for each
+// kind of Raft membership change. Slow WAN clusters may need longer timeouts
+// while fast local clusters may prefer to fail quickly. The Raft future
+// watchdog always waits somewhat longer than these timeouts.
+func WithRaftOperationTimeouts(timeouts RaftOperationTimeouts) Option {
+	return func(a *Autopilot) {
+		a.raftTimeouts = timeouts
+	}
+}
+
+// WithEnricher returns an option to register an Enricher which will be run in
+// the background between state updates. Its data is considered stale once it
+// is older than maxAge. A zero maxAge means the data never becomes stale. This
+// option may be given multiple times to register multiple enrichers.
+func WithEnricher(enricher Enricher, maxAge time.Duration) Option {
+	return func(a *Autopilot) {
+		if enricher != nil {
+			a.enrichment.add(enricher, maxAge)
+		}
+	}
+}
+
+// WithStatsFetcher returns an option to have autopilot fetch each server's
+// stats with the given StatsFetcher instead of calling the delegate's
+// FetchServerStats method. At most parallelism servers are fetched from
+// concurrently, DefaultStatsFetchParallelism when zero, and each call is
+// limited to the given timeout. A zero timeout only limits the calls by the
+// deadline of the whole fetch, which is half the update interval.
+func WithStatsFetcher(fetcher StatsFetcher, parallelism int, timeout time.Duration) Option {
+	return func(a *Autopilot) {
+		a.statsFetch = statsFetchConfig{
+			fetcher:     fetcher,
+			parallelism: parallelism,
+			timeout:     timeout,
+		}
+	}
+}
+
+// WithStateHistory returns an option to retain the given number of the most
+// recently computed states so that they can be retrieved with StateHistory.
+// Zero, the default, retains no states.
+func WithStateHistory(size int) Option {
+	return func(a *Autopilot) {
+		a.history.size = size
+	}
+}
+
+// WithDecisionLog returns an option to retain the given number of the most
+// recent promotions, demotions, removals, skipped removals and leadership
+// transfers so that they can be retrieved with Decisions. Zero, the default,
+// retains no decisions.
+func WithDecisionLog(size int) Option {
+	return func(a *Autopilot) {
+		a.decisions.size = size
+	}
+}
+
+// WithMetricsSink returns an option to emit autopilot's metrics to the given
+// sink rather than the global go-metrics instance.
+func WithMetricsSink(sink metrics.MetricSink) Option {
+	return func(a *Autopilot) {
+		a.metricSink = sink
+	}
+}
+
+// WithLocalServerID returns an option to tell autopilot the Raft ID of the
+// server it is running on. Autopilot will never remove this server, even if
+// the delegate reports it as failed or it is missing from the known servers.
+func WithLocalServerID(id raft.ServerID) Option {
+	return func(a *Autopilot) {
+		a.localServerID = id
+	}
+}
+
 // WithReconciliationDisabled returns an option to initially disable reconciliation
 // for all autopilot go routines. This may be changed in the future with calls to
 // EnableReconciliation and DisableReconciliation.
@@ -87,6 +296,15 @@ func WithReconciliationDisabled() Option {
 	}
 }
 
+// WithMembershipChangesDisabled returns an option to initially disable the
+// given kinds of membership changes. This may be changed in the future with
+// calls to EnableMembershipChanges and DisableMembershipChanges.
+func WithMembershipChangesDisabled(changes ...MembershipChangeType) Option {
+	return func(a *Autopilot) {
+		a.DisableMembershipChanges(changes...)
+	}
+}
+
 // ExecutionStatus represents the current status of the autopilot background go routines
 type ExecutionStatus string
 
@@ -94,6 +312,10 @@ const (
 	NotRunning   ExecutionStatus = "not-running"
 	Running      ExecutionStatus = "running"
 	ShuttingDown ExecutionStatus = "shutting-down"
+
+	// Standby is the status of autopilot while it only updates the state,
+	// having been started with StartStandby.
+	Standby ExecutionStatus = "standby"
 )
 
 type execInfo struct {
@@ -129,6 +351,28 @@ type Autopilot struct {
 	// for filling in parts of the autopilot state that the core module doesn't
 	// control such as the Ext fields on the Server and State types.
 	promoter Promoter
+	// promoterTimeout is the maximum amount of time the promoter may take to
+	// calculate promotions and demotions. Zero means there is no limit.
+	promoterTimeout time.Duration
+	// promoterFallback controls whether the default promoter should be used
+	// after the configured promoter fails.
+	promoterFallback bool
+	// promoterFallbackActive is whether the default promoter is currently
+	// being used because the configured promoter failed.
+	promoterFallbackActive bool
+	// promoterLastError and promoterLastErrorTime record the failure of the
+	// promoter in the most recent reconcile round.
+	promoterLastError     string
+	promoterLastErrorTime time.Time
+	// promoterLock protects promoter, promoterFallbackActive and the
+	// promoter's last error
+	promoterLock sync.Mutex
+	// promoterSwap is held for reading by rounds and state updates so that
+	// SetPromoter only replaces the promoter between them.
+	promoterSwap sync.RWMutex
+	// promoterIsolation controls how the Config and State given to the
+	// promoter are protected from modification.
+	promoterIsolation PromoterIsolation
 	// raft is an interface that implements all the parts of the Raft library interface
 	// that we use. It is an interface to allow for mocking raft during testing.
 	raft Raft
@@ -163,11 +407,35 @@ type Autopilot struct {
 	// find and remove any dead/failed servers
 	removeDeadCh chan struct{}
 
+	// onDemandCh is used to hand rounds requested with ReconcileNow and
+	// PruneNow to the running autopilot go routines.
+	onDemandCh chan onDemandRound
+
+	// refreshCh is used to trigger an early update of the autopilot state
+	refreshCh chan struct{}
+
+	// observations are the Raft observations which trigger an immediate
+	// state update and reconciliation.
+	observations <-chan raft.Observation
+
+	// replication derives the servers' stats from Raft's observations when
+	// WithReplicationStats is used.
+	replication *replicationStats
+
+	// observedCh is used by the state updater to trigger a reconciliation
+	// round after updating the state for a Raft observation.
+	observedCh chan struct{}
+
 	// reconciliationEnabled controls whether reconciliation is enabled while
 	// autopilot is running
 	reconciliationEnabled bool
 
-	// reconciliationLock synchronizes access to reconciliationEnabled
+	// disabledChanges are the kinds of membership changes which have been
+	// disabled independently of reconciliation as a whole.
+	disabledChanges map[MembershipChangeType]struct{}
+
+	// reconciliationLock synchronizes access to reconciliationEnabled and
+	// disabledChanges
 	reconciliationLock sync.RWMutex
 
 	// leaderLock implements a cancellable mutex that will be used to ensure
@@ -184,6 +452,141 @@ type Autopilot struct {
 
 	// execLock protects access to the execution field
 	execLock sync.Mutex
+
+	// eventHandlers are the functions to call with every emitted event.
+	eventHandlers []EventHandler
+	// notifiers are told of every membership change.
+	notifiers []Notifier
+	// subscriptions are the channels to send every emitted event to.
+	subscriptions subscriptions
+
+	// firstActionConfirmed is whether the delegate has confirmed that
+	// autopilot may perform its first destructive action.
+	firstActionConfirmed bool
+	// firstActionLock protects firstActionConfirmed
+	firstActionLock sync.Mutex
+
+	// lockouts tracks failed operations against servers so that autopilot
+	// can stop acting on servers after repeated failures.
+	lockouts lockoutTracker
+
+	// quarantine tracks recently removed servers so that they are not
+	// promoted again straight away.
+	quarantine quarantineTracker
+
+	// changeBudget limits the rate of membership changes.
+	changeBudget changeBudget
+
+	// transferBudget limits the rate of leadership transfers.
+	transferBudget changeBudget
+
+	// transferBlackouts are when leadership transfers may not be made.
+	transferBlackouts []MaintenanceWindow
+
+	// invariantChecks enables checking membership changes with an
+	// InvariantChecker before they are made.
+	invariantChecks bool
+
+	// lifecycle tracks when servers were first seen and first failed so
+	// that the time taken to promote and remove them can be measured.
+	lifecycle serverLifecycle
+
+	// canaries tracks the canaries of new versions being soaked.
+	canaries canaryTracker
+
+	// failedDemotions tracks the failed voters demoted rather than removed.
+	failedDemotions failedDemotionTracker
+
+	// evacuations tracks the zones that voters are being moved out of.
+	evacuations evacuationTracker
+
+	// replacements tracks the servers being replaced with ReplaceServer.
+	replacements replacementTracker
+
+	// clock detects wall clock jumps between state updates.
+	clock clockTracker
+
+	// localServerID is the Raft ID of the server autopilot is running on. It
+	// is empty when not provided with the WithLocalServerID option.
+	localServerID raft.ServerID
+
+	// round is the ID of the reconcile or prune round in progress. It is
+	// empty when no round is in progress.
+	round string
+	// collectedChanges accumulates the membership changes made during an on
+	// demand round. It is nil when changes are not being collected.
+	collectedChanges *[]MembershipChange
+	// roundUsage accumulates the resource usage of the round in progress.
+	roundUsage *roundUsage
+	// lastRoundUsage is the resource usage of the last completed round of
+	// each kind.
+	lastRoundUsage map[RoundKind]RoundUsage
+	// roundLock protects round, collectedChanges, roundUsage and
+	// lastRoundUsage
+	roundLock sync.RWMutex
+
+	// failureTolerance tracks when the failure tolerance is exhausted and
+	// restored so that events can be emitted for both.
+	failureTolerance failureToleranceTracker
+
+	// features holds the explicitly enabled or disabled features. Features
+	// not present use their default.
+	features map[Feature]bool
+
+	// knownServers detects sharp regressions of the delegate's known servers
+	// so that destructive actions can be held while they settle.
+	knownServers knownServersTracker
+
+	// raftTimeouts are the timeouts for each kind of Raft membership change.
+	raftTimeouts RaftOperationTimeouts
+
+	// watchdog tracks Raft membership changes which have not resolved so
+	// that further changes are not issued on top of them.
+	watchdog futureWatchdog
+
+	// changes issues the Raft membership changes one at a time.
+	changes changeExecutor
+
+	// enrichment runs the registered enrichers and holds their results.
+	enrichment enrichmentTracker
+
+	// decisions retains the most recent decisions autopilot made.
+	decisions decisionLog
+
+	// history retains the most recently computed states.
+	history stateHistory
+
+	// statsFetch is how server stats are fetched when a StatsFetcher is
+	// registered in place of the delegate's FetchServerStats.
+	statsFetch statsFetchConfig
+
+	// metricSink is where metrics are emitted. When nil the global go-metrics
+	// instance is used.
+	metricSink metrics.MetricSink
+
+	// subsystemLoggers are the named loggers of each subsystem.
+	subsystemLoggers subsystemLoggers
+
+	// unhealthyLeader tracks how long the leader has been unhealthy.
+	unhealthyLeader unhealthyLeaderTracker
+
+	// leaderHandoff tracks a leader whose removal was refused until
+	// leadership has been transferred away from it.
+	leaderHandoff leaderHandoffTracker
+
+	// transferRetries verifies and retries leadership transfers.
+	transferRetries transferRetryTracker
+
+	// persistence tracks the health last given to a StatePersister.
+	persistence healthPersistence
+
+	// operations serializes persisting and loading the operations in
+	// progress with an OperationPersister.
+	operations operationPersistence
+
+	// maintenanceWindows are when demotions, removals and leadership
+	// transfers may be made. They may be made at any time when empty.
+	maintenanceWindows []MaintenanceWindow
 }
 
 // New will create a new Autopilot instance utilizing the given Raft and Delegate.
@@ -198,6 +601,9 @@ func New(raft Raft, delegate ApplicationIntegration, options ...Option) *Autopil
 		logger:   hclog.Default().Named("autopilot"),
 		// should this be buffered?
 		removeDeadCh:          make(chan struct{}, 1),
+		refreshCh:             make(chan struct{}, 1),
+		observedCh:            make(chan struct{}, 1),
+		onDemandCh:            make(chan onDemandRound),
 		reconciliationEnabled: true,
 		reconcileInterval:     DefaultReconcileInterval,
 		updateInterval:        DefaultUpdateInterval,
@@ -212,6 +618,20 @@ func New(raft Raft, delegate ApplicationIntegration, options ...Option) *Autopil
 	return a
 }
 
+// now returns the current time from the configured TimeProvider.
+func (a *Autopilot) now() time.Time {
+	if a.time == nil {
+		return time.Now()
+	}
+	return a.time.Now()
+}
+
+// isLocalServer returns whether the given ID is that of the server autopilot
+// is running on.
+func (a *Autopilot) isLocalServer(id raft.ServerID) bool {
+	return a.localServerID != "" && a.localServerID == id
+}
+
 // RemoveDeadServers will trigger an immediate removal of dead/failed servers.
 func (a *Autopilot) RemoveDeadServers() {
 	select {
@@ -220,6 +640,15 @@ func (a *Autopilot) RemoveDeadServers() {
 	}
 }
 
+// requestRefresh triggers an early update of the autopilot state if one is
+// not already pending.
+func (a *Autopilot) requestRefresh() {
+	select {
+	case a.refreshCh <- struct{}{}:
+	default:
+	}
+}
+
 // GetState retrieves the current autopilot State
 func (a *Autopilot) GetState() *State {
 	a.stateLock.RLock()
@@ -267,3 +696,45 @@ func (a *Autopilot) ReconciliationEnabled() bool {
 	defer a.reconciliationLock.RUnlock()
 	return a.reconciliationEnabled
 }
+
+// EnableMembershipChanges turns the given kinds of membership changes back on
+// after they were disabled with DisableMembershipChanges. They are still only
+// made while reconciliation is enabled.
+func (a *Autopilot) EnableMembershipChanges(changes ...MembershipChangeType) {
+	a.reconciliationLock.Lock()
+	defer a.reconciliationLock.Unlock()
+	for _, change := range changes {
+		if _, ok := a.disabledChanges[change]; ok {
+			delete(a.disabledChanges, change)
+			a.logger.Info("membership changes now enabled", "change", change)
+		}
+	}
+}
+
+// DisableMembershipChanges turns off the given kinds of membership changes,
+// such as demotions and removals, while leaving the others running. For
+// example promotions may continue while demotions and dead server cleanup are
+// frozen during an incident.
+func (a *Autopilot) DisableMembershipChanges(changes ...MembershipChangeType) {
+	a.reconciliationLock.Lock()
+	defer a.reconciliationLock.Unlock()
+	for _, change := range changes {
+		if _, ok := a.disabledChanges[change]; ok {
+			continue
+		}
+		if a.disabledChanges == nil {
+			a.disabledChanges = make(map[MembershipChangeType]struct{})
+		}
+		a.disabledChanges[change] = struct{}{}
+		a.logger.Info("membership changes now disabled", "change", change)
+	}
+}
+
+// MembershipChangesEnabled returns whether the kind of membership change has
+// not been disabled with DisableMembershipChanges.
+func (a *Autopilot) MembershipChangesEnabled(change MembershipChangeType) bool {
+	a.reconciliationLock.RLock()
+	defer a.reconciliationLock.RUnlock()
+	_, disabled := a.disabledChanges[change]
+	return !disabled
+}
@@ -4,8 +4,10 @@
 package autopilot
 
 import (
+	"context"
 	"testing"
 
+	"github.com/hashicorp/raft"
 	"github.com/stretchr/testify/require"
 )
 
@@ -40,3 +42,47 @@ func TestDisabledReconcilation(t *testing.T) {
 	ap = New(NewMockRaft(t), NewMockApplicationIntegration(t), WithLogger(logger))
 	require.True(t, ap.ReconciliationEnabled())
 }
+
+func TestDisabledMembershipChanges(t *testing.T) {
+	logger := testLogger(t)
+	mdelegate := NewMockApplicationIntegration(t)
+	ap := New(NewMockRaft(t), mdelegate, WithLogger(logger), WithMembershipChangesDisabled(MembershipChangeDemotion, MembershipChangeRemoval))
+	require.True(t, ap.MembershipChangesEnabled(MembershipChangePromotion))
+	require.False(t, ap.MembershipChangesEnabled(MembershipChangeDemotion))
+	require.False(t, ap.MembershipChangesEnabled(MembershipChangeRemoval))
+	require.True(t, ap.MembershipChangesEnabled(MembershipChangeLeadershipTransfer))
+
+	state := &State{
+		Leader: "a",
+		Servers: map[raft.ServerID]*ServerState{
+			"a": {Server: Server{ID: "a"}, State: RaftLeader, Health: ServerHealth{Healthy: true}},
+			"b": {Server: Server{ID: "b"}, State: RaftVoter, Health: ServerHealth{Healthy: true}},
+		},
+	}
+
+	// no demotions are made through raft
+	done, err := ap.applyDemotions(context.Background(), nil, state, RaftChanges{Demotions: []raft.ServerID{"b"}})
+	require.NoError(t, err)
+	require.False(t, done)
+
+	// dead servers are not looked for
+	mdelegate.On("AutopilotConfig").Return(&Config{CleanupDeadServers: true, MinQuorum: 1}).Once()
+	require.NoError(t, ap.pruneDeadServers(context.Background()))
+
+	ap.EnableMembershipChanges(MembershipChangeDemotion)
+	require.True(t, ap.MembershipChangesEnabled(MembershipChangeDemotion))
+	require.False(t, ap.MembershipChangesEnabled(MembershipChangeRemoval))
+
+	ap.DisableMembershipChanges(MembershipChangePromotion)
+	require.False(t, ap.MembershipChangesEnabled(MembershipChangePromotion))
+}
+
+func TestOnDemandRoundsRequireRunning(t *testing.T) {
+	ap := New(NewMockRaft(t), NewMockApplicationIntegration(t), WithLogger(testLogger(t)))
+
+	_, err := ap.ReconcileNow(context.Background())
+	require.EqualError(t, err, "cannot perform a reconcile round: autopilot is not running")
+
+	_, err = ap.PruneNow(context.Background())
+	require.ErrorIs(t, err, ErrNotRunning)
+}
@@ -0,0 +1,18 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package autopilothttp provides a net/http handler exposing an Autopilot's
+// state to operators as JSON.
+//
+// The handler returned by NewHandler serves the following read only
+// endpoints, relative to wherever it is mounted:
+//
+//	GET /state       the full autopilot State
+//	GET /health      the cluster's health along with each server's
+//	GET /tolerance   the failure tolerance and its breakdown by failure domain
+//	GET /decisions   the decision audit log, see autopilot.WithDecisionLog
+//
+// Mount it beneath a prefix with http.StripPrefix, for example:
+//
+//	mux.Handle("/v1/autopilot/", http.StripPrefix("/v1/autopilot", autopilothttp.NewHandler(ap)))
+package autopilothttp
@@ -0,0 +1,184 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilothttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/raft"
+	autopilot "github.com/hashicorp/raft-autopilot"
+)
+
+// Health is the response of the health endpoint.
+type Health struct {
+	// Healthy is whether every server in the cluster is healthy.
+	Healthy bool
+
+	// FailureTolerance is the number of voters which could fail without
+	// the cluster losing quorum.
+	FailureTolerance int
+
+	// Leader is the ID of the leader.
+	Leader raft.ServerID
+
+	// Voters are the IDs of the voters.
+	Voters []raft.ServerID
+
+	// Servers are the health of every server ordered by ID.
+	Servers []ServerHealth
+}
+
+// ServerHealth is the health of a single server.
+type ServerHealth struct {
+	ID          raft.ServerID
+	Name        string
+	Address     raft.ServerAddress
+	NodeStatus  autopilot.NodeStatus
+	Version     string
+	State       autopilot.RaftState
+	Healthy     bool
+	StableSince time.Time
+	LastContact time.Duration
+	LastTerm    uint64
+	LastIndex   uint64
+}
+
+// FailureTolerance is the response of the tolerance endpoint.
+type FailureTolerance struct {
+	FailureTolerance           int
+	OptimisticFailureTolerance int
+	Inputs                     autopilot.FailureToleranceInputs
+	FailureDomains             map[string]*autopilot.FailureDomainTolerance
+}
+
+// Error is the response of any endpoint which failed.
+type Error struct {
+	Error string
+}
+
+// handler serves the autopilot endpoints.
+type handler struct {
+	autopilot *autopilot.Autopilot
+}
+
+// NewHandler returns an http.Handler serving the autopilot's state, health,
+// failure tolerance and decisions as JSON. The state dependent endpoints
+// respond with 503 Service Unavailable while the autopilot has no state, such
+// as when the local server is not the leader, and the health endpoint
+// responds with 429 Too Many Requests while the cluster is unhealthy so that
+// it may be used as a health check.
+func NewHandler(a *autopilot.Autopilot) http.Handler {
+	h := &handler{autopilot: a}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/state", h.get(h.state))
+	mux.HandleFunc("/health", h.get(h.health))
+	mux.HandleFunc("/tolerance", h.get(h.tolerance))
+	mux.HandleFunc("/decisions", h.get(h.decisions))
+	return mux
+}
+
+// get restricts the endpoint to GET and HEAD requests.
+func (h *handler) get(endpoint http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			respond(w, http.StatusMethodNotAllowed, &Error{Error: "method not allowed"})
+			return
+		}
+		endpoint(w, r)
+	}
+}
+
+// currentState returns the autopilot state or responds with an error when
+// there is no state with a known leader.
+func (h *handler) currentState(w http.ResponseWriter) (*autopilot.State, bool) {
+	state := h.autopilot.GetState()
+	if state == nil || state.Leader == "" {
+		respond(w, http.StatusServiceUnavailable, &Error{Error: autopilot.ErrNoState.Error()})
+		return nil, false
+	}
+	return state, true
+}
+
+func (h *handler) state(w http.ResponseWriter, _ *http.Request) {
+	if state, ok := h.currentState(w); ok {
+		respond(w, http.StatusOK, state)
+	}
+}
+
+func (h *handler) health(w http.ResponseWriter, _ *http.Request) {
+	state, ok := h.currentState(w)
+	if !ok {
+		return
+	}
+
+	health := &Health{
+		Healthy:          state.Healthy,
+		FailureTolerance: state.FailureTolerance,
+		Leader:           state.Leader,
+		Voters:           state.Voters,
+		Servers:          make([]ServerHealth, 0, len(state.Servers)),
+	}
+	for _, srv := range state.Servers {
+		health.Servers = append(health.Servers, ServerHealth{
+			ID:          srv.Server.ID,
+			Name:        srv.Server.Name,
+			Address:     srv.Server.Address,
+			NodeStatus:  srv.Server.NodeStatus,
+			Version:     srv.Server.Version,
+			State:       srv.State,
+			Healthy:     srv.Health.Healthy,
+			StableSince: srv.Health.StableSince,
+			LastContact: srv.Stats.LastContact,
+			LastTerm:    srv.Stats.LastTerm,
+			LastIndex:   srv.Stats.LastIndex,
+		})
+	}
+	sort.Slice(health.Servers, func(i, j int) bool {
+		return health.Servers[i].ID < health.Servers[j].ID
+	})
+
+	status := http.StatusOK
+	if !state.Healthy {
+		status = http.StatusTooManyRequests
+	}
+	respond(w, status, health)
+}
+
+func (h *handler) tolerance(w http.ResponseWriter, _ *http.Request) {
+	state, ok := h.currentState(w)
+	if !ok {
+		return
+	}
+
+	respond(w, http.StatusOK, &FailureTolerance{
+		FailureTolerance:           state.FailureTolerance,
+		OptimisticFailureTolerance: state.OptimisticFailureTolerance,
+		Inputs:                     state.FailureToleranceInputs,
+		FailureDomains:             state.FailureDomains,
+	})
+}
+
+func (h *handler) decisions(w http.ResponseWriter, _ *http.Request) {
+	decisions := h.autopilot.Decisions()
+	if decisions == nil {
+		decisions = []autopilot.Decision{}
+	}
+	respond(w, http.StatusOK, decisions)
+}
+
+// respond writes the value as the JSON response.
+func respond(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	// the status has been written so there is nothing more to be done
+	_ = enc.Encode(v)
+}
@@ -0,0 +1,88 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilothttp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/raft"
+	autopilot "github.com/hashicorp/raft-autopilot"
+	"github.com/hashicorp/raft-autopilot/autopilottest"
+	"github.com/stretchr/testify/require"
+)
+
+func get(t *testing.T, h http.Handler, path string, v interface{}) int {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	if v != nil {
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), v))
+	}
+	return rec.Code
+}
+
+func TestHandler(t *testing.T) {
+	c := autopilottest.NewCluster(&autopilot.Config{
+		CleanupDeadServers:   true,
+		LastContactThreshold: time.Second,
+		MaxTrailingLogs:      100,
+		MinQuorum:            3,
+	})
+	c.AddServer("server-2", raft.Voter, nil).
+		AddServer("server-3", raft.Voter, nil).
+		AddServer("server-4", raft.Nonvoter, nil).
+		FailServer("server-4")
+
+	ap := c.New(autopilot.WithDecisionLog(10))
+	h := NewHandler(ap)
+
+	// there is no state until autopilot has run
+	var apiErr Error
+	require.Equal(t, http.StatusServiceUnavailable, get(t, h, "/state", &apiErr))
+	require.Equal(t, autopilot.ErrNoState.Error(), apiErr.Error)
+	require.Equal(t, http.StatusServiceUnavailable, get(t, h, "/health", nil))
+
+	var decisions []autopilot.Decision
+	require.Equal(t, http.StatusOK, get(t, h, "/decisions", &decisions))
+	require.Empty(t, decisions)
+
+	require.NoError(t, ap.Step(context.Background()))
+
+	var state autopilot.State
+	require.Equal(t, http.StatusOK, get(t, h, "/state", &state))
+	require.Equal(t, raft.ServerID("server-1"), state.Leader)
+	require.Len(t, state.Servers, 4)
+
+	// the failed server leaves the cluster unhealthy
+	var health Health
+	require.Equal(t, http.StatusTooManyRequests, get(t, h, "/health", &health))
+	require.False(t, health.Healthy)
+	require.Equal(t, raft.ServerID("server-1"), health.Leader)
+	require.Len(t, health.Servers, 4)
+	require.Equal(t, raft.ServerID("server-1"), health.Servers[0].ID)
+	require.True(t, health.Servers[0].Healthy)
+	require.Equal(t, raft.ServerID("server-4"), health.Servers[3].ID)
+	require.False(t, health.Servers[3].Healthy)
+
+	var tolerance FailureTolerance
+	require.Equal(t, http.StatusOK, get(t, h, "/tolerance", &tolerance))
+	require.Equal(t, 3, tolerance.Inputs.Voters)
+
+	require.Equal(t, http.StatusOK, get(t, h, "/decisions", &decisions))
+	require.Len(t, decisions, 1)
+	require.Equal(t, autopilot.DecisionRemove, decisions[0].Type)
+	require.Equal(t, raft.ServerID("server-4"), decisions[0].ServerID)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/state", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	require.Equal(t, "GET, HEAD", rec.Header().Get("Allow"))
+}
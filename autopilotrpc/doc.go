@@ -0,0 +1,23 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package autopilotrpc implements the Autopilot service described by
+// autopilot.proto so that external tooling may inspect and control the
+// autopilot of any application in the same way.
+//
+// The service exposes the autopilot state, the health of individual servers,
+// on demand reconciliation, pausing and resuming reconciliation and a stream
+// of the latest state sampled at an interval. The Server methods have the same
+// shape as the methods of the server interface generated from autopilot.proto
+// by protoc-gen-go-grpc, with the messages represented by the types of this
+// package, so that applications may serve it over gRPC with a thin adapter
+// translating the generated messages:
+//
+//	protoc --go_out=. --go-grpc_out=. autopilot.proto
+//
+// Errors wrap the autopilot errors, such as autopilot.ErrNoState and
+// autopilot.ErrUnknownServer, which adapters should map to the UNAVAILABLE and
+// NOT_FOUND status codes respectively.
+package autopilotrpc
+
+//go:generate mockery --name StateStream --case snake --inpackage
@@ -0,0 +1,59 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilotrpc
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockStateStream is an autogenerated mock type for the StateStream type
+type MockStateStream struct {
+	mock.Mock
+}
+
+// Context provides a mock function with given fields:
+func (_m *MockStateStream) Context() context.Context {
+	ret := _m.Called()
+
+	var r0 context.Context
+	if rf, ok := ret.Get(0).(func() context.Context); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(context.Context)
+		}
+	}
+
+	return r0
+}
+
+// Send provides a mock function with given fields: _a0
+func (_m *MockStateStream) Send(_a0 *State) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*State) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewMockStateStream interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewMockStateStream creates a new instance of MockStateStream. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewMockStateStream(t mockConstructorTestingTNewMockStateStream) *MockStateStream {
+	mock := &MockStateStream{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,262 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilotrpc
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/raft"
+	autopilot "github.com/hashicorp/raft-autopilot"
+)
+
+// DefaultWatchInterval is how often Watch checks for a new autopilot state.
+const DefaultWatchInterval = time.Second
+
+// GetStateRequest is the request of the GetState method.
+type GetStateRequest struct{}
+
+// GetServerHealthRequest is the request of the GetServerHealth method.
+type GetServerHealthRequest struct {
+	ID raft.ServerID
+}
+
+// TriggerReconcileRequest is the request of the TriggerReconcile method.
+type TriggerReconcileRequest struct{}
+
+// TriggerReconcileResponse is the response of the TriggerReconcile method.
+type TriggerReconcileResponse struct {
+	Changes []*MembershipChange
+}
+
+// SetReconciliationRequest is the request of the SetReconciliation method.
+type SetReconciliationRequest struct {
+	Enabled bool
+}
+
+// SetReconciliationResponse is the response of the SetReconciliation method.
+type SetReconciliationResponse struct {
+	// Enabled is whether reconciliation is now enabled.
+	Enabled bool
+}
+
+// WatchRequest is the request of the Watch method.
+type WatchRequest struct{}
+
+// State is the autopilot state message.
+type State struct {
+	Healthy                    bool
+	FailureTolerance           int32
+	OptimisticFailureTolerance int32
+	Leader                     raft.ServerID
+	Voters                     []raft.ServerID
+
+	// Servers are ordered by ID.
+	Servers []*ServerState
+}
+
+// ServerState is the state and health of a single server.
+type ServerState struct {
+	ID          raft.ServerID
+	Name        string
+	Address     raft.ServerAddress
+	NodeStatus  autopilot.NodeStatus
+	Version     string
+	Meta        map[string]string
+	NodeType    autopilot.NodeType
+	State       autopilot.RaftState
+	Healthy     bool
+	StableSince time.Time
+	Reasons     []string
+	LastContact time.Duration
+	LastTerm    uint64
+	LastIndex   uint64
+}
+
+// MembershipChange is a membership change autopilot made.
+type MembershipChange struct {
+	Type           autopilot.MembershipChangeType
+	Time           time.Time
+	Round          string
+	ServerID       raft.ServerID
+	PreviousLeader raft.ServerID
+	Message        string
+}
+
+// StateStream is the stream the states are sent on by Watch. The server
+// stream generated for the Watch method by protoc-gen-go-grpc satisfies it
+// once adapted to send the generated State message.
+type StateStream interface {
+	Context() context.Context
+	Send(*State) error
+}
+
+// Option is an option for the Server.
+type Option func(*Server)
+
+// WithWatchInterval returns an option to set how often Watch checks for a new
+// autopilot state. It defaults to DefaultWatchInterval.
+func WithWatchInterval(interval time.Duration) Option {
+	return func(s *Server) {
+		if interval > 0 {
+			s.watchInterval = interval
+		}
+	}
+}
+
+// Server implements the Autopilot service for an autopilot.
+type Server struct {
+	autopilot     *autopilot.Autopilot
+	watchInterval time.Duration
+}
+
+// NewServer creates the Autopilot service for the autopilot.
+func NewServer(a *autopilot.Autopilot, options ...Option) *Server {
+	s := &Server{
+		autopilot:     a,
+		watchInterval: DefaultWatchInterval,
+	}
+	for _, opt := range options {
+		opt(s)
+	}
+	return s
+}
+
+// GetState returns the current autopilot state. An error wrapping
+// autopilot.ErrNoState is returned while there is no state with a known
+// leader.
+func (s *Server) GetState(_ context.Context, _ *GetStateRequest) (*State, error) {
+	state := s.autopilot.GetState()
+	if state == nil || state.Leader == "" {
+		return nil, fmt.Errorf("cannot get the state: %w", autopilot.ErrNoState)
+	}
+	return convertState(state), nil
+}
+
+// GetServerHealth returns the state and health of the server. An error
+// wrapping autopilot.ErrUnknownServer is returned when the server is not in
+// the autopilot state.
+func (s *Server) GetServerHealth(_ context.Context, req *GetServerHealthRequest) (*ServerState, error) {
+	state := s.autopilot.GetState()
+	if state == nil || state.Leader == "" {
+		return nil, fmt.Errorf("cannot get the health of server %q: %w", req.ID, autopilot.ErrNoState)
+	}
+
+	srv, ok := state.Servers[req.ID]
+	if !ok {
+		return nil, fmt.Errorf("cannot get the health of server %q: %w", req.ID, autopilot.ErrUnknownServer)
+	}
+	return convertServer(srv), nil
+}
+
+// TriggerReconcile performs a reconciliation round immediately and returns the
+// membership changes it made.
+func (s *Server) TriggerReconcile(ctx context.Context, _ *TriggerReconcileRequest) (*TriggerReconcileResponse, error) {
+	changes, err := s.autopilot.ReconcileNow(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &TriggerReconcileResponse{Changes: make([]*MembershipChange, 0, len(changes))}
+	for _, change := range changes {
+		resp.Changes = append(resp.Changes, &MembershipChange{
+			Type:           change.Type,
+			Time:           change.Time,
+			Round:          change.Round,
+			ServerID:       change.Server.ID,
+			PreviousLeader: change.PreviousLeader,
+			Message:        change.Message,
+		})
+	}
+	return resp, nil
+}
+
+// SetReconciliation enables or disables reconciliation. While disabled
+// autopilot keeps computing the state but makes no membership changes. This
+// is independent of any maintenance windows, which only limit when enabled
+// reconciliation makes disruptive changes.
+func (s *Server) SetReconciliation(_ context.Context, req *SetReconciliationRequest) (*SetReconciliationResponse, error) {
+	if req.Enabled {
+		s.autopilot.EnableReconciliation()
+	} else {
+		s.autopilot.DisableReconciliation()
+	}
+	return &SetReconciliationResponse{Enabled: s.autopilot.ReconciliationEnabled()}, nil
+}
+
+// Watch sends the current state and then samples the latest state at the
+// watch interval, sending it whenever it has changed, until the stream's
+// context is done or sending fails. States computed and replaced within a
+// single interval are not sent, nor are states without a known leader.
+func (s *Server) Watch(_ *WatchRequest, stream StateStream) error {
+	ctx := stream.Context()
+
+	ticker := time.NewTicker(s.watchInterval)
+	defer ticker.Stop()
+
+	var last *autopilot.State
+	for {
+		// autopilot replaces rather than modifies the state so a new
+		// pointer means a new state
+		if state := s.autopilot.GetState(); state != last && state != nil && state.Leader != "" {
+			if err := stream.Send(convertState(state)); err != nil {
+				return err
+			}
+			last = state
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// convertState converts the autopilot state to the State message.
+func convertState(state *autopilot.State) *State {
+	msg := &State{
+		Healthy:                    state.Healthy,
+		FailureTolerance:           int32(state.FailureTolerance),
+		OptimisticFailureTolerance: int32(state.OptimisticFailureTolerance),
+		Leader:                     state.Leader,
+		Voters:                     append([]raft.ServerID(nil), state.Voters...),
+		Servers:                    make([]*ServerState, 0, len(state.Servers)),
+	}
+	for _, srv := range state.Servers {
+		msg.Servers = append(msg.Servers, convertServer(srv))
+	}
+	sort.Slice(msg.Servers, func(i, j int) bool {
+		return msg.Servers[i].ID < msg.Servers[j].ID
+	})
+	return msg
+}
+
+// convertServer converts the server's state to the ServerState message.
+func convertServer(srv *autopilot.ServerState) *ServerState {
+	msg := &ServerState{
+		ID:          srv.Server.ID,
+		Name:        srv.Server.Name,
+		Address:     srv.Server.Address,
+		NodeStatus:  srv.Server.NodeStatus,
+		Version:     srv.Server.Version,
+		NodeType:    srv.Server.NodeType,
+		State:       srv.State,
+		Healthy:     srv.Health.Healthy,
+		StableSince: srv.Health.StableSince,
+		Reasons:     append([]string(nil), srv.Health.Reasons...),
+		LastContact: srv.Stats.LastContact,
+		LastTerm:    srv.Stats.LastTerm,
+		LastIndex:   srv.Stats.LastIndex,
+	}
+	if srv.Server.Meta != nil {
+		msg.Meta = make(map[string]string, len(srv.Server.Meta))
+		for k, v := range srv.Server.Meta {
+			msg.Meta[k] = v
+		}
+	}
+	return msg
+}
@@ -0,0 +1,111 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilotrpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/raft"
+	autopilot "github.com/hashicorp/raft-autopilot"
+	"github.com/hashicorp/raft-autopilot/autopilottest"
+	"github.com/stretchr/testify/require"
+)
+
+// testStream is a StateStream delivering the states on a channel.
+type testStream struct {
+	ctx    context.Context
+	states chan *State
+}
+
+func (s *testStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *testStream) Send(state *State) error {
+	s.states <- state
+	return nil
+}
+
+func TestServer(t *testing.T) {
+	c := autopilottest.NewCluster(&autopilot.Config{
+		LastContactThreshold: time.Second,
+		MaxTrailingLogs:      100,
+	})
+	c.AddServer("server-2", raft.Voter, map[string]string{"zone": "a"}).
+		AddServer("server-3", raft.Voter, nil)
+
+	ap := c.New()
+	srv := NewServer(ap, WithWatchInterval(time.Millisecond))
+	ctx := context.Background()
+
+	_, err := srv.GetState(ctx, &GetStateRequest{})
+	require.ErrorIs(t, err, autopilot.ErrNoState)
+
+	require.NoError(t, ap.Step(ctx))
+
+	state, err := srv.GetState(ctx, &GetStateRequest{})
+	require.NoError(t, err)
+	require.True(t, state.Healthy)
+	require.Equal(t, raft.ServerID("server-1"), state.Leader)
+	require.Len(t, state.Servers, 3)
+	for i, id := range []raft.ServerID{"server-1", "server-2", "server-3"} {
+		require.Equal(t, id, state.Servers[i].ID)
+	}
+
+	health, err := srv.GetServerHealth(ctx, &GetServerHealthRequest{ID: "server-2"})
+	require.NoError(t, err)
+	require.True(t, health.Healthy)
+	require.Equal(t, autopilot.RaftVoter, health.State)
+	require.Equal(t, map[string]string{"zone": "a"}, health.Meta)
+
+	_, err = srv.GetServerHealth(ctx, &GetServerHealthRequest{ID: "server-9"})
+	require.ErrorIs(t, err, autopilot.ErrUnknownServer)
+
+	// autopilot only reconciles on demand while it is running
+	_, err = srv.TriggerReconcile(ctx, &TriggerReconcileRequest{})
+	require.ErrorIs(t, err, autopilot.ErrNotRunning)
+
+	resp, err := srv.SetReconciliation(ctx, &SetReconciliationRequest{})
+	require.NoError(t, err)
+	require.False(t, resp.Enabled)
+	require.False(t, ap.ReconciliationEnabled())
+
+	resp, err = srv.SetReconciliation(ctx, &SetReconciliationRequest{Enabled: true})
+	require.NoError(t, err)
+	require.True(t, resp.Enabled)
+	require.True(t, ap.ReconciliationEnabled())
+}
+
+func TestServerWatch(t *testing.T) {
+	c := autopilottest.NewCluster(&autopilot.Config{
+		LastContactThreshold: time.Second,
+		MaxTrailingLogs:      100,
+	})
+	c.AddServer("server-2", raft.Voter, nil)
+
+	ap := c.New()
+	srv := NewServer(ap, WithWatchInterval(time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &testStream{ctx: ctx, states: make(chan *State)}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Watch(&WatchRequest{}, stream)
+	}()
+
+	// no state is sent until there is one with a known leader
+	require.NoError(t, ap.Step(context.Background()))
+	state := <-stream.states
+	require.Len(t, state.Servers, 2)
+
+	c.AddServer("server-3", raft.Nonvoter, nil)
+	require.NoError(t, ap.Step(context.Background()))
+	state = <-stream.states
+	require.Len(t, state.Servers, 3)
+
+	cancel()
+	require.ErrorIs(t, <-errCh, context.Canceled)
+}
@@ -0,0 +1,91 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilottest
+
+import (
+	context "context"
+
+	autopilot "github.com/hashicorp/raft-autopilot"
+
+	mock "github.com/stretchr/testify/mock"
+
+	raft "github.com/hashicorp/raft"
+)
+
+// ApplicationIntegration is an autogenerated mock type for the ApplicationIntegration type
+type ApplicationIntegration struct {
+	mock.Mock
+}
+
+// AutopilotConfig provides a mock function with given fields:
+func (_m *ApplicationIntegration) AutopilotConfig() *autopilot.Config {
+	ret := _m.Called()
+
+	var r0 *autopilot.Config
+	if rf, ok := ret.Get(0).(func() *autopilot.Config); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*autopilot.Config)
+		}
+	}
+
+	return r0
+}
+
+// FetchServerStats provides a mock function with given fields: _a0, _a1
+func (_m *ApplicationIntegration) FetchServerStats(_a0 context.Context, _a1 map[raft.ServerID]*autopilot.Server) map[raft.ServerID]*autopilot.ServerStats {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 map[raft.ServerID]*autopilot.ServerStats
+	if rf, ok := ret.Get(0).(func(context.Context, map[raft.ServerID]*autopilot.Server) map[raft.ServerID]*autopilot.ServerStats); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[raft.ServerID]*autopilot.ServerStats)
+		}
+	}
+
+	return r0
+}
+
+// KnownServers provides a mock function with given fields:
+func (_m *ApplicationIntegration) KnownServers() map[raft.ServerID]*autopilot.Server {
+	ret := _m.Called()
+
+	var r0 map[raft.ServerID]*autopilot.Server
+	if rf, ok := ret.Get(0).(func() map[raft.ServerID]*autopilot.Server); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[raft.ServerID]*autopilot.Server)
+		}
+	}
+
+	return r0
+}
+
+// NotifyState provides a mock function with given fields: _a0
+func (_m *ApplicationIntegration) NotifyState(_a0 *autopilot.State) {
+	_m.Called(_a0)
+}
+
+// RemoveFailedServer provides a mock function with given fields: _a0
+func (_m *ApplicationIntegration) RemoveFailedServer(_a0 *autopilot.Server) {
+	_m.Called(_a0)
+}
+
+type mockConstructorTestingTNewApplicationIntegration interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewApplicationIntegration creates a new instance of ApplicationIntegration. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewApplicationIntegration(t mockConstructorTestingTNewApplicationIntegration) *ApplicationIntegration {
+	mock := &ApplicationIntegration{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,70 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilottest
+
+import (
+	autopilot "github.com/hashicorp/raft-autopilot"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ApprovalDelegate is an autogenerated mock type for the ApprovalDelegate type
+type ApprovalDelegate struct {
+	mock.Mock
+}
+
+// ApproveDemotion provides a mock function with given fields: _a0
+func (_m *ApprovalDelegate) ApproveDemotion(_a0 *autopilot.Server) bool {
+	ret := _m.Called(_a0)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(*autopilot.Server) bool); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// ApproveLeadershipTransfer provides a mock function with given fields: _a0
+func (_m *ApprovalDelegate) ApproveLeadershipTransfer(_a0 *autopilot.Server) bool {
+	ret := _m.Called(_a0)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(*autopilot.Server) bool); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// ApproveRemoval provides a mock function with given fields: _a0
+func (_m *ApprovalDelegate) ApproveRemoval(_a0 *autopilot.Server) bool {
+	ret := _m.Called(_a0)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(*autopilot.Server) bool); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewApprovalDelegate interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewApprovalDelegate creates a new instance of ApprovalDelegate. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewApprovalDelegate(t mockConstructorTestingTNewApprovalDelegate) *ApprovalDelegate {
+	mock := &ApprovalDelegate{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,47 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilottest
+
+import (
+	"sync"
+	"time"
+
+	autopilot "github.com/hashicorp/raft-autopilot"
+)
+
+// VirtualClock is an autopilot.TimeProvider whose time only moves when told to.
+type VirtualClock struct {
+	lock sync.Mutex
+	now  time.Time
+}
+
+var _ autopilot.TimeProvider = (*VirtualClock)(nil)
+
+// NewVirtualClock creates a VirtualClock starting at the given time.
+func NewVirtualClock(start time.Time) *VirtualClock {
+	return &VirtualClock{now: start}
+}
+
+// Now returns the current virtual time.
+func (c *VirtualClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+// Advance moves the virtual time forward by the given duration and returns
+// the new time.
+func (c *VirtualClock) Advance(d time.Duration) time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.now = c.now.Add(d)
+	return c.now
+}
+
+// Set changes the virtual time to the given time.
+func (c *VirtualClock) Set(t time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.now = t
+}
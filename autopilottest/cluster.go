@@ -0,0 +1,134 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilottest
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/raft"
+	autopilot "github.com/hashicorp/raft-autopilot"
+)
+
+// Cluster ties together a FakeRaft, FakeDelegate and VirtualClock describing
+// the same set of servers. It is built with NewCluster and AddServer after
+// which the components may be passed to autopilot.New.
+type Cluster struct {
+	Raft     *FakeRaft
+	Delegate *FakeDelegate
+	Clock    *VirtualClock
+
+	// lag is the number of entries each server trails the leader's log by.
+	lag map[raft.ServerID]uint64
+}
+
+// NewCluster creates a Cluster with the given configuration and a single
+// voter named "server-1" which is the leader.
+func NewCluster(config *autopilot.Config) *Cluster {
+	c := &Cluster{
+		Raft:     NewFakeRaft("server-1"),
+		Delegate: NewFakeDelegate(config),
+		Clock:    NewVirtualClock(time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)),
+		lag:      make(map[raft.ServerID]uint64),
+	}
+
+	c.AddServer("server-1", raft.Voter, nil)
+	return c
+}
+
+// AddServer adds a healthy, alive server to both the Raft configuration and
+// the delegate's known servers.
+func (c *Cluster) AddServer(id raft.ServerID, suffrage raft.ServerSuffrage, meta map[string]string) *Cluster {
+	address := raft.ServerAddress(fmt.Sprintf("198.18.0.%d:8300", len(c.Raft.Servers())+1))
+
+	c.Raft.lock.Lock()
+	c.Raft.servers = append(c.Raft.servers, raft.Server{Suffrage: suffrage, ID: id, Address: address})
+	lastIndex, lastTerm := c.Raft.lastIndex, c.Raft.lastTerm
+	c.Raft.lock.Unlock()
+
+	c.Delegate.SetServer(&autopilot.Server{
+		ID:          id,
+		Name:        string(id),
+		Address:     address,
+		NodeStatus:  autopilot.NodeAlive,
+		NodeType:    autopilot.NodeVoter,
+		Meta:        meta,
+		RaftVersion: 3,
+		Version:     "1.0.0",
+	})
+	c.Delegate.SetStats(id, autopilot.ServerStats{LastTerm: lastTerm, LastIndex: lastIndex})
+	return c
+}
+
+// FailServer marks the server as failed within the delegate and stops
+// reporting stats for it.
+func (c *Cluster) FailServer(id raft.ServerID) *Cluster {
+	c.Delegate.SetNodeStatus(id, autopilot.NodeFailed)
+	c.Delegate.lock.Lock()
+	delete(c.Delegate.stats, id)
+	c.Delegate.lock.Unlock()
+	return c
+}
+
+// RecoverServer marks a failed server as alive again and resumes reporting
+// stats for it.
+func (c *Cluster) RecoverServer(id raft.ServerID) *Cluster {
+	c.Delegate.SetNodeStatus(id, autopilot.NodeAlive)
+	c.syncStats(id)
+	return c
+}
+
+// LagServer causes the server's stats to trail the leader's last log index by
+// the given number of entries. Zero lets the server catch up again.
+func (c *Cluster) LagServer(id raft.ServerID, entries uint64) *Cluster {
+	c.lag[id] = entries
+	c.syncStats(id)
+	return c
+}
+
+// AdvanceLog appends entries to the leader's log. Every server with stats
+// follows the leader other than trailing by the entries set with LagServer.
+func (c *Cluster) AdvanceLog(entries uint64) *Cluster {
+	c.Raft.lock.Lock()
+	c.Raft.lastIndex += entries
+	c.Raft.lock.Unlock()
+
+	c.Delegate.lock.Lock()
+	ids := make([]raft.ServerID, 0, len(c.Delegate.stats))
+	for id := range c.Delegate.stats {
+		ids = append(ids, id)
+	}
+	c.Delegate.lock.Unlock()
+
+	for _, id := range ids {
+		c.syncStats(id)
+	}
+	return c
+}
+
+// syncStats sets the server's stats from the leader's last log, less the
+// server's lag.
+func (c *Cluster) syncStats(id raft.ServerID) {
+	c.Raft.lock.Lock()
+	lastIndex, lastTerm := c.Raft.lastIndex, c.Raft.lastTerm
+	c.Raft.lock.Unlock()
+
+	if lag := c.lag[id]; lag < lastIndex {
+		lastIndex -= lag
+	} else {
+		lastIndex = 0
+	}
+	c.Delegate.SetStats(id, autopilot.ServerStats{LastTerm: lastTerm, LastIndex: lastIndex})
+}
+
+// Options returns the autopilot options needed to use the cluster's clock
+// along with any others given.
+func (c *Cluster) Options(opts ...autopilot.Option) []autopilot.Option {
+	return append([]autopilot.Option{autopilot.WithTimeProvider(c.Clock)}, opts...)
+}
+
+// New creates an Autopilot instance for the cluster.
+func (c *Cluster) New(opts ...autopilot.Option) *autopilot.Autopilot {
+	return autopilot.New(c.Raft, c.Delegate, c.Options(opts...)...)
+}
@@ -0,0 +1,524 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilottest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/raft"
+	autopilot "github.com/hashicorp/raft-autopilot"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCluster(t *testing.T) {
+	c := NewCluster(&autopilot.Config{
+		LastContactThreshold:    time.Second,
+		MaxTrailingLogs:         100,
+		ServerStabilizationTime: 10 * time.Second,
+	})
+	c.AddServer("server-2", raft.Nonvoter, nil).
+		AddServer("server-3", raft.Voter, map[string]string{"zone": "b"})
+
+	ap := c.New()
+	state, err := ap.ComputeState(context.Background())
+	require.NoError(t, err)
+
+	require.Equal(t, raft.ServerID("server-1"), state.Leader)
+	require.Len(t, state.Servers, 3)
+	require.True(t, state.Healthy)
+	require.Equal(t, autopilot.RaftNonVoter, state.Servers["server-2"].State)
+	require.Equal(t, "b", state.Servers["server-3"].Server.Meta["zone"])
+	require.Equal(t, c.Clock.Now(), state.Servers["server-2"].Health.StableSince)
+
+	c.FailServer("server-3")
+	state, err = ap.ComputeState(context.Background())
+	require.NoError(t, err)
+	require.False(t, state.Servers["server-3"].Health.Healthy)
+	require.Equal(t, autopilot.NodeFailed, state.Servers["server-3"].Server.NodeStatus)
+}
+
+func TestClusterLagAndRecovery(t *testing.T) {
+	c := NewCluster(&autopilot.Config{
+		LastContactThreshold: time.Second,
+		MaxTrailingLogs:      100,
+	})
+	c.AddServer("server-2", raft.Voter, nil).
+		AddServer("server-3", raft.Voter, nil)
+	ap := c.New()
+
+	c.LagServer("server-2", 500).AdvanceLog(1000)
+	state, err := ap.ComputeState(context.Background())
+	require.NoError(t, err)
+	require.False(t, state.Servers["server-2"].Health.Healthy)
+	require.Equal(t, uint64(501), state.Servers["server-2"].Stats.LastIndex)
+	require.True(t, state.Servers["server-3"].Health.Healthy)
+	require.Equal(t, uint64(1001), state.Servers["server-3"].Stats.LastIndex)
+
+	c.LagServer("server-2", 0).FailServer("server-3")
+	state, err = ap.ComputeState(context.Background())
+	require.NoError(t, err)
+	require.True(t, state.Servers["server-2"].Health.Healthy)
+	require.False(t, state.Servers["server-3"].Health.Healthy)
+
+	c.RecoverServer("server-3")
+	state, err = ap.ComputeState(context.Background())
+	require.NoError(t, err)
+	require.True(t, state.Servers["server-3"].Health.Healthy)
+}
+
+func TestFakeDelegateOnCall(t *testing.T) {
+	c := NewCluster(&autopilot.Config{
+		LastContactThreshold: time.Second,
+		MaxTrailingLogs:      100,
+	})
+	c.AddServer("server-2", raft.Voter, nil)
+	ap := c.New()
+
+	// fail the server between autopilot learning of it and fetching its stats
+	c.Delegate.OnCall("FetchServerStats", func() { c.FailServer("server-2") })
+
+	state, err := ap.ComputeState(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, c.Delegate.Calls("FetchServerStats"))
+	require.Equal(t, autopilot.NodeAlive, state.Servers["server-2"].Server.NodeStatus)
+	require.False(t, state.Servers["server-2"].Health.Healthy)
+
+	// hooks only run once
+	c.RecoverServer("server-2")
+	state, err = ap.ComputeState(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, c.Delegate.Calls("FetchServerStats"))
+	require.True(t, state.Servers["server-2"].Health.Healthy)
+}
+
+func TestFakeRaft(t *testing.T) {
+	r := NewFakeRaft("a", raft.Server{Suffrage: raft.Voter, ID: "a", Address: "198.18.0.1:8300"})
+
+	require.NoError(t, r.AddNonvoter("b", "198.18.0.2:8300", 0, 0).Error())
+	suffrage, ok := r.Suffrage("b")
+	require.True(t, ok)
+	require.Equal(t, raft.Nonvoter, suffrage)
+
+	require.NoError(t, r.AddVoter("b", "198.18.0.2:8300", 0, 0).Error())
+	suffrage, _ = r.Suffrage("b")
+	require.Equal(t, raft.Voter, suffrage)
+
+	require.NoError(t, r.LeadershipTransferToServer("b", "198.18.0.2:8300").Error())
+	require.Equal(t, raft.ServerID("b"), r.LeaderID())
+	require.Equal(t, raft.ServerAddress("198.18.0.2:8300"), r.Leader())
+
+	injected := errors.New("injected")
+	r.FailNext("DemoteVoter", injected)
+	require.ErrorIs(t, r.DemoteVoter("a", 0, 0).Error(), injected)
+	require.NoError(t, r.DemoteVoter("a", 0, 0).Error())
+
+	require.NoError(t, r.RemoveServer("a", 0, 0).Error())
+	_, ok = r.Suffrage("a")
+	require.False(t, ok)
+	require.Len(t, r.GetConfiguration().Configuration().Servers, 1)
+	require.Equal(t, uint64(6), r.LastIndex())
+}
+
+func TestVirtualClock(t *testing.T) {
+	start := time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)
+	c := NewVirtualClock(start)
+	require.Equal(t, start, c.Now())
+	require.Equal(t, start.Add(time.Minute), c.Advance(time.Minute))
+	require.Equal(t, start.Add(time.Minute), c.Now())
+}
+
+func TestOnDemandRounds(t *testing.T) {
+	c := NewCluster(&autopilot.Config{
+		CleanupDeadServers:   true,
+		LastContactThreshold: time.Second,
+		MaxTrailingLogs:      100,
+		MinQuorum:            3,
+	})
+	c.AddServer("server-2", raft.Voter, nil).
+		AddServer("server-3", raft.Voter, nil).
+		AddServer("server-4", raft.Voter, nil).
+		AddServer("server-5", raft.Nonvoter, nil).
+		FailServer("server-5")
+
+	// only on demand rounds will run
+	ap := c.New(autopilot.WithReconcileInterval(time.Hour))
+	ap.Start(context.Background())
+	defer func() { <-ap.Stop() }()
+
+	changes, err := ap.ReconcileNow(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, changes)
+
+	changes, err = ap.PruneNow(context.Background())
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	require.Equal(t, autopilot.MembershipChangeRemoval, changes[0].Type)
+	require.Equal(t, raft.ServerID("server-5"), changes[0].Server.ID)
+	require.Equal(t, []raft.ServerID{"server-5"}, c.Delegate.Removed())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = ap.ReconcileNow(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestPruneContinuesAfterErrors(t *testing.T) {
+	c := NewCluster(&autopilot.Config{
+		CleanupDeadServers:   true,
+		LastContactThreshold: time.Second,
+		MaxTrailingLogs:      100,
+		MinQuorum:            3,
+	})
+	c.AddServer("server-2", raft.Voter, nil).
+		AddServer("server-3", raft.Voter, nil).
+		AddServer("server-4", raft.Nonvoter, nil).
+		AddServer("server-5", raft.Nonvoter, nil).
+		FailServer("server-5")
+	c.Delegate.DeleteServer("server-4")
+
+	injected := errors.New("injected")
+	c.Raft.FailNext("RemoveServer", injected)
+
+	ap := c.New(autopilot.WithDecisionLog(10))
+	err := ap.Step(context.Background())
+	require.ErrorIs(t, err, injected)
+
+	// the failed server was still removed after the stale server failed to be
+	require.Equal(t, []raft.ServerID{"server-5"}, c.Delegate.Removed())
+
+	var types []autopilot.DecisionType
+	for _, d := range ap.Decisions() {
+		types = append(types, d.Type)
+	}
+	require.Equal(t, []autopilot.DecisionType{autopilot.DecisionRemovalFailed, autopilot.DecisionRemove}, types)
+	require.Equal(t, raft.ServerID("server-4"), ap.Decisions()[0].ServerID)
+	require.Contains(t, ap.Decisions()[0].Values["error"], "injected")
+}
+
+func TestRaftObservations(t *testing.T) {
+	c := NewCluster(&autopilot.Config{
+		LastContactThreshold: time.Second,
+		MaxTrailingLogs:      100,
+	})
+
+	observations := make(chan raft.Observation)
+	ap := c.New(
+		autopilot.WithUpdateInterval(time.Hour),
+		autopilot.WithReconcileInterval(time.Hour),
+		autopilot.WithRaftObservations(observations),
+	)
+	ap.Start(context.Background())
+	defer func() { <-ap.Stop() }()
+
+	require.Len(t, ap.GetState().Servers, 1)
+	c.AddServer("server-2", raft.Nonvoter, nil)
+
+	// irrelevant observations are ignored
+	observations <- raft.Observation{Data: raft.RequestVoteRequest{}}
+	require.Len(t, ap.GetState().Servers, 1)
+
+	// the state is updated and reconciled without waiting for the next
+	// intervals
+	observations <- raft.Observation{Data: raft.PeerObservation{Peer: raft.Server{ID: "server-2"}}}
+	require.Eventually(t, func() bool {
+		for _, srv := range c.Raft.Servers() {
+			if srv.ID == "server-2" {
+				return srv.Suffrage == raft.Voter
+			}
+		}
+		return false
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Len(t, ap.GetState().Servers, 2)
+
+	// a closed channel does not stop autopilot
+	close(observations)
+	_, err := ap.ReconcileNow(context.Background())
+	require.NoError(t, err)
+}
+
+func TestStandby(t *testing.T) {
+	c := NewCluster(&autopilot.Config{
+		LastContactThreshold: time.Second,
+		MaxTrailingLogs:      100,
+	})
+	c.AddServer("server-2", raft.Nonvoter, nil)
+
+	ap := c.New(autopilot.WithUpdateInterval(10*time.Millisecond), autopilot.WithReconcileInterval(time.Hour))
+	ap.StartStandby(context.Background())
+	defer func() { <-ap.Stop() }()
+
+	status, _ := ap.IsRunning()
+	require.Equal(t, autopilot.Standby, status)
+
+	// the state is built but never acted upon
+	require.Eventually(t, func() bool {
+		return len(ap.GetState().Servers) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	_, err := ap.ReconcileNow(context.Background())
+	require.ErrorIs(t, err, autopilot.ErrNotRunning)
+	suffrage, _ := c.Raft.Suffrage("server-2")
+	require.Equal(t, raft.Nonvoter, suffrage)
+
+	// starting takes over with the state built while in standby
+	ap.Start(context.Background())
+	require.Len(t, ap.GetState().Servers, 2)
+	require.Eventually(t, func() bool {
+		status, _ := ap.IsRunning()
+		return status == autopilot.Running
+	}, 5*time.Second, 10*time.Millisecond)
+
+	changes, err := ap.ReconcileNow(context.Background())
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	require.Equal(t, autopilot.MembershipChangePromotion, changes[0].Type)
+}
+
+type persistingDelegate struct {
+	*FakeDelegate
+
+	loaded    map[raft.ServerID]autopilot.PersistedServerHealth
+	persisted []map[raft.ServerID]autopilot.PersistedServerHealth
+}
+
+func (d *persistingDelegate) PersistServerHealth(health map[raft.ServerID]autopilot.PersistedServerHealth) {
+	d.persisted = append(d.persisted, health)
+}
+
+func (d *persistingDelegate) LoadServerHealth() map[raft.ServerID]autopilot.PersistedServerHealth {
+	return d.loaded
+}
+
+func TestStatePersister(t *testing.T) {
+	c := NewCluster(&autopilot.Config{
+		LastContactThreshold: time.Second,
+		MaxTrailingLogs:      100,
+	})
+	c.AddServer("server-2", raft.Nonvoter, nil).
+		AddServer("server-3", raft.Nonvoter, nil)
+
+	start := c.Clock.Now()
+	delegate := &persistingDelegate{
+		FakeDelegate: c.Delegate,
+		loaded: map[raft.ServerID]autopilot.PersistedServerHealth{
+			"server-2": {Healthy: true, StableSince: start.Add(-time.Hour)},
+			"server-3": {Healthy: false, StableSince: start.Add(-time.Hour)},
+		},
+	}
+	ap := autopilot.New(c.Raft, delegate, c.Options(autopilot.WithReconciliationDisabled())...)
+	require.NoError(t, ap.Step(context.Background()))
+
+	// only the health which has not changed is restored
+	state := ap.GetState()
+	require.Equal(t, start.Add(-time.Hour), state.Servers["server-2"].Health.StableSince)
+	require.Equal(t, start, state.Servers["server-3"].Health.StableSince)
+	require.Equal(t, start, state.Servers["server-1"].Health.StableSince)
+
+	require.Len(t, delegate.persisted, 1)
+	require.Equal(t, map[raft.ServerID]autopilot.PersistedServerHealth{
+		"server-1": {Healthy: true, StableSince: start},
+		"server-2": {Healthy: true, StableSince: start.Add(-time.Hour)},
+		"server-3": {Healthy: true, StableSince: start},
+	}, delegate.persisted[0])
+
+	// the health is only persisted again once it changes
+	c.Clock.Advance(time.Minute)
+	require.NoError(t, ap.Step(context.Background()))
+	require.Len(t, delegate.persisted, 1)
+
+	c.FailServer("server-3")
+	c.Clock.Advance(time.Minute)
+	require.NoError(t, ap.Step(context.Background()))
+	require.Len(t, delegate.persisted, 2)
+	require.False(t, delegate.persisted[1]["server-3"].Healthy)
+}
+
+type operationPersistingDelegate struct {
+	*FakeDelegate
+
+	operations autopilot.PersistedOperations
+}
+
+func (d *operationPersistingDelegate) PersistOperations(ops autopilot.PersistedOperations) {
+	d.operations = ops
+}
+
+func (d *operationPersistingDelegate) LoadOperations() autopilot.PersistedOperations {
+	return d.operations
+}
+
+func TestEvacuationLeaderChange(t *testing.T) {
+	c := NewCluster(&autopilot.Config{
+		LastContactThreshold:    time.Second,
+		MaxTrailingLogs:         100,
+		ServerStabilizationTime: 10 * time.Second,
+		ZoneKey:                 "zone",
+	})
+	c.AddServer("server-2", raft.Voter, map[string]string{"zone": "a"}).
+		AddServer("server-3", raft.Voter, map[string]string{"zone": "b"}).
+		AddServer("server-4", raft.Nonvoter, map[string]string{"zone": "b"}).
+		AddServer("server-5", raft.Nonvoter, map[string]string{"zone": "c"})
+
+	leader := c.Delegate.KnownServers()["server-1"]
+	leader.Meta = map[string]string{"zone": "a"}
+	c.Delegate.SetServer(leader)
+
+	step := func(ap *autopilot.Autopilot) {
+		c.Clock.Advance(time.Minute)
+		require.NoError(t, ap.Step(context.Background()))
+	}
+
+	// without persistence the leader's zone may not be evacuated as the next
+	// leader would abandon the evacuation
+	evacuation := autopilot.WithFeatures(autopilot.FeatureZoneEvacuation)
+	first := c.New(evacuation)
+	step(first)
+	require.Error(t, first.EvacuateZone("a"))
+
+	// the non-voters were promoted by the first round
+	delegate := &operationPersistingDelegate{FakeDelegate: c.Delegate}
+	first = autopilot.New(c.Raft, delegate, c.Options(evacuation)...)
+	step(first)
+	require.NoError(t, first.EvacuateZone("a"))
+	require.Len(t, delegate.operations.Evacuations, 1)
+	require.Equal(t, "a", delegate.operations.Evacuations[0].Zone)
+	require.Equal(t, 5, delegate.operations.Evacuations[0].TargetVoters)
+
+	// the first leader demotes the other voter within the zone and then moves
+	// leadership out of the zone
+	for i := 0; i < 10 && c.Raft.LeaderID() == "server-1"; i++ {
+		step(first)
+	}
+	require.NotEqual(t, raft.ServerID("server-1"), c.Raft.LeaderID())
+	suffrage, _ := c.Raft.Suffrage("server-2")
+	require.Equal(t, raft.Nonvoter, suffrage)
+	suffrage, _ = c.Raft.Suffrage("server-1")
+	require.Equal(t, raft.Voter, suffrage)
+
+	// the new leader continues the evacuation, demoting the old leader rather
+	// than promoting the zone's servers again
+	second := autopilot.New(c.Raft, delegate, c.Options(evacuation)...)
+	for i := 0; i < 10; i++ {
+		step(second)
+		if suffrage, _ = c.Raft.Suffrage("server-1"); suffrage == raft.Nonvoter {
+			break
+		}
+	}
+	require.Equal(t, raft.Nonvoter, suffrage)
+	suffrage, _ = c.Raft.Suffrage("server-2")
+	require.Equal(t, raft.Nonvoter, suffrage)
+
+	step(second)
+	evacuations := second.Evacuations()
+	require.Len(t, evacuations, 1)
+	require.True(t, evacuations[0].Complete)
+
+	require.NoError(t, second.RestoreZone("a"))
+	require.Empty(t, delegate.operations.Evacuations)
+}
+
+func TestMaxRemovalsPerRound(t *testing.T) {
+	c := NewCluster(&autopilot.Config{
+		CleanupDeadServers:   true,
+		LastContactThreshold: time.Second,
+		MaxTrailingLogs:      100,
+		MinQuorum:            3,
+		MaxRemovalsPerRound:  2,
+	})
+	c.AddServer("server-2", raft.Voter, nil).
+		AddServer("server-3", raft.Voter, nil).
+		AddServer("server-4", raft.Nonvoter, nil).
+		AddServer("server-5", raft.Nonvoter, nil).
+		AddServer("server-6", raft.Nonvoter, nil).
+		FailServer("server-5").
+		FailServer("server-6")
+	c.Delegate.DeleteServer("server-4")
+
+	ap := c.New(autopilot.WithDecisionLog(10))
+	require.NoError(t, ap.Step(context.Background()))
+
+	// the stale server is removed before the failed servers
+	_, ok := c.Raft.Suffrage("server-4")
+	require.False(t, ok)
+	require.Equal(t, []raft.ServerID{"server-5"}, c.Delegate.Removed())
+
+	var skipped []raft.ServerID
+	for _, d := range ap.Decisions() {
+		if d.Type == autopilot.DecisionSkipRemoval {
+			require.Equal(t, autopilot.ReasonRemovalLimit, d.Reason)
+			skipped = append(skipped, d.ServerID)
+		}
+	}
+	require.Equal(t, []raft.ServerID{"server-6"}, skipped)
+
+	// the remaining server is removed in the next round
+	require.NoError(t, ap.Step(context.Background()))
+	require.Equal(t, []raft.ServerID{"server-5", "server-6"}, c.Delegate.Removed())
+}
+
+func TestFailedVoterDemotionPeriod(t *testing.T) {
+	c := NewCluster(&autopilot.Config{
+		CleanupDeadServers:        true,
+		LastContactThreshold:      time.Second,
+		MaxTrailingLogs:           100,
+		MinQuorum:                 3,
+		FailedVoterDemotionPeriod: time.Minute,
+	})
+	c.AddServer("server-2", raft.Voter, nil).
+		AddServer("server-3", raft.Voter, nil).
+		AddServer("server-4", raft.Voter, nil).
+		AddServer("server-5", raft.Voter, nil).
+		FailServer("server-5")
+
+	ap := c.New()
+	require.NoError(t, ap.Step(context.Background()))
+
+	// the failed voter is demoted rather than removed
+	suffrage, ok := c.Raft.Suffrage("server-5")
+	require.True(t, ok)
+	require.Equal(t, raft.Nonvoter, suffrage)
+	require.Empty(t, c.Delegate.Removed())
+
+	c.Clock.Advance(30 * time.Second)
+	require.NoError(t, ap.Step(context.Background()))
+	require.Empty(t, c.Delegate.Removed())
+
+	// once failed for the period after its demotion it is removed
+	c.Clock.Advance(30 * time.Second)
+	require.NoError(t, ap.Step(context.Background()))
+	require.Equal(t, []raft.ServerID{"server-5"}, c.Delegate.Removed())
+}
+
+func TestProtectedServersNotRemoved(t *testing.T) {
+	c := NewCluster(&autopilot.Config{
+		CleanupDeadServers:   true,
+		LastContactThreshold: time.Second,
+		MaxTrailingLogs:      100,
+		MinQuorum:            3,
+	})
+	c.AddServer("server-2", raft.Voter, nil).
+		AddServer("server-3", raft.Voter, nil).
+		AddServer("server-4", raft.Nonvoter, nil).
+		AddServer("server-5", raft.Nonvoter, nil)
+	c.Delegate.SetServer(&autopilot.Server{
+		ID:          "server-4",
+		Name:        "server-4",
+		Address:     "198.18.0.4:8300",
+		NodeStatus:  autopilot.NodeAlive,
+		NodeType:    autopilot.NodeVoter,
+		RaftVersion: 3,
+		Version:     "1.0.0",
+		Protected:   true,
+	})
+	c.FailServer("server-4").FailServer("server-5")
+
+	ap := c.New()
+	require.NoError(t, ap.Step(context.Background()))
+
+	require.Equal(t, []raft.ServerID{"server-5"}, c.Delegate.Removed())
+	_, ok := c.Raft.Suffrage("server-4")
+	require.True(t, ok)
+}
@@ -0,0 +1,44 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilottest
+
+import (
+	autopilot "github.com/hashicorp/raft-autopilot"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ConfigProvider is an autogenerated mock type for the ConfigProvider type
+type ConfigProvider struct {
+	mock.Mock
+}
+
+// AutopilotConfig provides a mock function with given fields:
+func (_m *ConfigProvider) AutopilotConfig() *autopilot.Config {
+	ret := _m.Called()
+
+	var r0 *autopilot.Config
+	if rf, ok := ret.Get(0).(func() *autopilot.Config); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*autopilot.Config)
+		}
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewConfigProvider interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewConfigProvider creates a new instance of ConfigProvider. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewConfigProvider(t mockConstructorTestingTNewConfigProvider) *ConfigProvider {
+	mock := &ConfigProvider{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,201 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilottest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/raft"
+	autopilot "github.com/hashicorp/raft-autopilot"
+)
+
+// DefaultConformanceTimeout is how long each ApplicationIntegration method may
+// take during the conformance checks when no timeout is configured.
+const DefaultConformanceTimeout = time.Second
+
+// ConformanceOptions configures the checks made by TestApplicationIntegration.
+type ConformanceOptions struct {
+	// Timeout is how long each ApplicationIntegration method may take before
+	// it is considered to be blocking. Defaults to DefaultConformanceTimeout.
+	Timeout time.Duration
+
+	// FailedServer is passed to RemoveFailedServer to check that it returns
+	// promptly. As removing a server is destructive the check is skipped when
+	// this is nil.
+	FailedServer *autopilot.Server
+}
+
+// TestApplicationIntegration runs a suite of checks against an
+// ApplicationIntegration implementation as subtests of t, verifying that it
+// meets autopilot's expectations:
+//
+//   - AutopilotConfig returns a configuration with sane thresholds.
+//   - KnownServers returns servers keyed by their own non-empty IDs with
+//     unique addresses, and the IDs are stable between calls.
+//   - FetchServerStats only returns stats for the requested servers, honours
+//     its context and returns sane stats.
+//   - NotifyState and RemoveFailedServer return promptly.
+//
+// It is intended to be called from the application's own tests with a
+// delegate connected to a test instance of the application.
+func TestApplicationIntegration(t *testing.T, delegate autopilot.ApplicationIntegration, opts ConformanceOptions) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultConformanceTimeout
+	}
+
+	t.Run("autopilot-config", func(t *testing.T) {
+		var conf *autopilot.Config
+		returnsWithin(t, opts.Timeout, "AutopilotConfig", func() {
+			conf = delegate.AutopilotConfig()
+		})
+
+		if conf == nil {
+			t.Fatal("AutopilotConfig returned a nil configuration")
+		}
+		if conf.LastContactThreshold <= 0 {
+			t.Errorf("LastContactThreshold must be positive, got %s", conf.LastContactThreshold)
+		}
+		if conf.MaxTrailingLogs == 0 {
+			t.Error("MaxTrailingLogs must be positive")
+		}
+		if conf.ServerStabilizationTime < 0 {
+			t.Errorf("ServerStabilizationTime must not be negative, got %s", conf.ServerStabilizationTime)
+		}
+	})
+
+	t.Run("known-servers", func(t *testing.T) {
+		var first, second map[raft.ServerID]*autopilot.Server
+		returnsWithin(t, opts.Timeout, "KnownServers", func() {
+			first = delegate.KnownServers()
+			second = delegate.KnownServers()
+		})
+
+		if len(first) == 0 {
+			t.Fatal("KnownServers returned no servers")
+		}
+
+		addresses := make(map[raft.ServerAddress]raft.ServerID)
+		for id, srv := range first {
+			switch {
+			case srv == nil:
+				t.Errorf("server %q is nil", id)
+				continue
+			case id == "":
+				t.Error("a server has an empty ID")
+			case srv.ID != id:
+				t.Errorf("server %q is keyed by %q", srv.ID, id)
+			}
+
+			if srv.Address == "" {
+				t.Errorf("server %q has an empty address", id)
+			} else if other, ok := addresses[srv.Address]; ok {
+				t.Errorf("servers %q and %q share the address %q", other, id, srv.Address)
+			}
+			addresses[srv.Address] = id
+
+			switch srv.NodeStatus {
+			case autopilot.NodeAlive, autopilot.NodeFailed, autopilot.NodeLeft, autopilot.NodeUnknown:
+			default:
+				t.Errorf("server %q has an unknown node status %q", id, srv.NodeStatus)
+			}
+
+			if _, ok := second[id]; !ok {
+				t.Errorf("server %q was missing when KnownServers was called again, server IDs must be stable", id)
+			}
+		}
+	})
+
+	t.Run("fetch-server-stats", func(t *testing.T) {
+		known := delegate.KnownServers()
+
+		// leave out one server to ensure that only the requested servers
+		// have their stats returned
+		requested := make(map[raft.ServerID]*autopilot.Server)
+		var excluded raft.ServerID
+		for id, srv := range known {
+			if excluded == "" && len(known) > 1 {
+				excluded = id
+				continue
+			}
+			requested[id] = srv
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout/2)
+		defer cancel()
+
+		var stats map[raft.ServerID]*autopilot.ServerStats
+		returnsWithin(t, opts.Timeout, "FetchServerStats", func() {
+			stats = delegate.FetchServerStats(ctx, requested)
+		})
+
+		for id, s := range stats {
+			if _, ok := requested[id]; !ok {
+				t.Errorf("stats were returned for server %q which was not requested", id)
+			}
+			if s == nil {
+				t.Errorf("nil stats were returned for server %q", id)
+				continue
+			}
+			if s.LastIndex > 0 && s.LastTerm == 0 {
+				t.Errorf("server %q reports a last index of %d without a last term", id, s.LastIndex)
+			}
+		}
+	})
+
+	t.Run("fetch-server-stats-cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		returnsWithin(t, opts.Timeout, "FetchServerStats with a cancelled context", func() {
+			delegate.FetchServerStats(ctx, delegate.KnownServers())
+		})
+	})
+
+	t.Run("notify-state", func(t *testing.T) {
+		state := &autopilot.State{
+			Healthy: true,
+			Servers: make(map[raft.ServerID]*autopilot.ServerState),
+		}
+		for id, srv := range delegate.KnownServers() {
+			state.Servers[id] = &autopilot.ServerState{Server: *srv}
+		}
+
+		returnsWithin(t, opts.Timeout, "NotifyState", func() {
+			delegate.NotifyState(state)
+		})
+	})
+
+	t.Run("remove-failed-server", func(t *testing.T) {
+		if opts.FailedServer == nil {
+			t.Skip("no FailedServer was configured")
+		}
+
+		returnsWithin(t, opts.Timeout, "RemoveFailedServer", func() {
+			delegate.RemoveFailedServer(opts.FailedServer)
+		})
+	})
+}
+
+// returnsWithin fails the test when fn does not return within the timeout.
+// Should fn block forever its go routine is leaked.
+func returnsWithin(t *testing.T, timeout time.Duration, name string, fn func()) {
+	t.Helper()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+	case <-timer.C:
+		t.Fatalf("%s did not return within %s", name, timeout)
+	}
+}
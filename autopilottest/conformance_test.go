@@ -0,0 +1,27 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilottest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/raft"
+	autopilot "github.com/hashicorp/raft-autopilot"
+)
+
+func TestApplicationIntegrationConformance(t *testing.T) {
+	c := NewCluster(&autopilot.Config{
+		LastContactThreshold:    time.Second,
+		MaxTrailingLogs:         100,
+		ServerStabilizationTime: 10 * time.Second,
+	})
+	c.AddServer("server-2", raft.Voter, nil).
+		AddServer("server-3", raft.Nonvoter, nil).
+		FailServer("server-3")
+
+	TestApplicationIntegration(t, c.Delegate, ConformanceOptions{
+		FailedServer: c.Delegate.KnownServers()["server-3"],
+	})
+}
@@ -0,0 +1,33 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilottest
+
+import (
+	autopilot "github.com/hashicorp/raft-autopilot"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// DecisionRecorder is an autogenerated mock type for the DecisionRecorder type
+type DecisionRecorder struct {
+	mock.Mock
+}
+
+// RecordDecision provides a mock function with given fields: _a0
+func (_m *DecisionRecorder) RecordDecision(_a0 autopilot.Decision) {
+	_m.Called(_a0)
+}
+
+type mockConstructorTestingTNewDecisionRecorder interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewDecisionRecorder creates a new instance of DecisionRecorder. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewDecisionRecorder(t mockConstructorTestingTNewDecisionRecorder) *DecisionRecorder {
+	mock := &DecisionRecorder{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,23 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package autopilottest provides utilities for testing applications and
+// promoters built upon the autopilot package.
+//
+// It contains mockery generated mocks of all the autopilot interfaces, an in
+// memory FakeRaft, a scriptable FakeDelegate, a VirtualClock and a Cluster
+// builder for setting up scenarios such as failing, recovering and lagging
+// servers. Unlike the mocks within the autopilot
+// package itself, which exist to test autopilot, the exported API of this
+// package follows the same compatibility guarantees as the autopilot package.
+//
+// Simulate replays a declarative Scenario of servers joining, failing and
+// lagging over virtual time against autopilot's loop and reports the decisions
+// made, which allows validating promoters against realistic cluster timelines.
+//
+// Applications may also run TestApplicationIntegration from their own tests
+// to verify that their ApplicationIntegration implementation meets autopilot's
+// expectations.
+package autopilottest
+
+//go:generate mockery --all --case snake --dir .. --output . --outpkg autopilottest
@@ -0,0 +1,63 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilottest
+
+import (
+	context "context"
+
+	autopilot "github.com/hashicorp/raft-autopilot"
+
+	mock "github.com/stretchr/testify/mock"
+
+	raft "github.com/hashicorp/raft"
+)
+
+// Enricher is an autogenerated mock type for the Enricher type
+type Enricher struct {
+	mock.Mock
+}
+
+// Enrich provides a mock function with given fields: ctx, servers
+func (_m *Enricher) Enrich(ctx context.Context, servers map[raft.ServerID]*autopilot.Server) map[raft.ServerID]interface{} {
+	ret := _m.Called(ctx, servers)
+
+	var r0 map[raft.ServerID]interface{}
+	if rf, ok := ret.Get(0).(func(context.Context, map[raft.ServerID]*autopilot.Server) map[raft.ServerID]interface{}); ok {
+		r0 = rf(ctx, servers)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[raft.ServerID]interface{})
+		}
+	}
+
+	return r0
+}
+
+// Name provides a mock function with given fields:
+func (_m *Enricher) Name() string {
+	ret := _m.Called()
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewEnricher interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewEnricher creates a new instance of Enricher. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewEnricher(t mockConstructorTestingTNewEnricher) *Enricher {
+	mock := &Enricher{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
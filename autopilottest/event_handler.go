@@ -0,0 +1,33 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilottest
+
+import (
+	autopilot "github.com/hashicorp/raft-autopilot"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// EventHandler is an autogenerated mock type for the EventHandler type
+type EventHandler struct {
+	mock.Mock
+}
+
+// Execute provides a mock function with given fields: _a0
+func (_m *EventHandler) Execute(_a0 autopilot.Event) {
+	_m.Called(_a0)
+}
+
+type mockConstructorTestingTNewEventHandler interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewEventHandler creates a new instance of EventHandler. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewEventHandler(t mockConstructorTestingTNewEventHandler) *EventHandler {
+	mock := &EventHandler{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,41 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilottest
+
+import mock "github.com/stretchr/testify/mock"
+
+// ExtCloner is an autogenerated mock type for the ExtCloner type
+type ExtCloner struct {
+	mock.Mock
+}
+
+// CloneExt provides a mock function with given fields:
+func (_m *ExtCloner) CloneExt() interface{} {
+	ret := _m.Called()
+
+	var r0 interface{}
+	if rf, ok := ret.Get(0).(func() interface{}); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(interface{})
+		}
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewExtCloner interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewExtCloner creates a new instance of ExtCloner. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewExtCloner(t mockConstructorTestingTNewExtCloner) *ExtCloner {
+	mock := &ExtCloner{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
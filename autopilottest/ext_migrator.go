@@ -0,0 +1,33 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilottest
+
+import (
+	autopilot "github.com/hashicorp/raft-autopilot"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ExtMigrator is an autogenerated mock type for the ExtMigrator type
+type ExtMigrator struct {
+	mock.Mock
+}
+
+// MigrateExt provides a mock function with given fields: previous, conf, state
+func (_m *ExtMigrator) MigrateExt(previous autopilot.Promoter, conf *autopilot.Config, state *autopilot.State) {
+	_m.Called(previous, conf, state)
+}
+
+type mockConstructorTestingTNewExtMigrator interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewExtMigrator creates a new instance of ExtMigrator. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewExtMigrator(t mockConstructorTestingTNewExtMigrator) *ExtMigrator {
+	mock := &ExtMigrator{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,33 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilottest
+
+import (
+	autopilot "github.com/hashicorp/raft-autopilot"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// FailedServerRemover is an autogenerated mock type for the FailedServerRemover type
+type FailedServerRemover struct {
+	mock.Mock
+}
+
+// RemoveFailedServer provides a mock function with given fields: _a0
+func (_m *FailedServerRemover) RemoveFailedServer(_a0 *autopilot.Server) {
+	_m.Called(_a0)
+}
+
+type mockConstructorTestingTNewFailedServerRemover interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewFailedServerRemover creates a new instance of FailedServerRemover. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewFailedServerRemover(t mockConstructorTestingTNewFailedServerRemover) *FailedServerRemover {
+	mock := &FailedServerRemover{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,187 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilottest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/hashicorp/raft"
+	autopilot "github.com/hashicorp/raft-autopilot"
+)
+
+// FakeDelegate is a configurable implementation of the
+// autopilot.ApplicationIntegration interface. It records the servers autopilot
+// asks to be removed and the states it is notified of. Tests may script
+// changes to the cluster by registering functions with OnCall which run when
+// autopilot next calls the delegate.
+type FakeDelegate struct {
+	lock    sync.Mutex
+	config  *autopilot.Config
+	servers map[raft.ServerID]*autopilot.Server
+	stats   map[raft.ServerID]*autopilot.ServerStats
+
+	removed []raft.ServerID
+	states  []*autopilot.State
+
+	// calls counts the calls of each method and hooks holds the functions
+	// to run upon the next call of each method.
+	calls map[string]int
+	hooks map[string][]func()
+}
+
+var _ autopilot.ApplicationIntegration = (*FakeDelegate)(nil)
+
+// NewFakeDelegate creates a FakeDelegate returning the given configuration
+// and no known servers.
+func NewFakeDelegate(config *autopilot.Config) *FakeDelegate {
+	return &FakeDelegate{
+		config:  config,
+		servers: make(map[raft.ServerID]*autopilot.Server),
+		stats:   make(map[raft.ServerID]*autopilot.ServerStats),
+		calls:   make(map[string]int),
+		hooks:   make(map[string][]func()),
+	}
+}
+
+// OnCall registers a function to run once upon the next call of the named
+// delegate method, such as "KnownServers", before the method does anything
+// else. The function may use the delegate's setters to change the cluster
+// which allows scripting changes to happen part way through an autopilot
+// round.
+func (d *FakeDelegate) OnCall(method string, fn func()) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.hooks[method] = append(d.hooks[method], fn)
+}
+
+// Calls returns the number of times the named delegate method was called.
+func (d *FakeDelegate) Calls(method string) int {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.calls[method]
+}
+
+// called counts a call of the method and runs any functions registered for
+// it with OnCall. It must be called without holding the lock.
+func (d *FakeDelegate) called(method string) {
+	d.lock.Lock()
+	d.calls[method]++
+	hooks := d.hooks[method]
+	delete(d.hooks, method)
+	d.lock.Unlock()
+
+	for _, fn := range hooks {
+		fn()
+	}
+}
+
+// SetConfig sets the configuration returned by AutopilotConfig.
+func (d *FakeDelegate) SetConfig(config *autopilot.Config) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.config = config
+}
+
+// SetServer adds or replaces a known server.
+func (d *FakeDelegate) SetServer(srv *autopilot.Server) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	copied := *srv
+	d.servers[srv.ID] = &copied
+}
+
+// SetNodeStatus changes the status of a known server.
+func (d *FakeDelegate) SetNodeStatus(id raft.ServerID, status autopilot.NodeStatus) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if srv, ok := d.servers[id]; ok {
+		srv.NodeStatus = status
+	}
+}
+
+// DeleteServer removes a known server.
+func (d *FakeDelegate) DeleteServer(id raft.ServerID) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	delete(d.servers, id)
+	delete(d.stats, id)
+}
+
+// SetStats sets the stats returned for a server by FetchServerStats.
+func (d *FakeDelegate) SetStats(id raft.ServerID, stats autopilot.ServerStats) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.stats[id] = &stats
+}
+
+// Removed returns the IDs of all servers passed to RemoveFailedServer.
+func (d *FakeDelegate) Removed() []raft.ServerID {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return append([]raft.ServerID(nil), d.removed...)
+}
+
+// States returns all the states passed to NotifyState.
+func (d *FakeDelegate) States() []*autopilot.State {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return append([]*autopilot.State(nil), d.states...)
+}
+
+func (d *FakeDelegate) AutopilotConfig() *autopilot.Config {
+	d.called("AutopilotConfig")
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.config
+}
+
+func (d *FakeDelegate) NotifyState(state *autopilot.State) {
+	d.called("NotifyState")
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.states = append(d.states, state)
+}
+
+func (d *FakeDelegate) FetchServerStats(_ context.Context, servers map[raft.ServerID]*autopilot.Server) map[raft.ServerID]*autopilot.ServerStats {
+	d.called("FetchServerStats")
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	stats := make(map[raft.ServerID]*autopilot.ServerStats)
+	for id := range servers {
+		if s, ok := d.stats[id]; ok {
+			copied := *s
+			stats[id] = &copied
+		}
+	}
+	return stats
+}
+
+func (d *FakeDelegate) KnownServers() map[raft.ServerID]*autopilot.Server {
+	d.called("KnownServers")
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	servers := make(map[raft.ServerID]*autopilot.Server, len(d.servers))
+	for id, srv := range d.servers {
+		copied := *srv
+		servers[id] = &copied
+	}
+	return servers
+}
+
+func (d *FakeDelegate) RemoveFailedServer(srv *autopilot.Server) {
+	d.called("RemoveFailedServer")
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.removed = append(d.removed, srv.ID)
+	delete(d.servers, srv.ID)
+	delete(d.stats, srv.ID)
+}
@@ -0,0 +1,239 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilottest
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/raft"
+	autopilot "github.com/hashicorp/raft-autopilot"
+)
+
+// FakeRaft is an in memory implementation of the autopilot.Raft interface. It
+// maintains a Raft configuration which is modified by the membership changes
+// autopilot makes so that tests can assert upon the resulting configuration.
+type FakeRaft struct {
+	lock      sync.Mutex
+	servers   []raft.Server
+	leader    raft.ServerID
+	state     raft.RaftState
+	lastIndex uint64
+	lastTerm  uint64
+
+	// errors holds errors to return from the next call of each method.
+	errors map[string]error
+}
+
+var _ autopilot.Raft = (*FakeRaft)(nil)
+
+// NewFakeRaft creates a FakeRaft with the given servers where the local server
+// is the leader.
+func NewFakeRaft(leader raft.ServerID, servers ...raft.Server) *FakeRaft {
+	return &FakeRaft{
+		servers:   append([]raft.Server(nil), servers...),
+		leader:    leader,
+		state:     raft.Leader,
+		lastIndex: 1,
+		lastTerm:  1,
+		errors:    make(map[string]error),
+	}
+}
+
+// FailNext causes the next call to the named Raft method, such as "AddVoter",
+// to fail with the given error without modifying the configuration.
+func (r *FakeRaft) FailNext(method string, err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.errors[method] = err
+}
+
+// SetLastLog sets the index and term of the last log entry.
+func (r *FakeRaft) SetLastLog(index, term uint64) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.lastIndex = index
+	r.lastTerm = term
+}
+
+// SetState sets the Raft state of the local server.
+func (r *FakeRaft) SetState(state raft.RaftState) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.state = state
+}
+
+// Servers returns a copy of the servers in the current configuration.
+func (r *FakeRaft) Servers() []raft.Server {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]raft.Server(nil), r.servers...)
+}
+
+// Suffrage returns the suffrage of the server and whether it is in the
+// configuration at all.
+func (r *FakeRaft) Suffrage(id raft.ServerID) (raft.ServerSuffrage, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if idx := r.indexOf(id); idx >= 0 {
+		return r.servers[idx].Suffrage, true
+	}
+	return 0, false
+}
+
+// LeaderID returns the ID of the current leader.
+func (r *FakeRaft) LeaderID() raft.ServerID {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.leader
+}
+
+func (r *FakeRaft) AddNonvoter(id raft.ServerID, address raft.ServerAddress, _ uint64, _ time.Duration) raft.IndexFuture {
+	return r.change("AddNonvoter", func() error {
+		if idx := r.indexOf(id); idx >= 0 {
+			r.servers[idx].Address = address
+			return nil
+		}
+		r.servers = append(r.servers, raft.Server{Suffrage: raft.Nonvoter, ID: id, Address: address})
+		return nil
+	})
+}
+
+func (r *FakeRaft) AddVoter(id raft.ServerID, address raft.ServerAddress, _ uint64, _ time.Duration) raft.IndexFuture {
+	return r.change("AddVoter", func() error {
+		if idx := r.indexOf(id); idx >= 0 {
+			r.servers[idx].Address = address
+			r.servers[idx].Suffrage = raft.Voter
+			return nil
+		}
+		r.servers = append(r.servers, raft.Server{Suffrage: raft.Voter, ID: id, Address: address})
+		return nil
+	})
+}
+
+func (r *FakeRaft) DemoteVoter(id raft.ServerID, _ uint64, _ time.Duration) raft.IndexFuture {
+	return r.change("DemoteVoter", func() error {
+		idx := r.indexOf(id)
+		if idx < 0 {
+			return fmt.Errorf("server %s is not in the configuration", id)
+		}
+		r.servers[idx].Suffrage = raft.Nonvoter
+		return nil
+	})
+}
+
+func (r *FakeRaft) RemoveServer(id raft.ServerID, _ uint64, _ time.Duration) raft.IndexFuture {
+	return r.change("RemoveServer", func() error {
+		idx := r.indexOf(id)
+		if idx < 0 {
+			return nil
+		}
+		r.servers = append(r.servers[:idx], r.servers[idx+1:]...)
+		return nil
+	})
+}
+
+func (r *FakeRaft) LeadershipTransferToServer(id raft.ServerID, _ raft.ServerAddress) raft.Future {
+	return r.change("LeadershipTransferToServer", func() error {
+		idx := r.indexOf(id)
+		if idx < 0 || r.servers[idx].Suffrage != raft.Voter {
+			return fmt.Errorf("server %s is not a voter", id)
+		}
+		r.leader = id
+		return nil
+	})
+}
+
+func (r *FakeRaft) LastIndex() uint64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.lastIndex
+}
+
+func (r *FakeRaft) Leader() raft.ServerAddress {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if idx := r.indexOf(r.leader); idx >= 0 {
+		return r.servers[idx].Address
+	}
+	return ""
+}
+
+func (r *FakeRaft) GetConfiguration() raft.ConfigurationFuture {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return &configurationFuture{
+		config: raft.Configuration{Servers: append([]raft.Server(nil), r.servers...)},
+		index:  r.lastIndex,
+		err:    r.takeError("GetConfiguration"),
+	}
+}
+
+func (r *FakeRaft) Stats() map[string]string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return map[string]string{
+		"last_log_index": strconv.FormatUint(r.lastIndex, 10),
+		"last_log_term":  strconv.FormatUint(r.lastTerm, 10),
+	}
+}
+
+func (r *FakeRaft) State() raft.RaftState {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.state
+}
+
+// change applies a configuration change unless an error was queued for the
+// method. Successful changes increment the last index.
+func (r *FakeRaft) change(method string, fn func() error) *indexFuture {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if err := r.takeError(method); err != nil {
+		return &indexFuture{err: err}
+	}
+
+	if err := fn(); err != nil {
+		return &indexFuture{err: err}
+	}
+
+	r.lastIndex++
+	return &indexFuture{index: r.lastIndex}
+}
+
+func (r *FakeRaft) takeError(method string) error {
+	err := r.errors[method]
+	delete(r.errors, method)
+	return err
+}
+
+func (r *FakeRaft) indexOf(id raft.ServerID) int {
+	for i, srv := range r.servers {
+		if srv.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+type indexFuture struct {
+	index uint64
+	err   error
+}
+
+func (f *indexFuture) Index() uint64 { return f.index }
+func (f *indexFuture) Error() error  { return f.err }
+
+type configurationFuture struct {
+	config raft.Configuration
+	index  uint64
+	err    error
+}
+
+func (f *configurationFuture) Index() uint64                     { return f.index }
+func (f *configurationFuture) Error() error                      { return f.err }
+func (f *configurationFuture) Configuration() raft.Configuration { return f.config }
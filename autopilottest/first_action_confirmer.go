@@ -0,0 +1,39 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilottest
+
+import mock "github.com/stretchr/testify/mock"
+
+// FirstActionConfirmer is an autogenerated mock type for the FirstActionConfirmer type
+type FirstActionConfirmer struct {
+	mock.Mock
+}
+
+// ConfirmFirstAction provides a mock function with given fields:
+func (_m *FirstActionConfirmer) ConfirmFirstAction() bool {
+	ret := _m.Called()
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func() bool); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewFirstActionConfirmer interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewFirstActionConfirmer creates a new instance of FirstActionConfirmer. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewFirstActionConfirmer(t mockConstructorTestingTNewFirstActionConfirmer) *FirstActionConfirmer {
+	mock := &FirstActionConfirmer{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
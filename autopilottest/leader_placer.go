@@ -0,0 +1,46 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilottest
+
+import (
+	autopilot "github.com/hashicorp/raft-autopilot"
+	mock "github.com/stretchr/testify/mock"
+
+	raft "github.com/hashicorp/raft"
+)
+
+// LeaderPlacer is an autogenerated mock type for the LeaderPlacer type
+type LeaderPlacer struct {
+	mock.Mock
+}
+
+// PreferredLeaders provides a mock function with given fields: _a0, _a1
+func (_m *LeaderPlacer) PreferredLeaders(_a0 *autopilot.Config, _a1 *autopilot.State) []raft.ServerID {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []raft.ServerID
+	if rf, ok := ret.Get(0).(func(*autopilot.Config, *autopilot.State) []raft.ServerID); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]raft.ServerID)
+		}
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewLeaderPlacer interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewLeaderPlacer creates a new instance of LeaderPlacer. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewLeaderPlacer(t mockConstructorTestingTNewLeaderPlacer) *LeaderPlacer {
+	mock := &LeaderPlacer{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,47 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilottest
+
+import (
+	autopilot "github.com/hashicorp/raft-autopilot"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// OperationPersister is an autogenerated mock type for the OperationPersister type
+type OperationPersister struct {
+	mock.Mock
+}
+
+// LoadOperations provides a mock function with given fields:
+func (_m *OperationPersister) LoadOperations() autopilot.PersistedOperations {
+	ret := _m.Called()
+
+	var r0 autopilot.PersistedOperations
+	if rf, ok := ret.Get(0).(func() autopilot.PersistedOperations); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(autopilot.PersistedOperations)
+	}
+
+	return r0
+}
+
+// PersistOperations provides a mock function with given fields: _a0
+func (_m *OperationPersister) PersistOperations(_a0 autopilot.PersistedOperations) {
+	_m.Called(_a0)
+}
+
+type mockConstructorTestingTNewOperationPersister interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewOperationPersister creates a new instance of OperationPersister. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewOperationPersister(t mockConstructorTestingTNewOperationPersister) *OperationPersister {
+	mock := &OperationPersister{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
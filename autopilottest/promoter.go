@@ -0,0 +1,122 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilottest
+
+import (
+	autopilot "github.com/hashicorp/raft-autopilot"
+	mock "github.com/stretchr/testify/mock"
+
+	raft "github.com/hashicorp/raft"
+)
+
+// Promoter is an autogenerated mock type for the Promoter type
+type Promoter struct {
+	mock.Mock
+}
+
+// CalculatePromotionsAndDemotions provides a mock function with given fields: _a0, _a1
+func (_m *Promoter) CalculatePromotionsAndDemotions(_a0 *autopilot.Config, _a1 *autopilot.State) autopilot.RaftChanges {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 autopilot.RaftChanges
+	if rf, ok := ret.Get(0).(func(*autopilot.Config, *autopilot.State) autopilot.RaftChanges); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(autopilot.RaftChanges)
+	}
+
+	return r0
+}
+
+// FilterFailedServerRemovals provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Promoter) FilterFailedServerRemovals(_a0 *autopilot.Config, _a1 *autopilot.State, _a2 *autopilot.FailedServers) *autopilot.FailedServers {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 *autopilot.FailedServers
+	if rf, ok := ret.Get(0).(func(*autopilot.Config, *autopilot.State, *autopilot.FailedServers) *autopilot.FailedServers); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*autopilot.FailedServers)
+		}
+	}
+
+	return r0
+}
+
+// GetNodeTypes provides a mock function with given fields: _a0, _a1
+func (_m *Promoter) GetNodeTypes(_a0 *autopilot.Config, _a1 *autopilot.State) map[raft.ServerID]autopilot.NodeType {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 map[raft.ServerID]autopilot.NodeType
+	if rf, ok := ret.Get(0).(func(*autopilot.Config, *autopilot.State) map[raft.ServerID]autopilot.NodeType); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[raft.ServerID]autopilot.NodeType)
+		}
+	}
+
+	return r0
+}
+
+// GetServerExt provides a mock function with given fields: _a0, _a1
+func (_m *Promoter) GetServerExt(_a0 *autopilot.Config, _a1 *autopilot.ServerState) interface{} {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 interface{}
+	if rf, ok := ret.Get(0).(func(*autopilot.Config, *autopilot.ServerState) interface{}); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(interface{})
+		}
+	}
+
+	return r0
+}
+
+// GetStateExt provides a mock function with given fields: _a0, _a1
+func (_m *Promoter) GetStateExt(_a0 *autopilot.Config, _a1 *autopilot.State) interface{} {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 interface{}
+	if rf, ok := ret.Get(0).(func(*autopilot.Config, *autopilot.State) interface{}); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(interface{})
+		}
+	}
+
+	return r0
+}
+
+// IsPotentialVoter provides a mock function with given fields: _a0
+func (_m *Promoter) IsPotentialVoter(_a0 autopilot.NodeType) bool {
+	ret := _m.Called(_a0)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(autopilot.NodeType) bool); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewPromoter interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewPromoter creates a new instance of Promoter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewPromoter(t mockConstructorTestingTNewPromoter) *Promoter {
+	mock := &Promoter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
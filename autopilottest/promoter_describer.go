@@ -0,0 +1,53 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilottest
+
+import mock "github.com/stretchr/testify/mock"
+
+// PromoterDescriber is an autogenerated mock type for the PromoterDescriber type
+type PromoterDescriber struct {
+	mock.Mock
+}
+
+// Describe provides a mock function with given fields:
+func (_m *PromoterDescriber) Describe() string {
+	ret := _m.Called()
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+// Name provides a mock function with given fields:
+func (_m *PromoterDescriber) Name() string {
+	ret := _m.Called()
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewPromoterDescriber interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewPromoterDescriber creates a new instance of PromoterDescriber. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewPromoterDescriber(t mockConstructorTestingTNewPromoterDescriber) *PromoterDescriber {
+	mock := &PromoterDescriber{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,33 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilottest
+
+import (
+	autopilot "github.com/hashicorp/raft-autopilot"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// RoundAwareRemover is an autogenerated mock type for the RoundAwareRemover type
+type RoundAwareRemover struct {
+	mock.Mock
+}
+
+// RemoveFailedServerInRound provides a mock function with given fields: round, srv
+func (_m *RoundAwareRemover) RemoveFailedServerInRound(round string, srv *autopilot.Server) {
+	_m.Called(round, srv)
+}
+
+type mockConstructorTestingTNewRoundAwareRemover interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewRoundAwareRemover creates a new instance of RoundAwareRemover. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewRoundAwareRemover(t mockConstructorTestingTNewRoundAwareRemover) *RoundAwareRemover {
+	mock := &RoundAwareRemover{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
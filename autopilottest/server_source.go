@@ -0,0 +1,46 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilottest
+
+import (
+	autopilot "github.com/hashicorp/raft-autopilot"
+	mock "github.com/stretchr/testify/mock"
+
+	raft "github.com/hashicorp/raft"
+)
+
+// ServerSource is an autogenerated mock type for the ServerSource type
+type ServerSource struct {
+	mock.Mock
+}
+
+// KnownServers provides a mock function with given fields:
+func (_m *ServerSource) KnownServers() map[raft.ServerID]*autopilot.Server {
+	ret := _m.Called()
+
+	var r0 map[raft.ServerID]*autopilot.Server
+	if rf, ok := ret.Get(0).(func() map[raft.ServerID]*autopilot.Server); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[raft.ServerID]*autopilot.Server)
+		}
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewServerSource interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewServerSource creates a new instance of ServerSource. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewServerSource(t mockConstructorTestingTNewServerSource) *ServerSource {
+	mock := &ServerSource{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,49 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilottest
+
+import (
+	context "context"
+
+	autopilot "github.com/hashicorp/raft-autopilot"
+
+	mock "github.com/stretchr/testify/mock"
+
+	raft "github.com/hashicorp/raft"
+)
+
+// ServerStatsFetcher is an autogenerated mock type for the ServerStatsFetcher type
+type ServerStatsFetcher struct {
+	mock.Mock
+}
+
+// FetchServerStats provides a mock function with given fields: _a0, _a1
+func (_m *ServerStatsFetcher) FetchServerStats(_a0 context.Context, _a1 map[raft.ServerID]*autopilot.Server) map[raft.ServerID]*autopilot.ServerStats {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 map[raft.ServerID]*autopilot.ServerStats
+	if rf, ok := ret.Get(0).(func(context.Context, map[raft.ServerID]*autopilot.Server) map[raft.ServerID]*autopilot.ServerStats); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[raft.ServerID]*autopilot.ServerStats)
+		}
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewServerStatsFetcher interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewServerStatsFetcher creates a new instance of ServerStatsFetcher. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewServerStatsFetcher(t mockConstructorTestingTNewServerStatsFetcher) *ServerStatsFetcher {
+	mock := &ServerStatsFetcher{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
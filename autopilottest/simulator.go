@@ -0,0 +1,254 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilottest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/raft"
+	autopilot "github.com/hashicorp/raft-autopilot"
+)
+
+// ScenarioAction is a change made to the simulated cluster.
+type ScenarioAction string
+
+const (
+	// ActionJoin adds the server to the cluster as a healthy, alive server.
+	ActionJoin ScenarioAction = "join"
+
+	// ActionFail marks the server as failed and stops reporting its stats.
+	ActionFail ScenarioAction = "fail"
+
+	// ActionRecover marks a failed server as alive again.
+	ActionRecover ScenarioAction = "recover"
+
+	// ActionLag causes the server to trail the leader's log by the event's
+	// Entries. Lagging by zero entries lets the server catch up again.
+	ActionLag ScenarioAction = "lag"
+
+	// ActionLeave marks the server as having left the cluster and stops
+	// reporting its stats.
+	ActionLeave ScenarioAction = "leave"
+)
+
+// ScenarioEvent is a change to the simulated cluster made at a point in time.
+type ScenarioEvent struct {
+	// At is when the event happens relative to the start of the scenario.
+	At time.Duration
+
+	// Action is the change to make.
+	Action ScenarioAction
+
+	// Server is the server the change is made to.
+	Server raft.ServerID
+
+	// Suffrage is the suffrage a joining server is added to the Raft
+	// configuration with.
+	Suffrage raft.ServerSuffrage
+
+	// Meta is the metadata of a joining server.
+	Meta map[string]string
+
+	// Entries is the number of log entries a lagging server trails by.
+	Entries uint64
+}
+
+// ScenarioServer is a server present when a scenario starts.
+type ScenarioServer struct {
+	ID       raft.ServerID
+	Suffrage raft.ServerSuffrage
+	Meta     map[string]string
+}
+
+// Scenario declaratively describes a cluster timeline to simulate. The
+// cluster starts with a single voter named "server-1" which is the leader,
+// like a cluster created with NewCluster, to which the Servers are added.
+type Scenario struct {
+	// Config is the autopilot configuration for the duration of the
+	// scenario.
+	Config *autopilot.Config
+
+	// Servers are the servers present when the scenario starts.
+	Servers []ScenarioServer
+
+	// Events are the changes made to the cluster over time. Events at the
+	// same time are made in the order given.
+	Events []ScenarioEvent
+
+	// Duration is how long to simulate for.
+	Duration time.Duration
+
+	// Interval is the virtual time between iterations of autopilot's loop.
+	// It defaults to 10 seconds.
+	Interval time.Duration
+
+	// LogEntriesPerInterval is the number of entries appended to the leader's
+	// log each interval.
+	LogEntriesPerInterval uint64
+}
+
+// SimulatedDecision is a decision autopilot made during a simulation.
+type SimulatedDecision struct {
+	// Elapsed is the virtual time since the start of the scenario at which
+	// the decision was made.
+	Elapsed time.Duration
+
+	autopilot.Decision
+}
+
+// String describes the decision without its randomly generated round ID so
+// that the descriptions of repeated simulations may be compared.
+func (d SimulatedDecision) String() string {
+	return fmt.Sprintf("+%s %s %s (%s)", d.Elapsed, d.Type, d.ServerID, d.Reason)
+}
+
+// SimulationResult is the outcome of a simulation.
+type SimulationResult struct {
+	// Decisions are all the decisions autopilot made in the order they were
+	// made. Decisions made within the same round are ordered by server ID as
+	// autopilot makes them in no particular order.
+	Decisions []SimulatedDecision
+
+	// Servers is the Raft configuration at the end of the scenario.
+	Servers []raft.Server
+
+	// State is the final autopilot state.
+	State *autopilot.State
+}
+
+// Timeline returns the descriptions of all the decisions.
+func (r *SimulationResult) Timeline() []string {
+	timeline := make([]string, len(r.Decisions))
+	for i, d := range r.Decisions {
+		timeline[i] = d.String()
+	}
+	return timeline
+}
+
+// recordingDelegate is a FakeDelegate which also records the decisions made.
+type recordingDelegate struct {
+	*FakeDelegate
+
+	lock      sync.Mutex
+	decisions []autopilot.Decision
+}
+
+var _ autopilot.DecisionRecorder = (*recordingDelegate)(nil)
+
+func (d *recordingDelegate) RecordDecision(decision autopilot.Decision) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.decisions = append(d.decisions, decision)
+}
+
+// take returns the decisions recorded since the last call.
+func (d *recordingDelegate) take() []autopilot.Decision {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	decisions := d.decisions
+	d.decisions = nil
+	return decisions
+}
+
+// Simulate replays the scenario against autopilot using a Cluster and the
+// given options, such as autopilot.WithPromoter. Each interval the due events
+// are made, the leader's log is advanced and a single iteration of autopilot's
+// loop is performed with autopilot.Step before the virtual clock is advanced.
+// The same scenario always results in the same decisions.
+func Simulate(scenario Scenario, opts ...autopilot.Option) (*SimulationResult, error) {
+	if scenario.Config == nil {
+		return nil, fmt.Errorf("a scenario requires an autopilot configuration")
+	}
+	interval := scenario.Interval
+	if interval == 0 {
+		interval = 10 * time.Second
+	}
+	if interval < 0 || scenario.Duration < 0 {
+		return nil, fmt.Errorf("the scenario duration and interval must not be negative")
+	}
+
+	c := NewCluster(scenario.Config)
+	for _, srv := range scenario.Servers {
+		c.AddServer(srv.ID, srv.Suffrage, srv.Meta)
+	}
+
+	events := append([]ScenarioEvent(nil), scenario.Events...)
+	sort.SliceStable(events, func(i, j int) bool { return events[i].At < events[j].At })
+
+	delegate := &recordingDelegate{FakeDelegate: c.Delegate}
+	ap := autopilot.New(c.Raft, delegate, c.Options(opts...)...)
+
+	ctx := context.Background()
+	start := c.Clock.Now()
+	result := &SimulationResult{}
+	for elapsed := time.Duration(0); elapsed <= scenario.Duration; elapsed += interval {
+		for len(events) > 0 && events[0].At <= elapsed {
+			if err := c.apply(events[0]); err != nil {
+				return nil, err
+			}
+			events = events[1:]
+		}
+		c.AdvanceLog(scenario.LogEntriesPerInterval)
+
+		// errors are expected while the cluster is unhealthy and are
+		// reflected by the decisions not made
+		ap.Step(ctx)
+
+		for _, d := range sortedDecisions(delegate.take()) {
+			result.Decisions = append(result.Decisions, SimulatedDecision{
+				Elapsed:  d.Time.Sub(start),
+				Decision: d,
+			})
+		}
+		c.Clock.Advance(interval)
+	}
+
+	result.Servers = c.Raft.Servers()
+	result.State = ap.GetState()
+	return result, nil
+}
+
+// sortedDecisions orders the decisions made within each round by server ID
+// while retaining the order of the rounds.
+func sortedDecisions(decisions []autopilot.Decision) []autopilot.Decision {
+	rounds := make(map[string]int)
+	for _, d := range decisions {
+		if _, ok := rounds[d.Round]; !ok {
+			rounds[d.Round] = len(rounds)
+		}
+	}
+
+	sort.SliceStable(decisions, func(i, j int) bool {
+		ri, rj := rounds[decisions[i].Round], rounds[decisions[j].Round]
+		if ri != rj {
+			return ri < rj
+		}
+		return decisions[i].ServerID < decisions[j].ServerID
+	})
+	return decisions
+}
+
+// apply makes the event's change to the cluster.
+func (c *Cluster) apply(event ScenarioEvent) error {
+	switch event.Action {
+	case ActionJoin:
+		c.AddServer(event.Server, event.Suffrage, event.Meta)
+	case ActionFail:
+		c.FailServer(event.Server)
+	case ActionRecover:
+		c.RecoverServer(event.Server)
+	case ActionLag:
+		c.LagServer(event.Server, event.Entries)
+	case ActionLeave:
+		c.FailServer(event.Server)
+		c.Delegate.SetNodeStatus(event.Server, autopilot.NodeLeft)
+	default:
+		return fmt.Errorf("unknown scenario action %q for server %q", event.Action, event.Server)
+	}
+	return nil
+}
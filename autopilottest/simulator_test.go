@@ -0,0 +1,71 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilottest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/raft"
+	autopilot "github.com/hashicorp/raft-autopilot"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimulate(t *testing.T) {
+	scenario := Scenario{
+		Config: &autopilot.Config{
+			CleanupDeadServers:      true,
+			LastContactThreshold:    time.Second,
+			MaxTrailingLogs:         100,
+			MinQuorum:               3,
+			ServerStabilizationTime: 30 * time.Second,
+		},
+		Servers: []ScenarioServer{
+			{ID: "server-2", Suffrage: raft.Voter},
+			{ID: "server-3", Suffrage: raft.Voter},
+			{ID: "server-5", Suffrage: raft.Voter},
+		},
+		Events: []ScenarioEvent{
+			{At: 2 * time.Minute, Action: ActionFail, Server: "server-3"},
+			{At: 10 * time.Second, Action: ActionJoin, Server: "server-4", Suffrage: raft.Nonvoter},
+			{At: 10 * time.Second, Action: ActionLag, Server: "server-4", Entries: 1000},
+			{At: time.Minute, Action: ActionLag, Server: "server-4"},
+		},
+		Duration:              3 * time.Minute,
+		LogEntriesPerInterval: 50,
+	}
+
+	result, err := Simulate(scenario)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"+1m0s promote server-4 (stable-non-voter)",
+		"+2m0s remove server-3 (failed-server)",
+		// the application forgets the failed server after which autopilot
+		// removes it from the Raft configuration
+		"+2m10s remove server-3 (stale-server)",
+	}, result.Timeline())
+
+	require.Len(t, result.Servers, 4)
+	for _, srv := range result.Servers {
+		require.Equal(t, raft.Voter, srv.Suffrage)
+		require.NotEqual(t, raft.ServerID("server-3"), srv.ID)
+	}
+	require.True(t, result.State.Healthy)
+
+	// the same scenario always results in the same decisions
+	again, err := Simulate(scenario)
+	require.NoError(t, err)
+	require.Equal(t, result.Timeline(), again.Timeline())
+}
+
+func TestSimulateInvalidScenario(t *testing.T) {
+	_, err := Simulate(Scenario{})
+	require.Error(t, err)
+
+	_, err = Simulate(Scenario{
+		Config: &autopilot.Config{},
+		Events: []ScenarioEvent{{Action: "explode", Server: "server-1"}},
+	})
+	require.EqualError(t, err, `unknown scenario action "explode" for server "server-1"`)
+}
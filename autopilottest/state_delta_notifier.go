@@ -0,0 +1,33 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilottest
+
+import (
+	autopilot "github.com/hashicorp/raft-autopilot"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// StateDeltaNotifier is an autogenerated mock type for the StateDeltaNotifier type
+type StateDeltaNotifier struct {
+	mock.Mock
+}
+
+// NotifyStateDelta provides a mock function with given fields: _a0
+func (_m *StateDeltaNotifier) NotifyStateDelta(_a0 *autopilot.StateDelta) {
+	_m.Called(_a0)
+}
+
+type mockConstructorTestingTNewStateDeltaNotifier interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewStateDeltaNotifier creates a new instance of StateDeltaNotifier. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewStateDeltaNotifier(t mockConstructorTestingTNewStateDeltaNotifier) *StateDeltaNotifier {
+	mock := &StateDeltaNotifier{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
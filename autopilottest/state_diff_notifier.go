@@ -0,0 +1,33 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilottest
+
+import (
+	autopilot "github.com/hashicorp/raft-autopilot"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// StateDiffNotifier is an autogenerated mock type for the StateDiffNotifier type
+type StateDiffNotifier struct {
+	mock.Mock
+}
+
+// NotifyStateDiff provides a mock function with given fields: _a0
+func (_m *StateDiffNotifier) NotifyStateDiff(_a0 *autopilot.StateDiff) {
+	_m.Called(_a0)
+}
+
+type mockConstructorTestingTNewStateDiffNotifier interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewStateDiffNotifier creates a new instance of StateDiffNotifier. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewStateDiffNotifier(t mockConstructorTestingTNewStateDiffNotifier) *StateDiffNotifier {
+	mock := &StateDiffNotifier{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
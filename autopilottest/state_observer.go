@@ -0,0 +1,33 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilottest
+
+import (
+	autopilot "github.com/hashicorp/raft-autopilot"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// StateObserver is an autogenerated mock type for the StateObserver type
+type StateObserver struct {
+	mock.Mock
+}
+
+// NotifyState provides a mock function with given fields: _a0
+func (_m *StateObserver) NotifyState(_a0 *autopilot.State) {
+	_m.Called(_a0)
+}
+
+type mockConstructorTestingTNewStateObserver interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewStateObserver creates a new instance of StateObserver. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewStateObserver(t mockConstructorTestingTNewStateObserver) *StateObserver {
+	mock := &StateObserver{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,51 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilottest
+
+import (
+	autopilot "github.com/hashicorp/raft-autopilot"
+	mock "github.com/stretchr/testify/mock"
+
+	raft "github.com/hashicorp/raft"
+)
+
+// StatePersister is an autogenerated mock type for the StatePersister type
+type StatePersister struct {
+	mock.Mock
+}
+
+// LoadServerHealth provides a mock function with given fields:
+func (_m *StatePersister) LoadServerHealth() map[raft.ServerID]autopilot.PersistedServerHealth {
+	ret := _m.Called()
+
+	var r0 map[raft.ServerID]autopilot.PersistedServerHealth
+	if rf, ok := ret.Get(0).(func() map[raft.ServerID]autopilot.PersistedServerHealth); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[raft.ServerID]autopilot.PersistedServerHealth)
+		}
+	}
+
+	return r0
+}
+
+// PersistServerHealth provides a mock function with given fields: _a0
+func (_m *StatePersister) PersistServerHealth(_a0 map[raft.ServerID]autopilot.PersistedServerHealth) {
+	_m.Called(_a0)
+}
+
+type mockConstructorTestingTNewStatePersister interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewStatePersister creates a new instance of StatePersister. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewStatePersister(t mockConstructorTestingTNewStatePersister) *StatePersister {
+	mock := &StatePersister{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
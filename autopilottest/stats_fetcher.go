@@ -0,0 +1,54 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilottest
+
+import (
+	context "context"
+
+	autopilot "github.com/hashicorp/raft-autopilot"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// StatsFetcher is an autogenerated mock type for the StatsFetcher type
+type StatsFetcher struct {
+	mock.Mock
+}
+
+// FetchStats provides a mock function with given fields: ctx, srv
+func (_m *StatsFetcher) FetchStats(ctx context.Context, srv *autopilot.Server) (*autopilot.ServerStats, error) {
+	ret := _m.Called(ctx, srv)
+
+	var r0 *autopilot.ServerStats
+	if rf, ok := ret.Get(0).(func(context.Context, *autopilot.Server) *autopilot.ServerStats); ok {
+		r0 = rf(ctx, srv)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*autopilot.ServerStats)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *autopilot.Server) error); ok {
+		r1 = rf(ctx, srv)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+type mockConstructorTestingTNewStatsFetcher interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewStatsFetcher creates a new instance of StatsFetcher. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewStatsFetcher(t mockConstructorTestingTNewStatsFetcher) *StatsFetcher {
+	mock := &StatsFetcher{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
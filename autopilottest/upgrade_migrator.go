@@ -0,0 +1,42 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilottest
+
+import (
+	autopilot "github.com/hashicorp/raft-autopilot"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// UpgradeMigrator is an autogenerated mock type for the UpgradeMigrator type
+type UpgradeMigrator struct {
+	mock.Mock
+}
+
+// UpgradeMigrationInProgress provides a mock function with given fields: conf, state
+func (_m *UpgradeMigrator) UpgradeMigrationInProgress(conf *autopilot.Config, state *autopilot.State) bool {
+	ret := _m.Called(conf, state)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(*autopilot.Config, *autopilot.State) bool); ok {
+		r0 = rf(conf, state)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewUpgradeMigrator interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewUpgradeMigrator creates a new instance of UpgradeMigrator. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewUpgradeMigrator(t mockConstructorTestingTNewUpgradeMigrator) *UpgradeMigrator {
+	mock := &UpgradeMigrator{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,66 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"sync"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/raft"
+)
+
+// changeBudget limits how many Raft membership changes autopilot makes
+// within a sliding window of time. Without a limit a large cluster recovering
+// from an outage can see a storm of configuration changes.
+type changeBudget struct {
+	lock sync.Mutex
+
+	// max is the number of changes allowed within the window. Zero disables
+	// the budget entirely.
+	max    int
+	window time.Duration
+
+	// changes are the times of the changes made within the window.
+	changes []time.Time
+}
+
+// take consumes one change from the budget and returns whether the change may
+// be made. The time is only requested when a budget is configured.
+func (b *changeBudget) take(now func() time.Time) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.max < 1 {
+		return true
+	}
+
+	current := now()
+	kept := b.changes[:0]
+	for _, t := range b.changes {
+		if current.Sub(t) < b.window {
+			kept = append(kept, t)
+		}
+	}
+	b.changes = kept
+
+	if len(b.changes) >= b.max {
+		return false
+	}
+
+	b.changes = append(b.changes, current)
+	return true
+}
+
+// takeChangeBudget returns whether the given membership change may be made
+// within the configured budget. Deferred changes are logged and counted.
+func (a *Autopilot) takeChangeBudget(change MembershipChangeType, id raft.ServerID) bool {
+	if a.changeBudget.take(a.now) {
+		return true
+	}
+
+	a.roundLogger().Debug("deferring membership change as the change budget is exhausted", "change", change, "id", id)
+	a.metricsSink().IncrCounterWithLabels(changesDeferredKey, 1, []metrics.Label{{Name: "change", Value: string(change)}})
+	return false
+}
@@ -0,0 +1,90 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangeBudget(t *testing.T) {
+	start := time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)
+	now := start
+	clock := func() time.Time { return now }
+
+	budget := changeBudget{max: 2, window: time.Minute}
+	require.True(t, budget.take(clock))
+	now = start.Add(30 * time.Second)
+	require.True(t, budget.take(clock))
+	require.False(t, budget.take(clock))
+
+	// the first change leaves the window
+	now = start.Add(time.Minute)
+	require.True(t, budget.take(clock))
+	require.False(t, budget.take(clock))
+
+	// without a max the time is never requested
+	var disabled changeBudget
+	require.True(t, disabled.take(func() time.Time {
+		t.Fatal("time should not be requested")
+		return time.Time{}
+	}))
+}
+
+func TestApplyPromotionsChangeBudget(t *testing.T) {
+	now := time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)
+
+	state := &State{
+		Leader: "a",
+		Servers: map[raft.ServerID]*ServerState{
+			"a": {
+				Server: Server{ID: "a", Address: "198.18.0.1:8300"},
+				State:  RaftLeader,
+				Health: ServerHealth{Healthy: true},
+			},
+			"b": {
+				Server: Server{ID: "b", Address: "198.18.0.2:8300"},
+				State:  RaftNonVoter,
+				Health: ServerHealth{Healthy: true},
+			},
+			"c": {
+				Server: Server{ID: "c", Address: "198.18.0.3:8300"},
+				State:  RaftNonVoter,
+				Health: ServerHealth{Healthy: true},
+			},
+		},
+	}
+	changes := RaftChanges{Promotions: []raft.ServerID{"b", "c"}}
+
+	sink := metrics.NewInmemSink(time.Minute, time.Minute)
+	mtime := NewMockTimeProvider(t)
+	mtime.On("Now").Return(now)
+	mraft := NewMockRaft(t)
+	mraft.On("AddVoter", raft.ServerID("b"), raft.ServerAddress("198.18.0.2:8300"), uint64(0), time.Duration(0)).
+		Return(&raftIndexFuture{}).
+		Once()
+
+	a := &Autopilot{
+		logger:       hclog.NewNullLogger(),
+		time:         mtime,
+		raft:         mraft,
+		metricSink:   sink,
+		changeBudget: changeBudget{max: 1, window: time.Minute},
+	}
+
+	// only the first promotion fits within the budget
+	done, err := a.applyPromotions(context.Background(), state, changes)
+	require.True(t, done)
+	require.NoError(t, err)
+
+	intervals := sink.Data()
+	require.NotEmpty(t, intervals)
+	require.Equal(t, 1, intervals[0].Counters["autopilot.changes_deferred;change=promotion"].Count)
+}
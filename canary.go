@@ -0,0 +1,118 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hashicorp/raft"
+)
+
+// canary is the first server of a version which no voter had to be promoted.
+type canary struct {
+	id raft.ServerID
+
+	// since is when the canary last became a healthy voter. The soak period
+	// starts over whenever it is seen unhealthy.
+	since time.Time
+}
+
+// canaryTracker tracks the canary of each version being soaked.
+type canaryTracker struct {
+	lock     sync.Mutex
+	canaries map[string]*canary
+}
+
+// observed returns a copy of the canaries updated from the state without
+// recording it. Canaries which left or which are neither voters nor being
+// promoted are forgotten so that another server of their version may become
+// the canary. The soak period does not start until the canary is a voter and
+// restarts whenever it is unhealthy.
+func (t *canaryTracker) observed(state *State, promoting map[raft.ServerID]struct{}, now time.Time) map[string]*canary {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	canaries := make(map[string]*canary, len(t.canaries))
+	for version, c := range t.canaries {
+		srv, ok := state.Servers[c.id]
+		if !ok {
+			continue
+		}
+
+		c := *c
+		if !srv.HasVotingRights() {
+			if _, ok := promoting[c.id]; !ok {
+				continue
+			}
+			c.since = now
+		} else if !srv.Health.Healthy {
+			c.since = now
+		}
+		canaries[version] = &c
+	}
+	return canaries
+}
+
+// record replaces the tracked canaries with those calculated by a round.
+func (t *canaryTracker) record(canaries map[string]*canary) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.canaries = canaries
+}
+
+// promoteCanaries limits the promotions of servers with a Version which no
+// voter has to a single canary. The remaining servers of that version are
+// only promoted once the canary has been a healthy voter for the configured
+// CanarySoakPeriod. The updated canaries are set within the updates rather
+// than recorded.
+func (a *Autopilot) promoteCanaries(conf *Config, state *State, changes RaftChanges, updates *trackerUpdates) RaftChanges {
+	if conf.CanarySoakPeriod <= 0 {
+		return changes
+	}
+
+	now := a.now()
+	promoting := make(map[raft.ServerID]struct{}, len(changes.Promotions))
+	for _, id := range changes.Promotions {
+		promoting[id] = struct{}{}
+	}
+	canaries := a.canaries.observed(state, promoting, now)
+	updates.canaries = canaries
+
+	seen := make(map[string]struct{})
+	for _, id := range state.Voters {
+		if srv, ok := state.Servers[id]; ok {
+			seen[srv.Server.Version] = struct{}{}
+		}
+	}
+
+	var promotions []raft.ServerID
+	for _, id := range changes.Promotions {
+		srv, ok := state.Servers[id]
+		if !ok {
+			promotions = append(promotions, id)
+			continue
+		}
+
+		version := srv.Server.Version
+		if c, ok := canaries[version]; ok {
+			switch {
+			case c.id == id:
+			case now.Sub(c.since) >= conf.CanarySoakPeriod:
+				a.roundLogger().Info("canary of version has soaked, promoting further servers", "version", version, "canary", c.id)
+				delete(canaries, version)
+			default:
+				a.roundLogger().Debug("Not promoting server until the canary of its version has soaked", "id", id, "version", version, "canary", c.id)
+				continue
+			}
+		} else if _, ok := seen[version]; !ok {
+			a.roundLogger().Info("promoting server as the canary of a new version", "id", id, "version", version)
+			canaries[version] = &canary{id: id, since: now}
+		}
+		promotions = append(promotions, id)
+	}
+
+	changes.Promotions = promotions
+	return changes
+}
@@ -0,0 +1,118 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+// promoteCanariesRound limits the promotions to the canaries and records the
+// canaries as a reconciliation round would.
+func promoteCanariesRound(a *Autopilot, conf *Config, state *State, ids ...raft.ServerID) []raft.ServerID {
+	updates := &trackerUpdates{}
+	changes := a.promoteCanaries(conf, state, RaftChanges{Promotions: ids}, updates)
+	a.recordTrackerUpdates(state, updates)
+	return changes.Promotions
+}
+
+func TestPromoteCanaries(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mtime := NewMockTimeProvider(t)
+	mtime.On("Now").Return(func() time.Time { return now })
+
+	a := &Autopilot{logger: testLogger(t), time: mtime}
+	conf := &Config{CanarySoakPeriod: 10 * time.Minute}
+
+	server := func(id raft.ServerID, version string, state RaftState, healthy bool) *ServerState {
+		return &ServerState{
+			Server: Server{ID: id, Version: version},
+			State:  state,
+			Health: ServerHealth{Healthy: healthy},
+		}
+	}
+	state := &State{
+		Leader: "a",
+		Voters: []raft.ServerID{"a"},
+		Servers: map[raft.ServerID]*ServerState{
+			"a": server("a", "1.0.0", RaftLeader, true),
+			"b": server("b", "2.0.0", RaftNonVoter, true),
+			"c": server("c", "2.0.0", RaftNonVoter, true),
+			"d": server("d", "1.0.0", RaftNonVoter, true),
+		},
+	}
+	promote := func(ids ...raft.ServerID) []raft.ServerID {
+		return promoteCanariesRound(a, conf, state, ids...)
+	}
+
+	// only one server of the new version is promoted while servers of a
+	// version the voters already have are unaffected
+	require.Equal(t, []raft.ServerID{"b", "d"}, promote("b", "c", "d"))
+	require.Equal(t, []raft.ServerID{"b"}, promote("b", "c"))
+
+	// the canary becomes a voter and starts soaking
+	state.Voters = append(state.Voters, "b")
+	state.Servers["b"].State = RaftVoter
+	now = now.Add(5 * time.Minute)
+	require.Empty(t, promote("c"))
+
+	// the soak period restarts when the canary is unhealthy
+	state.Servers["b"].Health.Healthy = false
+	now = now.Add(5 * time.Minute)
+	require.Empty(t, promote("c"))
+	state.Servers["b"].Health.Healthy = true
+	now = now.Add(5 * time.Minute)
+	require.Empty(t, promote("c"))
+
+	now = now.Add(5 * time.Minute)
+	require.Equal(t, []raft.ServerID{"c"}, promote("c"))
+	require.Equal(t, []raft.ServerID{"c"}, promote("c"))
+}
+
+func TestPromoteCanariesReplacesAbandonedCanary(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mtime := NewMockTimeProvider(t)
+	mtime.On("Now").Return(func() time.Time { return now })
+
+	a := &Autopilot{logger: testLogger(t), time: mtime}
+	conf := &Config{CanarySoakPeriod: 10 * time.Minute}
+	state := &State{
+		Leader: "a",
+		Voters: []raft.ServerID{"a"},
+		Servers: map[raft.ServerID]*ServerState{
+			"a": {Server: Server{ID: "a", Version: "1.0.0"}, State: RaftLeader},
+			"b": {Server: Server{ID: "b", Version: "2.0.0"}, State: RaftNonVoter},
+			"c": {Server: Server{ID: "c", Version: "2.0.0"}, State: RaftNonVoter},
+		},
+	}
+
+	require.Equal(t, []raft.ServerID{"b"}, promoteCanariesRound(a, conf, state, "b", "c"))
+
+	// b is no longer being promoted so c becomes the canary instead
+	require.Equal(t, []raft.ServerID{"c"}, promoteCanariesRound(a, conf, state, "c"))
+}
+
+func TestPromoteCanariesNotRecorded(t *testing.T) {
+	a := &Autopilot{logger: testLogger(t), time: &runtimeTimeProvider{}}
+	conf := &Config{CanarySoakPeriod: 10 * time.Minute}
+	state := &State{
+		Leader: "a",
+		Voters: []raft.ServerID{"a"},
+		Servers: map[raft.ServerID]*ServerState{
+			"a": {Server: Server{ID: "a", Version: "1.0.0"}, State: RaftLeader},
+			"b": {Server: Server{ID: "b", Version: "2.0.0"}, State: RaftNonVoter},
+			"c": {Server: Server{ID: "c", Version: "2.0.0"}, State: RaftNonVoter},
+		},
+	}
+
+	// the canary is only tracked once the updates are recorded
+	updates := &trackerUpdates{}
+	changes := a.promoteCanaries(conf, state, RaftChanges{Promotions: []raft.ServerID{"b", "c"}}, updates)
+	require.Equal(t, []raft.ServerID{"b"}, changes.Promotions)
+	require.Empty(t, a.canaries.canaries)
+	require.Equal(t, []raft.ServerID{"c"}, promoteCanariesRound(a, conf, state, "c", "b"))
+}
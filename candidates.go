@@ -0,0 +1,55 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"time"
+
+	"github.com/hashicorp/raft"
+)
+
+// PromotionCandidates returns the non-voters in the current state which are
+// eligible to be promoted, ordered by their Value. These are the healthy
+// servers of a node type the promoter considers a potential voter which have
+// been stable for the server stabilization time and that autopilot is not
+// otherwise holding back, such as due to a lockout. Applications may use this
+// to show which servers are awaiting promotion. Whether they are promoted is
+// still up to the promoter.
+func (a *Autopilot) PromotionCandidates() []raft.ServerID {
+	state := a.GetState()
+	if state == nil {
+		return nil
+	}
+
+	conf := a.delegate.AutopilotConfig()
+	if conf == nil {
+		return nil
+	}
+
+	now := a.now()
+	var candidates []raft.ServerID
+	for id, srv := range state.Servers {
+		if a.promotionEligible(conf, state, srv, now) {
+			candidates = append(candidates, id)
+		}
+	}
+
+	sortByValue(candidates, state)
+	return candidates
+}
+
+// promotionEligible returns whether the server is a non-voter which could be
+// promoted at the given time.
+func (a *Autopilot) promotionEligible(conf *Config, state *State, srv *ServerState, now time.Time) bool {
+	if srv.State != RaftNonVoter || srv.Ignored || srv.Health.TermAhead || srv.Health.Flapping || !srv.Health.Healthy || !srv.caughtUp() {
+		return false
+	}
+
+	if a.lockouts.isLockedOut(srv.Server.ID, now) || a.quarantine.quarantined(&srv.Server, func() time.Time { return now }) {
+		return false
+	}
+
+	minStable := state.serverStabilizationTimeAt(conf.ForServer(&srv.Server), now)
+	return srv.Health.IsStable(now, minStable) && a.getPromoter().IsPotentialVoter(srv.Server.NodeType)
+}
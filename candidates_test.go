@@ -0,0 +1,48 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromotionCandidates(t *testing.T) {
+	now := time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)
+	stable := ServerHealth{Healthy: true, StableSince: now.Add(-time.Hour)}
+
+	state := &State{
+		Leader: "a",
+		Servers: map[raft.ServerID]*ServerState{
+			"a": {Server: Server{ID: "a", NodeType: NodeVoter}, State: RaftLeader, Health: stable},
+			"b": {Server: Server{ID: "b", NodeType: NodeVoter, Value: 1}, State: RaftNonVoter, Health: stable},
+			"c": {Server: Server{ID: "c", NodeType: NodeVoter, Value: 2}, State: RaftNonVoter, Health: stable},
+			// recently became healthy
+			"d": {Server: Server{ID: "d", NodeType: NodeVoter}, State: RaftNonVoter, Health: ServerHealth{Healthy: true, StableSince: now}},
+			"e": {Server: Server{ID: "e", NodeType: NodeVoter}, State: RaftNonVoter, Health: ServerHealth{StableSince: now.Add(-time.Hour)}},
+			"f": {Server: Server{ID: "f", NodeType: "read-replica"}, State: RaftNonVoter, Health: stable},
+			"g": {Server: Server{ID: "g", NodeType: NodeVoter}, State: RaftNonVoter, Health: stable, Ignored: true},
+			"h": {Server: Server{ID: "h", NodeType: NodeVoter}, State: RaftNonVoter, Health: stable, CatchUp: &CatchUpProgress{Lag: 1000}},
+		},
+	}
+
+	mtime := NewMockTimeProvider(t)
+	mtime.On("Now").Return(now)
+	mdel := NewMockApplicationIntegration(t)
+	mdel.On("AutopilotConfig").Return(&Config{ServerStabilizationTime: time.Minute})
+
+	a := &Autopilot{
+		logger:   hclog.NewNullLogger(),
+		time:     mtime,
+		delegate: mdel,
+		promoter: DefaultPromoter(),
+		state:    state,
+	}
+
+	require.Equal(t, []raft.ServerID{"c", "b"}, a.PromotionCandidates())
+}
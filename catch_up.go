@@ -0,0 +1,55 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+// CatchUpProgress tracks how a non-voter's replication is catching up with
+// the leader. It is only tracked when a CatchUpMaxLag is configured.
+type CatchUpProgress struct {
+	// Lag is how many log entries the server is behind the leader.
+	Lag uint64
+
+	// Observations is the number of consecutive state updates in which the
+	// lag was within the CatchUpMaxLag or had not grown since the previous
+	// update.
+	Observations int
+
+	// CaughtUp is set once the lag is within the CatchUpMaxLag and has been
+	// making progress for at least CatchUpObservations state updates. Only
+	// servers which have caught up are eligible for promotion.
+	CaughtUp bool
+}
+
+// nextCatchUpProgress calculates the catch up progress of a non-voter from its
+// progress in the previous state and its current lag behind the leader. The
+// leader's last index is zero when it is unknown.
+func nextCatchUpProgress(conf *Config, prev *CatchUpProgress, lastIndex, leaderLastIndex uint64) *CatchUpProgress {
+	if leaderLastIndex == 0 {
+		return &CatchUpProgress{}
+	}
+
+	progress := &CatchUpProgress{}
+	if leaderLastIndex > lastIndex {
+		progress.Lag = leaderLastIndex - lastIndex
+	}
+
+	if progress.Lag <= conf.CatchUpMaxLag || (prev != nil && progress.Lag <= prev.Lag) {
+		progress.Observations = 1
+		if prev != nil {
+			progress.Observations = prev.Observations + 1
+		}
+	}
+
+	required := conf.CatchUpObservations
+	if required < 1 {
+		required = 1
+	}
+	progress.CaughtUp = progress.Lag <= conf.CatchUpMaxLag && progress.Observations >= required
+	return progress
+}
+
+// caughtUp returns whether the server may be promoted as far as its catch up
+// progress is concerned. Servers without tracked progress have caught up.
+func (s *ServerState) caughtUp() bool {
+	return s.CatchUp == nil || s.CatchUp.CaughtUp
+}
@@ -0,0 +1,75 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextCatchUpProgress(t *testing.T) {
+	conf := &Config{CatchUpMaxLag: 100, CatchUpObservations: 2}
+
+	// far behind the leader but shrinking
+	progress := nextCatchUpProgress(conf, nil, 0, 1000)
+	require.Equal(t, &CatchUpProgress{Lag: 1000}, progress)
+
+	progress = nextCatchUpProgress(conf, progress, 500, 1000)
+	require.Equal(t, &CatchUpProgress{Lag: 500, Observations: 1}, progress)
+
+	// within the budget having made progress for enough observations
+	progress = nextCatchUpProgress(conf, progress, 950, 1010)
+	require.Equal(t, &CatchUpProgress{Lag: 60, Observations: 2, CaughtUp: true}, progress)
+
+	// falling behind again resets the progress
+	progress = nextCatchUpProgress(conf, progress, 950, 1200)
+	require.Equal(t, &CatchUpProgress{Lag: 250}, progress)
+
+	// no progress can be made without knowing the leader's index
+	require.Equal(t, &CatchUpProgress{}, nextCatchUpProgress(conf, progress, 950, 0))
+}
+
+func TestBuildServerStateCatchUp(t *testing.T) {
+	now := time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)
+
+	inputs := &nextStateInputs{
+		Now:         now,
+		Config:      &Config{LastContactThreshold: time.Second, MaxTrailingLogs: 500, CatchUpMaxLag: 10},
+		IsLeader:    true,
+		LatestIndex: 1000,
+		LastTerm:    5,
+		KnownServers: map[raft.ServerID]*Server{
+			"a": {ID: "a", NodeStatus: NodeAlive},
+		},
+		FetchedStats: map[raft.ServerID]*ServerStats{
+			"a": {LastTerm: 5, LastIndex: 600},
+		},
+	}
+
+	// healthy but not yet caught up
+	state := buildServerState(inputs, raft.Server{ID: "a", Suffrage: raft.Nonvoter})
+	require.True(t, state.Health.Healthy)
+	require.Equal(t, &CatchUpProgress{Lag: 400}, state.CatchUp)
+	require.False(t, state.caughtUp())
+
+	inputs.CurrentState = &State{Servers: map[raft.ServerID]*ServerState{"a": &state}}
+	inputs.FetchedStats["a"] = &ServerStats{LastTerm: 5, LastIndex: 995}
+	state = buildServerState(inputs, raft.Server{ID: "a", Suffrage: raft.Nonvoter})
+	require.Equal(t, &CatchUpProgress{Lag: 5, Observations: 1, CaughtUp: true}, state.CatchUp)
+	require.True(t, state.caughtUp())
+
+	// the progress is retained when the stats could not be fetched
+	inputs.CurrentState = &State{Servers: map[raft.ServerID]*ServerState{"a": &state}}
+	inputs.FetchedStats = nil
+	state = buildServerState(inputs, raft.Server{ID: "a", Suffrage: raft.Nonvoter})
+	require.Equal(t, &CatchUpProgress{Lag: 5, Observations: 1, CaughtUp: true}, state.CatchUp)
+
+	// voters are not tracked
+	state = buildServerState(inputs, raft.Server{ID: "a", Suffrage: raft.Voter})
+	require.Nil(t, state.CatchUp)
+	require.True(t, state.caughtUp())
+}
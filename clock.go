@@ -0,0 +1,71 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"sync"
+	"time"
+)
+
+// clockJumpThreshold is the minimum difference between the wall clock and the
+// monotonic clock time elapsed between two observations for the wall clock to
+// be considered to have jumped.
+const clockJumpThreshold = time.Second
+
+// clockTracker detects wall clock jumps such as those caused by NTP corrections.
+// Time values obtained from time.Now carry a monotonic clock reading which is
+// used for all duration calculations and so are unaffected by jumps. Times
+// without a monotonic reading, such as those from an imported state or another
+// TimeProvider, are not and need to be adjusted.
+type clockTracker struct {
+	lock sync.Mutex
+	last time.Time
+}
+
+// observe records the given time and returns how far the wall clock jumped
+// since the previous observation. Zero is returned when either time lacks a
+// monotonic clock reading or the difference is below clockJumpThreshold.
+func (c *clockTracker) observe(now time.Time) time.Duration {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	last := c.last
+	c.last = now
+
+	if last.IsZero() || !hasMonotonic(now) || !hasMonotonic(last) {
+		return 0
+	}
+
+	jump := now.Round(0).Sub(last.Round(0)) - now.Sub(last)
+	if jump > -clockJumpThreshold && jump < clockJumpThreshold {
+		return 0
+	}
+	return jump
+}
+
+// hasMonotonic returns whether the time carries a monotonic clock reading.
+// Round(0) strips the monotonic reading leaving the time otherwise unchanged.
+func hasMonotonic(t time.Time) bool {
+	return t != t.Round(0)
+}
+
+// adjustForClock protects a previously recorded time against wall clock jumps.
+// Times without a monotonic clock reading are shifted by the jump so that the
+// duration elapsed since them is neither reset nor inflated. Any time which is
+// still after now, for example because it was recorded on a server whose clock
+// was ahead, is clamped to now.
+func adjustForClock(t time.Time, now time.Time, jump time.Duration) time.Time {
+	if t.IsZero() {
+		return t
+	}
+
+	if jump != 0 && !hasMonotonic(t) {
+		t = t.Add(jump)
+	}
+
+	if t.After(now) {
+		return now
+	}
+	return t
+}
@@ -0,0 +1,47 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClockTracker(t *testing.T) {
+	var c clockTracker
+
+	// the first observation has nothing to compare against
+	require.Zero(t, c.observe(time.Now()))
+	// without a jump the wall and monotonic clocks agree
+	require.Zero(t, c.observe(time.Now()))
+
+	// times without monotonic readings cannot be used to detect jumps
+	wall := time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)
+	require.False(t, hasMonotonic(wall))
+	require.Zero(t, c.observe(wall))
+	require.Zero(t, c.observe(wall.Add(time.Hour)))
+}
+
+func TestAdjustForClock(t *testing.T) {
+	now := time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)
+
+	// zero times are left alone
+	require.True(t, adjustForClock(time.Time{}, now, time.Hour).IsZero())
+
+	// times without monotonic readings are shifted by the jump
+	past := now.Add(-time.Hour)
+	require.Equal(t, now.Add(-30*time.Minute), adjustForClock(past, now, 30*time.Minute))
+	require.Equal(t, now.Add(-90*time.Minute), adjustForClock(past, now, -30*time.Minute))
+
+	// times in the future are clamped
+	require.Equal(t, now, adjustForClock(now.Add(time.Minute), now, 0))
+	require.Equal(t, now, adjustForClock(past, now, 2*time.Hour))
+
+	// times with monotonic readings are unaffected by jumps
+	mono := time.Now()
+	require.True(t, hasMonotonic(mono))
+	require.Equal(t, mono, adjustForClock(mono, mono.Add(time.Minute), time.Hour))
+}
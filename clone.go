@@ -0,0 +1,152 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"time"
+
+	"github.com/hashicorp/raft"
+)
+
+// ExtCloner may optionally be implemented by the values stored within the Ext
+// fields and Enrichment data so that Clone copies them deeply. Values which do
+// not implement it are shared between the original and the clone and must
+// therefore not be modified.
+type ExtCloner interface {
+	CloneExt() interface{}
+}
+
+// cloneExt returns a deep copy of the value when it implements ExtCloner and
+// otherwise the value itself.
+func cloneExt(ext interface{}) interface{} {
+	if cloner, ok := ext.(ExtCloner); ok {
+		return cloner.CloneExt()
+	}
+	return ext
+}
+
+// cloneIDs returns a copy of the server IDs retaining a nil slice.
+func cloneIDs(ids []raft.ServerID) []raft.ServerID {
+	if ids == nil {
+		return nil
+	}
+	return append([]raft.ServerID{}, ids...)
+}
+
+// Clone returns a deep copy of the state which may be retained and modified
+// without racing with autopilot. See ExtCloner for how the Ext fields are
+// copied.
+func (s *State) Clone() *State {
+	if s == nil {
+		return nil
+	}
+
+	clone := *s
+	clone.Voters = cloneIDs(s.Voters)
+	clone.HeldServers = cloneIDs(s.HeldServers)
+	clone.Ext = cloneExt(s.Ext)
+
+	if s.Servers != nil {
+		clone.Servers = make(map[raft.ServerID]*ServerState, len(s.Servers))
+		for id, srv := range s.Servers {
+			clone.Servers[id] = srv.Clone()
+		}
+	}
+
+	if s.FailureDomains != nil {
+		clone.FailureDomains = make(map[string]*FailureDomainTolerance, len(s.FailureDomains))
+		for key, tolerance := range s.FailureDomains {
+			if tolerance == nil {
+				clone.FailureDomains[key] = nil
+				continue
+			}
+
+			t := *tolerance
+			if tolerance.Domains != nil {
+				t.Domains = make(map[string]*FailureDomain, len(tolerance.Domains))
+				for value, domain := range tolerance.Domains {
+					if domain == nil {
+						t.Domains[value] = nil
+						continue
+					}
+					d := *domain
+					d.Voters = cloneIDs(domain.Voters)
+					t.Domains[value] = &d
+				}
+			}
+			clone.FailureDomains[key] = &t
+		}
+	}
+
+	if s.SpreadViolations != nil {
+		clone.SpreadViolations = make([]SpreadViolation, len(s.SpreadViolations))
+		for i, v := range s.SpreadViolations {
+			v.Voters = cloneIDs(v.Voters)
+			clone.SpreadViolations[i] = v
+		}
+	}
+
+	if s.LeaderStats != nil {
+		stats := *s.LeaderStats
+		clone.LeaderStats = &stats
+	}
+
+	return &clone
+}
+
+// Clone returns a deep copy of the server's state.
+func (s *ServerState) Clone() *ServerState {
+	if s == nil {
+		return nil
+	}
+
+	clone := *s
+	clone.Server = s.Server.Clone()
+	clone.Stats = s.Stats.Clone()
+
+	if s.Health.Reasons != nil {
+		clone.Health.Reasons = append([]string{}, s.Health.Reasons...)
+	}
+	if s.Health.transitions != nil {
+		clone.Health.transitions = append([]time.Time{}, s.Health.transitions...)
+	}
+
+	if s.CatchUp != nil {
+		progress := *s.CatchUp
+		clone.CatchUp = &progress
+	}
+	if s.Lockout != nil {
+		lockout := *s.Lockout
+		clone.Lockout = &lockout
+	}
+
+	if s.Enrichments != nil {
+		clone.Enrichments = make(map[string]Enrichment, len(s.Enrichments))
+		for name, enrichment := range s.Enrichments {
+			enrichment.Data = cloneExt(enrichment.Data)
+			clone.Enrichments[name] = enrichment
+		}
+	}
+
+	return &clone
+}
+
+// Clone returns a deep copy of the server.
+func (s Server) Clone() Server {
+	if s.Meta != nil {
+		meta := make(map[string]string, len(s.Meta))
+		for k, v := range s.Meta {
+			meta[k] = v
+		}
+		s.Meta = meta
+	}
+	s.Ext = cloneExt(s.Ext)
+	return s
+}
+
+// Clone returns a deep copy of the stats.
+func (s ServerStats) Clone() ServerStats {
+	s.Ext = cloneExt(s.Ext)
+	return s
+}
@@ -0,0 +1,107 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+// cloneableExt is an Ext value which implements ExtCloner.
+type cloneableExt struct {
+	Values []string
+}
+
+func (e *cloneableExt) CloneExt() interface{} {
+	return &cloneableExt{Values: append([]string{}, e.Values...)}
+}
+
+func TestStateClone(t *testing.T) {
+	require.Nil(t, (*State)(nil).Clone())
+	require.Nil(t, (*ServerState)(nil).Clone())
+
+	now := time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)
+	state := &State{
+		firstStateTime: now,
+		Leader:         "a",
+		Voters:         []raft.ServerID{"a"},
+		Servers: map[raft.ServerID]*ServerState{
+			"a": {
+				Server: Server{
+					ID:   "a",
+					Meta: map[string]string{"zone": "1"},
+					Ext:  &cloneableExt{Values: []string{"server"}},
+				},
+				State: RaftLeader,
+				Stats: ServerStats{LastIndex: 10, Ext: &cloneableExt{Values: []string{"stats"}}},
+				Health: ServerHealth{
+					Healthy:     true,
+					StableSince: now,
+					Reasons:     []string{"reason"},
+					transitions: []time.Time{now},
+				},
+				CatchUp:     &CatchUpProgress{Lag: 5},
+				Lockout:     &ServerLockout{Failures: 1},
+				Enrichments: map[string]Enrichment{"disk": {Data: &cloneableExt{Values: []string{"disk"}}}},
+			},
+		},
+		FailureDomains: map[string]*FailureDomainTolerance{
+			"zone": {Key: "zone", Domains: map[string]*FailureDomain{"1": {Voters: []raft.ServerID{"a"}}}},
+		},
+		HeldServers:      []raft.ServerID{"b"},
+		SpreadViolations: []SpreadViolation{{Key: "zone", Value: "1", Voters: []raft.ServerID{"a"}}},
+		LeaderStats:      &LeaderStats{Term: 2},
+		Ext:              &cloneableExt{Values: []string{"state"}},
+	}
+
+	clone := state.Clone()
+	require.Equal(t, state, clone)
+
+	// modifying the clone must leave the original untouched
+	srv := clone.Servers["a"]
+	srv.Server.Meta["zone"] = "2"
+	srv.Server.Ext.(*cloneableExt).Values[0] = "changed"
+	srv.Stats.Ext.(*cloneableExt).Values[0] = "changed"
+	srv.Health.Reasons[0] = "changed"
+	srv.Health.transitions[0] = time.Time{}
+	srv.CatchUp.Lag = 0
+	srv.Lockout.Failures = 0
+	srv.Enrichments["disk"].Data.(*cloneableExt).Values[0] = "changed"
+	clone.Voters[0] = "changed"
+	clone.HeldServers[0] = "changed"
+	clone.FailureDomains["zone"].Domains["1"].Voters[0] = "changed"
+	clone.SpreadViolations[0].Voters[0] = "changed"
+	clone.LeaderStats.Term = 0
+	clone.Ext.(*cloneableExt).Values[0] = "changed"
+	clone.Servers["b"] = &ServerState{}
+
+	orig := state.Servers["a"]
+	require.Equal(t, "1", orig.Server.Meta["zone"])
+	require.Equal(t, "server", orig.Server.Ext.(*cloneableExt).Values[0])
+	require.Equal(t, "stats", orig.Stats.Ext.(*cloneableExt).Values[0])
+	require.Equal(t, "reason", orig.Health.Reasons[0])
+	require.Equal(t, now, orig.Health.transitions[0])
+	require.Equal(t, uint64(5), orig.CatchUp.Lag)
+	require.Equal(t, 1, orig.Lockout.Failures)
+	require.Equal(t, "disk", orig.Enrichments["disk"].Data.(*cloneableExt).Values[0])
+	require.Equal(t, raft.ServerID("a"), state.Voters[0])
+	require.Equal(t, raft.ServerID("b"), state.HeldServers[0])
+	require.Equal(t, raft.ServerID("a"), state.FailureDomains["zone"].Domains["1"].Voters[0])
+	require.Equal(t, raft.ServerID("a"), state.SpreadViolations[0].Voters[0])
+	require.Equal(t, uint64(2), state.LeaderStats.Term)
+	require.Equal(t, "state", state.Ext.(*cloneableExt).Values[0])
+	require.Len(t, state.Servers, 1)
+}
+
+func TestServerCloneSharesExt(t *testing.T) {
+	// Ext values which do not implement ExtCloner are shared
+	ext := map[string]string{"k": "v"}
+	srv := Server{ID: "a", Ext: ext}
+	clone := srv.Clone()
+	clone.Ext.(map[string]string)["k"] = "changed"
+	require.Equal(t, "changed", ext["k"])
+}
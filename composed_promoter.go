@@ -0,0 +1,144 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"github.com/hashicorp/raft"
+)
+
+// ComposedPromoter stacks an ordered list of Promoters so that applications can
+// layer policies, such as zone awareness on top of upgrade migrations on top of
+// the StablePromoter. The first promoter is the bottom of the stack and each
+// following one is layered on top of those before it. Their results are merged
+// as follows:
+//
+//   - Promotions and demotions are the union of all the promoters' changes. A
+//     demotion wins over a promotion of the same server.
+//   - The desired leader is that of the top most promoter nominating one. The
+//     other nominations follow as leader candidates from the top down.
+//   - Node types given by a promoter replace those of the promoters below it.
+//   - Failed server removals are filtered by every promoter in turn.
+//   - A node type is a potential voter when any promoter considers it to be.
+//   - The server and state Ext values are those of the top most promoter
+//     returning a non-nil value.
+type ComposedPromoter struct {
+	promoters []Promoter
+}
+
+// NewComposedPromoter returns a ComposedPromoter stacking the given promoters
+// from the bottom up. Nil promoters are ignored.
+func NewComposedPromoter(promoters ...Promoter) *ComposedPromoter {
+	c := &ComposedPromoter{}
+	for _, p := range promoters {
+		if p != nil {
+			c.promoters = append(c.promoters, p)
+		}
+	}
+	return c
+}
+
+func (c *ComposedPromoter) GetServerExt(conf *Config, srv *ServerState) interface{} {
+	for i := len(c.promoters) - 1; i >= 0; i-- {
+		if ext := c.promoters[i].GetServerExt(conf, srv); ext != nil {
+			return ext
+		}
+	}
+	return nil
+}
+
+func (c *ComposedPromoter) GetStateExt(conf *Config, s *State) interface{} {
+	for i := len(c.promoters) - 1; i >= 0; i-- {
+		if ext := c.promoters[i].GetStateExt(conf, s); ext != nil {
+			return ext
+		}
+	}
+	return nil
+}
+
+func (c *ComposedPromoter) GetNodeTypes(conf *Config, s *State) map[raft.ServerID]NodeType {
+	types := make(map[raft.ServerID]NodeType)
+	for _, p := range c.promoters {
+		for id, typ := range p.GetNodeTypes(conf, s) {
+			types[id] = typ
+		}
+	}
+	return types
+}
+
+func (c *ComposedPromoter) CalculatePromotionsAndDemotions(conf *Config, s *State) RaftChanges {
+	var merged RaftChanges
+	var leaders []raft.ServerID
+
+	promoted := make(map[raft.ServerID]struct{})
+	demoted := make(map[raft.ServerID]struct{})
+	for i := len(c.promoters) - 1; i >= 0; i-- {
+		changes := c.promoters[i].CalculatePromotionsAndDemotions(conf, s)
+
+		for _, id := range changes.Promotions {
+			if _, ok := promoted[id]; !ok {
+				promoted[id] = struct{}{}
+				merged.Promotions = append(merged.Promotions, id)
+			}
+		}
+		for _, id := range changes.Demotions {
+			if _, ok := demoted[id]; !ok {
+				demoted[id] = struct{}{}
+				merged.Demotions = append(merged.Demotions, id)
+			}
+		}
+
+		leaders = append(leaders, leaderCandidates(changes)...)
+	}
+
+	// a demotion wins over a promotion of the same server
+	merged.Promotions = withoutServers(merged.Promotions, demoted)
+
+	candidates := leaderCandidates(RaftChanges{LeaderCandidates: leaders})
+	if len(candidates) > 0 {
+		merged.Leader = candidates[0]
+		merged.LeaderCandidates = candidates[1:]
+	}
+	return merged
+}
+
+func (c *ComposedPromoter) FilterFailedServerRemovals(conf *Config, s *State, failed *FailedServers) *FailedServers {
+	for _, p := range c.promoters {
+		if failed == nil {
+			return nil
+		}
+		failed = p.FilterFailedServerRemovals(conf, s, failed)
+	}
+	return failed
+}
+
+func (c *ComposedPromoter) IsPotentialVoter(nodeType NodeType) bool {
+	for _, p := range c.promoters {
+		if p.IsPotentialVoter(nodeType) {
+			return true
+		}
+	}
+	return false
+}
+
+// UpgradeMigrationInProgress reports a migration as in progress when any of the
+// promoters implementing UpgradeMigrator does.
+func (c *ComposedPromoter) UpgradeMigrationInProgress(conf *Config, s *State) bool {
+	for _, p := range c.promoters {
+		if migrator, ok := p.(UpgradeMigrator); ok && migrator.UpgradeMigrationInProgress(conf, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutServers returns the IDs which are not in the given set.
+func withoutServers(ids []raft.ServerID, exclude map[raft.ServerID]struct{}) []raft.ServerID {
+	var result []raft.ServerID
+	for _, id := range ids {
+		if _, ok := exclude[id]; !ok {
+			result = append(result, id)
+		}
+	}
+	return result
+}
@@ -0,0 +1,98 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"testing"
+
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComposedPromoter_CalculatePromotionsAndDemotions(t *testing.T) {
+	conf := &Config{}
+	state := &State{}
+
+	bottom := NewMockPromoter(t)
+	bottom.On("CalculatePromotionsAndDemotions", conf, state).Return(RaftChanges{
+		Promotions: []raft.ServerID{"a", "b"},
+		Leader:     "x",
+	}).Once()
+
+	top := NewMockPromoter(t)
+	top.On("CalculatePromotionsAndDemotions", conf, state).Return(RaftChanges{
+		Promotions:       []raft.ServerID{"c", "a"},
+		Demotions:        []raft.ServerID{"b", "d"},
+		Leader:           "y",
+		LeaderCandidates: []raft.ServerID{"x", "z"},
+	}).Once()
+
+	changes := NewComposedPromoter(bottom, nil, top).CalculatePromotionsAndDemotions(conf, state)
+	require.Equal(t, RaftChanges{
+		// the demotion of b wins over its promotion
+		Promotions: []raft.ServerID{"c", "a"},
+		Demotions:  []raft.ServerID{"b", "d"},
+		// the top most nomination comes first
+		Leader:           "y",
+		LeaderCandidates: []raft.ServerID{"x", "z"},
+	}, changes)
+}
+
+func TestComposedPromoter_GetNodeTypes(t *testing.T) {
+	conf := &Config{}
+	state := &State{}
+
+	bottom := NewMockPromoter(t)
+	bottom.On("GetNodeTypes", conf, state).Return(map[raft.ServerID]NodeType{
+		"a": NodeVoter,
+		"b": NodeVoter,
+	}).Once()
+
+	top := NewMockPromoter(t)
+	top.On("GetNodeTypes", conf, state).Return(map[raft.ServerID]NodeType{
+		"b": "read-replica",
+	}).Once()
+	top.On("IsPotentialVoter", NodeType("read-replica")).Return(false)
+	bottom.On("IsPotentialVoter", NodeVoter).Return(true)
+	bottom.On("IsPotentialVoter", NodeType("read-replica")).Return(false)
+
+	promoter := NewComposedPromoter(bottom, top)
+	require.Equal(t, map[raft.ServerID]NodeType{
+		"a": NodeVoter,
+		"b": "read-replica",
+	}, promoter.GetNodeTypes(conf, state))
+
+	require.True(t, promoter.IsPotentialVoter(NodeVoter))
+	require.False(t, promoter.IsPotentialVoter("read-replica"))
+}
+
+func TestComposedPromoter_FilterFailedServerRemovals(t *testing.T) {
+	conf := &Config{}
+	state := &State{}
+	failed := &FailedServers{StaleVoters: []raft.ServerID{"a", "b"}}
+	filtered := &FailedServers{StaleVoters: []raft.ServerID{"a"}}
+
+	bottom := NewMockPromoter(t)
+	bottom.On("FilterFailedServerRemovals", conf, state, failed).Return(filtered).Once()
+	top := NewMockPromoter(t)
+	top.On("FilterFailedServerRemovals", conf, state, filtered).Return(filtered).Once()
+
+	require.Equal(t, filtered, NewComposedPromoter(bottom, top).FilterFailedServerRemovals(conf, state, failed))
+}
+
+func TestComposedPromoter_Ext(t *testing.T) {
+	conf := &Config{}
+	state := &State{}
+	srv := &ServerState{}
+
+	bottom := NewMockPromoter(t)
+	bottom.On("GetServerExt", conf, srv).Return("bottom").Once()
+	top := NewMockPromoter(t)
+	top.On("GetServerExt", conf, srv).Return(nil).Once()
+	top.On("GetStateExt", conf, state).Return("top").Once()
+
+	promoter := NewComposedPromoter(bottom, top)
+	require.Equal(t, "bottom", promoter.GetServerExt(conf, srv))
+	require.Equal(t, "top", promoter.GetStateExt(conf, state))
+}
@@ -0,0 +1,158 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// ConfigError describes why a single field of a Config is invalid. The errors
+// returned by Config.Validate may be inspected with errors.As.
+type ConfigError struct {
+	// Field is the name of the invalid Config field.
+	Field string
+
+	// Reason describes why the field is invalid.
+	Reason string
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Field, e.Reason)
+}
+
+// Validate normalizes the configuration and then checks it for nonsensical
+// settings. Normalization sets UnknownStatusTreatment to its default when
+// unset and trims whitespace from, and removes empty and duplicate entries
+// of, FailureDomainKeys, IgnoredServerSelectors and ZoneKey. The returned
+// error is nil when the configuration is valid and otherwise wraps a
+// ConfigError for every invalid field.
+func (c *Config) Validate() error {
+	if c == nil {
+		return &ConfigError{Field: "Config", Reason: "no configuration was provided"}
+	}
+
+	c.normalize()
+
+	var result error
+	invalid := func(field, format string, args ...interface{}) {
+		result = multierror.Append(result, &ConfigError{Field: field, Reason: fmt.Sprintf(format, args...)})
+	}
+
+	if c.LastContactThreshold <= 0 {
+		invalid("LastContactThreshold", "must be positive, got %s", c.LastContactThreshold)
+	}
+
+	if c.MaxTrailingLogs == 0 {
+		invalid("MaxTrailingLogs", "must be positive as otherwise every follower is unhealthy")
+	}
+
+	if c.CleanupDeadServers && c.MinQuorum == 0 {
+		invalid("MinQuorum", "must be set when CleanupDeadServers is enabled to prevent removing too many servers")
+	}
+
+	if c.TargetVoters != 0 && c.TargetVoters < c.MinQuorum {
+		invalid("TargetVoters", "must not be less than MinQuorum (%d), got %d", c.MinQuorum, c.TargetVoters)
+	}
+
+	if c.ServerStabilizationTime < 0 {
+		invalid("ServerStabilizationTime", "must not be negative, got %s", c.ServerStabilizationTime)
+	}
+
+	if c.DeadServerRemovalGracePeriod < 0 {
+		invalid("DeadServerRemovalGracePeriod", "must not be negative, got %s", c.DeadServerRemovalGracePeriod)
+	}
+
+	if c.UnhealthyLeaderTransferDelay < 0 {
+		invalid("UnhealthyLeaderTransferDelay", "must not be negative, got %s", c.UnhealthyLeaderTransferDelay)
+	}
+
+	if c.StatsOutageGracePeriod < 0 {
+		invalid("StatsOutageGracePeriod", "must not be negative, got %s", c.StatsOutageGracePeriod)
+	}
+	if c.CatchUpObservations < 0 {
+		invalid("CatchUpObservations", "must not be negative, got %d", c.CatchUpObservations)
+	}
+	if c.CanarySoakPeriod < 0 {
+		invalid("CanarySoakPeriod", "must not be negative, got %s", c.CanarySoakPeriod)
+	}
+	if c.StatsCacheTTL < 0 {
+		invalid("StatsCacheTTL", "must not be negative, got %s", c.StatsCacheTTL)
+	}
+
+	switch c.UnknownStatusTreatment {
+	case UnknownStatusFailed, UnknownStatusHold:
+	default:
+		invalid("UnknownStatusTreatment", "unknown treatment %q", c.UnknownStatusTreatment)
+	}
+
+	switch c.MaxVersionSkew {
+	case VersionSkewAny, VersionSkewMinor, VersionSkewPatch, VersionSkewNone:
+	default:
+		invalid("MaxVersionSkew", "unknown version skew %q", c.MaxVersionSkew)
+	}
+
+	for _, expr := range c.IgnoredServerSelectors {
+		if _, err := parseServerSelector(expr); err != nil {
+			invalid("IgnoredServerSelectors", "%v", err)
+		}
+	}
+
+	for i, sc := range c.SpreadConstraints {
+		field := fmt.Sprintf("SpreadConstraints[%d]", i)
+		if sc.Key == "" {
+			invalid(field, "a Server.Meta key is required")
+		}
+		if sc.MaxVoters <= 0 {
+			invalid(field, "MaxVoters must be positive, got %d", sc.MaxVoters)
+		}
+	}
+
+	for i, o := range c.Overrides {
+		field := fmt.Sprintf("Overrides[%d]", i)
+		if o.LastContactThreshold < 0 || o.ServerStabilizationTime < 0 {
+			invalid(field, "durations must not be negative")
+		}
+		if o.Selector != "" {
+			if _, err := parseServerSelector(o.Selector); err != nil {
+				invalid(field, "%v", err)
+			}
+		}
+	}
+
+	return result
+}
+
+// normalize fills in the defaults and tidies up the list settings.
+func (c *Config) normalize() {
+	if c.UnknownStatusTreatment == "" {
+		c.UnknownStatusTreatment = UnknownStatusFailed
+	}
+
+	c.ZoneKey = strings.TrimSpace(c.ZoneKey)
+	c.FailureDomainKeys = normalizeStrings(c.FailureDomainKeys)
+	c.IgnoredServerSelectors = normalizeStrings(c.IgnoredServerSelectors)
+}
+
+// normalizeStrings trims the whitespace from the values and removes any which
+// are empty or duplicated while preserving their order.
+func normalizeStrings(values []string) []string {
+	if values == nil {
+		return nil
+	}
+
+	seen := make(map[string]struct{}, len(values))
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if _, ok := seen[v]; ok || v == "" {
+			continue
+		}
+		seen[v] = struct{}{}
+		result = append(result, v)
+	}
+	return result
+}
@@ -0,0 +1,118 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigValidate(t *testing.T) {
+	valid := func() *Config {
+		return &Config{
+			CleanupDeadServers:      true,
+			LastContactThreshold:    time.Second,
+			MaxTrailingLogs:         100,
+			MinQuorum:               3,
+			ServerStabilizationTime: 10 * time.Second,
+		}
+	}
+
+	type testCase struct {
+		modify func(*Config)
+		fields []string
+	}
+
+	cases := map[string]testCase{
+		"valid": {
+			modify: func(*Config) {},
+		},
+		"no-min-quorum": {
+			modify: func(c *Config) { c.MinQuorum = 0 },
+			fields: []string{"MinQuorum"},
+		},
+		"no-min-quorum-without-cleanup": {
+			modify: func(c *Config) {
+				c.CleanupDeadServers = false
+				c.MinQuorum = 0
+			},
+		},
+		"negative-durations": {
+			modify: func(c *Config) {
+				c.LastContactThreshold = -time.Second
+				c.ServerStabilizationTime = -time.Second
+				c.StatsOutageGracePeriod = -time.Second
+				c.StatsCacheTTL = -time.Second
+			},
+			fields: []string{"LastContactThreshold", "ServerStabilizationTime", "StatsOutageGracePeriod", "StatsCacheTTL"},
+		},
+		"no-trailing-logs": {
+			modify: func(c *Config) { c.MaxTrailingLogs = 0 },
+			fields: []string{"MaxTrailingLogs"},
+		},
+		"bad-treatment": {
+			modify: func(c *Config) { c.UnknownStatusTreatment = "ignore" },
+			fields: []string{"UnknownStatusTreatment"},
+		},
+		"bad-selector": {
+			modify: func(c *Config) { c.IgnoredServerSelectors = []string{"pool=build", "=core"} },
+			fields: []string{"IgnoredServerSelectors"},
+		},
+		"bad-version-skew": {
+			modify: func(c *Config) { c.MaxVersionSkew = "major" },
+			fields: []string{"MaxVersionSkew"},
+		},
+		"bad-spread-constraints": {
+			modify: func(c *Config) {
+				c.SpreadConstraints = []SpreadConstraint{{Key: "rack", MaxVoters: 2}, {MaxVoters: 1}, {Key: "rack"}}
+			},
+			fields: []string{"SpreadConstraints[1]", "SpreadConstraints[2]"},
+		},
+	}
+
+	for name, tcase := range cases {
+		t.Run(name, func(t *testing.T) {
+			conf := valid()
+			tcase.modify(conf)
+
+			err := conf.Validate()
+			if len(tcase.fields) == 0 {
+				require.NoError(t, err)
+				return
+			}
+
+			var merr *multierror.Error
+			require.True(t, errors.As(err, &merr))
+
+			var fields []string
+			for _, err := range merr.Errors {
+				var cerr *ConfigError
+				require.True(t, errors.As(err, &cerr))
+				fields = append(fields, cerr.Field)
+			}
+			require.Equal(t, tcase.fields, fields)
+		})
+	}
+
+	var nilConf *Config
+	require.Error(t, nilConf.Validate())
+}
+
+func TestConfigValidateNormalizes(t *testing.T) {
+	conf := &Config{
+		LastContactThreshold: time.Second,
+		MaxTrailingLogs:      100,
+		ZoneKey:              " zone ",
+		FailureDomainKeys:    []string{"zone", " rack", "", "zone "},
+	}
+
+	require.NoError(t, conf.Validate())
+	require.Equal(t, UnknownStatusFailed, conf.UnknownStatusTreatment)
+	require.Equal(t, "zone", conf.ZoneKey)
+	require.Equal(t, []string{"zone", "rack"}, conf.FailureDomainKeys)
+}
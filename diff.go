@@ -0,0 +1,154 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"sort"
+
+	"github.com/hashicorp/raft"
+)
+
+// HealthChange is a server whose health changed between two states.
+type HealthChange struct {
+	// ServerID is the server whose health changed.
+	ServerID raft.ServerID
+
+	// Healthy is the server's health within the newer state.
+	Healthy bool
+
+	// Reasons describes why the server is not healthy within the newer
+	// state. It is empty when the server became healthy.
+	Reasons []string
+}
+
+// StateDiff is the difference between two autopilot states. All the server
+// IDs are sorted.
+type StateDiff struct {
+	// Added are the servers which are only in the newer state.
+	Added []raft.ServerID
+
+	// Removed are the servers which are only in the older state.
+	Removed []raft.ServerID
+
+	// HealthChanges are the servers in both states whose health changed.
+	HealthChanges []HealthChange
+
+	// GainedVote are the servers which have voting rights within the newer
+	// state but did not in the older one, including added voters.
+	GainedVote []raft.ServerID
+
+	// LostVote are the servers which had voting rights within the older state
+	// but do not in the newer one, including removed voters.
+	LostVote []raft.ServerID
+
+	// PreviousLeader and Leader are the leaders of the older and newer states
+	// respectively. LeaderChanged is set when they differ.
+	PreviousLeader raft.ServerID
+	Leader         raft.ServerID
+	LeaderChanged  bool
+
+	// HealthyChanged is set when the health of the cluster as a whole changed
+	// in which case Healthy is its health within the newer state.
+	HealthyChanged bool
+	Healthy        bool
+}
+
+// Empty returns whether there is no difference between the states.
+func (d *StateDiff) Empty() bool {
+	return len(d.Added) == 0 &&
+		len(d.Removed) == 0 &&
+		len(d.HealthChanges) == 0 &&
+		len(d.GainedVote) == 0 &&
+		len(d.LostVote) == 0 &&
+		!d.LeaderChanged &&
+		!d.HealthyChanged
+}
+
+// StateDiffNotifier may optionally be implemented by the
+// ApplicationIntegration to be given the difference between the previous and
+// new state after every state update which changed anything the StateDiff
+// describes, such as to log or alert on it. It is called synchronously after
+// NotifyState and therefore should not block.
+type StateDiffNotifier interface {
+	NotifyStateDiff(*StateDiff)
+}
+
+// Diff returns the difference between the older and newer states. A nil state
+// is treated as a state without any servers.
+func Diff(older, newer *State) *StateDiff {
+	if older == nil {
+		older = &State{}
+	}
+	if newer == nil {
+		newer = &State{}
+	}
+
+	d := &StateDiff{
+		PreviousLeader: older.Leader,
+		Leader:         newer.Leader,
+		LeaderChanged:  older.Leader != newer.Leader,
+		HealthyChanged: older.Healthy != newer.Healthy,
+		Healthy:        newer.Healthy,
+	}
+
+	for id, srv := range newer.Servers {
+		prev, ok := older.Servers[id]
+		if !ok {
+			d.Added = append(d.Added, id)
+			if srv.HasVotingRights() {
+				d.GainedVote = append(d.GainedVote, id)
+			}
+			continue
+		}
+
+		if prev.Health.Healthy != srv.Health.Healthy {
+			d.HealthChanges = append(d.HealthChanges, HealthChange{
+				ServerID: id,
+				Healthy:  srv.Health.Healthy,
+				Reasons:  srv.Health.Reasons,
+			})
+		}
+
+		if voter := srv.HasVotingRights(); voter && !prev.HasVotingRights() {
+			d.GainedVote = append(d.GainedVote, id)
+		} else if !voter && prev.HasVotingRights() {
+			d.LostVote = append(d.LostVote, id)
+		}
+	}
+
+	for id, prev := range older.Servers {
+		if _, ok := newer.Servers[id]; ok {
+			continue
+		}
+		d.Removed = append(d.Removed, id)
+		if prev.HasVotingRights() {
+			d.LostVote = append(d.LostVote, id)
+		}
+	}
+
+	for _, ids := range [][]raft.ServerID{d.Added, d.Removed, d.GainedVote, d.LostVote} {
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	}
+	sort.Slice(d.HealthChanges, func(i, j int) bool {
+		return d.HealthChanges[i].ServerID < d.HealthChanges[j].ServerID
+	})
+	return d
+}
+
+// notifyStateDiff gives the difference between the states to the delegate
+// when it implements StateDiffNotifier and anything changed.
+func (a *Autopilot) notifyStateDiff(older, newer *State) {
+	notifier, ok := a.delegate.(StateDiffNotifier)
+	if !ok {
+		return
+	}
+
+	diff := Diff(older, newer)
+	if diff.Empty() {
+		return
+	}
+
+	a.countDelegateCall("NotifyStateDiff")
+	notifier.NotifyStateDiff(diff)
+}
@@ -0,0 +1,98 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"testing"
+
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+// diffNotifyingDelegate records the diffs given to NotifyStateDiff.
+type diffNotifyingDelegate struct {
+	*MockApplicationIntegration
+	diffs []*StateDiff
+}
+
+func (d *diffNotifyingDelegate) NotifyStateDiff(diff *StateDiff) {
+	d.diffs = append(d.diffs, diff)
+}
+
+func diffTestServer(id raft.ServerID, state RaftState, healthy bool) *ServerState {
+	return &ServerState{
+		Server: Server{ID: id},
+		State:  state,
+		Health: ServerHealth{Healthy: healthy},
+	}
+}
+
+func TestDiff(t *testing.T) {
+	older := &State{
+		Healthy: true,
+		Leader:  "a",
+		Servers: map[raft.ServerID]*ServerState{
+			"a": diffTestServer("a", RaftLeader, true),
+			"b": diffTestServer("b", RaftVoter, true),
+			"c": diffTestServer("c", RaftVoter, true),
+			"d": diffTestServer("d", RaftNonVoter, true),
+			"e": diffTestServer("e", RaftVoter, true),
+		},
+	}
+
+	failed := diffTestServer("c", RaftVoter, false)
+	failed.Health.Reasons = []string{`node status is "failed"`}
+	newer := &State{
+		Healthy: false,
+		Leader:  "b",
+		Servers: map[raft.ServerID]*ServerState{
+			"a": diffTestServer("a", RaftVoter, true),
+			"b": diffTestServer("b", RaftLeader, true),
+			"c": failed,
+			"d": diffTestServer("d", RaftVoter, true),
+			"g": diffTestServer("g", RaftNonVoter, false),
+			"f": diffTestServer("f", RaftVoter, true),
+		},
+	}
+
+	require.Equal(t, &StateDiff{
+		Added:   []raft.ServerID{"f", "g"},
+		Removed: []raft.ServerID{"e"},
+		HealthChanges: []HealthChange{
+			{ServerID: "c", Healthy: false, Reasons: []string{`node status is "failed"`}},
+		},
+		GainedVote:     []raft.ServerID{"d", "f"},
+		LostVote:       []raft.ServerID{"e"},
+		PreviousLeader: "a",
+		Leader:         "b",
+		LeaderChanged:  true,
+		HealthyChanged: true,
+		Healthy:        false,
+	}, Diff(older, newer))
+
+	require.True(t, Diff(older, older).Empty())
+	require.True(t, Diff(nil, nil).Empty())
+
+	d := Diff(nil, older)
+	require.Equal(t, []raft.ServerID{"a", "b", "c", "d", "e"}, d.Added)
+	require.Equal(t, []raft.ServerID{"a", "b", "c", "e"}, d.GainedVote)
+}
+
+func TestNotifyStateDiff(t *testing.T) {
+	delegate := &diffNotifyingDelegate{MockApplicationIntegration: NewMockApplicationIntegration(t)}
+	a := &Autopilot{logger: testLogger(t), delegate: delegate}
+
+	state := &State{
+		Leader:  "a",
+		Servers: map[raft.ServerID]*ServerState{"a": diffTestServer("a", RaftLeader, true)},
+	}
+
+	// unchanged states are not notified
+	a.notifyStateDiff(state, state)
+	require.Empty(t, delegate.diffs)
+
+	a.notifyStateDiff(nil, state)
+	require.Len(t, delegate.diffs, 1)
+	require.Equal(t, []raft.ServerID{"a"}, delegate.diffs[0].Added)
+}
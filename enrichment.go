@@ -0,0 +1,154 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/raft"
+)
+
+// Enricher gathers supplemental information about servers from sources outside
+// of Raft and the application, such as a cloud provider's instance status or
+// an inventory system's hardware health. Enrichers are run asynchronously
+// between state updates so that slow sources never delay them. The most recent
+// results are attached to each ServerState where promoters and applications
+// may consider them when making health and removal decisions.
+type Enricher interface {
+	// Name identifies the enricher. Its data is attached to each ServerState's
+	// Enrichments under this name.
+	Name() string
+
+	// Enrich gathers data for the given servers. Servers missing from the
+	// returned map will have no data attached for this enricher. The context
+	// will be cancelled when autopilot is stopped.
+	Enrich(ctx context.Context, servers map[raft.ServerID]*Server) map[raft.ServerID]interface{}
+}
+
+// Enrichment is the data an Enricher attached to a server.
+type Enrichment struct {
+	// Data is what the enricher returned for the server.
+	Data interface{}
+
+	// UpdatedAt is when the enricher returned the data.
+	UpdatedAt time.Time
+
+	// Stale is whether the data is older than the enricher's max age.
+	// Consumers should avoid making destructive decisions based on stale data.
+	Stale bool
+}
+
+// enricherState tracks the latest results of a single enricher.
+type enricherState struct {
+	enricher Enricher
+
+	// maxAge is how old the data may be before it is considered stale. Zero
+	// means the data never becomes stale.
+	maxAge time.Duration
+
+	running   bool
+	data      map[raft.ServerID]interface{}
+	updatedAt time.Time
+}
+
+// enrichmentTracker runs the registered enrichers and holds their results.
+type enrichmentTracker struct {
+	lock      sync.Mutex
+	enrichers []*enricherState
+
+	// running tracks the enricher go routines so that stopping autopilot can
+	// wait for them to exit.
+	running sync.WaitGroup
+}
+
+// add registers a new enricher.
+func (t *enrichmentTracker) add(enricher Enricher, maxAge time.Duration) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.enrichers = append(t.enrichers, &enricherState{enricher: enricher, maxAge: maxAge})
+}
+
+// get returns the enrichments for a server or nil if there are none.
+func (t *enrichmentTracker) get(id raft.ServerID, now time.Time) map[string]Enrichment {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	var enrichments map[string]Enrichment
+	for _, e := range t.enrichers {
+		data, ok := e.data[id]
+		if !ok {
+			continue
+		}
+
+		if enrichments == nil {
+			enrichments = make(map[string]Enrichment)
+		}
+		enrichments[e.enricher.Name()] = Enrichment{
+			Data:      data,
+			UpdatedAt: e.updatedAt,
+			Stale:     e.maxAge > 0 && now.Sub(e.updatedAt) > e.maxAge,
+		}
+	}
+	return enrichments
+}
+
+// runEnrichers starts every enricher which is not still running from a previous
+// state update. Enrichers are given the servers in the current state and their
+// results will be attached to the servers in later states.
+func (a *Autopilot) runEnrichers(ctx context.Context) {
+	state := a.GetState()
+	if state == nil || len(state.Servers) == 0 {
+		return
+	}
+
+	a.enrichment.lock.Lock()
+	defer a.enrichment.lock.Unlock()
+
+	for _, e := range a.enrichment.enrichers {
+		if e.running {
+			continue
+		}
+
+		servers := make(map[raft.ServerID]*Server, len(state.Servers))
+		for id, srv := range state.Servers {
+			server := srv.Server
+			servers[id] = &server
+		}
+
+		e.running = true
+		a.enrichment.running.Add(1)
+		go a.runEnricher(ctx, e, servers)
+	}
+}
+
+// runEnricher calls a single enricher and records its results.
+func (a *Autopilot) runEnricher(ctx context.Context, e *enricherState, servers map[raft.ServerID]*Server) {
+	defer a.enrichment.running.Done()
+
+	data := a.callEnricher(ctx, e.enricher, servers)
+
+	a.enrichment.lock.Lock()
+	defer a.enrichment.lock.Unlock()
+
+	e.running = false
+	if data != nil && ctx.Err() == nil {
+		e.data = data
+		e.updatedAt = a.now()
+	}
+}
+
+// callEnricher calls Enrich on the enricher and converts any panic into a
+// logged error.
+func (a *Autopilot) callEnricher(ctx context.Context, enricher Enricher, servers map[raft.ServerID]*Server) (data map[raft.ServerID]interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			a.logger.Error("enricher panicked", "enricher", enricher.Name(), "panic", r)
+			data = nil
+		}
+	}()
+
+	return enricher.Enrich(ctx, servers)
+}
@@ -0,0 +1,103 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+// instanceStatusEnricher reports a fixed instance status for every server.
+type instanceStatusEnricher struct {
+	status string
+	calls  chan map[raft.ServerID]*Server
+}
+
+func (e *instanceStatusEnricher) Name() string {
+	return "instance-status"
+}
+
+func (e *instanceStatusEnricher) Enrich(_ context.Context, servers map[raft.ServerID]*Server) map[raft.ServerID]interface{} {
+	data := make(map[raft.ServerID]interface{})
+	for id := range servers {
+		data[id] = e.status
+	}
+	e.calls <- servers
+	return data
+}
+
+// panickingEnricher panics every time it is called.
+type panickingEnricher struct{}
+
+func (panickingEnricher) Name() string {
+	return "panics"
+}
+
+func (panickingEnricher) Enrich(context.Context, map[raft.ServerID]*Server) map[raft.ServerID]interface{} {
+	panic("enricher failure")
+}
+
+func TestEnrichers(t *testing.T) {
+	now := time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)
+	mtime := NewMockTimeProvider(t)
+	mtime.On("Now").Return(now)
+
+	enricher := &instanceStatusEnricher{status: "running", calls: make(chan map[raft.ServerID]*Server, 1)}
+	a := &Autopilot{
+		logger: hclog.NewNullLogger(),
+		time:   mtime,
+		state: &State{
+			Servers: map[raft.ServerID]*ServerState{
+				"a": {Server: Server{ID: "a"}},
+				"b": {Server: Server{ID: "b"}},
+			},
+		},
+	}
+	WithEnricher(enricher, time.Minute)(a)
+	WithEnricher(panickingEnricher{}, 0)(a)
+
+	require.Nil(t, a.enrichment.get("a", now))
+
+	a.runEnrichers(context.Background())
+	servers := <-enricher.calls
+	require.Len(t, servers, 2)
+	a.enrichment.running.Wait()
+
+	expected := map[string]Enrichment{
+		"instance-status": {Data: "running", UpdatedAt: now},
+	}
+	require.Equal(t, expected, a.enrichment.get("a", now))
+	require.Nil(t, a.enrichment.get("c", now))
+
+	// data older than the max age is reported as stale
+	enrichments := a.enrichment.get("b", now.Add(2*time.Minute))
+	require.True(t, enrichments["instance-status"].Stale)
+}
+
+func TestEnrichersCancelled(t *testing.T) {
+	enricher := &instanceStatusEnricher{status: "running", calls: make(chan map[raft.ServerID]*Server, 1)}
+	a := &Autopilot{
+		logger: hclog.NewNullLogger(),
+		state: &State{
+			Servers: map[raft.ServerID]*ServerState{
+				"a": {Server: Server{ID: "a"}},
+			},
+		},
+	}
+	WithEnricher(enricher, 0)(a)
+
+	// results returned after autopilot was stopped are discarded
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	a.runEnrichers(ctx)
+	<-enricher.calls
+	a.enrichment.running.Wait()
+
+	require.Nil(t, a.enrichment.get("a", time.Now()))
+}
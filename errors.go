@@ -0,0 +1,45 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"errors"
+
+	"github.com/hashicorp/raft"
+)
+
+// The errors returned by autopilot wrap these so that applications may
+// distinguish the expected failures, such as those while the local server is
+// not the leader, from real ones with errors.Is.
+var (
+	// ErrNoState is returned when an operation requires an autopilot state
+	// with a known leader but none is available, for example because the
+	// first state has not been computed yet.
+	ErrNoState = errors.New("no valid autopilot state is available")
+
+	// ErrNotLeader is returned when a Raft operation fails as the local
+	// server is not the leader. It is Raft's own error so that errors
+	// returned by Raft also match it.
+	ErrNotLeader = raft.ErrNotLeader
+
+	// ErrNotRunning is returned when an operation requires the autopilot go
+	// routines to be running, which they only are on the leader.
+	ErrNotRunning = errors.New("autopilot is not running")
+
+	// ErrQuorumRisk is returned when a change is refused as it would put the
+	// cluster's quorum at risk.
+	ErrQuorumRisk = errors.New("the change would put the quorum at risk")
+
+	// ErrUnknownServer is returned when an operation refers to a server which
+	// is not in the autopilot state.
+	ErrUnknownServer = errors.New("the server is not in the autopilot state")
+
+	// ErrLeadershipTransferFailed is returned when leadership could not be
+	// transferred to another server.
+	ErrLeadershipTransferFailed = errors.New("leadership transfer failed")
+
+	// ErrChangeQueueFull is returned when a Raft membership change cannot be
+	// queued as too many changes are already waiting to be made.
+	ErrChangeQueueFull = errors.New("the membership change queue is full")
+)
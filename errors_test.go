@@ -0,0 +1,45 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrors(t *testing.T) {
+	t.Run("no-state", func(t *testing.T) {
+		mdel := NewMockApplicationIntegration(t)
+		mdel.On("AutopilotConfig").Return(&Config{}).Once()
+
+		a := &Autopilot{logger: hclog.NewNullLogger(), delegate: mdel}
+
+		_, err := a.Plan()
+		require.ErrorIs(t, err, ErrNoState)
+		require.ErrorIs(t, a.ReplaceServer("a", "b", false), ErrNoState)
+	})
+
+	t.Run("unknown-server", func(t *testing.T) {
+		a := &Autopilot{
+			logger: hclog.NewNullLogger(),
+			state:  testState(replacementServers, "a", "a", "b", "c"),
+		}
+
+		require.ErrorIs(t, a.ReplaceServer("x", "d", false), ErrUnknownServer)
+		require.NotErrorIs(t, a.ReplaceServer("d", "a", false), ErrUnknownServer)
+	})
+
+	t.Run("quorum-risk", func(t *testing.T) {
+		var err error = &InvariantViolation{Change: MembershipChangeRemoval, ServerID: "a"}
+		require.ErrorIs(t, fmt.Errorf("wrapped: %w", err), ErrQuorumRisk)
+	})
+
+	t.Run("not-leader", func(t *testing.T) {
+		require.ErrorIs(t, fmt.Errorf("failed demoting server %s: %w", raft.ServerID("a"), raft.ErrNotLeader), ErrNotLeader)
+	})
+}
@@ -0,0 +1,393 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/raft"
+)
+
+// ZoneEvacuation reports the progress of moving voters out of a zone.
+type ZoneEvacuation struct {
+	// Zone is the value of the configured ZoneKey being evacuated.
+	Zone string
+
+	// StartedAt is when EvacuateZone was called for the zone.
+	StartedAt time.Time
+
+	// Voters are the servers within the zone which still have voting rights.
+	Voters []raft.ServerID
+
+	// HasLeader is whether the current leader is within the zone.
+	HasLeader bool
+
+	// Complete is whether no voters remain within the zone.
+	Complete bool
+}
+
+// zoneEvacuation is the internal record of an in progress evacuation.
+type zoneEvacuation struct {
+	startedAt time.Time
+
+	// targetVoters is the number of voters the cluster had when the
+	// evacuation started. Replacement voters will be promoted outside of
+	// the zone until this many exist.
+	targetVoters int
+}
+
+// evacuationTracker tracks all zones currently being evacuated.
+type evacuationTracker struct {
+	lock  sync.Mutex
+	zones map[string]*zoneEvacuation
+}
+
+// start begins evacuating the zone and returns whether it was not already
+// being evacuated.
+func (t *evacuationTracker) start(zone string, now time.Time, voters int) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.zones == nil {
+		t.zones = make(map[string]*zoneEvacuation)
+	}
+
+	if _, ok := t.zones[zone]; ok {
+		return false
+	}
+	t.zones[zone] = &zoneEvacuation{startedAt: now, targetVoters: voters}
+	return true
+}
+
+// stop ends the evacuation of the zone and returns whether it was evacuating.
+func (t *evacuationTracker) stop(zone string) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	_, ok := t.zones[zone]
+	delete(t.zones, zone)
+	return ok
+}
+
+// active returns the zones being evacuated along with the number of voters the
+// cluster should have outside of them. Evacuations without a known number of
+// voters use the provided one.
+func (t *evacuationTracker) active(voters int) (map[string]time.Time, int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	zones := make(map[string]time.Time, len(t.zones))
+	target := 0
+	for zone, evac := range t.zones {
+		evacTarget := evac.targetVoters
+		if evacTarget == 0 {
+			evacTarget = voters
+		}
+		if evacTarget > target {
+			target = evacTarget
+		}
+		zones[zone] = evac.startedAt
+	}
+	return zones, target
+}
+
+// persisted returns the evacuations in progress ordered by zone.
+func (t *evacuationTracker) persisted() []PersistedEvacuation {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	var evacuations []PersistedEvacuation
+	for zone, evac := range t.zones {
+		evacuations = append(evacuations, PersistedEvacuation{
+			Zone:         zone,
+			StartedAt:    evac.startedAt,
+			TargetVoters: evac.targetVoters,
+		})
+	}
+
+	sort.Slice(evacuations, func(i, j int) bool {
+		return evacuations[i].Zone < evacuations[j].Zone
+	})
+	return evacuations
+}
+
+// load replaces the evacuations in progress with the persisted ones.
+func (t *evacuationTracker) load(evacuations []PersistedEvacuation) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.zones = make(map[string]*zoneEvacuation, len(evacuations))
+	for _, evac := range evacuations {
+		t.zones[evac.Zone] = &zoneEvacuation{startedAt: evac.StartedAt, targetVoters: evac.TargetVoters}
+	}
+}
+
+// inProgress returns whether any zones are being evacuated.
+func (t *evacuationTracker) inProgress() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return len(t.zones) > 0
+}
+
+// EvacuateZone will start moving all voters out of the given zone. During each
+// reconciliation round healthy non-voters outside of the zone are promoted to
+// replace the zone's voters, then the voters within the zone are demoted and
+// finally leadership is transferred out of the zone. Servers within the zone
+// will not be promoted until RestoreZone is called. Progress can be monitored
+// with Evacuations.
+//
+// The evacuation is persisted when the delegate implements OperationPersister
+// so that the next leader continues it. Without one evacuating the leader's
+// zone is refused as the evacuation would be abandoned once leadership moves
+// out of the zone.
+func (a *Autopilot) EvacuateZone(zone string) error {
+	if !a.FeatureEnabled(FeatureZoneEvacuation) {
+		return fmt.Errorf("the %s feature is disabled", FeatureZoneEvacuation)
+	}
+
+	if zone == "" {
+		return fmt.Errorf("a zone to evacuate is required")
+	}
+
+	conf := a.delegate.AutopilotConfig()
+	if conf == nil || conf.ZoneKey == "" {
+		return fmt.Errorf("zone evacuation requires the ZoneKey to be configured")
+	}
+
+	state := a.GetState()
+	if state == nil || state.Leader == "" {
+		return fmt.Errorf("cannot evacuate a zone: %w", ErrNoState)
+	}
+
+	if _, ok := a.operationPersister(); !ok {
+		if leader, ok := state.Servers[state.Leader]; ok && leader.Server.Meta[conf.ZoneKey] == zone {
+			return fmt.Errorf("refusing to evacuate zone %q containing the leader as the next leader would not continue the evacuation without an OperationPersister", zone)
+		}
+	}
+
+	a.updateOperations(state, func() bool {
+		return a.evacuations.start(zone, a.now(), len(state.Voters))
+	})
+	a.logger.Info("evacuating zone", "zone", zone)
+	return nil
+}
+
+// RestoreZone stops evacuating the given zone, allowing its servers to be
+// promoted again. Voters which were moved out of the zone are not moved back
+// but the promoter is free to promote the zone's servers once more.
+func (a *Autopilot) RestoreZone(zone string) error {
+	stopped := false
+	a.updateOperations(a.GetState(), func() bool {
+		stopped = a.evacuations.stop(zone)
+		return stopped
+	})
+	if !stopped {
+		return fmt.Errorf("zone %q is not being evacuated", zone)
+	}
+
+	a.logger.Info("restored zone", "zone", zone)
+	return nil
+}
+
+// Evacuations returns the progress of all zones currently being evacuated
+// ordered by zone.
+func (a *Autopilot) Evacuations() []ZoneEvacuation {
+	state := a.GetState()
+	voters := 0
+	if state != nil {
+		voters = len(state.Voters)
+	}
+
+	zones, _ := a.evacuations.active(voters)
+	if len(zones) == 0 {
+		return nil
+	}
+
+	var zoneKey string
+	if conf := a.delegate.AutopilotConfig(); conf != nil {
+		zoneKey = conf.ZoneKey
+	}
+
+	evacuations := make([]ZoneEvacuation, 0, len(zones))
+	for zone, startedAt := range zones {
+		evac := ZoneEvacuation{Zone: zone, StartedAt: startedAt}
+		if state != nil && zoneKey != "" {
+			for _, id := range state.Voters {
+				srv, ok := state.Servers[id]
+				if !ok || srv.Server.Meta[zoneKey] != zone {
+					continue
+				}
+
+				evac.Voters = append(evac.Voters, id)
+				if id == state.Leader {
+					evac.HasLeader = true
+				}
+			}
+		}
+		evac.Complete = len(evac.Voters) == 0
+		evacuations = append(evacuations, evac)
+	}
+
+	sort.Slice(evacuations, func(i, j int) bool {
+		return evacuations[i].Zone < evacuations[j].Zone
+	})
+	return evacuations
+}
+
+// evacuateZones modifies the promoter's changes to move voters out of any zones
+// being evacuated. Promotions of servers within those zones are dropped and
+// healthy non-voters elsewhere are promoted to replace the zone's voters. Only
+// when no replacements remain to be promoted are the zone's voters demoted and
+// only while a majority of the remaining voters are healthy voters outside of
+// the zones and MinQuorum is respected. Finally leadership is moved out of the
+// zones.
+func (a *Autopilot) evacuateZones(conf *Config, state *State, changes RaftChanges) RaftChanges {
+	if !a.FeatureEnabled(FeatureZoneEvacuation) {
+		return changes
+	}
+
+	zones, targetVoters := a.evacuations.active(len(state.Voters))
+	if len(zones) == 0 {
+		return changes
+	}
+
+	if conf.ZoneKey == "" {
+		a.roundLogger().Warn("unable to evacuate zones without a configured ZoneKey")
+		return changes
+	}
+
+	inZone := func(srv *ServerState) bool {
+		_, ok := zones[srv.Server.Meta[conf.ZoneKey]]
+		return ok
+	}
+
+	result := RaftChanges{
+		Demotions: append([]raft.ServerID(nil), changes.Demotions...),
+		Leader:    changes.Leader,
+	}
+
+	for _, id := range changes.LeaderCandidates {
+		if srv, ok := state.Servers[id]; ok && inZone(srv) {
+			continue
+		}
+		result.LeaderCandidates = append(result.LeaderCandidates, id)
+	}
+
+	promoting := make(map[raft.ServerID]struct{})
+	outsideVoters := 0
+	for _, id := range changes.Promotions {
+		srv, ok := state.Servers[id]
+		if ok && inZone(srv) {
+			a.roundLogger().Debug("Not promoting server within an evacuating zone", "id", id)
+			continue
+		}
+
+		result.Promotions = append(result.Promotions, id)
+		promoting[id] = struct{}{}
+		if ok && !srv.HasVotingRights() {
+			outsideVoters++
+		}
+	}
+
+	var zoneVoters []raft.ServerID
+	healthyOutside := 0
+	for _, id := range state.Voters {
+		srv, ok := state.Servers[id]
+		if !ok {
+			continue
+		}
+
+		if inZone(srv) {
+			zoneVoters = append(zoneVoters, id)
+			continue
+		}
+
+		outsideVoters++
+		if srv.Health.Healthy {
+			healthyOutside++
+		}
+	}
+
+	// promote replacements for the zone's voters
+	now := a.now()
+	replacements := 0
+	if len(zoneVoters) > 0 {
+		var candidates []raft.ServerID
+		for id, srv := range state.Servers {
+			if _, ok := promoting[id]; ok {
+				continue
+			}
+
+			if inZone(srv) || !a.promotionEligible(conf, state, srv, now) {
+				continue
+			}
+
+			candidates = append(candidates, id)
+		}
+		sortByValue(candidates, state)
+
+		for _, id := range candidates {
+			if outsideVoters+replacements >= targetVoters {
+				break
+			}
+			result.Promotions = append(result.Promotions, id)
+			replacements++
+		}
+	}
+
+	// demote the zone's voters once all replacements have been promoted
+	if replacements == 0 {
+		demoting := make(map[raft.ServerID]struct{})
+		for _, id := range result.Demotions {
+			demoting[id] = struct{}{}
+		}
+
+		voters := len(state.Voters)
+		for _, id := range zoneVoters {
+			if _, ok := demoting[id]; ok || id == state.Leader || state.Servers[id].Server.Protected {
+				continue
+			}
+
+			remaining := voters - 1
+			if remaining < int(conf.MinQuorum) || healthyOutside < remaining/2+1 {
+				a.roundLogger().Debug("Not demoting server within an evacuating zone as it would leave too few healthy voters", "id", id)
+				break
+			}
+
+			result.Demotions = append(result.Demotions, id)
+			voters--
+		}
+	}
+
+	// move leadership out of the zones
+	if leader, ok := state.Servers[result.Leader]; ok && inZone(leader) {
+		result.Leader = ""
+	}
+
+	if leader, ok := state.Servers[state.Leader]; ok && inZone(leader) {
+		var fallback raft.ServerID
+		for _, id := range state.Voters {
+			srv := state.Servers[id]
+			if srv != nil && srv.Health.Healthy && !inZone(srv) {
+				fallback = id
+				break
+			}
+		}
+
+		switch {
+		case fallback == "":
+			a.roundLogger().Warn("unable to move leadership out of an evacuating zone as there are no healthy voters elsewhere")
+		case result.Leader == "" && len(result.LeaderCandidates) == 0:
+			result.Leader = fallback
+		default:
+			// try the promoter's candidates before falling back
+			result.LeaderCandidates = append(result.LeaderCandidates, fallback)
+		}
+	}
+
+	return result
+}
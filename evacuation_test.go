@@ -0,0 +1,183 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+// operationPersistingDelegate stores the operations given to
+// PersistOperations.
+type operationPersistingDelegate struct {
+	*MockApplicationIntegration
+	operations PersistedOperations
+}
+
+func (d *operationPersistingDelegate) PersistOperations(ops PersistedOperations) {
+	d.operations = ops
+}
+
+func (d *operationPersistingDelegate) LoadOperations() PersistedOperations {
+	return d.operations
+}
+
+// evacuationZones are the zones of the zone evacuation tests' servers.
+var evacuationZones = map[raft.ServerID]string{
+	"a1": "a",
+	"a2": "a",
+	"b1": "b",
+	"b2": "b",
+	"c1": "c",
+}
+
+// evacuationTestState is a testState of the servers within evacuationZones.
+func evacuationTestState(leader raft.ServerID, voters ...raft.ServerID) *State {
+	return withMeta(testState([]raft.ServerID{"a1", "a2", "b1", "b2", "c1"}, leader, voters...), "zone", evacuationZones)
+}
+
+func TestEvacuateZones(t *testing.T) {
+	conf := &Config{ZoneKey: "zone", ServerStabilizationTime: 10 * time.Second}
+
+	a := &Autopilot{
+		logger:   hclog.NewNullLogger(),
+		promoter: DefaultPromoter(),
+		features: map[Feature]bool{FeatureZoneEvacuation: true},
+	}
+
+	// nothing is modified without any evacuations
+	state := evacuationTestState("a1", "a1", "b1", "c1")
+	changes := RaftChanges{Promotions: []raft.ServerID{"a2", "b2"}}
+	require.Equal(t, changes, a.evacuateZones(conf, state, changes))
+
+	a.evacuations.start("a", time.Now(), 3)
+
+	// a replacement is promoted outside the zone, promotions within the zone
+	// are dropped and leadership is moved out of the zone
+	changes = a.evacuateZones(conf, state, RaftChanges{Promotions: []raft.ServerID{"a2"}})
+	require.Equal(t, RaftChanges{Promotions: []raft.ServerID{"b2"}, Leader: "b1"}, changes)
+
+	// with the replacement promoted the leader still needs to move
+	state = evacuationTestState("a1", "a1", "b1", "b2", "c1")
+	changes = a.evacuateZones(conf, state, RaftChanges{})
+	require.Equal(t, RaftChanges{Leader: "b1"}, changes)
+
+	// once leadership has moved the remaining zone voter is demoted
+	state = evacuationTestState("b1", "a1", "b1", "b2", "c1")
+	changes = a.evacuateZones(conf, state, RaftChanges{})
+	require.Equal(t, RaftChanges{Demotions: []raft.ServerID{"a1"}}, changes)
+
+	// demotions are withheld when too few healthy voters would remain
+	state = evacuationTestState("b1", "a1", "b1", "c1")
+	state.Servers["c1"].Health.Healthy = false
+	state.Servers["b2"].Health.Healthy = false
+	changes = a.evacuateZones(conf, state, RaftChanges{})
+	require.Equal(t, RaftChanges{}, changes)
+
+	// after restoring the zone the promoter's changes are used as is
+	a.evacuations.stop("a")
+	changes = RaftChanges{Promotions: []raft.ServerID{"a2"}}
+	require.Equal(t, changes, a.evacuateZones(conf, state, changes))
+}
+
+func TestEvacuateZonesCandidates(t *testing.T) {
+	conf := &Config{ZoneKey: "zone"}
+
+	a := &Autopilot{
+		logger:   hclog.NewNullLogger(),
+		promoter: DefaultPromoter(),
+		features: map[Feature]bool{FeatureZoneEvacuation: true},
+	}
+	a.evacuations.start("a", time.Now(), 3)
+
+	// replacements are held to the same eligibility as any other promotion
+	state := withMeta(testState([]raft.ServerID{"a1", "b1", "b2", "c1", "c2"}, "b1", "a1", "b1", "c1"), "zone", map[raft.ServerID]string{
+		"a1": "a",
+		"b1": "b",
+		"b2": "b",
+		"c1": "c",
+		"c2": "c",
+	})
+	state.Servers["b2"].Health.TermAhead = true
+	state.Servers["c2"].Server.NodeType = "read-replica"
+	require.Empty(t, a.evacuateZones(conf, state, RaftChanges{}).Promotions)
+
+	state.Servers["b2"].Health.TermAhead = false
+	require.Equal(t, []raft.ServerID{"b2"}, a.evacuateZones(conf, state, RaftChanges{}).Promotions)
+}
+
+func TestEvacuateZoneAPI(t *testing.T) {
+	mdel := NewMockApplicationIntegration(t)
+	a := &Autopilot{
+		logger:   hclog.NewNullLogger(),
+		delegate: mdel,
+		state:    evacuationTestState("a1", "a1", "b1", "c1"),
+		features: map[Feature]bool{FeatureZoneEvacuation: true},
+	}
+
+	require.Error(t, a.EvacuateZone(""))
+
+	mdel.On("AutopilotConfig").Return(&Config{}).Once()
+	require.Error(t, a.EvacuateZone("a"))
+
+	mdel.On("AutopilotConfig").Return(&Config{ZoneKey: "zone"})
+
+	// the leader's zone is not evacuated unless the evacuation is persisted
+	require.Error(t, a.EvacuateZone("a"))
+	require.Empty(t, a.Evacuations())
+
+	delegate := &operationPersistingDelegate{MockApplicationIntegration: mdel}
+	a.delegate = delegate
+	require.NoError(t, a.EvacuateZone("a"))
+	require.Len(t, delegate.operations.Evacuations, 1)
+	require.Equal(t, "a", delegate.operations.Evacuations[0].Zone)
+	require.Equal(t, 3, delegate.operations.Evacuations[0].TargetVoters)
+
+	evacuations := a.Evacuations()
+	require.Len(t, evacuations, 1)
+	require.Equal(t, "a", evacuations[0].Zone)
+	require.Equal(t, []raft.ServerID{"a1"}, evacuations[0].Voters)
+	require.True(t, evacuations[0].HasLeader)
+	require.False(t, evacuations[0].Complete)
+
+	a.state = evacuationTestState("b1", "b1", "b2", "c1")
+	evacuations = a.Evacuations()
+	require.Len(t, evacuations, 1)
+	require.Empty(t, evacuations[0].Voters)
+	require.True(t, evacuations[0].Complete)
+
+	require.NoError(t, a.RestoreZone("a"))
+	require.Error(t, a.RestoreZone("a"))
+	require.Empty(t, a.Evacuations())
+	require.Empty(t, delegate.operations.Evacuations)
+}
+
+func TestLoadOperations(t *testing.T) {
+	delegate := &operationPersistingDelegate{
+		MockApplicationIntegration: NewMockApplicationIntegration(t),
+		operations: PersistedOperations{
+			Evacuations: []PersistedEvacuation{{Zone: "a", StartedAt: time.Now(), TargetVoters: 3}},
+		},
+	}
+	a := &Autopilot{logger: hclog.NewNullLogger(), delegate: delegate}
+
+	// the persisted operations are loaded for a new leader
+	a.loadOperations(evacuationTestState("a1", "a1", "b1", "c1"))
+	zones, target := a.evacuations.active(3)
+	require.Contains(t, zones, "a")
+	require.Equal(t, 3, target)
+
+	// but not again while the leader is unchanged
+	delegate.operations = PersistedOperations{}
+	a.loadOperations(evacuationTestState("a1", "a1", "b1", "c1"))
+	require.True(t, a.evacuations.inProgress())
+
+	// another leader may have finished the evacuation meanwhile
+	a.loadOperations(evacuationTestState("b1", "a1", "b1", "c1"))
+	require.False(t, a.evacuations.inProgress())
+}
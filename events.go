@@ -0,0 +1,244 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/raft"
+)
+
+// EventType identifies the kind of occurrence an Event describes.
+type EventType string
+
+const (
+	// EventPromoterFailed is emitted when the promoter panics or does not
+	// finish calculating promotions and demotions within the allowed time.
+	EventPromoterFailed EventType = "promoter-failed"
+
+	// EventTermsDiverged is emitted when many servers start reporting a last
+	// log term other than the leader's. Autopilot will suppress demotions
+	// and double the server stabilization time until the terms converge.
+	EventTermsDiverged EventType = "terms-diverged"
+
+	// EventServerTermAhead is emitted when a server starts reporting a last
+	// log term greater than the leader's, indicating that an election is in
+	// progress or that the server is partitioned with a runaway term.
+	EventServerTermAhead EventType = "server-term-ahead"
+
+	// EventTermsConverged is emitted when the servers' terms have converged
+	// after previously diverging and normal operation resumes.
+	EventTermsConverged EventType = "terms-converged"
+
+	// EventClockJump is emitted when the wall clock is detected to have
+	// jumped, for example due to an NTP correction.
+	EventClockJump EventType = "clock-jump"
+
+	// EventFailureToleranceExhausted is emitted when the cluster can no longer
+	// tolerate the failure of any voter. The message includes the promotions
+	// and demotions which would restore the failure tolerance.
+	EventFailureToleranceExhausted EventType = "failure-tolerance-exhausted"
+
+	// EventFailureToleranceRestored is emitted once the failure tolerance has
+	// remained above zero for a while after being exhausted.
+	EventFailureToleranceRestored EventType = "failure-tolerance-restored"
+
+	// EventKnownServersRegressed is emitted when the delegate suddenly reports
+	// far fewer known servers than before. Demotions and removals are held
+	// for the known servers settle time.
+	EventKnownServersRegressed EventType = "known-servers-regressed"
+
+	// EventLeadershipTransfer is emitted when autopilot transfers leadership.
+	// The message describes which of the promoter's nominated candidates was
+	// chosen and why any preferred candidates were skipped.
+	EventLeadershipTransfer EventType = "leadership-transfer"
+
+	// EventServerPromoted is emitted when a server is promoted to a voter.
+	EventServerPromoted EventType = "server-promoted"
+
+	// EventServerDemoted is emitted when a voter is demoted to a non-voter.
+	EventServerDemoted EventType = "server-demoted"
+
+	// EventServerRemoved is emitted when a server is removed from the Raft
+	// configuration or the delegate is asked to remove a failed server.
+	EventServerRemoved EventType = "server-removed"
+
+	// EventServerHealthChanged is emitted when a server becomes healthy or
+	// unhealthy. The message includes the reasons a server is unhealthy.
+	EventServerHealthChanged EventType = "server-health-changed"
+
+	// EventPlannedChanges is emitted in dry run mode with the changes
+	// autopilot would have made.
+	EventPlannedChanges EventType = "planned-changes"
+
+	// EventStatsOutage is emitted when stats cannot be fetched for any
+	// server. When a StatsOutageGracePeriod is configured autopilot enters
+	// a degraded mode retaining the servers' previous health.
+	EventStatsOutage EventType = "stats-outage"
+
+	// EventStatsRestored is emitted when stats are available again after an
+	// outage.
+	EventStatsRestored EventType = "stats-restored"
+
+	// EventReplacementProgress is emitted when a server replacement started
+	// with ReplaceServer moves on to its next step.
+	EventReplacementProgress EventType = "replacement-progress"
+
+	// EventReplacementComplete is emitted when a server replacement has
+	// finished.
+	EventReplacementComplete EventType = "replacement-complete"
+
+	// EventRaftOperationStuck is emitted when a Raft membership change does
+	// not resolve within the watchdog duration. No further membership changes
+	// will be made until it resolves or the leader changes.
+	EventRaftOperationStuck EventType = "raft-operation-stuck"
+
+	// EventRaftOperationResolved is emitted when a previously stuck Raft
+	// membership change finally resolves.
+	EventRaftOperationResolved EventType = "raft-operation-resolved"
+
+	// EventInvariantViolation is emitted when invariant checks are enabled
+	// and autopilot refuses to make membership changes which would violate
+	// them.
+	EventInvariantViolation EventType = "invariant-violation"
+
+	// EventServerFlapping is emitted when a server's health begins flapping.
+	EventServerFlapping EventType = "server-flapping"
+
+	// EventLeadershipTransferFailed is emitted when leadership transfer
+	// retries are enabled and the transfers to a server have failed too many
+	// times. No further transfers to the server are attempted until another
+	// server is nominated.
+	EventLeadershipTransferFailed EventType = "leadership-transfer-failed"
+)
+
+// Event describes something notable that autopilot did or observed which
+// applications may want to alert on or record.
+type Event struct {
+	// Type is the kind of event.
+	Type EventType
+
+	// Time is when the event occurred according to autopilot's TimeProvider.
+	Time time.Time
+
+	// ServerID is the server the event pertains to. It will be empty for
+	// events which are not about a specific server.
+	ServerID raft.ServerID
+
+	// Message is a human readable description of the event.
+	Message string
+
+	// Round is the ID of the reconcile or prune round during which the event
+	// was emitted. It will be empty for events emitted outside of a round.
+	Round string
+}
+
+// EventHandler is a function that will be called for every event autopilot
+// emits. Handlers are called synchronously from autopilot's go routines and
+// therefore should not block.
+type EventHandler func(Event)
+
+// subscriptions holds the channels of all event subscribers.
+type subscriptions struct {
+	lock     sync.Mutex
+	channels map[chan Event]struct{}
+}
+
+// Subscribe returns a channel which will receive every event autopilot emits
+// along with a function to cancel the subscription. The channel will buffer up
+// to the given number of events. Events are dropped rather than blocking
+// autopilot when the buffer is full so subscribers should read promptly.
+// Cancelling the subscription closes the channel.
+func (a *Autopilot) Subscribe(buffer int) (<-chan Event, func()) {
+	ch := make(chan Event, buffer)
+
+	a.subscriptions.lock.Lock()
+	defer a.subscriptions.lock.Unlock()
+
+	if a.subscriptions.channels == nil {
+		a.subscriptions.channels = make(map[chan Event]struct{})
+	}
+	a.subscriptions.channels[ch] = struct{}{}
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			a.subscriptions.lock.Lock()
+			defer a.subscriptions.lock.Unlock()
+			delete(a.subscriptions.channels, ch)
+			close(ch)
+		})
+	}
+	return ch, cancel
+}
+
+// hasSubscribers returns whether there are any event subscribers.
+func (a *Autopilot) hasSubscribers() bool {
+	a.subscriptions.lock.Lock()
+	defer a.subscriptions.lock.Unlock()
+	return len(a.subscriptions.channels) > 0
+}
+
+// publish sends the event to all subscribers without blocking.
+func (a *Autopilot) publish(event Event) {
+	a.subscriptions.lock.Lock()
+	defer a.subscriptions.lock.Unlock()
+
+	for ch := range a.subscriptions.channels {
+		select {
+		case ch <- event:
+		default:
+			a.logger.Warn("dropping event for a subscriber which is not keeping up", "type", event.Type)
+		}
+	}
+}
+
+// emitEvent creates a new event and passes it to all the registered handlers
+// and subscribers.
+func (a *Autopilot) emitEvent(typ EventType, id raft.ServerID, message string) {
+	if len(a.eventHandlers) == 0 && !a.hasSubscribers() {
+		return
+	}
+
+	event := Event{
+		Type:     typ,
+		Time:     a.now(),
+		ServerID: id,
+		Message:  message,
+		Round:    a.currentRound(),
+	}
+
+	for _, handler := range a.eventHandlers {
+		handler(event)
+	}
+	a.publish(event)
+}
+
+// emitHealthChanges emits an event for every server whose health differs
+// between the two states.
+func (a *Autopilot) emitHealthChanges(prev, next *State) {
+	if prev == nil {
+		return
+	}
+
+	for id, srv := range next.Servers {
+		old, ok := prev.Servers[id]
+		if !ok || old.Health.Healthy == srv.Health.Healthy {
+			continue
+		}
+
+		message := "server is now healthy"
+		if !srv.Health.Healthy {
+			message = fmt.Sprintf("server is now unhealthy: %s", strings.Join(srv.Health.Reasons, ", "))
+		}
+		a.emitEvent(EventServerHealthChanged, id, message)
+	}
+
+	for _, id := range flappingServers(prev, next) {
+		a.emitEvent(EventServerFlapping, id, "server health is flapping, it will not be promoted until its health settles")
+	}
+}
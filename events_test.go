@@ -0,0 +1,74 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscribe(t *testing.T) {
+	a := &Autopilot{logger: hclog.NewNullLogger()}
+
+	events, cancel := a.Subscribe(2)
+	a.emitEvent(EventServerPromoted, "a", "promoted to a voter")
+	a.emitEvent(EventServerDemoted, "b", "demoted to a non-voter")
+	// the buffer is full so this event is dropped
+	a.emitEvent(EventServerRemoved, "c", "removed")
+
+	event := <-events
+	require.Equal(t, EventServerPromoted, event.Type)
+	require.Equal(t, raft.ServerID("a"), event.ServerID)
+	require.False(t, event.Time.IsZero())
+	event = <-events
+	require.Equal(t, EventServerDemoted, event.Type)
+
+	cancel()
+	cancel()
+	_, ok := <-events
+	require.False(t, ok)
+
+	// nothing is sent to cancelled subscriptions
+	a.emitEvent(EventServerRemoved, "c", "removed")
+}
+
+func TestEmitHealthChanges(t *testing.T) {
+	a := &Autopilot{logger: hclog.NewNullLogger()}
+	events, cancel := a.Subscribe(10)
+	defer cancel()
+
+	prev := &State{
+		Servers: map[raft.ServerID]*ServerState{
+			"a": {Health: ServerHealth{Healthy: true}},
+			"b": {Health: ServerHealth{Healthy: false}},
+			"c": {Health: ServerHealth{Healthy: true}},
+		},
+	}
+	next := &State{
+		Servers: map[raft.ServerID]*ServerState{
+			"a": {Health: ServerHealth{Healthy: false, Reasons: []string{"last contact is unknown"}}},
+			"b": {Health: ServerHealth{Healthy: true}},
+			"c": {Health: ServerHealth{Healthy: true}},
+			"d": {Health: ServerHealth{Healthy: false}},
+		},
+	}
+
+	a.emitHealthChanges(nil, next)
+	a.emitHealthChanges(prev, next)
+	require.Len(t, events, 2)
+
+	messages := make(map[raft.ServerID]string)
+	for i := 0; i < 2; i++ {
+		event := <-events
+		require.Equal(t, EventServerHealthChanged, event.Type)
+		messages[event.ServerID] = event.Message
+	}
+	require.Equal(t, map[raft.ServerID]string{
+		"a": "server is now unhealthy: last contact is unknown",
+		"b": "server is now healthy",
+	}, messages)
+}
@@ -0,0 +1,256 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/raft"
+)
+
+// DefaultChangeQueueSize is how many Raft membership changes may wait to be
+// issued by the change executor before further changes are refused.
+const DefaultChangeQueueSize = 16
+
+var (
+	// changeQueueDepthKey is the metric key for the number of Raft membership
+	// changes waiting to be issued.
+	changeQueueDepthKey = []string{"autopilot", "change_queue", "depth"}
+
+	// changeLatencyKey is the metric key for the time taken by a Raft
+	// membership change to resolve once issued.
+	changeLatencyKey = []string{"autopilot", "change", "latency"}
+
+	// changeQueueTimeKey is the metric key for the time a Raft membership
+	// change waited in the queue before being issued.
+	changeQueueTimeKey = []string{"autopilot", "change", "queue_time"}
+)
+
+// QueuedChange is a Raft membership change handled by the change executor.
+type QueuedChange struct {
+	// Operation is the Raft operation, such as "AddVoter".
+	Operation string
+
+	// ServerID is the server the operation is for.
+	ServerID raft.ServerID
+
+	// QueuedAt is when the change was queued.
+	QueuedAt time.Time
+
+	// StartedAt is when the change was issued to Raft. It is the zero time
+	// while the change is queued.
+	StartedAt time.Time
+}
+
+// ChangeQueue describes the Raft membership changes being made by autopilot.
+type ChangeQueue struct {
+	// Running is the change which has been issued to Raft and not yet
+	// resolved, if any.
+	Running *QueuedChange
+
+	// Queued are the changes waiting to be issued in the order they will be.
+	Queued []QueuedChange
+}
+
+// changeJob is a Raft membership change waiting to be issued by the change
+// executor.
+type changeJob struct {
+	change QueuedChange
+	issue  func() raft.Future
+	sink   metrics.MetricSink
+	now    func() time.Time
+
+	// ctx is the requester's context. The change is dropped rather than
+	// issued if it is done by the time the change reaches the front of the
+	// queue.
+	ctx context.Context
+
+	// result receives the outcome of the change. It is buffered so that the
+	// executor never blocks on a requester which has stopped waiting.
+	result chan error
+
+	// abandoned is closed when the executor should stop waiting for the
+	// change to resolve as it never will.
+	abandoned chan struct{}
+}
+
+// changeExecutor issues all of autopilot's Raft membership changes one at a
+// time, each only once the previous one has resolved, regardless of which
+// round or API call requested them. Its go routine runs whenever changes are
+// queued and exits once the queue is empty.
+type changeExecutor struct {
+	lock sync.Mutex
+
+	// size is the maximum number of queued changes, DefaultChangeQueueSize
+	// when zero.
+	size int
+
+	queue   []*changeJob
+	running *changeJob
+	active  bool
+}
+
+// submit queues the change and returns the channel its outcome will be sent
+// on. An error wrapping ErrChangeQueueFull is returned when the queue is full.
+// The change is not issued if the context is done before its turn comes, its
+// outcome being the context's error instead.
+func (e *changeExecutor) submit(ctx context.Context, sink metrics.MetricSink, now func() time.Time, op string, id raft.ServerID, issue func() raft.Future) (<-chan error, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	size := e.size
+	if size <= 0 {
+		size = DefaultChangeQueueSize
+	}
+	if len(e.queue) >= size {
+		return nil, fmt.Errorf("not queueing %s for server %s: %w", op, id, ErrChangeQueueFull)
+	}
+
+	job := &changeJob{
+		change: QueuedChange{
+			Operation: op,
+			ServerID:  id,
+			QueuedAt:  now(),
+		},
+		issue:     issue,
+		sink:      sink,
+		now:       now,
+		ctx:       ctx,
+		result:    make(chan error, 1),
+		abandoned: make(chan struct{}),
+	}
+	e.queue = append(e.queue, job)
+	sink.SetGauge(changeQueueDepthKey, float32(len(e.queue)))
+
+	if !e.active {
+		e.active = true
+		go e.run()
+	}
+	return job.result, nil
+}
+
+// run issues the queued changes in order until the queue is empty.
+func (e *changeExecutor) run() {
+	var job *changeJob
+	defer func() {
+		if job == nil {
+			return
+		}
+
+		// issuing the change panicked or exited the go routine without
+		// resolving it so its requester is told and another go routine takes
+		// over the queue
+		err := fmt.Errorf("%s for server %s did not resolve", job.change.Operation, job.change.ServerID)
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%s for server %s panicked: %v", job.change.Operation, job.change.ServerID, r)
+		}
+		job.result <- err
+
+		e.lock.Lock()
+		defer e.lock.Unlock()
+		e.running = nil
+		e.active = len(e.queue) > 0
+		if e.active {
+			go e.run()
+		}
+	}()
+
+	for {
+		e.lock.Lock()
+		if len(e.queue) == 0 {
+			e.running = nil
+			e.active = false
+			e.lock.Unlock()
+			return
+		}
+
+		job = e.queue[0]
+		e.queue[0] = nil
+		e.queue = e.queue[1:]
+		job.sink.SetGauge(changeQueueDepthKey, float32(len(e.queue)))
+
+		// the requester has given up on the change while it was queued
+		if err := job.ctx.Err(); err != nil {
+			e.lock.Unlock()
+			job.result <- fmt.Errorf("not issuing %s for server %s: %w", job.change.Operation, job.change.ServerID, err)
+			job = nil
+			continue
+		}
+
+		job.change.StartedAt = job.now()
+		e.running = job
+		abandoned := job.abandoned
+		e.lock.Unlock()
+
+		labels := []metrics.Label{{Name: "operation", Value: job.change.Operation}}
+		job.sink.AddSampleWithLabels(changeQueueTimeKey, durationMillis(job.change.StartedAt.Sub(job.change.QueuedAt)), labels)
+
+		future := job.issue()
+		resolved := make(chan error, 1)
+		go func() {
+			resolved <- resolveFuture(future)
+		}()
+
+		var err error
+		select {
+		case err = <-resolved:
+		case <-abandoned:
+			err = fmt.Errorf("abandoned waiting for %s for server %s to resolve", job.change.Operation, job.change.ServerID)
+		}
+		job.sink.AddSampleWithLabels(changeLatencyKey, durationMillis(job.now().Sub(job.change.StartedAt)), labels)
+		job.result <- err
+		job = nil
+	}
+}
+
+// resolveFuture waits for the future to resolve and returns its error. A
+// panic while waiting is returned as the error rather than crashing the
+// application.
+func resolveFuture(future raft.Future) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("raft future panicked: %v", r)
+		}
+	}()
+	return future.Error()
+}
+
+// abandon stops waiting for the running change, which will never resolve,
+// so that the queued changes may be made.
+func (e *changeExecutor) abandon() {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if e.running != nil && e.running.abandoned != nil {
+		close(e.running.abandoned)
+		e.running.abandoned = nil
+	}
+}
+
+// status returns the running and queued changes.
+func (e *changeExecutor) status() ChangeQueue {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	var status ChangeQueue
+	if e.running != nil {
+		running := e.running.change
+		status.Running = &running
+	}
+	for _, job := range e.queue {
+		status.Queued = append(status.Queued, job.change)
+	}
+	return status
+}
+
+// ChangeQueue returns the Raft membership change autopilot is waiting on and
+// those queued behind it. A change which stays running for a long time is
+// likely stuck.
+func (a *Autopilot) ChangeQueue() ChangeQueue {
+	return a.changes.status()
+}
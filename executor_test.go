@@ -0,0 +1,139 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangeExecutor(t *testing.T) {
+	mraft := NewMockRaft(t)
+	a := &Autopilot{
+		logger:  hclog.NewNullLogger(),
+		raft:    mraft,
+		changes: changeExecutor{size: 1},
+	}
+
+	future := &blockingFuture{release: make(chan struct{})}
+	mraft.On("DemoteVoter", raft.ServerID("b"), uint64(0), time.Duration(0)).Return(future).Once()
+	mraft.On("AddVoter", raft.ServerID("c"), raft.ServerAddress("198.18.0.3:8300"), uint64(0), time.Duration(0)).Return(&raftIndexFuture{}).Once()
+
+	demoted := make(chan error, 1)
+	go func() {
+		demoted <- a.demoteVoter(context.Background(), "b")
+	}()
+	require.Eventually(t, func() bool {
+		return a.ChangeQueue().Running != nil
+	}, time.Second, time.Millisecond)
+
+	added := make(chan error, 1)
+	go func() {
+		added <- a.addVoter(context.Background(), "c", "198.18.0.3:8300")
+	}()
+	require.Eventually(t, func() bool {
+		return len(a.ChangeQueue().Queued) == 1
+	}, time.Second, time.Millisecond)
+
+	// the addition waits for the demotion to resolve
+	queue := a.ChangeQueue()
+	require.Equal(t, "DemoteVoter", queue.Running.Operation)
+	require.Equal(t, raft.ServerID("b"), queue.Running.ServerID)
+	require.False(t, queue.Running.StartedAt.IsZero())
+	require.Equal(t, "AddVoter", queue.Queued[0].Operation)
+	require.True(t, queue.Queued[0].StartedAt.IsZero())
+
+	// no further changes may be queued
+	err := a.removeServer(context.Background(), "d")
+	require.ErrorIs(t, err, ErrChangeQueueFull)
+
+	close(future.release)
+	require.NoError(t, <-demoted)
+	require.NoError(t, <-added)
+
+	require.Eventually(t, func() bool {
+		queue := a.ChangeQueue()
+		return queue.Running == nil && len(queue.Queued) == 0
+	}, time.Second, time.Millisecond)
+}
+
+func TestChangeExecutor_Panic(t *testing.T) {
+	mraft := NewMockRaft(t)
+	a := &Autopilot{
+		logger: hclog.NewNullLogger(),
+		raft:   mraft,
+	}
+
+	mraft.On("DemoteVoter", raft.ServerID("b"), uint64(0), time.Duration(0)).Run(func(mock.Arguments) {
+		panic("boom")
+	}).Once()
+	mraft.On("AddVoter", raft.ServerID("c"), raft.ServerAddress("198.18.0.3:8300"), uint64(0), time.Duration(0)).Return(&raftIndexFuture{}).Once()
+
+	// the panic is reported as the change's error
+	err := a.demoteVoter(context.Background(), "b")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "panicked: boom")
+	require.Nil(t, a.StuckOperation())
+
+	// and the executor carries on making further changes
+	require.NoError(t, a.addVoter(context.Background(), "c", "198.18.0.3:8300"))
+	require.Eventually(t, func() bool {
+		queue := a.ChangeQueue()
+		return queue.Running == nil && len(queue.Queued) == 0
+	}, time.Second, time.Millisecond)
+}
+
+func TestChangeExecutor_Cancelled(t *testing.T) {
+	queuedAt := time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)
+	mtime := NewMockTimeProvider(t)
+	mtime.On("Now").Return(queuedAt)
+
+	mraft := NewMockRaft(t)
+	a := &Autopilot{
+		logger: hclog.NewNullLogger(),
+		raft:   mraft,
+		time:   mtime,
+	}
+
+	future := &blockingFuture{release: make(chan struct{})}
+	mraft.On("DemoteVoter", raft.ServerID("b"), uint64(0), time.Duration(0)).Return(future).Once()
+
+	demoted := make(chan error, 1)
+	go func() {
+		demoted <- a.demoteVoter(context.Background(), "b")
+	}()
+	require.Eventually(t, func() bool {
+		return a.ChangeQueue().Running != nil
+	}, time.Second, time.Millisecond)
+	require.Equal(t, queuedAt, a.ChangeQueue().Running.QueuedAt)
+	require.Equal(t, queuedAt, a.ChangeQueue().Running.StartedAt)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	added := make(chan error, 1)
+	go func() {
+		added <- a.addVoter(ctx, "c", "198.18.0.3:8300")
+	}()
+	require.Eventually(t, func() bool {
+		return len(a.ChangeQueue().Queued) == 1
+	}, time.Second, time.Millisecond)
+
+	// the requester stops waiting for the queued change
+	cancel()
+	require.ErrorIs(t, <-added, context.Canceled)
+
+	// and it is dropped rather than issued once the demotion resolves
+	close(future.release)
+	require.NoError(t, <-demoted)
+	require.Eventually(t, func() bool {
+		queue := a.ChangeQueue()
+		return queue.Running == nil && len(queue.Queued) == 0
+	}, time.Second, time.Millisecond)
+	mraft.AssertNotCalled(t, "AddVoter", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,131 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// stateExportVersion is the version of the format written by ExportState. It is
+// to be incremented whenever a change is made that older versions of this
+// package would not be able to import.
+const stateExportVersion = 1
+
+// StateExport is a self-contained capture of an autopilot State along with the
+// Config that was in effect. It allows a State captured from a production cluster
+// to be imported elsewhere for offline analysis.
+//
+// Note that the Ext fields of the Config, State and Servers are encoded with
+// encoding/json. Unless their types were registered with RegisterExtType they
+// will therefore not be of the same types that the promoter originally stored
+// within them when imported.
+type StateExport struct {
+	// Version is the version of the export format.
+	Version int
+
+	// ExportedAt is the time the export was created.
+	ExportedAt time.Time
+
+	// FirstStateTime is the time that the first state was generated. This is
+	// needed to accurately determine the effective server stabilization time.
+	FirstStateTime time.Time
+
+	Config *Config
+	State  *State
+}
+
+// ExportState writes the given State and Config to w in a format that can later
+// be read by ImportState.
+func ExportState(w io.Writer, state *State, conf *Config) error {
+	if state == nil {
+		return fmt.Errorf("cannot export a nil autopilot state")
+	}
+
+	// wrap any Ext values of registered types so they can be restored
+	state, conf = encodeStateExts(state, conf)
+
+	export := StateExport{
+		Version:        stateExportVersion,
+		ExportedAt:     time.Now(),
+		FirstStateTime: state.firstStateTime,
+		Config:         conf,
+		State:          state,
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "   ")
+	return enc.Encode(&export)
+}
+
+// ImportState reads a State and Config previously written by ExportState.
+func ImportState(r io.Reader) (*State, *Config, error) {
+	export, err := decodeStateExport(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return export.State, export.Config, nil
+}
+
+// decodeStateExport reads and validates a StateExport previously written by
+// ExportState.
+func decodeStateExport(r io.Reader) (*StateExport, error) {
+	var export StateExport
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return nil, fmt.Errorf("failed to decode the autopilot state export: %w", err)
+	}
+
+	if export.Version < 1 || export.Version > stateExportVersion {
+		return nil, fmt.Errorf("unsupported autopilot state export version %d", export.Version)
+	}
+
+	if export.State == nil {
+		return nil, fmt.Errorf("autopilot state export does not contain a state")
+	}
+
+	if err := decodeStateExts(export.State, export.Config); err != nil {
+		return nil, fmt.Errorf("failed to decode the autopilot state export: %w", err)
+	}
+
+	export.State.firstStateTime = export.FirstStateTime
+	return &export, nil
+}
+
+// ExportStateFile writes the given State and Config to the file at path. Any
+// existing file will be overwritten.
+func ExportStateFile(path string, state *State, conf *Config) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := ExportState(f, state, conf); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// ImportStateFile reads a State and Config from a file previously written by
+// ExportStateFile.
+func ImportStateFile(path string) (*State, *Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	return ImportState(f)
+}
+
+// ExportState writes the current autopilot State along with the latest Config
+// from the delegate to w in a format that can later be read by ImportState.
+func (a *Autopilot) ExportState(w io.Writer) error {
+	return ExportState(w, a.GetState(), a.delegate.AutopilotConfig())
+}
@@ -0,0 +1,59 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportImportState(t *testing.T) {
+	state := testState([]raft.ServerID{"a"}, "a", "a")
+	state.firstStateTime = time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)
+	conf := &Config{MinQuorum: 3}
+
+	var buf bytes.Buffer
+	require.NoError(t, ExportState(&buf, state, conf))
+
+	actualState, actualConf, err := ImportState(&buf)
+	require.NoError(t, err)
+	require.Equal(t, state, actualState)
+	require.Equal(t, conf, actualConf)
+}
+
+func TestExportImportStateFile(t *testing.T) {
+	state := testState([]raft.ServerID{"a"}, "a", "a")
+	state.firstStateTime = time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)
+	conf := &Config{MinQuorum: 3}
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, ExportStateFile(path, state, conf))
+
+	actualState, actualConf, err := ImportStateFile(path)
+	require.NoError(t, err)
+	require.Equal(t, state, actualState)
+	require.Equal(t, conf, actualConf)
+}
+
+func TestImportStateErrors(t *testing.T) {
+	cases := map[string]string{
+		"invalid-json":        `{`,
+		"unsupported-version": `{"Version": 1000, "State": {}}`,
+		"missing-version":     `{"State": {}}`,
+		"missing-state":       `{"Version": 1}`,
+	}
+
+	for name, input := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := ImportState(strings.NewReader(input))
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/hashicorp/raft"
+)
+
+// extRegistry holds the types registered with RegisterExtType.
+var extRegistry = struct {
+	lock   sync.RWMutex
+	byName map[string]reflect.Type
+	byType map[reflect.Type]string
+}{
+	byName: make(map[string]reflect.Type),
+	byType: make(map[reflect.Type]string),
+}
+
+// extEnvelope is how Ext values of registered types are serialized so that the
+// original type can be restored when they are read back.
+type extEnvelope struct {
+	ExtType string
+	Value   interface{}
+}
+
+// RegisterExtType registers the type of the given value under a name so that
+// Ext fields of the Config, State or Servers holding values of that type
+// survive serialization, for example by ExportState and StateReader. Values are
+// encoded with encoding/json so types needing custom encoding should implement
+// json.Marshaler and json.Unmarshaler. Promoters are expected to register their
+// Ext types within an init function. Like gob.RegisterName this will panic if
+// the name or type has already been registered differently.
+func RegisterExtType(name string, value interface{}) {
+	if name == "" {
+		panic("autopilot: cannot register an Ext type without a name")
+	}
+
+	typ := reflect.TypeOf(value)
+	if typ == nil {
+		panic("autopilot: cannot register a nil Ext type")
+	}
+
+	extRegistry.lock.Lock()
+	defer extRegistry.lock.Unlock()
+
+	if existing, ok := extRegistry.byName[name]; ok && existing != typ {
+		panic(fmt.Sprintf("autopilot: Ext type name %q registered for both %s and %s", name, existing, typ))
+	}
+
+	if existing, ok := extRegistry.byType[typ]; ok && existing != name {
+		panic(fmt.Sprintf("autopilot: Ext type %s registered with both names %q and %q", typ, existing, name))
+	}
+
+	extRegistry.byName[name] = typ
+	extRegistry.byType[typ] = name
+}
+
+// encodeExt wraps an Ext value of a registered type in an envelope recording
+// its type. Values of unregistered types are returned as is.
+func encodeExt(ext interface{}) interface{} {
+	if ext == nil {
+		return nil
+	}
+
+	extRegistry.lock.RLock()
+	name, ok := extRegistry.byType[reflect.TypeOf(ext)]
+	extRegistry.lock.RUnlock()
+
+	if !ok {
+		return ext
+	}
+	return &extEnvelope{ExtType: name, Value: ext}
+}
+
+// decodeExt restores an Ext value which was previously wrapped by encodeExt and
+// then decoded by encoding/json. Values which were not wrapped or whose type is
+// not registered are returned as is.
+func decodeExt(ext interface{}) (interface{}, error) {
+	fields, ok := ext.(map[string]interface{})
+	if !ok || len(fields) != 2 {
+		return ext, nil
+	}
+
+	name, ok := fields["ExtType"].(string)
+	if !ok {
+		return ext, nil
+	}
+
+	extRegistry.lock.RLock()
+	typ, ok := extRegistry.byName[name]
+	extRegistry.lock.RUnlock()
+
+	if !ok {
+		return ext, nil
+	}
+
+	raw, err := json.Marshal(fields["Value"])
+	if err != nil {
+		return nil, fmt.Errorf("failed to re-encode Ext value of type %q: %w", name, err)
+	}
+
+	if typ.Kind() == reflect.Ptr {
+		value := reflect.New(typ.Elem())
+		if err := json.Unmarshal(raw, value.Interface()); err != nil {
+			return nil, fmt.Errorf("failed to decode Ext value of type %q: %w", name, err)
+		}
+		return value.Interface(), nil
+	}
+
+	value := reflect.New(typ)
+	if err := json.Unmarshal(raw, value.Interface()); err != nil {
+		return nil, fmt.Errorf("failed to decode Ext value of type %q: %w", name, err)
+	}
+	return value.Elem().Interface(), nil
+}
+
+// encodeStateExts returns copies of the State and Config with all Ext values of
+// registered types wrapped by encodeExt. The originals are not modified.
+func encodeStateExts(state *State, conf *Config) (*State, *Config) {
+	if conf != nil {
+		confCopy := *conf
+		confCopy.Ext = encodeExt(conf.Ext)
+		conf = &confCopy
+	}
+
+	stateCopy := *state
+	stateCopy.Ext = encodeExt(state.Ext)
+	if state.Servers != nil {
+		stateCopy.Servers = make(map[raft.ServerID]*ServerState, len(state.Servers))
+		for id, srv := range state.Servers {
+			srvCopy := *srv
+			srvCopy.Server.Ext = encodeExt(srv.Server.Ext)
+			srvCopy.Stats.Ext = encodeExt(srv.Stats.Ext)
+			stateCopy.Servers[id] = &srvCopy
+		}
+	}
+
+	return &stateCopy, conf
+}
+
+// decodeStateExts restores all Ext values within the State and Config which
+// were wrapped by encodeStateExts. They are modified in place.
+func decodeStateExts(state *State, conf *Config) error {
+	var err error
+	if conf != nil {
+		if conf.Ext, err = decodeExt(conf.Ext); err != nil {
+			return err
+		}
+	}
+
+	if state.Ext, err = decodeExt(state.Ext); err != nil {
+		return err
+	}
+
+	for _, srv := range state.Servers {
+		if srv.Server.Ext, err = decodeExt(srv.Server.Ext); err != nil {
+			return err
+		}
+		if srv.Stats.Ext, err = decodeExt(srv.Stats.Ext); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+type testStateExt struct {
+	Zones map[string]int
+}
+
+type testServerExt struct {
+	Zone    string
+	Upgrade bool
+}
+
+type testStatsExt struct {
+	DiskFree    uint64
+	SnapshotAge time.Duration
+}
+
+type testUnregisteredExt struct {
+	Value string
+}
+
+func init() {
+	RegisterExtType("autopilot-test-state", &testStateExt{})
+	RegisterExtType("autopilot-test-server", testServerExt{})
+	RegisterExtType("autopilot-test-stats", &testStatsExt{})
+}
+
+func TestRegisterExtTypeConflicts(t *testing.T) {
+	// registering the same type under the same name again is fine
+	RegisterExtType("autopilot-test-server", testServerExt{})
+
+	require.Panics(t, func() { RegisterExtType("", testServerExt{}) })
+	require.Panics(t, func() { RegisterExtType("autopilot-test-nil", nil) })
+	require.Panics(t, func() { RegisterExtType("autopilot-test-server", &testServerExt{}) })
+	require.Panics(t, func() { RegisterExtType("autopilot-test-other", testServerExt{}) })
+}
+
+func TestExportImportExt(t *testing.T) {
+	state := testState([]raft.ServerID{"a"}, "a", "a")
+	state.firstStateTime = time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)
+	conf := &Config{MinQuorum: 3}
+	state.Ext = &testStateExt{Zones: map[string]int{"us-east-1a": 1}}
+	srv := state.Servers["a"]
+	srv.Server.Ext = testServerExt{Zone: "us-east-1a", Upgrade: true}
+	srv.Stats.Ext = &testStatsExt{DiskFree: 1 << 30, SnapshotAge: time.Minute}
+	conf.Ext = testUnregisteredExt{Value: "foo"}
+
+	var buf bytes.Buffer
+	require.NoError(t, ExportState(&buf, state, conf))
+
+	// exporting must not modify the original values
+	require.Equal(t, &testStateExt{Zones: map[string]int{"us-east-1a": 1}}, state.Ext)
+	require.Equal(t, testServerExt{Zone: "us-east-1a", Upgrade: true}, srv.Server.Ext)
+	require.Equal(t, &testStatsExt{DiskFree: 1 << 30, SnapshotAge: time.Minute}, srv.Stats.Ext)
+
+	actualState, actualConf, err := ImportState(&buf)
+	require.NoError(t, err)
+
+	// registered types are restored
+	require.Equal(t, state, actualState)
+
+	// unregistered types are decoded generically
+	require.Equal(t, map[string]interface{}{"Value": "foo"}, actualConf.Ext)
+}
@@ -0,0 +1,50 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+// ConfigExt returns the configuration's Ext as a T. False is returned when the
+// configuration is nil or its Ext is not a T.
+func ConfigExt[T any](c *Config) (T, bool) {
+	if c == nil {
+		var zero T
+		return zero, false
+	}
+	return extAs[T](c.Ext)
+}
+
+// StateExt returns the state's Ext as a T. False is returned when the state is
+// nil or its Ext is not a T.
+func StateExt[T any](s *State) (T, bool) {
+	if s == nil {
+		var zero T
+		return zero, false
+	}
+	return extAs[T](s.Ext)
+}
+
+// ServerExt returns the server's Ext as a T. False is returned when the server
+// is nil or its Ext is not a T.
+func ServerExt[T any](s *Server) (T, bool) {
+	if s == nil {
+		var zero T
+		return zero, false
+	}
+	return extAs[T](s.Ext)
+}
+
+// StatsExt returns the stats' Ext as a T. False is returned when the stats are
+// nil or their Ext is not a T.
+func StatsExt[T any](s *ServerStats) (T, bool) {
+	if s == nil {
+		var zero T
+		return zero, false
+	}
+	return extAs[T](s.Ext)
+}
+
+// extAs asserts that the Ext value is a T.
+func extAs[T any](ext interface{}) (T, bool) {
+	value, ok := ext.(T)
+	return value, ok
+}
@@ -0,0 +1,47 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type typedExt struct {
+	Zone string
+}
+
+func TestTypedExt(t *testing.T) {
+	srv := &Server{Ext: &typedExt{Zone: "a"}}
+	ext, ok := ServerExt[*typedExt](srv)
+	require.True(t, ok)
+	require.Equal(t, "a", ext.Zone)
+
+	// the wrong type and nil values are reported as missing
+	_, ok = ServerExt[typedExt](srv)
+	require.False(t, ok)
+	_, ok = ServerExt[*typedExt](nil)
+	require.False(t, ok)
+	_, ok = ServerExt[*typedExt](&Server{})
+	require.False(t, ok)
+
+	zone, ok := ConfigExt[string](&Config{Ext: "b"})
+	require.True(t, ok)
+	require.Equal(t, "b", zone)
+	_, ok = ConfigExt[string](nil)
+	require.False(t, ok)
+
+	count, ok := StateExt[int](&State{Ext: 3})
+	require.True(t, ok)
+	require.Equal(t, 3, count)
+	_, ok = StateExt[int](nil)
+	require.False(t, ok)
+
+	stats, ok := StatsExt[map[string]int](&ServerStats{Ext: map[string]int{"disk": 1}})
+	require.True(t, ok)
+	require.Equal(t, 1, stats["disk"])
+	_, ok = StatsExt[map[string]int](nil)
+	require.False(t, ok)
+}
@@ -0,0 +1,110 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/raft"
+)
+
+// failedDemotionTracker tracks the failed voters which were demoted rather
+// than removed so that their removal may wait for the
+// FailedVoterDemotionPeriod.
+type failedDemotionTracker struct {
+	lock      sync.Mutex
+	demotedAt map[raft.ServerID]time.Time
+}
+
+// demoted records that the failed voter was demoted.
+func (t *failedDemotionTracker) demoted(id raft.ServerID, now time.Time) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.demotedAt == nil {
+		t.demotedAt = make(map[raft.ServerID]time.Time)
+	}
+	t.demotedAt[id] = now
+}
+
+// removable returns the failed non-voters which may be removed, omitting those
+// demoted less than the period ago.
+func (t *failedDemotionTracker) removable(servers []*Server, period time.Duration, now time.Time) []*Server {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	var result []*Server
+	for _, srv := range servers {
+		if at, ok := t.demotedAt[srv.ID]; ok && now.Sub(at) < period {
+			continue
+		}
+		result = append(result, srv)
+	}
+	return result
+}
+
+// forgetRecovered forgets the demoted servers which are no longer among the
+// failed non-voters so that should they fail again later they are treated like
+// any other failed non-voter.
+func (t *failedDemotionTracker) forgetRecovered(servers []*Server) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	failed := make(map[raft.ServerID]bool, len(servers))
+	for _, srv := range servers {
+		failed[srv.ID] = true
+	}
+
+	for id := range t.demotedAt {
+		if !failed[id] {
+			delete(t.demotedAt, id)
+		}
+	}
+}
+
+// demoteFailedVoters demotes the failed voters to non-voters instead of
+// removing them. They are removed once they have remained failed for the
+// FailedVoterDemotionPeriod. A failed demotion does not stop the remaining
+// voters from being demoted, instead the errors are aggregated.
+func (a *Autopilot) demoteFailedVoters(ctx context.Context, toDemote []*Server) error {
+	if !a.MembershipChangesEnabled(MembershipChangeDemotion) {
+		a.roundLogger().Debug("not demoting failed voters as demotions are disabled")
+		return nil
+	}
+
+	var result error
+	for _, srv := range toDemote {
+		if a.isLocalServer(srv.ID) {
+			a.roundLogger().Error("refusing to demote the local server", "id", srv.ID)
+			continue
+		}
+
+		if !a.approveDemotion(srv) || !a.takeChangeBudget(MembershipChangeDemotion, srv.ID) {
+			continue
+		}
+
+		a.roundLogger().Info("demoting failed server before removing it", "id", srv.ID, "address", srv.Address, "name", srv.Name)
+		if err := a.demoteVoter(ctx, srv.ID); err != nil {
+			a.lockouts.failed(srv.ID, MembershipChangeDemotion, a.now(), err)
+			result = multierror.Append(result, fmt.Errorf("failed demoting server %s: %w", srv.ID, err))
+			continue
+		}
+		a.lockouts.succeeded(srv.ID)
+		a.failedDemotions.demoted(srv.ID, a.now())
+
+		message := fmt.Sprintf("demoted to a non-voter as its node status is %q", srv.NodeStatus)
+		a.metricsSink().IncrCounterWithLabels(demotionsKey, 1, []metrics.Label{{Name: "id", Value: string(srv.ID)}})
+		a.emitEvent(EventServerDemoted, srv.ID, message)
+		a.notifyMembershipChange(MembershipChangeDemotion, srv, "", message)
+		a.recordDecision(DecisionDemote, ReasonFailedServer, srv.ID, message, map[string]string{
+			"node_status": string(srv.NodeStatus),
+		})
+	}
+	return result
+}
@@ -0,0 +1,39 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDemoteFailedVotersContinuesAfterError(t *testing.T) {
+	mraft := NewMockRaft(t)
+	a := &Autopilot{
+		logger: hclog.NewNullLogger(),
+		raft:   mraft,
+		time:   &runtimeTimeProvider{},
+	}
+
+	mraft.On("DemoteVoter", raft.ServerID("b"), uint64(0), time.Duration(0)).Return(&raftIndexFuture{err: fmt.Errorf("injected")}).Once()
+	mraft.On("DemoteVoter", raft.ServerID("c"), uint64(0), time.Duration(0)).Return(&raftIndexFuture{}).Once()
+
+	err := a.demoteFailedVoters(context.Background(), []*Server{
+		{ID: "b", Name: "node2", NodeStatus: NodeFailed},
+		{ID: "c", Name: "node3", NodeStatus: NodeFailed},
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "failed demoting server b")
+
+	// the server after the failed one was still demoted
+	now := a.now()
+	removable := a.failedDemotions.removable([]*Server{{ID: "b"}, {ID: "c"}}, time.Minute, now)
+	require.Equal(t, []*Server{{ID: "b"}}, removable)
+}
@@ -0,0 +1,105 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"sort"
+
+	"github.com/hashicorp/raft"
+)
+
+// FailureDomainTolerance describes whether the cluster can survive the loss of
+// every voter within a failure domain. Failure domains are identified by the
+// values of a single Server.Meta key such as a zone or a host group.
+type FailureDomainTolerance struct {
+	// Key is the Server.Meta key whose values identify the failure domains.
+	Key string
+
+	// CanLoseAny is true when the loss of any single failure domain would
+	// still leave a quorum of healthy voters. It is false when no voters
+	// have a value for the key.
+	CanLoseAny bool
+
+	// Domains is the per domain breakdown keyed by the Server.Meta value.
+	Domains map[string]*FailureDomain
+}
+
+// FailureDomain is the voter placement within a single failure domain.
+type FailureDomain struct {
+	// Voters are the IDs of all voters within the failure domain.
+	Voters []raft.ServerID
+
+	// HealthyVoters is the number of voters within the failure domain
+	// which are currently healthy.
+	HealthyVoters int
+
+	// CanLose is true when losing every voter within this failure domain
+	// would still leave a quorum of healthy voters.
+	CanLose bool
+}
+
+// failureDomainTolerances computes the FailureDomainTolerance for each of the
+// configured failure domain keys. Voters that have no value for a key are not
+// considered part of any failure domain for that key but still contribute to
+// the quorum when other domains are lost.
+func failureDomainTolerances(conf *Config, s *State) map[string]*FailureDomainTolerance {
+	if conf == nil || len(conf.FailureDomainKeys) == 0 {
+		return nil
+	}
+
+	healthyVoters := 0
+	for _, id := range s.Voters {
+		if srv, ok := s.Servers[id]; ok && srv.Health.Healthy {
+			healthyVoters++
+		}
+	}
+	quorum := requiredQuorum(len(s.Voters))
+
+	result := make(map[string]*FailureDomainTolerance)
+	for _, key := range conf.FailureDomainKeys {
+		tolerance := &FailureDomainTolerance{
+			Key:     key,
+			Domains: make(map[string]*FailureDomain),
+		}
+
+		for _, id := range s.Voters {
+			srv, ok := s.Servers[id]
+			if !ok {
+				continue
+			}
+
+			value, ok := srv.Server.Meta[key]
+			if !ok || value == "" {
+				continue
+			}
+
+			domain, ok := tolerance.Domains[value]
+			if !ok {
+				domain = &FailureDomain{}
+				tolerance.Domains[value] = domain
+			}
+
+			domain.Voters = append(domain.Voters, id)
+			if srv.Health.Healthy {
+				domain.HealthyVoters++
+			}
+		}
+
+		tolerance.CanLoseAny = len(tolerance.Domains) > 0
+		for _, domain := range tolerance.Domains {
+			domain.CanLose = healthyVoters-domain.HealthyVoters >= quorum
+			if !domain.CanLose {
+				tolerance.CanLoseAny = false
+			}
+
+			sort.Slice(domain.Voters, func(i, j int) bool {
+				return domain.Voters[i] < domain.Voters[j]
+			})
+		}
+
+		result[key] = tolerance
+	}
+
+	return result
+}
@@ -0,0 +1,91 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"testing"
+
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailureDomainTolerances(t *testing.T) {
+	voter := func(zone, rack string, healthy bool) *ServerState {
+		return &ServerState{
+			Server: Server{
+				Meta: map[string]string{"zone": zone, "rack": rack},
+			},
+			State:  RaftVoter,
+			Health: ServerHealth{Healthy: healthy},
+		}
+	}
+
+	state := &State{
+		Servers: map[raft.ServerID]*ServerState{
+			"a": voter("us-east-1a", "r1", true),
+			"b": voter("us-east-1b", "r1", true),
+			"c": voter("us-east-1c", "r2", true),
+			"d": voter("us-east-1c", "r3", true),
+			"e": voter("us-east-1a", "r1", true),
+			// non-voters are not considered
+			"f": {
+				Server: Server{Meta: map[string]string{"zone": "us-east-1d"}},
+				State:  RaftNonVoter,
+				Health: ServerHealth{Healthy: true},
+			},
+		},
+		Voters: []raft.ServerID{"a", "b", "c", "d", "e"},
+	}
+
+	t.Run("no-keys", func(t *testing.T) {
+		require.Nil(t, failureDomainTolerances(&Config{}, state))
+	})
+
+	t.Run("zones-and-racks", func(t *testing.T) {
+		conf := &Config{FailureDomainKeys: []string{"zone", "rack", "missing"}}
+
+		expected := map[string]*FailureDomainTolerance{
+			"zone": {
+				Key:        "zone",
+				CanLoseAny: true,
+				Domains: map[string]*FailureDomain{
+					"us-east-1a": {Voters: []raft.ServerID{"a", "e"}, HealthyVoters: 2, CanLose: true},
+					"us-east-1b": {Voters: []raft.ServerID{"b"}, HealthyVoters: 1, CanLose: true},
+					"us-east-1c": {Voters: []raft.ServerID{"c", "d"}, HealthyVoters: 2, CanLose: true},
+				},
+			},
+			"rack": {
+				Key:        "rack",
+				CanLoseAny: false,
+				Domains: map[string]*FailureDomain{
+					"r1": {Voters: []raft.ServerID{"a", "b", "e"}, HealthyVoters: 3, CanLose: false},
+					"r2": {Voters: []raft.ServerID{"c"}, HealthyVoters: 1, CanLose: true},
+					"r3": {Voters: []raft.ServerID{"d"}, HealthyVoters: 1, CanLose: true},
+				},
+			},
+			"missing": {
+				Key:        "missing",
+				CanLoseAny: false,
+				Domains:    map[string]*FailureDomain{},
+			},
+		}
+
+		require.Equal(t, expected, failureDomainTolerances(conf, state))
+	})
+
+	t.Run("unhealthy-voters", func(t *testing.T) {
+		state.Servers["b"].Health.Healthy = false
+		defer func() { state.Servers["b"].Health.Healthy = true }()
+
+		conf := &Config{FailureDomainKeys: []string{"zone"}}
+		actual := failureDomainTolerances(conf, state)["zone"]
+
+		// only 4 healthy voters remain so losing either of the zones
+		// with 2 healthy voters would leave just 2 of the required 3
+		require.False(t, actual.CanLoseAny)
+		require.False(t, actual.Domains["us-east-1a"].CanLose)
+		require.True(t, actual.Domains["us-east-1b"].CanLose)
+		require.False(t, actual.Domains["us-east-1c"].CanLose)
+	})
+}
@@ -0,0 +1,89 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"sort"
+)
+
+// Feature identifies an optional autopilot subsystem which may be enabled or
+// disabled with the WithFeatures and WithoutFeatures options. Every feature is
+// disabled unless enabled with WithFeatures.
+type Feature string
+
+const (
+	// FeatureApplyRevalidation re-checks each promotion and demotion against
+	// the latest state immediately before applying it.
+	FeatureApplyRevalidation Feature = "apply-revalidation"
+
+	// FeatureZoneEvacuation allows zones to be evacuated with EvacuateZone.
+	FeatureZoneEvacuation Feature = "zone-evacuation"
+
+	// FeatureTermDivergenceGuard suppresses demotions and doubles the server
+	// stabilization time while the servers' Raft terms diverge.
+	FeatureTermDivergenceGuard Feature = "term-divergence-guard"
+
+	// FeatureClockJumpProtection adjusts recorded times for wall clock jumps.
+	FeatureClockJumpProtection Feature = "clock-jump-protection"
+
+	// FeatureFailureToleranceEvents emits events and metrics when the failure
+	// tolerance is exhausted and restored.
+	FeatureFailureToleranceEvents Feature = "failure-tolerance-events"
+
+	// FeatureTermAheadRefresh refreshes the state early when a server starts
+	// reporting a term ahead of the leader's so that an election in progress
+	// is noticed sooner.
+	FeatureTermAheadRefresh Feature = "term-ahead-refresh"
+
+	// FeatureOddVoters holds back promotions and demotions which would leave
+	// an even number of voters when an odd number can be kept instead.
+	FeatureOddVoters Feature = "odd-voters"
+)
+
+// WithFeatures returns an option to enable the given features.
+func WithFeatures(features ...Feature) Option {
+	return func(a *Autopilot) {
+		a.setFeatures(features, true)
+	}
+}
+
+// WithoutFeatures returns an option to disable the given features.
+func WithoutFeatures(features ...Feature) Option {
+	return func(a *Autopilot) {
+		a.setFeatures(features, false)
+	}
+}
+
+func (a *Autopilot) setFeatures(features []Feature, enabled bool) {
+	if a.features == nil {
+		a.features = make(map[Feature]bool)
+	}
+
+	for _, feature := range features {
+		a.features[feature] = enabled
+	}
+}
+
+// FeatureEnabled returns whether the given feature is enabled.
+func (a *Autopilot) FeatureEnabled(feature Feature) bool {
+	return a.features[feature]
+}
+
+// Features returns all the enabled features in sorted order. This allows
+// operators to verify exactly which autopilot behaviors are active. The
+// features are fixed when autopilot is created so they are reported here
+// rather than alongside the ExecutionStatus returned by IsRunning.
+func (a *Autopilot) Features() []Feature {
+	var enabled []Feature
+	for feature, ok := range a.features {
+		if ok {
+			enabled = append(enabled, feature)
+		}
+	}
+
+	sort.Slice(enabled, func(i, j int) bool {
+		return enabled[i] < enabled[j]
+	})
+	return enabled
+}
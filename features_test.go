@@ -0,0 +1,42 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeatures(t *testing.T) {
+	// no features are enabled for a zero value Autopilot
+	a := &Autopilot{}
+	require.Empty(t, a.Features())
+	require.False(t, a.FeatureEnabled(FeatureZoneEvacuation))
+
+	// nor are any enabled by default
+	a = New(NewMockRaft(t), NewMockApplicationIntegration(t))
+	require.Empty(t, a.Features())
+
+	// disabled features refuse to be used
+	require.Error(t, a.EvacuateZone("a"))
+
+	a = New(NewMockRaft(t), NewMockApplicationIntegration(t),
+		WithFeatures(FeatureZoneEvacuation, FeatureTermDivergenceGuard, FeatureApplyRevalidation),
+		WithoutFeatures(FeatureTermDivergenceGuard),
+		WithFeatures("experimental"),
+	)
+
+	require.True(t, a.FeatureEnabled(FeatureZoneEvacuation))
+	require.True(t, a.FeatureEnabled(FeatureApplyRevalidation))
+	require.False(t, a.FeatureEnabled(FeatureTermDivergenceGuard))
+	require.True(t, a.FeatureEnabled("experimental"))
+	require.False(t, a.FeatureEnabled("unknown"))
+
+	require.Equal(t, []Feature{
+		FeatureApplyRevalidation,
+		"experimental",
+		FeatureZoneEvacuation,
+	}, a.Features())
+}
@@ -0,0 +1,49 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+// FirstActionConfirmer may optionally be implemented by the ApplicationIntegration
+// to gate the first destructive action that autopilot performs after the process
+// has started. This lets applications hold off demotions and removals until
+// their own readiness signals have been met, such as a full anti-entropy sync
+// having completed after a leader failover.
+type FirstActionConfirmer interface {
+	// ConfirmFirstAction is called before the first demotion or removal. When
+	// it returns false the action will be skipped and the method will be
+	// called again before the next attempt. Once it has returned true it will
+	// not be called again for the lifetime of the Autopilot instance.
+	ConfirmFirstAction() bool
+}
+
+// confirmDestructiveAction returns whether autopilot may go ahead with a
+// demotion or removal. Destructive actions are held while the known servers
+// settle after a regression. When the delegate implements FirstActionConfirmer
+// it will be consulted until it confirms the first destructive action.
+func (a *Autopilot) confirmDestructiveAction() bool {
+	if a.knownServers.holding(a.now) {
+		a.roundLogger().Info("holding demotions and removals while the known servers settle")
+		return false
+	}
+
+	confirmer, ok := a.delegate.(FirstActionConfirmer)
+	if !ok {
+		return true
+	}
+
+	a.firstActionLock.Lock()
+	defer a.firstActionLock.Unlock()
+
+	if a.firstActionConfirmed {
+		return true
+	}
+
+	a.countDelegateCall("ConfirmFirstAction")
+	if !confirmer.ConfirmFirstAction() {
+		a.roundLogger().Info("application has not yet confirmed the first demotion or removal")
+		return false
+	}
+
+	a.firstActionConfirmed = true
+	return true
+}
@@ -0,0 +1,84 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+type confirmingDelegate struct {
+	*MockApplicationIntegration
+	confirm bool
+	calls   int
+}
+
+func (d *confirmingDelegate) ConfirmFirstAction() bool {
+	d.calls++
+	return d.confirm
+}
+
+func TestConfirmFirstAction(t *testing.T) {
+	state := &State{
+		Leader: "a",
+		Servers: map[raft.ServerID]*ServerState{
+			"a": {
+				Server: Server{ID: "a", Address: "198.18.0.1:8300"},
+				State:  RaftLeader,
+				Health: ServerHealth{Healthy: true},
+			},
+			"b": {
+				Server: Server{ID: "b", Address: "198.18.0.2:8300"},
+				State:  RaftVoter,
+				Health: ServerHealth{Healthy: true},
+			},
+		},
+	}
+	changes := RaftChanges{Demotions: []raft.ServerID{"b"}}
+
+	mraft := NewMockRaft(t)
+	mdel := &confirmingDelegate{MockApplicationIntegration: NewMockApplicationIntegration(t)}
+
+	a := &Autopilot{
+		logger:   hclog.NewNullLogger(),
+		raft:     mraft,
+		delegate: mdel,
+	}
+
+	// not yet confirmed so no demotion should take place but the
+	// round should still be stopped
+	done, err := a.applyDemotions(context.Background(), nil, state, changes)
+	require.True(t, done)
+	require.NoError(t, err)
+	require.Equal(t, 1, mdel.calls)
+
+	mdel.confirm = true
+	mraft.On("DemoteVoter", raft.ServerID("b"), uint64(0), time.Duration(0)).Return(&raftIndexFuture{}).Twice()
+
+	done, err = a.applyDemotions(context.Background(), nil, state, changes)
+	require.True(t, done)
+	require.NoError(t, err)
+	require.Equal(t, 2, mdel.calls)
+
+	// once confirmed the delegate is not asked again
+	mdel.confirm = false
+	done, err = a.applyDemotions(context.Background(), nil, state, changes)
+	require.True(t, done)
+	require.NoError(t, err)
+	require.Equal(t, 2, mdel.calls)
+}
+
+func TestConfirmFirstActionNotImplemented(t *testing.T) {
+	a := &Autopilot{
+		logger:   hclog.NewNullLogger(),
+		delegate: NewMockApplicationIntegration(t),
+	}
+
+	require.True(t, a.confirmDestructiveAction())
+}
@@ -0,0 +1,75 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"time"
+
+	"github.com/hashicorp/raft"
+)
+
+// trackFlapping records whether the server's health changed in this state
+// update and determines whether it is flapping. A server is flapping once its
+// health has changed more than the FlapThreshold within the FlapWindow and
+// remains so until its health has not changed for the FlapDampingPeriod.
+func (h *ServerHealth) trackFlapping(conf *Config, changed bool, now time.Time) {
+	kept := h.transitions[:0:0]
+	for _, t := range h.transitions {
+		if now.Sub(t) < conf.FlapWindow {
+			kept = append(kept, t)
+		}
+	}
+	h.transitions = kept
+
+	if changed {
+		h.transitions = append(h.transitions, now)
+		h.lastTransition = now
+	}
+
+	if len(h.transitions) > conf.FlapThreshold {
+		h.Flapping = true
+	} else if h.Flapping && now.Sub(h.lastTransition) >= conf.FlapDampingPeriod {
+		h.Flapping = false
+	}
+}
+
+// demoteFlapping adds a flapping voter to the demotions when DemoteFlapping is
+// configured. At most one voter is demoted each round and never when it would
+// leave fewer voters than the MinQuorum.
+func (a *Autopilot) demoteFlapping(conf *Config, state *State, changes RaftChanges) RaftChanges {
+	if conf.FlapThreshold < 1 || !conf.DemoteFlapping {
+		return changes
+	}
+
+	voters := len(state.Voters) - len(changes.Demotions)
+	if voters-1 < int(conf.MinQuorum) {
+		return changes
+	}
+
+	for _, id := range state.Voters {
+		srv, ok := state.Servers[id]
+		if !ok || !srv.Health.Flapping || id == state.Leader || srv.Ignored || srv.Server.Protected || containsServer(changes.Demotions, id) {
+			continue
+		}
+
+		a.roundLogger().Info("demoting a flapping voter", "id", id)
+		changes.Promotions = withoutServer(changes.Promotions, id)
+		changes.Demotions = append(changes.Demotions, id)
+		return changes
+	}
+	return changes
+}
+
+// flappingServers returns the IDs of the servers whose flapping began between
+// the two states.
+func flappingServers(prev, next *State) []raft.ServerID {
+	var ids []raft.ServerID
+	for id, srv := range next.Servers {
+		old, ok := prev.Servers[id]
+		if srv.Health.Flapping && (!ok || !old.Health.Flapping) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
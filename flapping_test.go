@@ -0,0 +1,79 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrackFlapping(t *testing.T) {
+	start := time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)
+	conf := &Config{FlapThreshold: 2, FlapWindow: time.Minute, FlapDampingPeriod: 5 * time.Minute}
+
+	var health ServerHealth
+	health.trackFlapping(conf, true, start)
+	health.trackFlapping(conf, true, start.Add(10*time.Second))
+	require.False(t, health.Flapping)
+
+	// changes which have left the window are not counted
+	health.trackFlapping(conf, true, start.Add(70*time.Second))
+	require.False(t, health.Flapping)
+
+	health.trackFlapping(conf, true, start.Add(75*time.Second))
+	health.trackFlapping(conf, true, start.Add(80*time.Second))
+	require.True(t, health.Flapping)
+
+	// the server keeps flapping until its health settles for the damping
+	// period, well after the changes have left the window
+	health.trackFlapping(conf, false, start.Add(5*time.Minute))
+	require.True(t, health.Flapping)
+	health.trackFlapping(conf, false, start.Add(80*time.Second+5*time.Minute))
+	require.False(t, health.Flapping)
+}
+
+func TestDemoteFlapping(t *testing.T) {
+	state := &State{
+		Leader: "a",
+		Voters: []raft.ServerID{"a", "b", "c", "d", "e"},
+		Servers: map[raft.ServerID]*ServerState{
+			"a": {Server: Server{ID: "a"}, State: RaftLeader, Health: ServerHealth{Healthy: true, Flapping: true}},
+			"b": {Server: Server{ID: "b"}, State: RaftVoter, Health: ServerHealth{Healthy: true}},
+			"c": {Server: Server{ID: "c"}, State: RaftVoter, Health: ServerHealth{Healthy: true, Flapping: true}},
+			"d": {Server: Server{ID: "d"}, State: RaftVoter, Health: ServerHealth{Healthy: true, Flapping: true}},
+			"e": {Server: Server{ID: "e"}, State: RaftVoter, Health: ServerHealth{Healthy: true}},
+		},
+	}
+	a := &Autopilot{logger: hclog.NewNullLogger()}
+
+	// nothing is demoted unless configured
+	conf := &Config{FlapThreshold: 2, MinQuorum: 3}
+	require.Equal(t, RaftChanges{}, a.demoteFlapping(conf, state, RaftChanges{}))
+
+	// one voter other than the leader is demoted each round
+	conf.DemoteFlapping = true
+	require.Equal(t, RaftChanges{Demotions: []raft.ServerID{"c"}}, a.demoteFlapping(conf, state, RaftChanges{}))
+	require.Equal(t, RaftChanges{Demotions: []raft.ServerID{"c", "d"}}, a.demoteFlapping(conf, state, RaftChanges{Demotions: []raft.ServerID{"c"}}))
+
+	// as long as MinQuorum voters remain
+	conf.MinQuorum = 4
+	require.Equal(t, RaftChanges{Demotions: []raft.ServerID{"c"}}, a.demoteFlapping(conf, state, RaftChanges{Demotions: []raft.ServerID{"c"}}))
+}
+
+func TestPromotionEligibleFlapping(t *testing.T) {
+	state := &State{
+		Servers: map[raft.ServerID]*ServerState{
+			"a": {Server: Server{ID: "a", NodeType: NodeVoter}, State: RaftNonVoter, Health: ServerHealth{Healthy: true, Flapping: true}},
+		},
+	}
+	a := &Autopilot{logger: hclog.NewNullLogger(), promoter: DefaultPromoter()}
+	require.False(t, a.promotionEligible(&Config{}, state, state.Servers["a"], time.Now()))
+
+	state.Servers["a"].Health.Flapping = false
+	require.True(t, a.promotionEligible(&Config{}, state, state.Servers["a"], time.Now()))
+}
@@ -0,0 +1,81 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"sync"
+	"time"
+)
+
+// StateSnapshot is a past State along with when it was computed.
+type StateSnapshot struct {
+	// Time is when the state was computed.
+	Time time.Time
+
+	// State is the state autopilot computed. It must not be modified.
+	State *State
+}
+
+// stateHistory is a ring buffer of the most recently computed states.
+type stateHistory struct {
+	lock sync.Mutex
+
+	// size is the number of snapshots retained. Zero disables the history.
+	size int
+
+	snapshots []StateSnapshot
+	// next is the index the next snapshot will be written to once the
+	// buffer is full.
+	next int
+}
+
+// record adds the state to the history, overwriting the oldest snapshot once
+// the history is full.
+func (h *stateHistory) record(now time.Time, s *State) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.size < 1 {
+		return
+	}
+
+	snapshot := StateSnapshot{Time: now, State: s}
+	if len(h.snapshots) < h.size {
+		h.snapshots = append(h.snapshots, snapshot)
+		return
+	}
+
+	h.snapshots[h.next] = snapshot
+	h.next = (h.next + 1) % h.size
+}
+
+// list returns the snapshots from oldest to newest.
+func (h *stateHistory) list() []StateSnapshot {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	result := make([]StateSnapshot, 0, len(h.snapshots))
+	result = append(result, h.snapshots[h.next:]...)
+	result = append(result, h.snapshots[:h.next]...)
+	return result
+}
+
+// StateHistory returns the most recently computed states from oldest to
+// newest. The number retained is set with the WithStateHistory option and no
+// states are retained without it. The returned states must not be modified.
+func (a *Autopilot) StateHistory() []StateSnapshot {
+	return a.history.list()
+}
+
+// StateHistorySince returns the retained states computed at or after the
+// given time from oldest to newest.
+func (a *Autopilot) StateHistorySince(since time.Time) []StateSnapshot {
+	var result []StateSnapshot
+	for _, snapshot := range a.history.list() {
+		if !snapshot.Time.Before(since) {
+			result = append(result, snapshot)
+		}
+	}
+	return result
+}
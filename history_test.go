@@ -0,0 +1,43 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateHistory(t *testing.T) {
+	start := time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)
+
+	a := &Autopilot{}
+	WithStateHistory(3)(a)
+	require.Empty(t, a.StateHistory())
+
+	var states []*State
+	for i := 0; i < 5; i++ {
+		s := &State{FailureTolerance: i}
+		states = append(states, s)
+		a.history.record(start.Add(time.Duration(i)*time.Minute), s)
+	}
+
+	// only the newest three are retained from oldest to newest
+	require.Equal(t, []StateSnapshot{
+		{Time: start.Add(2 * time.Minute), State: states[2]},
+		{Time: start.Add(3 * time.Minute), State: states[3]},
+		{Time: start.Add(4 * time.Minute), State: states[4]},
+	}, a.StateHistory())
+
+	require.Equal(t, []StateSnapshot{
+		{Time: start.Add(3 * time.Minute), State: states[3]},
+		{Time: start.Add(4 * time.Minute), State: states[4]},
+	}, a.StateHistorySince(start.Add(3*time.Minute)))
+
+	// without a size nothing is retained
+	var disabled Autopilot
+	disabled.history.record(start, &State{})
+	require.Empty(t, disabled.StateHistory())
+}
@@ -0,0 +1,30 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+// applyHysteresis retains the previous health verdict until the new verdict
+// has been reached for the configured number of consecutive observations.
+// The health must already have been judged from the latest stats.
+func (h *ServerHealth) applyHysteresis(conf *Config, prev *ServerHealth) {
+	if h.Healthy == prev.Healthy {
+		h.pendingObservations = 0
+		return
+	}
+
+	required := conf.UnhealthyObservations
+	if !prev.Healthy {
+		required = conf.HealthyObservations
+	}
+
+	pending := prev.pendingObservations + 1
+	if pending >= required {
+		h.pendingObservations = 0
+		return
+	}
+
+	// hold on to the previous verdict and the reasons for it
+	h.Healthy = prev.Healthy
+	h.Reasons = prev.Reasons
+	h.pendingObservations = pending
+}
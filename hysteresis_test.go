@@ -0,0 +1,55 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyHysteresis(t *testing.T) {
+	conf := &Config{UnhealthyObservations: 3, HealthyObservations: 2}
+
+	// observe judges the health and applies the hysteresis against the
+	// previous health, returning the resulting health
+	observe := func(prev ServerHealth, healthy bool) ServerHealth {
+		next := prev
+		next.Healthy = healthy
+		next.Reasons = nil
+		if !healthy {
+			next.Reasons = []string{"last contact too high"}
+		}
+		next.applyHysteresis(conf, &prev)
+		return next
+	}
+
+	health := ServerHealth{Healthy: true}
+
+	// a single unhealthy observation does not change the health
+	health = observe(health, false)
+	require.True(t, health.Healthy)
+	require.Empty(t, health.Reasons)
+	health = observe(health, true)
+	require.True(t, health.Healthy)
+
+	// the count restarts after a healthy observation
+	health = observe(health, false)
+	health = observe(health, false)
+	require.True(t, health.Healthy)
+	health = observe(health, false)
+	require.False(t, health.Healthy)
+	require.Equal(t, []string{"last contact too high"}, health.Reasons)
+
+	// recovering requires fewer observations
+	health = observe(health, true)
+	require.False(t, health.Healthy)
+	health = observe(health, true)
+	require.True(t, health.Healthy)
+
+	// without the configuration the health changes immediately
+	conf = &Config{}
+	health = observe(health, false)
+	require.False(t, health.Healthy)
+}
@@ -0,0 +1,98 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"context"
+
+	"github.com/hashicorp/raft"
+)
+
+// ConfigProvider provides autopilot's configuration.
+type ConfigProvider interface {
+	// AutopilotConfig is used to retrieve the latest configuration from the delegate
+	AutopilotConfig() *Config
+}
+
+// StateObserver is notified of every state autopilot computes.
+type StateObserver interface {
+	// NotifyState will be called when the autopilot state is updated. The application may choose to emit metrics
+	// or perform other actions based on this information. It is not called when the application implements
+	// StateDeltaNotifier.
+	NotifyState(*State)
+}
+
+// ServerStatsFetcher fetches the stats of all the servers at once. Unlike a
+// StatsFetcher, which fetches the stats of a single server, it is part of the
+// ApplicationIntegration.
+type ServerStatsFetcher interface {
+	// FetchServerStats will be called to request the application fetch the ServerStats out of band. Usually this
+	// will require an RPC to each server. It is not called when a StatsFetcher is registered with the
+	// WithStatsFetcher option.
+	FetchServerStats(context.Context, map[raft.ServerID]*Server) map[raft.ServerID]*ServerStats
+}
+
+// ServerSource provides the servers known to the application.
+type ServerSource interface {
+	// KnownServers fetchs the list of servers as known to the application
+	KnownServers() map[raft.ServerID]*Server
+}
+
+// FailedServerRemover removes failed servers from the application.
+type FailedServerRemover interface {
+	// RemoveFailedServer notifies the application to forcefully remove the server in the failed state
+	// It is expected that this returns nearly immediately so if a longer running operation needs to be
+	// performed then the Delegate implementation should spawn a go routine itself.
+	RemoveFailedServer(*Server)
+}
+
+// Integration composes an ApplicationIntegration from the capabilities an
+// application implements. Config and Servers are required while the others
+// may be left nil:
+//
+//   - Without Stats no stats are fetched unless a StatsFetcher is registered
+//     with WithStatsFetcher or WithRaftReplicationStats.
+//   - Without Observer the states are not observed.
+//   - Without Remover failed servers are never removed, so CleanupDeadServers
+//     should be left disabled.
+//
+// The optional delegate interfaces, such as DecisionRecorder, are discovered
+// upon the ApplicationIntegration itself so applications implementing them
+// should embed the Integration within their own type.
+type Integration struct {
+	Config   ConfigProvider
+	Servers  ServerSource
+	Stats    ServerStatsFetcher
+	Observer StateObserver
+	Remover  FailedServerRemover
+}
+
+var _ ApplicationIntegration = (*Integration)(nil)
+
+func (i *Integration) AutopilotConfig() *Config {
+	return i.Config.AutopilotConfig()
+}
+
+func (i *Integration) KnownServers() map[raft.ServerID]*Server {
+	return i.Servers.KnownServers()
+}
+
+func (i *Integration) FetchServerStats(ctx context.Context, servers map[raft.ServerID]*Server) map[raft.ServerID]*ServerStats {
+	if i.Stats == nil {
+		return nil
+	}
+	return i.Stats.FetchServerStats(ctx, servers)
+}
+
+func (i *Integration) NotifyState(state *State) {
+	if i.Observer != nil {
+		i.Observer.NotifyState(state)
+	}
+}
+
+func (i *Integration) RemoveFailedServer(srv *Server) {
+	if i.Remover != nil {
+		i.Remover.RemoveFailedServer(srv)
+	}
+}
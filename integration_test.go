@@ -0,0 +1,48 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/raft"
+	mock "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntegration(t *testing.T) {
+	conf := &Config{MinQuorum: 3}
+	servers := map[raft.ServerID]*Server{"a": {ID: "a"}}
+
+	t.Run("required-only", func(t *testing.T) {
+		mdel := NewMockApplicationIntegration(t)
+		mdel.On("AutopilotConfig").Return(conf).Once()
+		mdel.On("KnownServers").Return(servers).Once()
+
+		i := &Integration{Config: mdel, Servers: mdel}
+		require.Same(t, conf, i.AutopilotConfig())
+		require.Equal(t, servers, i.KnownServers())
+
+		// the optional capabilities do nothing when missing
+		require.Nil(t, i.FetchServerStats(context.Background(), servers))
+		i.NotifyState(&State{})
+		i.RemoveFailedServer(servers["a"])
+	})
+
+	t.Run("all", func(t *testing.T) {
+		stats := map[raft.ServerID]*ServerStats{"a": {LastIndex: 10}}
+		state := &State{Leader: "a"}
+
+		mdel := NewMockApplicationIntegration(t)
+		mdel.On("FetchServerStats", mock.Anything, servers).Return(stats).Once()
+		mdel.On("NotifyState", state).Once()
+		mdel.On("RemoveFailedServer", servers["a"]).Once()
+
+		i := &Integration{Stats: mdel, Observer: mdel, Remover: mdel}
+		require.Equal(t, stats, i.FetchServerStats(context.Background(), servers))
+		i.NotifyState(state)
+		i.RemoveFailedServer(servers["a"])
+	})
+}
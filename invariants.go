@@ -0,0 +1,180 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/raft"
+)
+
+// InvariantViolation describes a membership change which would leave the
+// cluster unsafe. It is returned by the InvariantChecker and may be inspected
+// with errors.As. It also matches ErrQuorumRisk.
+type InvariantViolation struct {
+	// Change is the kind of membership change.
+	Change MembershipChangeType
+
+	// ServerID is the server the change was for.
+	ServerID raft.ServerID
+
+	// Voters is the number of voters there would be after the change.
+	Voters int
+
+	// HealthyVoters is the number of healthy voters there would be after the
+	// change.
+	HealthyVoters int
+
+	// Reason describes which invariant would no longer hold.
+	Reason string
+}
+
+func (v *InvariantViolation) Error() string {
+	return fmt.Sprintf("%s of %s would leave %d voters of which %d are healthy: %s", v.Change, v.ServerID, v.Voters, v.HealthyVoters, v.Reason)
+}
+
+// Unwrap allows the violation to match ErrQuorumRisk.
+func (v *InvariantViolation) Unwrap() error {
+	return ErrQuorumRisk
+}
+
+// InvariantChecker verifies that a sequence of membership changes never
+// leaves fewer voters than the configured MinQuorum and never leaves a cluster
+// whose healthy voters form a majority without one. Promoter authors may use it
+// within their tests and the WithInvariantChecks option enables it at runtime
+// as a last line of defence.
+//
+// Promotions are checked before demotions and then removals, each one at a
+// time in the order given. Autopilot never makes promotions and demotions
+// within the same round so checking both together is conservative.
+type InvariantChecker struct {
+	minQuorum int
+}
+
+// NewInvariantChecker creates an InvariantChecker enforcing the MinQuorum of
+// the configuration. A nil configuration only enforces the healthy majority.
+func NewInvariantChecker(conf *Config) *InvariantChecker {
+	c := &InvariantChecker{}
+	if conf != nil {
+		c.minQuorum = int(conf.MinQuorum)
+	}
+	return c
+}
+
+// CheckChanges checks the promotions and demotions of the changes against the
+// state. Leadership transfers do not alter the voters and are not checked.
+func (c *InvariantChecker) CheckChanges(state *State, changes RaftChanges) error {
+	return c.check(state, changes.Promotions, changes.Demotions, nil)
+}
+
+// CheckRemovals checks the removal of the servers from the state.
+func (c *InvariantChecker) CheckRemovals(state *State, removals []raft.ServerID) error {
+	return c.check(state, nil, nil, removals)
+}
+
+// CheckPlan checks all the changes of the plan against the state.
+func (c *InvariantChecker) CheckPlan(state *State, plan PlannedChanges) error {
+	return c.check(state, plan.Promotions, plan.Demotions, plan.Removals)
+}
+
+// check applies the promotions, demotions and removals to a copy of the
+// state's voters one at a time and returns the first change after which an
+// invariant no longer holds.
+func (c *InvariantChecker) check(state *State, promotions, demotions, removals []raft.ServerID) error {
+	if state == nil {
+		return nil
+	}
+
+	voters := make(map[raft.ServerID]bool)
+	healthy := 0
+	for id, srv := range state.Servers {
+		if srv.HasVotingRights() {
+			voters[id] = srv.Health.Healthy
+			if srv.Health.Healthy {
+				healthy++
+			}
+		}
+	}
+
+	step := func(change MembershipChangeType, id raft.ServerID) error {
+		srv, ok := state.Servers[id]
+		if !ok {
+			return nil
+		}
+
+		majority := healthy >= len(voters)/2+1
+		if change == MembershipChangePromotion {
+			if _, ok := voters[id]; ok {
+				return nil
+			}
+			voters[id] = srv.Health.Healthy
+			if srv.Health.Healthy {
+				healthy++
+			}
+		} else {
+			wasHealthy, ok := voters[id]
+			if !ok {
+				return nil
+			}
+			delete(voters, id)
+			if wasHealthy {
+				healthy--
+			}
+
+			if len(voters) < c.minQuorum {
+				return &InvariantViolation{
+					Change:        change,
+					ServerID:      id,
+					Voters:        len(voters),
+					HealthyVoters: healthy,
+					Reason:        fmt.Sprintf("fewer voters than the minimum quorum of %d", c.minQuorum),
+				}
+			}
+		}
+
+		if majority && healthy < len(voters)/2+1 {
+			return &InvariantViolation{
+				Change:        change,
+				ServerID:      id,
+				Voters:        len(voters),
+				HealthyVoters: healthy,
+				Reason:        "the healthy voters would no longer form a majority",
+			}
+		}
+		return nil
+	}
+
+	for _, id := range promotions {
+		if err := step(MembershipChangePromotion, id); err != nil {
+			return err
+		}
+	}
+	for _, id := range demotions {
+		if err := step(MembershipChangeDemotion, id); err != nil {
+			return err
+		}
+	}
+	for _, id := range removals {
+		if err := step(MembershipChangeRemoval, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkInvariants returns an error when invariant checks are enabled and the
+// planned changes would violate them. The violation is also logged and emitted
+// as an event.
+func (a *Autopilot) checkInvariants(conf *Config, state *State, plan PlannedChanges) error {
+	if !a.invariantChecks {
+		return nil
+	}
+
+	err := NewInvariantChecker(conf).CheckPlan(state, plan)
+	if v, ok := err.(*InvariantViolation); ok {
+		a.roundLogger().Error("refusing to make membership changes which would violate a quorum safety invariant", "error", err)
+		a.emitEvent(EventInvariantViolation, v.ServerID, err.Error())
+	}
+	return err
+}
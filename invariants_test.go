@@ -0,0 +1,158 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+// invariantServers are the servers of the invariant tests' states.
+var invariantServers = []raft.ServerID{"a", "b", "c", "d", "e", "f"}
+
+func TestInvariantChecker(t *testing.T) {
+	type testCase struct {
+		minQuorum uint
+		changes   RaftChanges
+		removals  []raft.ServerID
+		violation *InvariantViolation
+	}
+
+	cases := map[string]testCase{
+		"no-changes": {
+			minQuorum: 3,
+		},
+		"promote-healthy": {
+			minQuorum: 3,
+			changes:   RaftChanges{Promotions: []raft.ServerID{"e"}},
+		},
+		"demote-unhealthy": {
+			minQuorum: 3,
+			changes:   RaftChanges{Demotions: []raft.ServerID{"d"}},
+		},
+		"demote-below-min-quorum": {
+			minQuorum: 4,
+			changes:   RaftChanges{Demotions: []raft.ServerID{"d"}},
+			violation: &InvariantViolation{
+				Change:        MembershipChangeDemotion,
+				ServerID:      "d",
+				Voters:        3,
+				HealthyVoters: 3,
+				Reason:        "fewer voters than the minimum quorum of 4",
+			},
+		},
+		"demote-healthy-majority": {
+			minQuorum: 2,
+			changes:   RaftChanges{Demotions: []raft.ServerID{"b", "c"}},
+			violation: &InvariantViolation{
+				Change:        MembershipChangeDemotion,
+				ServerID:      "c",
+				Voters:        2,
+				HealthyVoters: 1,
+				Reason:        "the healthy voters would no longer form a majority",
+			},
+		},
+		"promotion-makes-demotion-safe": {
+			minQuorum: 3,
+			changes: RaftChanges{
+				Promotions: []raft.ServerID{"e"},
+				Demotions:  []raft.ServerID{"b"},
+			},
+		},
+		"promote-unhealthy-keeping-majority": {
+			changes: RaftChanges{Promotions: []raft.ServerID{"f"}},
+		},
+		"promote-several": {
+			changes: RaftChanges{Promotions: []raft.ServerID{"e", "f", "z"}},
+		},
+		"demote-after-unhealthy-promotion": {
+			changes: RaftChanges{
+				Promotions: []raft.ServerID{"f"},
+				Demotions:  []raft.ServerID{"c"},
+			},
+			violation: &InvariantViolation{
+				Change:        MembershipChangeDemotion,
+				ServerID:      "c",
+				Voters:        4,
+				HealthyVoters: 2,
+				Reason:        "the healthy voters would no longer form a majority",
+			},
+		},
+		"remove-in-order": {
+			minQuorum: 3,
+			removals:  []raft.ServerID{"d", "e", "c"},
+			violation: &InvariantViolation{
+				Change:        MembershipChangeRemoval,
+				ServerID:      "c",
+				Voters:        2,
+				HealthyVoters: 2,
+				Reason:        "fewer voters than the minimum quorum of 3",
+			},
+		},
+		"unknown-servers": {
+			minQuorum: 4,
+			changes:   RaftChanges{Demotions: []raft.ServerID{"z", "e"}},
+			removals:  []raft.ServerID{"y"},
+		},
+	}
+
+	state := testState(invariantServers, "a", "a", "b", "c", "d")
+	state.Servers["d"].Health.Healthy = false
+	state.Servers["f"].Health.Healthy = false
+
+	for name, tcase := range cases {
+		t.Run(name, func(t *testing.T) {
+			checker := NewInvariantChecker(&Config{MinQuorum: tcase.minQuorum})
+			err := checker.CheckPlan(state, PlannedChanges{
+				Promotions: tcase.changes.Promotions,
+				Demotions:  tcase.changes.Demotions,
+				Removals:   tcase.removals,
+			})
+			if tcase.violation == nil {
+				require.NoError(t, err)
+				return
+			}
+
+			var violation *InvariantViolation
+			require.True(t, errors.As(err, &violation))
+			require.Equal(t, tcase.violation, violation)
+		})
+	}
+}
+
+func TestCheckInvariants(t *testing.T) {
+	state := testState(invariantServers, "a", "a", "b", "c", "d")
+	state.Servers["d"].Health.Healthy = false
+	state.Servers["f"].Health.Healthy = false
+	plan := PlannedChanges{Demotions: []raft.ServerID{"b", "c"}}
+
+	// disabled by default
+	a := &Autopilot{logger: hclog.NewNullLogger()}
+	require.NoError(t, a.checkInvariants(&Config{}, state, plan))
+
+	var events []Event
+	mtime := NewMockTimeProvider(t)
+	mtime.On("Now").Return(time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC))
+	a = &Autopilot{
+		logger: hclog.NewNullLogger(),
+		time:   mtime,
+	}
+	WithInvariantChecks()(a)
+	WithEventHandler(func(e Event) { events = append(events, e) })(a)
+
+	err := a.checkInvariants(&Config{}, state, plan)
+	var violation *InvariantViolation
+	require.True(t, errors.As(err, &violation))
+	require.Len(t, events, 1)
+	require.Equal(t, EventInvariantViolation, events[0].Type)
+	require.Equal(t, raft.ServerID("c"), events[0].ServerID)
+	require.Equal(t, "demotion of c would leave 2 voters of which 1 are healthy: the healthy voters would no longer form a majority", events[0].Message)
+
+	require.NoError(t, a.checkInvariants(&Config{}, state, PlannedChanges{Removals: []raft.ServerID{"d"}}))
+}
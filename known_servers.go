@@ -0,0 +1,106 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hashicorp/raft"
+)
+
+const (
+	// DefaultKnownServersSettleTime is the suggested duration for which
+	// demotions and removals are held after the delegate's known servers
+	// regress when enabled with WithKnownServersSettleTime.
+	DefaultKnownServersSettleTime = 30 * time.Second
+
+	// minKnownServersForRegression is the minimum number of previously known
+	// servers for a regression to be detected. Small clusters legitimately
+	// lose half of their servers.
+	minKnownServersForRegression = 3
+)
+
+// knownServersTracker detects when the delegate's view of the known servers
+// regresses sharply, for example when the application restarts and briefly
+// reports only itself. Destructive actions are held for the settle time after
+// a regression rather than treating the rest of the servers as stale.
+type knownServersTracker struct {
+	lock sync.Mutex
+
+	// settleTime is how long to hold destructive actions after a regression.
+	// Zero disables regression detection.
+	settleTime time.Duration
+
+	last      map[raft.ServerID]struct{}
+	holdUntil time.Time
+}
+
+// observe records the latest known servers and returns whether they regressed
+// compared to the previous view. A regression is when fewer than half of the
+// previously known servers remain.
+func (t *knownServersTracker) observe(now time.Time, known map[raft.ServerID]*Server) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.settleTime <= 0 {
+		return false
+	}
+
+	previous := t.last
+	t.last = make(map[raft.ServerID]struct{}, len(known))
+	for id := range known {
+		t.last[id] = struct{}{}
+	}
+
+	if len(previous) < minKnownServersForRegression {
+		return false
+	}
+
+	remaining := 0
+	for id := range previous {
+		if _, ok := known[id]; ok {
+			remaining++
+		}
+	}
+
+	if remaining*2 >= len(previous) {
+		return false
+	}
+
+	t.holdUntil = now.Add(t.settleTime)
+	return true
+}
+
+// holding returns whether destructive actions are currently being held. The
+// time function is only called while a hold may be in effect.
+func (t *knownServersTracker) holding(now func() time.Time) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.holdUntil.IsZero() {
+		return false
+	}
+
+	if now().Before(t.holdUntil) {
+		return true
+	}
+
+	t.holdUntil = time.Time{}
+	return false
+}
+
+// observeKnownServers checks the delegate's known servers for a regression and
+// starts holding destructive actions when one is found.
+func (a *Autopilot) observeKnownServers(now time.Time, known map[raft.ServerID]*Server) {
+	if !a.knownServers.observe(now, known) {
+		return
+	}
+
+	a.logger.Warn("the known servers regressed sharply, holding demotions and removals while they settle",
+		"known_servers", len(known),
+		"settle_time", a.knownServers.settleTime,
+	)
+	a.emitEvent(EventKnownServersRegressed, "", "the application's known servers regressed sharply, demotions and removals are held while they settle")
+}
@@ -0,0 +1,85 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+func knownServersView(ids ...raft.ServerID) map[raft.ServerID]*Server {
+	known := make(map[raft.ServerID]*Server)
+	for _, id := range ids {
+		known[id] = &Server{ID: id, NodeStatus: NodeAlive}
+	}
+	return known
+}
+
+func TestKnownServersTracker(t *testing.T) {
+	now := time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)
+	clock := func(t time.Time) func() time.Time {
+		return func() time.Time { return t }
+	}
+
+	tracker := knownServersTracker{settleTime: time.Minute}
+
+	require.False(t, tracker.observe(now, knownServersView("a", "b", "c", "d", "e")))
+	// losing a minority of servers is not a regression
+	require.False(t, tracker.observe(now, knownServersView("a", "b", "c", "d")))
+	require.False(t, tracker.holding(clock(now)))
+
+	// the application restarting and only reporting itself is
+	require.True(t, tracker.observe(now, knownServersView("a")))
+	require.True(t, tracker.holding(clock(now.Add(30*time.Second))))
+
+	// the shrunken view is not compared against again
+	require.False(t, tracker.observe(now.Add(time.Second), knownServersView("a")))
+	require.False(t, tracker.holding(clock(now.Add(time.Minute))))
+
+	// small views never regress
+	require.False(t, tracker.observe(now, knownServersView("a", "b")))
+	require.False(t, tracker.observe(now, knownServersView()))
+
+	// disabled trackers never hold
+	disabled := knownServersTracker{}
+	require.False(t, disabled.observe(now, knownServersView("a", "b", "c")))
+	require.False(t, disabled.observe(now, knownServersView()))
+	require.False(t, disabled.holding(func() time.Time {
+		t.Fatal("the time should not be needed")
+		return time.Time{}
+	}))
+}
+
+func TestKnownServersRegressionHoldsDemotions(t *testing.T) {
+	var events []Event
+	a := &Autopilot{
+		logger:       hclog.NewNullLogger(),
+		delegate:     NewMockApplicationIntegration(t),
+		knownServers: knownServersTracker{settleTime: time.Hour},
+		eventHandlers: []EventHandler{func(e Event) {
+			events = append(events, e)
+		}},
+	}
+
+	a.observeKnownServers(time.Now(), knownServersView("a", "b", "c"))
+	require.True(t, a.confirmDestructiveAction())
+
+	a.observeKnownServers(time.Now(), knownServersView("a"))
+	require.False(t, a.confirmDestructiveAction())
+	require.Len(t, events, 1)
+	require.Equal(t, EventKnownServersRegressed, events[0].Type)
+}
+
+func TestKnownServersSettleTimeOption(t *testing.T) {
+	// regressions are not detected unless configured
+	a := New(nil, nil)
+	require.Zero(t, a.knownServers.settleTime)
+
+	a = New(nil, nil, WithKnownServersSettleTime(DefaultKnownServersSettleTime))
+	require.Equal(t, DefaultKnownServersSettleTime, a.knownServers.settleTime)
+}
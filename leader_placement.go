@@ -0,0 +1,105 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"github.com/hashicorp/raft"
+)
+
+// LeaderPlacer may optionally be implemented by the Promoter to choose where
+// leadership should reside. Unlike RaftChanges.Leader, which nominates a
+// server to transfer leadership to once, it is consulted every round so that
+// autopilot keeps leadership on the preferred servers, such as those running
+// on larger machines.
+type LeaderPlacer interface {
+	// PreferredLeaders returns the servers which should hold leadership in
+	// order of preference. Leadership is left alone while the leader is any
+	// of them. Returning no servers falls back to the placement configured
+	// with Config.PreferredLeaders and Config.PreferredLeaderZone.
+	PreferredLeaders(*Config, *State) []raft.ServerID
+}
+
+// preferredLeaders returns the servers which should hold leadership in order
+// of preference. The promoter's LeaderPlacer is consulted first followed by
+// the configured servers and then the voters within the configured zone.
+func (a *Autopilot) preferredLeaders(conf *Config, state *State) []raft.ServerID {
+	if placer, ok := a.getPromoter().(LeaderPlacer); ok && !a.usingFallbackPromoter() {
+		if preferred := a.callLeaderPlacer(placer, conf, state); len(preferred) > 0 {
+			return preferred
+		}
+	}
+
+	if len(conf.PreferredLeaders) > 0 {
+		return conf.PreferredLeaders
+	}
+
+	if conf.PreferredLeaderZone == "" || conf.ZoneKey == "" {
+		return nil
+	}
+
+	var preferred []raft.ServerID
+	for _, id := range state.Voters {
+		if srv, ok := state.Servers[id]; ok && srv.Server.Meta[conf.ZoneKey] == conf.PreferredLeaderZone {
+			preferred = append(preferred, id)
+		}
+	}
+	sortByValue(preferred, state)
+	return preferred
+}
+
+// callLeaderPlacer calls PreferredLeaders on the placer and logs any panic.
+func (a *Autopilot) callLeaderPlacer(placer LeaderPlacer, conf *Config, state *State) (preferred []raft.ServerID) {
+	defer func() {
+		if r := recover(); r != nil {
+			a.subsystemRoundLogger(SubsystemPromoter).Error("promoter panicked choosing the preferred leaders", "panic", r)
+			preferred = nil
+		}
+	}()
+
+	return placer.PreferredLeaders(conf, state)
+}
+
+// placeLeader modifies the changes to transfer leadership to the first healthy
+// and stable preferred leader whenever the leader is not one of the preferred
+// leaders. An explicit nomination of a leader by the promoter takes precedence.
+func (a *Autopilot) placeLeader(conf *Config, state *State, changes RaftChanges) RaftChanges {
+	if changes.Leader != "" || len(changes.LeaderCandidates) > 0 {
+		return changes
+	}
+
+	preferred := a.preferredLeaders(conf, state)
+	if len(preferred) == 0 {
+		return changes
+	}
+
+	for _, id := range preferred {
+		if id == state.Leader {
+			return changes
+		}
+	}
+
+	now := a.now()
+	var candidates []raft.ServerID
+	for _, id := range preferred {
+		srv, ok := state.Servers[id]
+		if !ok || !srv.HasVotingRights() || srv.Ignored {
+			continue
+		}
+
+		minStable := state.serverStabilizationTimeAt(conf.ForServer(&srv.Server), now)
+		if srv.Health.IsStable(now, minStable) {
+			candidates = append(candidates, id)
+		}
+	}
+
+	if len(candidates) == 0 {
+		a.subsystemRoundLogger(SubsystemTransfer).Debug("none of the preferred leaders are healthy and stable voters", "preferred", preferred)
+		return changes
+	}
+
+	a.subsystemRoundLogger(SubsystemTransfer).Info("leader is not one of the preferred leaders", "leader", state.Leader, "candidates", candidates)
+	changes.Leader = candidates[0]
+	changes.LeaderCandidates = candidates[1:]
+	return changes
+}
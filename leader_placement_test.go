@@ -0,0 +1,91 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+type placingPromoter struct {
+	StablePromoter
+	preferred []raft.ServerID
+}
+
+func (p *placingPromoter) PreferredLeaders(*Config, *State) []raft.ServerID {
+	return p.preferred
+}
+
+func TestPlaceLeader(t *testing.T) {
+	state := &State{
+		Leader: "a",
+		Voters: []raft.ServerID{"a", "b", "c", "d"},
+		Servers: map[raft.ServerID]*ServerState{
+			"a": {Server: Server{ID: "a", Meta: map[string]string{"zone": "1"}}, State: RaftLeader, Health: ServerHealth{Healthy: true}},
+			"b": {Server: Server{ID: "b", Meta: map[string]string{"zone": "2"}}, State: RaftVoter, Health: ServerHealth{Healthy: true}},
+			"c": {Server: Server{ID: "c", Meta: map[string]string{"zone": "2"}, Value: 1}, State: RaftVoter, Health: ServerHealth{Healthy: true}},
+			"d": {Server: Server{ID: "d", Meta: map[string]string{"zone": "2"}}, State: RaftVoter},
+			"e": {Server: Server{ID: "e", Meta: map[string]string{"zone": "2"}}, State: RaftNonVoter, Health: ServerHealth{Healthy: true}},
+		},
+	}
+
+	type testCase struct {
+		conf     Config
+		promoter Promoter
+		changes  RaftChanges
+		expected RaftChanges
+	}
+
+	cases := map[string]testCase{
+		"no-preference": {
+			expected: RaftChanges{},
+		},
+		"leader-preferred": {
+			conf:     Config{PreferredLeaders: []raft.ServerID{"b", "a"}},
+			expected: RaftChanges{},
+		},
+		"preferred-servers": {
+			// d is unhealthy and e is not a voter
+			conf:     Config{PreferredLeaders: []raft.ServerID{"d", "e", "c", "b"}},
+			expected: RaftChanges{Leader: "c", LeaderCandidates: []raft.ServerID{"b"}},
+		},
+		"preferred-zone": {
+			conf:     Config{ZoneKey: "zone", PreferredLeaderZone: "2"},
+			expected: RaftChanges{Leader: "c", LeaderCandidates: []raft.ServerID{"b"}},
+		},
+		"promoter-nomination-wins": {
+			conf:     Config{PreferredLeaders: []raft.ServerID{"b"}},
+			changes:  RaftChanges{Leader: "c"},
+			expected: RaftChanges{Leader: "c"},
+		},
+		"leader-placer": {
+			conf:     Config{PreferredLeaders: []raft.ServerID{"b"}},
+			promoter: &placingPromoter{preferred: []raft.ServerID{"c"}},
+			expected: RaftChanges{Leader: "c", LeaderCandidates: []raft.ServerID{}},
+		},
+		"leader-placer-falls-back": {
+			conf:     Config{PreferredLeaders: []raft.ServerID{"b"}},
+			promoter: &placingPromoter{},
+			expected: RaftChanges{Leader: "b", LeaderCandidates: []raft.ServerID{}},
+		},
+	}
+
+	for name, tcase := range cases {
+		t.Run(name, func(t *testing.T) {
+			promoter := tcase.promoter
+			if promoter == nil {
+				promoter = DefaultPromoter()
+			}
+
+			a := &Autopilot{
+				logger:   hclog.NewNullLogger(),
+				promoter: promoter,
+			}
+			require.Equal(t, tcase.expected, a.placeLeader(&tcase.conf, state, tcase.changes))
+		})
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/hashicorp/raft"
+)
+
+// leaderHandoffTracker tracks a leader whose removal was refused so that the
+// next reconciliation round moves leadership away from it.
+type leaderHandoffTracker struct {
+	lock sync.Mutex
+	id   raft.ServerID
+}
+
+// request records that leadership should be moved off of the server.
+func (t *leaderHandoffTracker) request(id raft.ServerID) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.id = id
+}
+
+// requested returns whether leadership should be moved off of the current
+// leader. A request for any other server is disregarded as leadership has
+// already moved.
+func (t *leaderHandoffTracker) requested(leader raft.ServerID) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.id != "" && t.id == leader
+}
+
+// forgetOthers forgets a request for any server other than the current leader
+// as leadership has already moved off of it.
+func (t *leaderHandoffTracker) forgetOthers(leader raft.ServerID) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.id != leader {
+		t.id = ""
+	}
+}
+
+// protectLeader prevents the changes from demoting the current leader. Raft
+// would otherwise have the leader step down once the demotion is committed,
+// leaving the cluster without a leader until an election completes. Instead
+// the demotion is dropped and leadership is transferred to another voter so
+// that the demotion may be made by a later round, once the server is no
+// longer the leader. Leadership is also transferred away from a leader whose
+// removal was refused by the last dead server removal round.
+func (a *Autopilot) protectLeader(state *State, changes RaftChanges) RaftChanges {
+	demoted := false
+	for _, id := range changes.Demotions {
+		if id == state.Leader {
+			demoted = true
+			break
+		}
+	}
+
+	if !demoted && !a.leaderHandoff.requested(state.Leader) {
+		return changes
+	}
+
+	if demoted {
+		a.subsystemRoundLogger(SubsystemTransfer).Warn("refusing to demote the current leader, transferring leadership first", "leader", state.Leader)
+		changes.Demotions = withoutServer(changes.Demotions, state.Leader)
+	}
+
+	demotions := make(map[raft.ServerID]struct{})
+	for _, id := range changes.Demotions {
+		demotions[id] = struct{}{}
+	}
+
+	eligible := func(id raft.ServerID) bool {
+		_, demoting := demotions[id]
+		return id != state.Leader && !demoting && leaderIneligibleReason(state, id) == ""
+	}
+
+	// the servers the promoter nominated are preferred with the remaining
+	// voters ordered by how up to date they are
+	var candidates []raft.ServerID
+	for _, id := range leaderCandidates(changes) {
+		if eligible(id) {
+			candidates = append(candidates, id)
+		}
+	}
+	nominated := len(candidates)
+
+	for _, id := range state.Voters {
+		if eligible(id) && !containsServer(candidates[:nominated], id) {
+			candidates = append(candidates, id)
+		}
+	}
+
+	others := candidates[nominated:]
+	sort.SliceStable(others, func(i, j int) bool {
+		oi, oj := state.Servers[others[i]], state.Servers[others[j]]
+		if oi.Stats.LastIndex != oj.Stats.LastIndex {
+			return oi.Stats.LastIndex > oj.Stats.LastIndex
+		}
+		return oi.Server.ID < oj.Server.ID
+	})
+
+	if len(candidates) == 0 {
+		a.subsystemRoundLogger(SubsystemTransfer).Warn("there are no eligible voters to transfer leadership to", "leader", state.Leader)
+		changes.Leader = ""
+		changes.LeaderCandidates = nil
+		return changes
+	}
+
+	changes.Leader = candidates[0]
+	changes.LeaderCandidates = candidates[1:]
+	return changes
+}
+
+// containsServer returns whether the ID is within the list.
+func containsServer(ids []raft.ServerID, id raft.ServerID) bool {
+	for _, other := range ids {
+		if other == id {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutLeader returns the servers to remove without the current leader. When
+// apply is true the refusal is recorded and the next reconciliation round is
+// asked to transfer leadership away from it so that it may be removed later.
+func (a *Autopilot) withoutLeader(state *State, ids []raft.ServerID, apply bool) []raft.ServerID {
+	if state == nil || state.Leader == "" || !containsServer(ids, state.Leader) {
+		return ids
+	}
+
+	a.roundLogger().Warn("refusing to remove the current leader, transferring leadership first", "id", state.Leader)
+	if apply {
+		a.leaderHandoff.request(state.Leader)
+		a.recordDecision(DecisionSkipRemoval, ReasonCurrentLeader, state.Leader, "the current leader is not removed until leadership has been transferred", nil)
+	}
+	return withoutServer(ids, state.Leader)
+}
@@ -0,0 +1,83 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+func leaderProtectionTestState() *State {
+	state := testState([]raft.ServerID{"a", "b", "c", "d"}, "a", "a", "b", "c", "d")
+	state.Servers["b"].Stats.LastIndex = 10
+	state.Servers["c"].Stats.LastIndex = 12
+	state.Servers["d"].Stats.LastIndex = 20
+	state.Servers["d"].Health.Healthy = false
+	return state
+}
+
+func TestProtectLeader(t *testing.T) {
+	state := leaderProtectionTestState()
+	a := &Autopilot{logger: hclog.NewNullLogger()}
+
+	// changes not involving the leader are untouched
+	changes := RaftChanges{Demotions: []raft.ServerID{"b"}}
+	require.Equal(t, changes, a.protectLeader(state, changes))
+
+	// the demotion of the leader is replaced by a transfer to the most up to
+	// date healthy voter which is not being demoted
+	require.Equal(t, RaftChanges{
+		Demotions:        []raft.ServerID{"c"},
+		Leader:           "b",
+		LeaderCandidates: []raft.ServerID{},
+	}, a.protectLeader(state, RaftChanges{Demotions: []raft.ServerID{"a", "c"}}))
+
+	// the promoter's nominations are preferred
+	require.Equal(t, RaftChanges{
+		Leader:           "b",
+		LeaderCandidates: []raft.ServerID{"c"},
+	}, a.protectLeader(state, RaftChanges{Demotions: []raft.ServerID{"a"}, Leader: "a", LeaderCandidates: []raft.ServerID{"d", "b"}}))
+
+	// the leader is kept when no other voter is eligible
+	state.Servers["b"].Health.Healthy = false
+	state.Servers["c"].Health.Healthy = false
+	require.Equal(t, RaftChanges{}, a.protectLeader(state, RaftChanges{Demotions: []raft.ServerID{"a"}, Leader: "c"}))
+}
+
+func TestRemovalOfLeaderTransfersLeadership(t *testing.T) {
+	state := leaderProtectionTestState()
+	a := &Autopilot{logger: hclog.NewNullLogger()}
+
+	// planning does not request a transfer
+	require.Equal(t, []raft.ServerID{"b"}, a.withoutLeader(state, []raft.ServerID{"a", "b"}, false))
+	require.Equal(t, RaftChanges{}, a.protectLeader(state, RaftChanges{}))
+
+	// removing does and the next reconciliation transfers leadership
+	require.Equal(t, []raft.ServerID{"b"}, a.withoutLeader(state, []raft.ServerID{"a", "b"}, true))
+	require.Equal(t, RaftChanges{
+		Leader:           "c",
+		LeaderCandidates: []raft.ServerID{"b"},
+	}, a.protectLeader(state, RaftChanges{}))
+
+	// the request is forgotten once a round records that leadership moved
+	state.Leader = "c"
+	require.Equal(t, RaftChanges{}, a.protectLeader(state, RaftChanges{}))
+	a.recordTrackerUpdates(state, &trackerUpdates{})
+	state.Leader = "a"
+	require.Equal(t, RaftChanges{}, a.protectLeader(state, RaftChanges{}))
+}
+
+func TestApplyDemotionsSkipsLeader(t *testing.T) {
+	state := leaderProtectionTestState()
+	a := &Autopilot{logger: hclog.NewNullLogger()}
+
+	// no raft calls are expected as the only demotion is of the leader
+	done, err := a.applyDemotions(context.Background(), nil, state, RaftChanges{Demotions: []raft.ServerID{"a"}})
+	require.NoError(t, err)
+	require.False(t, done)
+}
@@ -0,0 +1,105 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"sync"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/raft"
+)
+
+var (
+	// timeToPromoteKey is the metric key for the time between autopilot first
+	// seeing a server and that server being promoted to a voter.
+	timeToPromoteKey = []string{"autopilot", "server", "time_to_promote"}
+
+	// timeToRemoveKey is the metric key for the time between autopilot first
+	// seeing a server as failed and that server being removed.
+	timeToRemoveKey = []string{"autopilot", "server", "time_to_remove"}
+)
+
+// serverLifecycle tracks when servers were first seen by autopilot and when they
+// first entered a failed state. These times are used to measure how long it takes
+// autopilot to promote new servers and remove failed ones. Unlike the autopilot
+// State this tracking is retained when autopilot is stopped so that the
+// measurements span leadership changes of the local node.
+type serverLifecycle struct {
+	lock        sync.Mutex
+	firstSeen   map[raft.ServerID]time.Time
+	firstFailed map[raft.ServerID]time.Time
+}
+
+// observe records the first seen and first failed times of all servers in the
+// given state. Servers no longer present in the state are forgotten about.
+func (l *serverLifecycle) observe(now time.Time, s *State) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.firstSeen == nil {
+		l.firstSeen = make(map[raft.ServerID]time.Time)
+	}
+	if l.firstFailed == nil {
+		l.firstFailed = make(map[raft.ServerID]time.Time)
+	}
+
+	for id, srv := range s.Servers {
+		if _, ok := l.firstSeen[id]; !ok {
+			l.firstSeen[id] = now
+		}
+
+		if srv.Server.NodeStatus == NodeAlive {
+			delete(l.firstFailed, id)
+		} else if _, ok := l.firstFailed[id]; !ok {
+			l.firstFailed[id] = now
+		}
+	}
+
+	for id := range l.firstSeen {
+		if _, ok := s.Servers[id]; !ok {
+			delete(l.firstSeen, id)
+			delete(l.firstFailed, id)
+		}
+	}
+}
+
+// failedSince returns the time at which the server was first seen as failed.
+func (l *serverLifecycle) failedSince(id raft.ServerID) (time.Time, bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	t, ok := l.firstFailed[id]
+	return t, ok
+}
+
+// promoted emits the time to promote metric for the given server.
+func (l *serverLifecycle) promoted(sink metrics.MetricSink, id raft.ServerID, now time.Time) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if seen, ok := l.firstSeen[id]; ok {
+		sink.AddSample(timeToPromoteKey, durationMillis(now.Sub(seen)))
+	}
+}
+
+// removed emits the time to remove metric for the given server and stops
+// tracking it.
+func (l *serverLifecycle) removed(sink metrics.MetricSink, id raft.ServerID, now time.Time) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if failed, ok := l.firstFailed[id]; ok {
+		sink.AddSample(timeToRemoveKey, durationMillis(now.Sub(failed)))
+	}
+
+	delete(l.firstSeen, id)
+	delete(l.firstFailed, id)
+}
+
+// durationMillis converts a duration into the floating point milliseconds
+// that go-metrics uses for timing samples.
+func durationMillis(d time.Duration) float32 {
+	return float32(d) / float32(time.Millisecond)
+}
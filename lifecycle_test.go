@@ -0,0 +1,102 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"testing"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+// testMetricsSink installs an in-memory sink as the global go-metrics sink for
+// the duration of the test.
+func testMetricsSink(t *testing.T) *metrics.InmemSink {
+	t.Helper()
+
+	sink := metrics.NewInmemSink(time.Minute, time.Minute)
+	conf := metrics.DefaultConfig("")
+	conf.EnableHostname = false
+	conf.EnableRuntimeMetrics = false
+	_, err := metrics.NewGlobal(conf, sink)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_, _ = metrics.NewGlobal(conf, &metrics.BlackholeSink{})
+	})
+	return sink
+}
+
+func testMetricSample(t *testing.T, sink *metrics.InmemSink, key string) metrics.SampledValue {
+	t.Helper()
+
+	intervals := sink.Data()
+	require.NotEmpty(t, intervals)
+	sample, ok := intervals[0].Samples[key]
+	require.True(t, ok, "sample %q was not emitted", key)
+	return sample
+}
+
+func TestServerLifecycle(t *testing.T) {
+	sink := testMetricsSink(t)
+
+	start := time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)
+
+	var l serverLifecycle
+
+	l.observe(start, &State{
+		Servers: map[raft.ServerID]*ServerState{
+			"a": {Server: Server{ID: "a", NodeStatus: NodeAlive}},
+			"b": {Server: Server{ID: "b", NodeStatus: NodeAlive}},
+		},
+	})
+
+	// b fails and c joins later on
+	l.observe(start.Add(10*time.Second), &State{
+		Servers: map[raft.ServerID]*ServerState{
+			"a": {Server: Server{ID: "a", NodeStatus: NodeAlive}},
+			"b": {Server: Server{ID: "b", NodeStatus: NodeFailed}},
+			"c": {Server: Server{ID: "c", NodeStatus: NodeAlive}},
+		},
+	})
+
+	// observing the failure again must not reset the failure time
+	l.observe(start.Add(20*time.Second), &State{
+		Servers: map[raft.ServerID]*ServerState{
+			"a": {Server: Server{ID: "a", NodeStatus: NodeAlive}},
+			"b": {Server: Server{ID: "b", NodeStatus: NodeFailed}},
+			"c": {Server: Server{ID: "c", NodeStatus: NodeAlive}},
+		},
+	})
+
+	failedSince, ok := l.failedSince("b")
+	require.True(t, ok)
+	require.Equal(t, start.Add(10*time.Second), failedSince)
+	_, ok = l.failedSince("a")
+	require.False(t, ok)
+
+	l.promoted(sink, "c", start.Add(40*time.Second))
+	promote := testMetricSample(t, sink, "autopilot.server.time_to_promote")
+	require.Equal(t, 1, promote.Count)
+	require.InDelta(t, 30000, promote.Max, 0.01)
+
+	l.removed(sink, "b", start.Add(70*time.Second))
+	remove := testMetricSample(t, sink, "autopilot.server.time_to_remove")
+	require.Equal(t, 1, remove.Count)
+	require.InDelta(t, 60000, remove.Max, 0.01)
+
+	_, ok = l.failedSince("b")
+	require.False(t, ok)
+
+	// servers no longer in the state are forgotten about
+	l.observe(start.Add(80*time.Second), &State{
+		Servers: map[raft.ServerID]*ServerState{
+			"a": {Server: Server{ID: "a", NodeStatus: NodeAlive}},
+		},
+	})
+	require.Len(t, l.firstSeen, 1)
+	require.Empty(t, l.firstFailed)
+}
@@ -0,0 +1,130 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hashicorp/raft"
+)
+
+const (
+	// DefaultLockoutThreshold is the suggested number of consecutive failed
+	// operations on a server after which autopilot will stop acting on it for
+	// a while when lockouts are enabled with WithOperationLockout.
+	DefaultLockoutThreshold = 3
+
+	// DefaultLockoutBase is the suggested initial duration of a server
+	// lockout which is doubled for every subsequent failure.
+	DefaultLockoutBase = 30 * time.Second
+
+	// DefaultLockoutMax is the suggested maximum duration of a server lockout.
+	DefaultLockoutMax = 10 * time.Minute
+)
+
+// ServerLockout records the failed operations autopilot has attempted against
+// a server. After too many consecutive failures the server will be locked out
+// and autopilot will not attempt to promote, demote or remove it until the
+// lockout expires. Each further failure doubles the duration of the lockout.
+type ServerLockout struct {
+	// Failures is the number of consecutive failed operations.
+	Failures int
+
+	// Operation is the kind of membership change which most recently failed.
+	Operation MembershipChangeType
+
+	// LastFailure is when the most recent operation failed.
+	LastFailure time.Time
+
+	// Until is the time until which the server is locked out. It is the
+	// zero value when the server has not yet been locked out.
+	Until time.Time
+
+	// Reason is the error of the most recently failed operation.
+	Reason string
+}
+
+// lockoutTracker tracks the ServerLockout of all servers that autopilot has
+// recently failed to perform operations on.
+type lockoutTracker struct {
+	lock sync.Mutex
+
+	// threshold is the number of consecutive failures after which a server
+	// will be locked out. Zero disables lockouts entirely.
+	threshold int
+	base      time.Duration
+	max       time.Duration
+
+	servers map[raft.ServerID]*ServerLockout
+}
+
+// failed records a failed operation for the given server.
+func (t *lockoutTracker) failed(id raft.ServerID, op MembershipChangeType, now time.Time, err error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.threshold < 1 {
+		return
+	}
+
+	if t.servers == nil {
+		t.servers = make(map[raft.ServerID]*ServerLockout)
+	}
+
+	lockout, ok := t.servers[id]
+	if !ok {
+		lockout = &ServerLockout{}
+		t.servers[id] = lockout
+	}
+
+	lockout.Failures++
+	lockout.Operation = op
+	lockout.LastFailure = now
+	lockout.Reason = err.Error()
+
+	if lockout.Failures < t.threshold {
+		return
+	}
+
+	duration := t.base
+	for i := t.threshold; i < lockout.Failures && duration < t.max; i++ {
+		duration *= 2
+	}
+	if duration > t.max {
+		duration = t.max
+	}
+	lockout.Until = now.Add(duration)
+}
+
+// succeeded clears any failures recorded for the given server.
+func (t *lockoutTracker) succeeded(id raft.ServerID) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	delete(t.servers, id)
+}
+
+// isLockedOut returns whether the server is currently locked out.
+func (t *lockoutTracker) isLockedOut(id raft.ServerID, now time.Time) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	lockout, ok := t.servers[id]
+	return ok && now.Before(lockout.Until)
+}
+
+// get returns a copy of the ServerLockout for the given server or nil if
+// there are no recorded failures for it.
+func (t *lockoutTracker) get(id raft.ServerID) *ServerLockout {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	lockout, ok := t.servers[id]
+	if !ok {
+		return nil
+	}
+
+	result := *lockout
+	return &result
+}
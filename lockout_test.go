@@ -0,0 +1,167 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockoutTracker(t *testing.T) {
+	now := time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)
+
+	tracker := lockoutTracker{
+		threshold: 2,
+		base:      10 * time.Second,
+		max:       30 * time.Second,
+	}
+
+	require.Nil(t, tracker.get("a"))
+	require.False(t, tracker.isLockedOut("a", now))
+
+	// below the threshold the failure is recorded without a lockout
+	tracker.failed("a", MembershipChangePromotion, now, injectedErr)
+	require.Equal(t, &ServerLockout{Failures: 1, Operation: MembershipChangePromotion, LastFailure: now, Reason: "injected err"}, tracker.get("a"))
+	require.False(t, tracker.isLockedOut("a", now))
+
+	tracker.failed("a", MembershipChangePromotion, now, injectedErr)
+	require.Equal(t, now.Add(10*time.Second), tracker.get("a").Until)
+	require.True(t, tracker.isLockedOut("a", now))
+	require.False(t, tracker.isLockedOut("a", now.Add(10*time.Second)))
+
+	// further failures double the lockout up to the maximum
+	tracker.failed("a", MembershipChangePromotion, now, injectedErr)
+	require.Equal(t, now.Add(20*time.Second), tracker.get("a").Until)
+	tracker.failed("a", MembershipChangePromotion, now, injectedErr)
+	require.Equal(t, now.Add(30*time.Second), tracker.get("a").Until)
+	tracker.failed("a", MembershipChangePromotion, now, injectedErr)
+	require.Equal(t, now.Add(30*time.Second), tracker.get("a").Until)
+	require.Equal(t, 5, tracker.get("a").Failures)
+
+	tracker.succeeded("a")
+	require.Nil(t, tracker.get("a"))
+	require.False(t, tracker.isLockedOut("a", now))
+
+	// a zero threshold disables tracking
+	var disabled lockoutTracker
+	disabled.failed("a", MembershipChangePromotion, now, injectedErr)
+	require.Nil(t, disabled.get("a"))
+}
+
+func TestApplyPromotionsLockout(t *testing.T) {
+	state := &State{
+		Leader: "a",
+		Servers: map[raft.ServerID]*ServerState{
+			"a": {
+				Server: Server{ID: "a", Address: "198.18.0.1:8300"},
+				State:  RaftLeader,
+				Health: ServerHealth{Healthy: true},
+			},
+			"b": {
+				Server: Server{ID: "b", Address: "198.18.0.2:8300"},
+				State:  RaftNonVoter,
+				Health: ServerHealth{Healthy: true},
+			},
+		},
+	}
+	changes := RaftChanges{Promotions: []raft.ServerID{"b"}}
+
+	mraft := NewMockRaft(t)
+	mraft.On("AddVoter", raft.ServerID("b"), raft.ServerAddress("198.18.0.2:8300"), uint64(0), time.Duration(0)).
+		Return(&raftIndexFuture{err: injectedErr}).
+		Once()
+
+	a := &Autopilot{
+		logger: hclog.NewNullLogger(),
+		raft:   mraft,
+		lockouts: lockoutTracker{
+			threshold: 1,
+			base:      time.Minute,
+			max:       time.Minute,
+		},
+	}
+
+	done, err := a.applyPromotions(context.Background(), state, changes)
+	require.True(t, done)
+	require.Error(t, err)
+
+	lockout := a.lockouts.get("b")
+	require.NotNil(t, lockout)
+	require.Equal(t, 1, lockout.Failures)
+
+	// the locked out server is not attempted again
+	done, err = a.applyPromotions(context.Background(), state, changes)
+	require.False(t, done)
+	require.NoError(t, err)
+}
+
+func TestApplyDemotionsLockout(t *testing.T) {
+	now := time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)
+	state := &State{
+		Leader: "a",
+		Servers: map[raft.ServerID]*ServerState{
+			"a": {
+				Server: Server{ID: "a", Address: "198.18.0.1:8300"},
+				State:  RaftLeader,
+				Health: ServerHealth{Healthy: true},
+			},
+			"b": {
+				Server: Server{ID: "b", Address: "198.18.0.2:8300"},
+				State:  RaftVoter,
+				Health: ServerHealth{Healthy: true},
+			},
+		},
+	}
+	changes := RaftChanges{Demotions: []raft.ServerID{"b"}}
+
+	mtime := NewMockTimeProvider(t)
+	mtime.On("Now").Return(now)
+	mraft := NewMockRaft(t)
+	mraft.On("DemoteVoter", raft.ServerID("b"), uint64(0), time.Duration(0)).
+		Return(&raftIndexFuture{err: injectedErr}).
+		Once()
+
+	a := &Autopilot{
+		logger: hclog.NewNullLogger(),
+		time:   mtime,
+		raft:   mraft,
+		lockouts: lockoutTracker{
+			threshold: 1,
+			base:      time.Minute,
+			max:       time.Minute,
+		},
+	}
+
+	done, err := a.applyDemotions(context.Background(), nil, state, changes)
+	require.True(t, done)
+	require.Error(t, err)
+	require.Equal(t, &ServerLockout{
+		Failures:    1,
+		Operation:   MembershipChangeDemotion,
+		LastFailure: now,
+		Until:       now.Add(time.Minute),
+		Reason:      "injected err",
+	}, a.lockouts.get("b"))
+
+	// the locked out server is not attempted again
+	done, err = a.applyDemotions(context.Background(), nil, state, changes)
+	require.False(t, done)
+	require.NoError(t, err)
+}
+
+func TestOperationLockoutOption(t *testing.T) {
+	// lockouts are disabled unless configured
+	a := New(nil, nil)
+	require.Zero(t, a.lockouts.threshold)
+
+	a = New(nil, nil, WithOperationLockout(DefaultLockoutThreshold, DefaultLockoutBase, DefaultLockoutMax))
+	require.Equal(t, DefaultLockoutThreshold, a.lockouts.threshold)
+	require.Equal(t, DefaultLockoutBase, a.lockouts.base)
+	require.Equal(t, DefaultLockoutMax, a.lockouts.max)
+}
@@ -0,0 +1,86 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"fmt"
+	"sync"
+
+	hclog "github.com/hashicorp/go-hclog"
+)
+
+// Subsystem identifies a part of autopilot which logs with its own named
+// sub-logger so that its level may be adjusted with SetLogLevel.
+type Subsystem string
+
+const (
+	// SubsystemReconcile logs the promotions and demotions of reconcile
+	// rounds along with plans being applied.
+	SubsystemReconcile Subsystem = "reconcile"
+	// SubsystemPrune logs the removal of failed and stale servers.
+	SubsystemPrune Subsystem = "prune"
+	// SubsystemState logs the periodic updates of the autopilot state.
+	SubsystemState Subsystem = "state"
+	// SubsystemPromoter logs failures of the promoter.
+	SubsystemPromoter Subsystem = "promoter"
+	// SubsystemTransfer logs leadership transfers.
+	SubsystemTransfer Subsystem = "transfer"
+)
+
+// subsystems are all the known subsystems.
+var subsystems = map[Subsystem]struct{}{
+	SubsystemReconcile: {},
+	SubsystemPrune:     {},
+	SubsystemState:     {},
+	SubsystemPromoter:  {},
+	SubsystemTransfer:  {},
+}
+
+// subsystemLoggers holds the sub-loggers of each subsystem. They are created
+// on first use from the autopilot logger.
+type subsystemLoggers struct {
+	lock    sync.Mutex
+	loggers map[Subsystem]hclog.Logger
+}
+
+// subsystemLogger returns the named sub-logger of the given subsystem.
+func (a *Autopilot) subsystemLogger(s Subsystem) hclog.Logger {
+	a.subsystemLoggers.lock.Lock()
+	defer a.subsystemLoggers.lock.Unlock()
+
+	if logger, ok := a.subsystemLoggers.loggers[s]; ok {
+		return logger
+	}
+
+	if a.subsystemLoggers.loggers == nil {
+		a.subsystemLoggers.loggers = make(map[Subsystem]hclog.Logger)
+	}
+	logger := a.logger.Named(string(s))
+	a.subsystemLoggers.loggers[s] = logger
+	return logger
+}
+
+// SetLogLevel changes the level of the given subsystem's logger while
+// autopilot is running, such as to enable debug output for pruning during an
+// incident. The levels of the subsystems are only independent of each other
+// and of the autopilot logger when the logger given to WithLogger was created
+// with hclog's IndependentLevels option. Otherwise the level is shared by all
+// the loggers as is usual for hclog sub-loggers.
+func (a *Autopilot) SetLogLevel(s Subsystem, level hclog.Level) error {
+	if _, ok := subsystems[s]; !ok {
+		return fmt.Errorf("unknown subsystem %q", s)
+	}
+
+	a.subsystemLogger(s).SetLevel(level)
+	return nil
+}
+
+// LogLevel returns the current level of the given subsystem's logger.
+func (a *Autopilot) LogLevel(s Subsystem) (hclog.Level, error) {
+	if _, ok := subsystems[s]; !ok {
+		return hclog.NoLevel, fmt.Errorf("unknown subsystem %q", s)
+	}
+
+	return a.subsystemLogger(s).GetLevel(), nil
+}
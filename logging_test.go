@@ -0,0 +1,51 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubsystemLogLevels(t *testing.T) {
+	var buf bytes.Buffer
+	a := &Autopilot{
+		logger: hclog.New(&hclog.LoggerOptions{
+			Name:              "autopilot",
+			Level:             hclog.Info,
+			Output:            &buf,
+			IndependentLevels: true,
+		}),
+	}
+
+	require.Error(t, a.SetLogLevel("unknown", hclog.Debug))
+	require.NoError(t, a.SetLogLevel(SubsystemPrune, hclog.Debug))
+
+	level, err := a.LogLevel(SubsystemPrune)
+	require.NoError(t, err)
+	require.Equal(t, hclog.Debug, level)
+
+	level, err = a.LogLevel(SubsystemState)
+	require.NoError(t, err)
+	require.Equal(t, hclog.Info, level)
+
+	// only the prune subsystem outputs debug logs
+	end := a.beginRound(RoundPrune)
+	a.roundLogger().Debug("pruning")
+	end()
+
+	end = a.beginRound(RoundReconcile)
+	a.roundLogger().Debug("reconciling")
+	end()
+
+	a.subsystemLogger(SubsystemState).Debug("updating")
+
+	out := buf.String()
+	require.Contains(t, out, "autopilot.prune: pruning")
+	require.NotContains(t, out, "reconciling")
+	require.NotContains(t, out, "updating")
+}
@@ -0,0 +1,95 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"time"
+)
+
+// MaintenanceWindow is a recurring period during which autopilot may make
+// disruptive changes to the cluster: demotions, removals and leadership
+// transfers. Promotions and state updates are never restricted.
+type MaintenanceWindow struct {
+	// Start is when the window opens as an offset from midnight.
+	Start time.Duration
+
+	// Duration is how long the window stays open. It may be longer than a day.
+	Duration time.Duration
+
+	// Days are the days of the week on which the window opens. When empty the
+	// window opens every day.
+	Days []time.Weekday
+
+	// Location is the time zone in which Start and Days are interpreted.
+	// Defaults to UTC.
+	Location *time.Location
+}
+
+// Contains returns whether the window is open at the given time.
+func (w *MaintenanceWindow) Contains(t time.Time) bool {
+	if w.Duration <= 0 {
+		return false
+	}
+
+	loc := w.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	t = t.In(loc)
+
+	// the window may have opened on a previous day and still be open
+	lookback := int((w.Start+w.Duration)/(24*time.Hour)) + 1
+	year, month, day := t.Date()
+	for i := 0; i <= lookback; i++ {
+		midnight := time.Date(year, month, day-i, 0, 0, 0, 0, loc)
+		if !w.opensOn(midnight.Weekday()) {
+			continue
+		}
+
+		open := midnight.Add(w.Start)
+		if !t.Before(open) && t.Before(open.Add(w.Duration)) {
+			return true
+		}
+	}
+	return false
+}
+
+// opensOn returns whether the window opens on the given day of the week.
+func (w *MaintenanceWindow) opensOn(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, d := range w.Days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// WithMaintenanceWindows returns an option to confine demotions, removals and
+// leadership transfers to the given windows. Disruptive changes are permitted
+// whenever any of the windows is open. When no windows are given, which is the
+// default, disruptive changes may be made at any time.
+func WithMaintenanceWindows(windows ...MaintenanceWindow) Option {
+	return func(a *Autopilot) {
+		a.maintenanceWindows = append(a.maintenanceWindows, windows...)
+	}
+}
+
+// inMaintenanceWindow returns whether disruptive changes may currently be
+// made. It is always true when no maintenance windows are configured.
+func (a *Autopilot) inMaintenanceWindow() bool {
+	if len(a.maintenanceWindows) == 0 {
+		return true
+	}
+
+	now := a.now()
+	for i := range a.maintenanceWindows {
+		if a.maintenanceWindows[i].Contains(now) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintenanceWindowContains(t *testing.T) {
+	est, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	// 2020-11-02 is a Monday
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2020, 11, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	type testCase struct {
+		window   MaintenanceWindow
+		time     time.Time
+		contains bool
+	}
+
+	cases := map[string]testCase{
+		"within": {
+			window:   MaintenanceWindow{Start: 2 * time.Hour, Duration: time.Hour},
+			time:     at(2, 2, 30),
+			contains: true,
+		},
+		"before": {
+			window: MaintenanceWindow{Start: 2 * time.Hour, Duration: time.Hour},
+			time:   at(2, 1, 59),
+		},
+		"end-is-exclusive": {
+			window: MaintenanceWindow{Start: 2 * time.Hour, Duration: time.Hour},
+			time:   at(2, 3, 0),
+		},
+		"spans-midnight": {
+			window:   MaintenanceWindow{Start: 23 * time.Hour, Duration: 2 * time.Hour},
+			time:     at(3, 0, 30),
+			contains: true,
+		},
+		"opened-on-the-previous-day": {
+			window:   MaintenanceWindow{Start: 23 * time.Hour, Duration: 2 * time.Hour, Days: []time.Weekday{time.Monday}},
+			time:     at(3, 0, 30),
+			contains: true,
+		},
+		"wrong-day": {
+			window: MaintenanceWindow{Start: 2 * time.Hour, Duration: time.Hour, Days: []time.Weekday{time.Saturday, time.Sunday}},
+			time:   at(2, 2, 30),
+		},
+		"multiple-days": {
+			window:   MaintenanceWindow{Duration: 48 * time.Hour, Days: []time.Weekday{time.Saturday}},
+			time:     at(1, 12, 0),
+			contains: true,
+		},
+		"location": {
+			window:   MaintenanceWindow{Start: 2 * time.Hour, Duration: time.Hour, Location: est},
+			time:     at(2, 7, 30),
+			contains: true,
+		},
+		"empty": {
+			window: MaintenanceWindow{},
+			time:   at(2, 0, 0),
+		},
+	}
+
+	for name, tcase := range cases {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tcase.contains, tcase.window.Contains(tcase.time))
+		})
+	}
+}
+
+func TestMaintenanceWindowHoldsDisruptiveChanges(t *testing.T) {
+	now := time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)
+	mtime := NewMockTimeProvider(t)
+	mtime.On("Now").Return(now)
+
+	state := planTestState()
+	conf := &Config{}
+
+	mpromoter := NewMockPromoter(t)
+	mpromoter.On("CalculatePromotionsAndDemotions", conf, state).Return(RaftChanges{
+		Promotions: []raft.ServerID{"c"},
+		Demotions:  []raft.ServerID{"b"},
+		Leader:     "b",
+	})
+
+	a := &Autopilot{
+		logger:             hclog.NewNullLogger(),
+		time:               mtime,
+		promoter:           mpromoter,
+		maintenanceWindows: []MaintenanceWindow{{Start: 2 * time.Hour, Duration: time.Hour}},
+	}
+
+	// outside of the window only the promotions remain
+	changes, _, err := a.reconcileChanges(conf, state)
+	require.NoError(t, err)
+	require.Equal(t, RaftChanges{Promotions: []raft.ServerID{"c"}}, changes)
+
+	removals, err := a.processRemovals(context.Background(), conf, state, true)
+	require.NoError(t, err)
+	require.Empty(t, removals)
+
+	// within the window everything is permitted
+	a.maintenanceWindows = append(a.maintenanceWindows, MaintenanceWindow{Start: 12 * time.Hour, Duration: time.Minute})
+	changes, _, err = a.reconcileChanges(conf, state)
+	require.NoError(t, err)
+	require.Equal(t, RaftChanges{
+		Promotions: []raft.ServerID{"c"},
+		Demotions:  []raft.ServerID{"b"},
+		Leader:     "b",
+	}, changes)
+}
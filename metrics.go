@@ -0,0 +1,120 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+)
+
+var (
+	// reconcileKey is the metric key for how long each reconcile round takes.
+	reconcileKey = []string{"autopilot", "reconcile"}
+
+	// promotionsKey is the metric key incremented for every server promoted.
+	promotionsKey = []string{"autopilot", "promotions"}
+
+	// demotionsKey is the metric key incremented for every server demoted.
+	demotionsKey = []string{"autopilot", "demotions"}
+
+	// removalsKey is the metric key incremented for every server removed.
+	removalsKey = []string{"autopilot", "removals"}
+
+	// healthyKey is the metric key for whether the cluster is healthy.
+	healthyKey = []string{"autopilot", "healthy"}
+
+	// failureToleranceKey is the metric key for the number of voters which
+	// could fail without losing quorum.
+	failureToleranceKey = []string{"autopilot", "failure_tolerance"}
+
+	// optimisticFailureToleranceKey is the metric key for the number of
+	// voters which could fail if promotable non-voters replaced them.
+	optimisticFailureToleranceKey = []string{"autopilot", "optimistic_failure_tolerance"}
+
+	// healthyVotersKey is the metric key for the number of healthy voters.
+	healthyVotersKey = []string{"autopilot", "healthy_voters"}
+
+	// serverHealthyKey is the metric key for whether an individual server is
+	// healthy. It is labeled with the server's ID and Raft state.
+	serverHealthyKey = []string{"autopilot", "server", "healthy"}
+
+	// roundWallTimeKey is the metric key for how long each round takes. The
+	// round metrics are labeled with the kind of round.
+	roundWallTimeKey = []string{"autopilot", "round", "wall_time"}
+
+	// roundCPUTimeKey is the metric key for the CPU time consumed by the
+	// process during each round.
+	roundCPUTimeKey = []string{"autopilot", "round", "cpu_time"}
+
+	// roundAllocBytesKey is the metric key for the bytes allocated by the
+	// process during each round.
+	roundAllocBytesKey = []string{"autopilot", "round", "alloc_bytes"}
+
+	// roundAllocationsKey is the metric key for the heap objects allocated by
+	// the process during each round.
+	roundAllocationsKey = []string{"autopilot", "round", "allocations"}
+
+	// roundDelegateCallsKey is the metric key incremented for every call made
+	// to the delegate during a round. It is also labeled with the method.
+	roundDelegateCallsKey = []string{"autopilot", "round", "delegate_calls"}
+
+	// changesDeferredKey is the metric key incremented for every membership
+	// change deferred by the change budget. It is labeled with the kind of
+	// change.
+	changesDeferredKey = []string{"autopilot", "changes_deferred"}
+)
+
+// metricsSink returns the sink autopilot should emit metrics to. This is the
+// sink given with the WithMetricsSink option or the global go-metrics
+// instance otherwise.
+func (a *Autopilot) metricsSink() metrics.MetricSink {
+	if a.metricSink != nil {
+		return a.metricSink
+	}
+	return metrics.Default()
+}
+
+// serverLabels returns the metric labels identifying a server.
+func serverLabels(srv *ServerState) []metrics.Label {
+	return []metrics.Label{
+		{Name: "id", Value: string(srv.Server.ID)},
+		{Name: "state", Value: string(srv.State)},
+	}
+}
+
+// boolGauge converts a bool into the value of a gauge.
+func boolGauge(b bool) float32 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// emitStateMetrics sets the gauges describing the given state.
+func (a *Autopilot) emitStateMetrics(s *State) {
+	sink := a.metricsSink()
+
+	healthyVoters := 0
+	for _, id := range s.Voters {
+		if srv, ok := s.Servers[id]; ok && srv.Health.Healthy {
+			healthyVoters++
+		}
+	}
+
+	sink.SetGauge(healthyKey, boolGauge(s.Healthy))
+	sink.SetGauge(failureToleranceKey, float32(s.FailureTolerance))
+	sink.SetGauge(optimisticFailureToleranceKey, float32(s.OptimisticFailureTolerance))
+	sink.SetGauge(healthyVotersKey, float32(healthyVoters))
+
+	for _, srv := range s.Servers {
+		sink.SetGaugeWithLabels(serverHealthyKey, boolGauge(srv.Health.Healthy), serverLabels(srv))
+	}
+}
+
+// measureReconcile emits how long the reconcile round which started at the
+// given time took.
+func (a *Autopilot) measureReconcile(start time.Time) {
+	a.metricsSink().AddSample(reconcileKey, durationMillis(time.Since(start)))
+}
@@ -0,0 +1,47 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"testing"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmitStateMetrics(t *testing.T) {
+	sink := metrics.NewInmemSink(time.Minute, time.Minute)
+	a := &Autopilot{}
+	WithMetricsSink(sink)(a)
+
+	a.emitStateMetrics(&State{
+		Healthy:                    false,
+		FailureTolerance:           0,
+		OptimisticFailureTolerance: 1,
+		Voters:                     []raft.ServerID{"a", "b", "c"},
+		Servers: map[raft.ServerID]*ServerState{
+			"a": {Server: Server{ID: "a"}, State: RaftLeader, Health: ServerHealth{Healthy: true}},
+			"b": {Server: Server{ID: "b"}, State: RaftVoter, Health: ServerHealth{Healthy: true}},
+			"c": {Server: Server{ID: "c"}, State: RaftVoter},
+			"d": {Server: Server{ID: "d"}, State: RaftNonVoter, Health: ServerHealth{Healthy: true}},
+		},
+	})
+	a.measureReconcile(time.Now())
+
+	intervals := sink.Data()
+	require.NotEmpty(t, intervals)
+	gauges := intervals[0].Gauges
+
+	require.Equal(t, float32(0), gauges["autopilot.healthy"].Value)
+	require.Equal(t, float32(0), gauges["autopilot.failure_tolerance"].Value)
+	require.Equal(t, float32(1), gauges["autopilot.optimistic_failure_tolerance"].Value)
+	require.Equal(t, float32(2), gauges["autopilot.healthy_voters"].Value)
+	require.Equal(t, float32(1), gauges["autopilot.server.healthy;id=a;state=leader"].Value)
+	require.Equal(t, float32(0), gauges["autopilot.server.healthy;id=c;state=voter"].Value)
+	require.Equal(t, float32(1), gauges["autopilot.server.healthy;id=d;state=non-voter"].Value)
+
+	require.Equal(t, 1, intervals[0].Samples["autopilot.reconcile"].Count)
+}
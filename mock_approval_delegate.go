@@ -0,0 +1,67 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilot
+
+import mock "github.com/stretchr/testify/mock"
+
+// MockApprovalDelegate is an autogenerated mock type for the ApprovalDelegate type
+type MockApprovalDelegate struct {
+	mock.Mock
+}
+
+// ApproveDemotion provides a mock function with given fields: _a0
+func (_m *MockApprovalDelegate) ApproveDemotion(_a0 *Server) bool {
+	ret := _m.Called(_a0)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(*Server) bool); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// ApproveLeadershipTransfer provides a mock function with given fields: _a0
+func (_m *MockApprovalDelegate) ApproveLeadershipTransfer(_a0 *Server) bool {
+	ret := _m.Called(_a0)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(*Server) bool); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// ApproveRemoval provides a mock function with given fields: _a0
+func (_m *MockApprovalDelegate) ApproveRemoval(_a0 *Server) bool {
+	ret := _m.Called(_a0)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(*Server) bool); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewMockApprovalDelegate interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewMockApprovalDelegate creates a new instance of MockApprovalDelegate. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewMockApprovalDelegate(t mockConstructorTestingTNewMockApprovalDelegate) *MockApprovalDelegate {
+	mock := &MockApprovalDelegate{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
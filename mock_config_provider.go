@@ -0,0 +1,41 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilot
+
+import mock "github.com/stretchr/testify/mock"
+
+// MockConfigProvider is an autogenerated mock type for the ConfigProvider type
+type MockConfigProvider struct {
+	mock.Mock
+}
+
+// AutopilotConfig provides a mock function with given fields:
+func (_m *MockConfigProvider) AutopilotConfig() *Config {
+	ret := _m.Called()
+
+	var r0 *Config
+	if rf, ok := ret.Get(0).(func() *Config); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*Config)
+		}
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewMockConfigProvider interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewMockConfigProvider creates a new instance of MockConfigProvider. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewMockConfigProvider(t mockConstructorTestingTNewMockConfigProvider) *MockConfigProvider {
+	mock := &MockConfigProvider{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
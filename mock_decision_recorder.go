@@ -0,0 +1,30 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilot
+
+import mock "github.com/stretchr/testify/mock"
+
+// MockDecisionRecorder is an autogenerated mock type for the DecisionRecorder type
+type MockDecisionRecorder struct {
+	mock.Mock
+}
+
+// RecordDecision provides a mock function with given fields: _a0
+func (_m *MockDecisionRecorder) RecordDecision(_a0 Decision) {
+	_m.Called(_a0)
+}
+
+type mockConstructorTestingTNewMockDecisionRecorder interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewMockDecisionRecorder creates a new instance of MockDecisionRecorder. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewMockDecisionRecorder(t mockConstructorTestingTNewMockDecisionRecorder) *MockDecisionRecorder {
+	mock := &MockDecisionRecorder{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,60 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilot
+
+import (
+	context "context"
+
+	raft "github.com/hashicorp/raft"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockEnricher is an autogenerated mock type for the Enricher type
+type MockEnricher struct {
+	mock.Mock
+}
+
+// Enrich provides a mock function with given fields: ctx, servers
+func (_m *MockEnricher) Enrich(ctx context.Context, servers map[raft.ServerID]*Server) map[raft.ServerID]interface{} {
+	ret := _m.Called(ctx, servers)
+
+	var r0 map[raft.ServerID]interface{}
+	if rf, ok := ret.Get(0).(func(context.Context, map[raft.ServerID]*Server) map[raft.ServerID]interface{}); ok {
+		r0 = rf(ctx, servers)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[raft.ServerID]interface{})
+		}
+	}
+
+	return r0
+}
+
+// Name provides a mock function with given fields:
+func (_m *MockEnricher) Name() string {
+	ret := _m.Called()
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewMockEnricher interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewMockEnricher creates a new instance of MockEnricher. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewMockEnricher(t mockConstructorTestingTNewMockEnricher) *MockEnricher {
+	mock := &MockEnricher{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,30 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilot
+
+import mock "github.com/stretchr/testify/mock"
+
+// MockEventHandler is an autogenerated mock type for the EventHandler type
+type MockEventHandler struct {
+	mock.Mock
+}
+
+// Execute provides a mock function with given fields: _a0
+func (_m *MockEventHandler) Execute(_a0 Event) {
+	_m.Called(_a0)
+}
+
+type mockConstructorTestingTNewMockEventHandler interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewMockEventHandler creates a new instance of MockEventHandler. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewMockEventHandler(t mockConstructorTestingTNewMockEventHandler) *MockEventHandler {
+	mock := &MockEventHandler{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,30 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilot
+
+import mock "github.com/stretchr/testify/mock"
+
+// MockExtMigrator is an autogenerated mock type for the ExtMigrator type
+type MockExtMigrator struct {
+	mock.Mock
+}
+
+// MigrateExt provides a mock function with given fields: previous, conf, state
+func (_m *MockExtMigrator) MigrateExt(previous Promoter, conf *Config, state *State) {
+	_m.Called(previous, conf, state)
+}
+
+type mockConstructorTestingTNewMockExtMigrator interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewMockExtMigrator creates a new instance of MockExtMigrator. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewMockExtMigrator(t mockConstructorTestingTNewMockExtMigrator) *MockExtMigrator {
+	mock := &MockExtMigrator{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,30 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilot
+
+import mock "github.com/stretchr/testify/mock"
+
+// MockFailedServerRemover is an autogenerated mock type for the FailedServerRemover type
+type MockFailedServerRemover struct {
+	mock.Mock
+}
+
+// RemoveFailedServer provides a mock function with given fields: _a0
+func (_m *MockFailedServerRemover) RemoveFailedServer(_a0 *Server) {
+	_m.Called(_a0)
+}
+
+type mockConstructorTestingTNewMockFailedServerRemover interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewMockFailedServerRemover creates a new instance of MockFailedServerRemover. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewMockFailedServerRemover(t mockConstructorTestingTNewMockFailedServerRemover) *MockFailedServerRemover {
+	mock := &MockFailedServerRemover{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,39 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilot
+
+import mock "github.com/stretchr/testify/mock"
+
+// MockFirstActionConfirmer is an autogenerated mock type for the FirstActionConfirmer type
+type MockFirstActionConfirmer struct {
+	mock.Mock
+}
+
+// ConfirmFirstAction provides a mock function with given fields:
+func (_m *MockFirstActionConfirmer) ConfirmFirstAction() bool {
+	ret := _m.Called()
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func() bool); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewMockFirstActionConfirmer interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewMockFirstActionConfirmer creates a new instance of MockFirstActionConfirmer. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewMockFirstActionConfirmer(t mockConstructorTestingTNewMockFirstActionConfirmer) *MockFirstActionConfirmer {
+	mock := &MockFirstActionConfirmer{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
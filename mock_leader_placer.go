@@ -0,0 +1,44 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilot
+
+import (
+	raft "github.com/hashicorp/raft"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockLeaderPlacer is an autogenerated mock type for the LeaderPlacer type
+type MockLeaderPlacer struct {
+	mock.Mock
+}
+
+// PreferredLeaders provides a mock function with given fields: _a0, _a1
+func (_m *MockLeaderPlacer) PreferredLeaders(_a0 *Config, _a1 *State) []raft.ServerID {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []raft.ServerID
+	if rf, ok := ret.Get(0).(func(*Config, *State) []raft.ServerID); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]raft.ServerID)
+		}
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewMockLeaderPlacer interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewMockLeaderPlacer creates a new instance of MockLeaderPlacer. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewMockLeaderPlacer(t mockConstructorTestingTNewMockLeaderPlacer) *MockLeaderPlacer {
+	mock := &MockLeaderPlacer{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
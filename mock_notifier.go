@@ -0,0 +1,30 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilot
+
+import mock "github.com/stretchr/testify/mock"
+
+// MockNotifier is an autogenerated mock type for the Notifier type
+type MockNotifier struct {
+	mock.Mock
+}
+
+// NotifyMembershipChange provides a mock function with given fields: _a0
+func (_m *MockNotifier) NotifyMembershipChange(_a0 MembershipChange) {
+	_m.Called(_a0)
+}
+
+type mockConstructorTestingTNewMockNotifier interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewMockNotifier creates a new instance of MockNotifier. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewMockNotifier(t mockConstructorTestingTNewMockNotifier) *MockNotifier {
+	mock := &MockNotifier{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,44 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilot
+
+import mock "github.com/stretchr/testify/mock"
+
+// MockOperationPersister is an autogenerated mock type for the OperationPersister type
+type MockOperationPersister struct {
+	mock.Mock
+}
+
+// LoadOperations provides a mock function with given fields:
+func (_m *MockOperationPersister) LoadOperations() PersistedOperations {
+	ret := _m.Called()
+
+	var r0 PersistedOperations
+	if rf, ok := ret.Get(0).(func() PersistedOperations); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(PersistedOperations)
+	}
+
+	return r0
+}
+
+// PersistOperations provides a mock function with given fields: _a0
+func (_m *MockOperationPersister) PersistOperations(_a0 PersistedOperations) {
+	_m.Called(_a0)
+}
+
+type mockConstructorTestingTNewMockOperationPersister interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewMockOperationPersister creates a new instance of MockOperationPersister. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewMockOperationPersister(t mockConstructorTestingTNewMockOperationPersister) *MockOperationPersister {
+	mock := &MockOperationPersister{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,53 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilot
+
+import mock "github.com/stretchr/testify/mock"
+
+// MockPromoterDescriber is an autogenerated mock type for the PromoterDescriber type
+type MockPromoterDescriber struct {
+	mock.Mock
+}
+
+// Describe provides a mock function with given fields:
+func (_m *MockPromoterDescriber) Describe() string {
+	ret := _m.Called()
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+// Name provides a mock function with given fields:
+func (_m *MockPromoterDescriber) Name() string {
+	ret := _m.Called()
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewMockPromoterDescriber interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewMockPromoterDescriber creates a new instance of MockPromoterDescriber. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewMockPromoterDescriber(t mockConstructorTestingTNewMockPromoterDescriber) *MockPromoterDescriber {
+	mock := &MockPromoterDescriber{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,30 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilot
+
+import mock "github.com/stretchr/testify/mock"
+
+// MockRoundAwareRemover is an autogenerated mock type for the RoundAwareRemover type
+type MockRoundAwareRemover struct {
+	mock.Mock
+}
+
+// RemoveFailedServerInRound provides a mock function with given fields: round, srv
+func (_m *MockRoundAwareRemover) RemoveFailedServerInRound(round string, srv *Server) {
+	_m.Called(round, srv)
+}
+
+type mockConstructorTestingTNewMockRoundAwareRemover interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewMockRoundAwareRemover creates a new instance of MockRoundAwareRemover. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewMockRoundAwareRemover(t mockConstructorTestingTNewMockRoundAwareRemover) *MockRoundAwareRemover {
+	mock := &MockRoundAwareRemover{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,44 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilot
+
+import (
+	raft "github.com/hashicorp/raft"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockServerSource is an autogenerated mock type for the ServerSource type
+type MockServerSource struct {
+	mock.Mock
+}
+
+// KnownServers provides a mock function with given fields:
+func (_m *MockServerSource) KnownServers() map[raft.ServerID]*Server {
+	ret := _m.Called()
+
+	var r0 map[raft.ServerID]*Server
+	if rf, ok := ret.Get(0).(func() map[raft.ServerID]*Server); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[raft.ServerID]*Server)
+		}
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewMockServerSource interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewMockServerSource creates a new instance of MockServerSource. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewMockServerSource(t mockConstructorTestingTNewMockServerSource) *MockServerSource {
+	mock := &MockServerSource{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,46 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilot
+
+import (
+	context "context"
+
+	raft "github.com/hashicorp/raft"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockServerStatsFetcher is an autogenerated mock type for the ServerStatsFetcher type
+type MockServerStatsFetcher struct {
+	mock.Mock
+}
+
+// FetchServerStats provides a mock function with given fields: _a0, _a1
+func (_m *MockServerStatsFetcher) FetchServerStats(_a0 context.Context, _a1 map[raft.ServerID]*Server) map[raft.ServerID]*ServerStats {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 map[raft.ServerID]*ServerStats
+	if rf, ok := ret.Get(0).(func(context.Context, map[raft.ServerID]*Server) map[raft.ServerID]*ServerStats); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[raft.ServerID]*ServerStats)
+		}
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewMockServerStatsFetcher interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewMockServerStatsFetcher creates a new instance of MockServerStatsFetcher. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewMockServerStatsFetcher(t mockConstructorTestingTNewMockServerStatsFetcher) *MockServerStatsFetcher {
+	mock := &MockServerStatsFetcher{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
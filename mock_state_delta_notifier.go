@@ -0,0 +1,30 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilot
+
+import mock "github.com/stretchr/testify/mock"
+
+// MockStateDeltaNotifier is an autogenerated mock type for the StateDeltaNotifier type
+type MockStateDeltaNotifier struct {
+	mock.Mock
+}
+
+// NotifyStateDelta provides a mock function with given fields: _a0
+func (_m *MockStateDeltaNotifier) NotifyStateDelta(_a0 *StateDelta) {
+	_m.Called(_a0)
+}
+
+type mockConstructorTestingTNewMockStateDeltaNotifier interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewMockStateDeltaNotifier creates a new instance of MockStateDeltaNotifier. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewMockStateDeltaNotifier(t mockConstructorTestingTNewMockStateDeltaNotifier) *MockStateDeltaNotifier {
+	mock := &MockStateDeltaNotifier{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
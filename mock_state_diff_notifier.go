@@ -0,0 +1,30 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilot
+
+import mock "github.com/stretchr/testify/mock"
+
+// MockStateDiffNotifier is an autogenerated mock type for the StateDiffNotifier type
+type MockStateDiffNotifier struct {
+	mock.Mock
+}
+
+// NotifyStateDiff provides a mock function with given fields: _a0
+func (_m *MockStateDiffNotifier) NotifyStateDiff(_a0 *StateDiff) {
+	_m.Called(_a0)
+}
+
+type mockConstructorTestingTNewMockStateDiffNotifier interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewMockStateDiffNotifier creates a new instance of MockStateDiffNotifier. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewMockStateDiffNotifier(t mockConstructorTestingTNewMockStateDiffNotifier) *MockStateDiffNotifier {
+	mock := &MockStateDiffNotifier{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,30 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilot
+
+import mock "github.com/stretchr/testify/mock"
+
+// MockStateObserver is an autogenerated mock type for the StateObserver type
+type MockStateObserver struct {
+	mock.Mock
+}
+
+// NotifyState provides a mock function with given fields: _a0
+func (_m *MockStateObserver) NotifyState(_a0 *State) {
+	_m.Called(_a0)
+}
+
+type mockConstructorTestingTNewMockStateObserver interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewMockStateObserver creates a new instance of MockStateObserver. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewMockStateObserver(t mockConstructorTestingTNewMockStateObserver) *MockStateObserver {
+	mock := &MockStateObserver{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
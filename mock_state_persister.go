@@ -0,0 +1,49 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilot
+
+import (
+	raft "github.com/hashicorp/raft"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockStatePersister is an autogenerated mock type for the StatePersister type
+type MockStatePersister struct {
+	mock.Mock
+}
+
+// LoadServerHealth provides a mock function with given fields:
+func (_m *MockStatePersister) LoadServerHealth() map[raft.ServerID]PersistedServerHealth {
+	ret := _m.Called()
+
+	var r0 map[raft.ServerID]PersistedServerHealth
+	if rf, ok := ret.Get(0).(func() map[raft.ServerID]PersistedServerHealth); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[raft.ServerID]PersistedServerHealth)
+		}
+	}
+
+	return r0
+}
+
+// PersistServerHealth provides a mock function with given fields: _a0
+func (_m *MockStatePersister) PersistServerHealth(_a0 map[raft.ServerID]PersistedServerHealth) {
+	_m.Called(_a0)
+}
+
+type mockConstructorTestingTNewMockStatePersister interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewMockStatePersister creates a new instance of MockStatePersister. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewMockStatePersister(t mockConstructorTestingTNewMockStatePersister) *MockStatePersister {
+	mock := &MockStatePersister{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
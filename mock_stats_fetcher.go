@@ -0,0 +1,52 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilot
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockStatsFetcher is an autogenerated mock type for the StatsFetcher type
+type MockStatsFetcher struct {
+	mock.Mock
+}
+
+// FetchStats provides a mock function with given fields: ctx, srv
+func (_m *MockStatsFetcher) FetchStats(ctx context.Context, srv *Server) (*ServerStats, error) {
+	ret := _m.Called(ctx, srv)
+
+	var r0 *ServerStats
+	if rf, ok := ret.Get(0).(func(context.Context, *Server) *ServerStats); ok {
+		r0 = rf(ctx, srv)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*ServerStats)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *Server) error); ok {
+		r1 = rf(ctx, srv)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+type mockConstructorTestingTNewMockStatsFetcher interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewMockStatsFetcher creates a new instance of MockStatsFetcher. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewMockStatsFetcher(t mockConstructorTestingTNewMockStatsFetcher) *MockStatsFetcher {
+	mock := &MockStatsFetcher{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,39 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package autopilot
+
+import mock "github.com/stretchr/testify/mock"
+
+// MockUpgradeMigrator is an autogenerated mock type for the UpgradeMigrator type
+type MockUpgradeMigrator struct {
+	mock.Mock
+}
+
+// UpgradeMigrationInProgress provides a mock function with given fields: conf, state
+func (_m *MockUpgradeMigrator) UpgradeMigrationInProgress(conf *Config, state *State) bool {
+	ret := _m.Called(conf, state)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(*Config, *State) bool); ok {
+		r0 = rf(conf, state)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewMockUpgradeMigrator interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewMockUpgradeMigrator creates a new instance of MockUpgradeMigrator. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewMockUpgradeMigrator(t mockConstructorTestingTNewMockUpgradeMigrator) *MockUpgradeMigrator {
+	mock := &MockUpgradeMigrator{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,86 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"time"
+
+	"github.com/hashicorp/raft"
+)
+
+// MembershipChangeType is the kind of change made to the Raft membership.
+type MembershipChangeType string
+
+const (
+	// MembershipChangePromotion is a server being promoted to a voter.
+	MembershipChangePromotion MembershipChangeType = "promotion"
+
+	// MembershipChangeDemotion is a voter being demoted to a non-voter.
+	MembershipChangeDemotion MembershipChangeType = "demotion"
+
+	// MembershipChangeRemoval is a stale or failed server being removed.
+	MembershipChangeRemoval MembershipChangeType = "removal"
+
+	// MembershipChangeLeadershipTransfer is leadership being transferred to
+	// another server.
+	MembershipChangeLeadershipTransfer MembershipChangeType = "leadership-transfer"
+)
+
+// MembershipChange describes a change autopilot made to the Raft membership.
+type MembershipChange struct {
+	// Type is the kind of change.
+	Type MembershipChangeType
+
+	// Time is when the change was made according to autopilot's
+	// TimeProvider.
+	Time time.Time
+
+	// Round is the ID of the round during which the change was made.
+	Round string
+
+	// Server is the server which was changed. For leadership transfers this
+	// is the new leader. Only the ID is set for removed servers which were
+	// not part of the autopilot state.
+	Server Server
+
+	// PreviousLeader is the server leadership was transferred away from. It
+	// is only set for leadership transfers.
+	PreviousLeader raft.ServerID
+
+	// Message is a human readable description of the change.
+	Message string
+}
+
+// Notifier is notified of every change autopilot makes to the Raft membership
+// with a structured description of it, such as to send alerts to a chat
+// service or webhook. Notifiers are called synchronously from autopilot's go
+// routines and therefore should hand off any slow work rather than block.
+type Notifier interface {
+	NotifyMembershipChange(MembershipChange)
+}
+
+// notifyMembershipChange passes the change to all the registered notifiers.
+func (a *Autopilot) notifyMembershipChange(typ MembershipChangeType, srv *Server, previousLeader raft.ServerID, message string) {
+	collecting := a.collectingChanges()
+	if len(a.notifiers) == 0 && !collecting {
+		return
+	}
+
+	change := MembershipChange{
+		Type:           typ,
+		Time:           a.now(),
+		Round:          a.currentRound(),
+		Server:         *srv,
+		PreviousLeader: previousLeader,
+		Message:        message,
+	}
+
+	if collecting {
+		a.collectChange(change)
+	}
+
+	for _, notifier := range a.notifiers {
+		notifier.NotifyMembershipChange(change)
+	}
+}
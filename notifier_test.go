@@ -0,0 +1,77 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifyMembershipChanges(t *testing.T) {
+	now := time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)
+
+	state := &State{
+		Leader: "a",
+		Servers: map[raft.ServerID]*ServerState{
+			"a": {Server: Server{ID: "a", Address: "198.18.0.1:8300"}, State: RaftLeader, Health: ServerHealth{Healthy: true}},
+			"b": {Server: Server{ID: "b", Address: "198.18.0.2:8300"}, State: RaftNonVoter, Health: ServerHealth{Healthy: true}},
+			"c": {Server: Server{ID: "c", Address: "198.18.0.3:8300"}, State: RaftVoter, Health: ServerHealth{Healthy: true}},
+		},
+	}
+
+	mtime := NewMockTimeProvider(t)
+	mtime.On("Now").Return(now)
+	mraft := NewMockRaft(t)
+	mdel := NewMockApplicationIntegration(t)
+	mnotifier := NewMockNotifier(t)
+
+	a := &Autopilot{
+		logger:   hclog.NewNullLogger(),
+		time:     mtime,
+		raft:     mraft,
+		delegate: mdel,
+	}
+	WithNotifier(mnotifier)(a)
+
+	mraft.On("AddVoter", raft.ServerID("b"), raft.ServerAddress("198.18.0.2:8300"), uint64(0), time.Duration(0)).
+		Return(&raftIndexFuture{}).Once()
+	mnotifier.On("NotifyMembershipChange", MembershipChange{
+		Type:    MembershipChangePromotion,
+		Time:    now,
+		Server:  state.Servers["b"].Server,
+		Message: "promoted to a voter",
+	}).Once()
+
+	done, err := a.applyPromotions(context.Background(), state, RaftChanges{Promotions: []raft.ServerID{"b"}})
+	require.True(t, done)
+	require.NoError(t, err)
+
+	mraft.On("LeadershipTransferToServer", raft.ServerID("c"), raft.ServerAddress("198.18.0.3:8300")).
+		Return(&raftIndexFuture{}).Once()
+	mnotifier.On("NotifyMembershipChange", MembershipChange{
+		Type:           MembershipChangeLeadershipTransfer,
+		Time:           now,
+		Server:         state.Servers["c"].Server,
+		PreviousLeader: "a",
+		Message:        "transferring leadership to c as it was the preferred candidate",
+	}).Once()
+
+	require.NoError(t, a.applyLeadershipTransfer(context.Background(), state, RaftChanges{Leader: "c"}))
+
+	failed := &Server{ID: "d", NodeStatus: NodeFailed}
+	mdel.On("RemoveFailedServer", failed).Once()
+	mnotifier.On("NotifyMembershipChange", MembershipChange{
+		Type:    MembershipChangeRemoval,
+		Time:    now,
+		Server:  *failed,
+		Message: `removed as its node status is "failed"`,
+	}).Once()
+
+	a.removeFailedServers([]*Server{failed})
+}
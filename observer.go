@@ -0,0 +1,56 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"context"
+
+	"github.com/hashicorp/raft"
+)
+
+// WithRaftObservations returns an option to react to Raft observations as
+// they happen rather than waiting for the next update and reconcile intervals.
+// The channel should be that of a raft.Observer registered by the application,
+// ideally with a non-blocking observer so that a busy autopilot never stalls
+// Raft. Leader changes, peer changes and failed or resumed heartbeats cause the
+// state to be updated immediately, followed by a reconciliation round. Other
+// observations are ignored.
+func WithRaftObservations(ch <-chan raft.Observation) Option {
+	return func(a *Autopilot) {
+		a.observations = ch
+	}
+}
+
+// relevantObservation returns whether the observation may have changed the
+// autopilot state.
+func relevantObservation(o raft.Observation) bool {
+	switch o.Data.(type) {
+	case raft.LeaderObservation, raft.PeerObservation,
+		raft.FailedHeartbeatObservation, raft.ResumedHeartbeatObservation:
+		return true
+	default:
+		return false
+	}
+}
+
+// handleObservation updates the state when the observation is relevant and
+// then asks for a reconciliation round. Observations arriving while a round
+// is already pending are coalesced into it.
+func (a *Autopilot) handleObservation(ctx context.Context, o raft.Observation) {
+	if a.replication != nil {
+		a.replication.observe(o)
+	}
+
+	if !relevantObservation(o) {
+		return
+	}
+
+	a.subsystemLogger(SubsystemState).Debug("updating the state after a raft observation", "observation", o.Data)
+	a.updateState(ctx)
+
+	select {
+	case a.observedCh <- struct{}{}:
+	default:
+	}
+}
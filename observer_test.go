@@ -0,0 +1,65 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelevantObservation(t *testing.T) {
+	type testCase struct {
+		data     interface{}
+		relevant bool
+	}
+
+	cases := map[string]testCase{
+		"leader":            {data: raft.LeaderObservation{}, relevant: true},
+		"peer":              {data: raft.PeerObservation{}, relevant: true},
+		"failed-heartbeat":  {data: raft.FailedHeartbeatObservation{}, relevant: true},
+		"resumed-heartbeat": {data: raft.ResumedHeartbeatObservation{}, relevant: true},
+		"raft-state":        {data: raft.RaftState(0), relevant: false},
+		"request-vote":      {data: raft.RequestVoteRequest{}, relevant: false},
+	}
+
+	for name, tcase := range cases {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tcase.relevant, relevantObservation(raft.Observation{Data: tcase.data}))
+		})
+	}
+}
+
+func TestHandleObservation(t *testing.T) {
+	mdel := NewMockApplicationIntegration(t)
+	mtime := NewMockTimeProvider(t)
+
+	a := &Autopilot{
+		logger:     hclog.NewNullLogger(),
+		delegate:   mdel,
+		time:       mtime,
+		observedCh: make(chan struct{}, 1),
+	}
+	ctx := context.Background()
+
+	// irrelevant observations neither update the state nor ask for a round
+	a.handleObservation(ctx, raft.Observation{Data: raft.RequestVoteRequest{}})
+	require.Len(t, a.observedCh, 0)
+
+	// relevant observations update the state and then ask for a round
+	mtime.On("Now").Return(time.Now()).Once()
+	mdel.On("AutopilotConfig").Return(nil).Once()
+	a.handleObservation(ctx, raft.Observation{Data: raft.LeaderObservation{}})
+	require.Len(t, a.observedCh, 1)
+
+	// observations while a round is pending are coalesced into it
+	mtime.On("Now").Return(time.Now()).Once()
+	mdel.On("AutopilotConfig").Return(nil).Once()
+	a.handleObservation(ctx, raft.Observation{Data: raft.PeerObservation{}})
+	require.Len(t, a.observedCh, 1)
+}
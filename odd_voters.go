@@ -0,0 +1,58 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"github.com/hashicorp/raft"
+)
+
+// keepVotersOdd adjusts the changes so that applying them does not leave an
+// even number of voters whenever that can be avoided. An even number of voters
+// requires a larger quorum without tolerating any more failures. Promotions
+// are applied before demotions so the promotions are checked against the
+// number of voters once the pending demotions are also made. When too many
+// promotions or demotions would be made the least preferred one is held back,
+// which delays a lone promotion until a second server is ready to be promoted
+// alongside it. It does nothing unless FeatureOddVoters is enabled.
+func (a *Autopilot) keepVotersOdd(state *State, changes RaftChanges) RaftChanges {
+	if !a.FeatureEnabled(FeatureOddVoters) {
+		return changes
+	}
+
+	planned := a.planRaftChanges(state, changes)
+	voters := len(state.Voters)
+
+	if len(planned.Promotions) > 0 {
+		if (voters+len(planned.Promotions)-len(planned.Demotions))%2 == 0 {
+			held := planned.Promotions[len(planned.Promotions)-1]
+			a.roundLogger().Info("Holding back a promotion to avoid an even number of voters", "id", held)
+			changes.Promotions = withoutServer(changes.Promotions, held)
+			planned.Promotions = planned.Promotions[:len(planned.Promotions)-1]
+		}
+
+		if len(planned.Promotions) > 0 {
+			// the demotions will not be applied this round
+			return changes
+		}
+	}
+
+	if len(planned.Demotions) > 0 && (voters-len(planned.Demotions))%2 == 0 {
+		held := planned.Demotions[len(planned.Demotions)-1]
+		a.roundLogger().Info("Holding back a demotion to avoid an even number of voters", "id", held)
+		changes.Demotions = withoutServer(changes.Demotions, held)
+	}
+
+	return changes
+}
+
+// withoutServer returns a copy of the IDs with every occurrence of id removed.
+func withoutServer(ids []raft.ServerID, id raft.ServerID) []raft.ServerID {
+	var result []raft.ServerID
+	for _, other := range ids {
+		if other != id {
+			result = append(result, other)
+		}
+	}
+	return result
+}
@@ -0,0 +1,86 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeepVotersOdd(t *testing.T) {
+	state := func(voters ...raft.ServerID) *State {
+		s := &State{
+			Leader:  "a",
+			Servers: make(map[raft.ServerID]*ServerState),
+		}
+		for _, id := range []raft.ServerID{"a", "b", "c", "d", "e"} {
+			s.Servers[id] = &ServerState{Server: Server{ID: id}, State: RaftNonVoter, Health: ServerHealth{Healthy: true}}
+		}
+		for _, id := range voters {
+			s.Servers[id].State = RaftVoter
+			s.Voters = append(s.Voters, id)
+		}
+		s.Servers["a"].State = RaftLeader
+		return s
+	}
+
+	type testCase struct {
+		state    *State
+		changes  RaftChanges
+		expected RaftChanges
+	}
+
+	cases := map[string]testCase{
+		"lone-promotion-held": {
+			state:    state("a", "b", "c"),
+			changes:  RaftChanges{Promotions: []raft.ServerID{"d"}},
+			expected: RaftChanges{},
+		},
+		"pair-of-promotions": {
+			state:    state("a", "b", "c"),
+			changes:  RaftChanges{Promotions: []raft.ServerID{"d", "e"}},
+			expected: RaftChanges{Promotions: []raft.ServerID{"d", "e"}},
+		},
+		"least-preferred-promotion-held": {
+			state:    state("a"),
+			changes:  RaftChanges{Promotions: []raft.ServerID{"b", "c", "d"}},
+			expected: RaftChanges{Promotions: []raft.ServerID{"b", "c"}},
+		},
+		"promotion-paired-with-demotion": {
+			state:    state("a", "b", "c"),
+			changes:  RaftChanges{Promotions: []raft.ServerID{"d"}, Demotions: []raft.ServerID{"c"}},
+			expected: RaftChanges{Promotions: []raft.ServerID{"d"}, Demotions: []raft.ServerID{"c"}},
+		},
+		"lone-demotion-held": {
+			state:    state("a", "b", "c"),
+			changes:  RaftChanges{Demotions: []raft.ServerID{"c"}},
+			expected: RaftChanges{},
+		},
+		"demotion-to-odd": {
+			state:    state("a", "b", "c", "d"),
+			changes:  RaftChanges{Demotions: []raft.ServerID{"d"}},
+			expected: RaftChanges{Demotions: []raft.ServerID{"d"}},
+		},
+		"ineffective-changes-ignored": {
+			state:    state("a", "b", "c"),
+			changes:  RaftChanges{Promotions: []raft.ServerID{"b", "d", "e"}, Demotions: []raft.ServerID{"e"}},
+			expected: RaftChanges{Promotions: []raft.ServerID{"b", "d", "e"}, Demotions: []raft.ServerID{"e"}},
+		},
+	}
+
+	for name, tcase := range cases {
+		t.Run(name, func(t *testing.T) {
+			a := &Autopilot{logger: hclog.NewNullLogger()}
+			a.setFeatures([]Feature{FeatureOddVoters}, true)
+			require.Equal(t, tcase.expected, a.keepVotersOdd(tcase.state, tcase.changes))
+
+			// nothing is changed unless the feature is enabled
+			a = &Autopilot{logger: hclog.NewNullLogger()}
+			require.Equal(t, tcase.changes, a.keepVotersOdd(tcase.state, tcase.changes))
+		})
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"context"
+	"fmt"
+)
+
+// onDemandRound is a request for the running autopilot go routine to perform a
+// round immediately rather than waiting for the next periodic one.
+type onDemandRound struct {
+	kind RoundKind
+
+	// result receives the outcome of the round. It is buffered so that the
+	// round can complete after the requester has given up waiting.
+	result chan onDemandResult
+}
+
+// onDemandResult is the outcome of an on demand round.
+type onDemandResult struct {
+	changes []MembershipChange
+	err     error
+}
+
+// ReconcileNow performs a reconciliation round immediately, outside of the
+// periodic schedule, and returns the membership changes it made. The round
+// acts upon the most recent autopilot state. An error is returned when
+// autopilot is not running, when the context is cancelled before the round
+// completes or when the round itself fails.
+func (a *Autopilot) ReconcileNow(ctx context.Context) ([]MembershipChange, error) {
+	return a.requestRound(ctx, RoundReconcile)
+}
+
+// PruneNow performs a dead server removal round immediately, outside of the
+// periodic schedule, and returns the removals it made. Unlike
+// RemoveDeadServers it waits for the round to complete. An error is returned
+// when autopilot is not running, when the context is cancelled before the
+// round completes or when the round itself fails.
+func (a *Autopilot) PruneNow(ctx context.Context) ([]MembershipChange, error) {
+	return a.requestRound(ctx, RoundPrune)
+}
+
+// requestRound hands a round to the running autopilot go routine so that it
+// never runs concurrently with the periodic rounds, and then waits for it.
+func (a *Autopilot) requestRound(ctx context.Context, kind RoundKind) ([]MembershipChange, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	status, done := a.IsRunning()
+	if status != Running {
+		return nil, fmt.Errorf("cannot perform a %s round: %w", kind, ErrNotRunning)
+	}
+
+	req := onDemandRound{
+		kind:   kind,
+		result: make(chan onDemandResult, 1),
+	}
+
+	select {
+	case a.onDemandCh <- req:
+	case <-done:
+		return nil, fmt.Errorf("cannot perform a %s round as autopilot stopped: %w", kind, ErrNotRunning)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	select {
+	case res := <-req.result:
+		return res.changes, res.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// runOnDemandRound performs the requested round while collecting the
+// membership changes it makes.
+func (a *Autopilot) runOnDemandRound(ctx context.Context, req onDemandRound) {
+	a.roundLock.Lock()
+	a.collectedChanges = &[]MembershipChange{}
+	a.roundLock.Unlock()
+
+	var err error
+	switch req.kind {
+	case RoundReconcile:
+		err = a.reconcile(ctx)
+	case RoundPrune:
+		err = a.pruneDeadServers(ctx)
+	default:
+		err = fmt.Errorf("cannot perform a %s round on demand", req.kind)
+	}
+
+	a.roundLock.Lock()
+	changes := *a.collectedChanges
+	a.collectedChanges = nil
+	a.roundLock.Unlock()
+
+	req.result <- onDemandResult{changes: changes, err: err}
+}
+
+// collectingChanges returns whether the membership changes made are being
+// collected for an on demand round.
+func (a *Autopilot) collectingChanges() bool {
+	a.roundLock.RLock()
+	defer a.roundLock.RUnlock()
+	return a.collectedChanges != nil
+}
+
+// collectChange records the membership change when an on demand round is
+// collecting them.
+func (a *Autopilot) collectChange(change MembershipChange) {
+	a.roundLock.Lock()
+	defer a.roundLock.Unlock()
+	if a.collectedChanges != nil {
+		*a.collectedChanges = append(*a.collectedChanges, change)
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestRound(t *testing.T) {
+	a := &Autopilot{
+		logger:     hclog.NewNullLogger(),
+		onDemandCh: make(chan onDemandRound),
+	}
+
+	// rounds are refused while not running
+	_, err := a.ReconcileNow(context.Background())
+	require.ErrorIs(t, err, ErrNotRunning)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = a.PruneNow(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+
+	// a round is not waited for once autopilot stops
+	done := make(chan struct{})
+	close(done)
+	a.execution = &execInfo{status: Running, done: done}
+	_, err = a.ReconcileNow(context.Background())
+	require.ErrorIs(t, err, ErrNotRunning)
+
+	// otherwise the running go routine performs the round
+	a.execution = &execInfo{status: Running, done: make(chan struct{})}
+	go func() {
+		for req := range a.onDemandCh {
+			a.runOnDemandRound(context.Background(), req)
+		}
+	}()
+	defer close(a.onDemandCh)
+
+	changes, err := a.ReconcileNow(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, changes)
+
+	_, err = a.requestRound(context.Background(), RoundPlan)
+	require.Error(t, err)
+}
+
+func TestCollectChange(t *testing.T) {
+	a := &Autopilot{}
+
+	// changes are only collected during on demand rounds
+	a.collectChange(MembershipChange{Type: MembershipChangePromotion})
+	require.False(t, a.collectingChanges())
+
+	a.collectedChanges = &[]MembershipChange{}
+	require.True(t, a.collectingChanges())
+	a.collectChange(MembershipChange{Type: MembershipChangePromotion})
+	a.collectChange(MembershipChange{Type: MembershipChangeDemotion})
+	require.Equal(t, []MembershipChange{
+		{Type: MembershipChangePromotion},
+		{Type: MembershipChangeDemotion},
+	}, *a.collectedChanges)
+}
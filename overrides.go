@@ -0,0 +1,97 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"time"
+)
+
+// ConfigOverride replaces some of the health thresholds for the servers it
+// matches, such as allowing WAN attached read replicas to trail further behind
+// the leader than local voters. A server matches when it matches both the
+// NodeType and Selector with empty values matching every server.
+type ConfigOverride struct {
+	// NodeType matches servers the promoter classed with this NodeType.
+	NodeType NodeType
+
+	// Selector is a label selector style expression, as used by
+	// Config.IgnoredServerSelectors, evaluated against Server.Meta.
+	Selector string
+
+	// LastContactThreshold overrides Config.LastContactThreshold when
+	// non-zero.
+	LastContactThreshold time.Duration
+
+	// MaxTrailingLogs overrides Config.MaxTrailingLogs when non-zero.
+	MaxTrailingLogs uint64
+
+	// ServerStabilizationTime overrides Config.ServerStabilizationTime when
+	// non-zero.
+	ServerStabilizationTime time.Duration
+}
+
+// matches returns whether the override applies to the server. Overrides with
+// an invalid selector never match.
+func (o *ConfigOverride) matches(srv *Server) bool {
+	if o.NodeType != "" && o.NodeType != srv.NodeType {
+		return false
+	}
+
+	if o.Selector == "" {
+		return true
+	}
+
+	sel, err := parseServerSelector(o.Selector)
+	if err != nil {
+		return false
+	}
+	return sel.matches(srv.Meta)
+}
+
+// ForServer returns the configuration which applies to the given server. Each
+// of the thresholds is taken from the first matching override which sets it
+// and otherwise from the configuration itself. The configuration is returned
+// as is when no overrides match.
+func (c *Config) ForServer(srv *Server) *Config {
+	if c == nil || len(c.Overrides) == 0 || srv == nil {
+		return c
+	}
+
+	var lastContact, stabilization time.Duration
+	var trailingLogs uint64
+	matched := false
+	for i := range c.Overrides {
+		o := &c.Overrides[i]
+		if !o.matches(srv) {
+			continue
+		}
+
+		matched = true
+		if lastContact == 0 {
+			lastContact = o.LastContactThreshold
+		}
+		if trailingLogs == 0 {
+			trailingLogs = o.MaxTrailingLogs
+		}
+		if stabilization == 0 {
+			stabilization = o.ServerStabilizationTime
+		}
+	}
+
+	if !matched {
+		return c
+	}
+
+	conf := *c
+	if lastContact != 0 {
+		conf.LastContactThreshold = lastContact
+	}
+	if trailingLogs != 0 {
+		conf.MaxTrailingLogs = trailingLogs
+	}
+	if stabilization != 0 {
+		conf.ServerStabilizationTime = stabilization
+	}
+	return &conf
+}
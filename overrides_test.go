@@ -0,0 +1,58 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigForServer(t *testing.T) {
+	conf := &Config{
+		LastContactThreshold:    time.Second,
+		MaxTrailingLogs:         100,
+		ServerStabilizationTime: 10 * time.Second,
+		Overrides: []ConfigOverride{
+			{Selector: "role=replica", MaxTrailingLogs: 5000},
+			{NodeType: "read-replica", LastContactThreshold: 5 * time.Second, MaxTrailingLogs: 1000},
+			{Selector: "=invalid", MaxTrailingLogs: 1},
+		},
+	}
+
+	// servers matching nothing use the configuration as is
+	require.Same(t, conf, conf.ForServer(&Server{ID: "a"}))
+
+	replica := conf.ForServer(&Server{ID: "b", NodeType: "read-replica", Meta: map[string]string{"role": "replica"}})
+	require.Equal(t, 5*time.Second, replica.LastContactThreshold)
+	require.Equal(t, uint64(5000), replica.MaxTrailingLogs)
+	require.Equal(t, 10*time.Second, replica.ServerStabilizationTime)
+
+	byType := conf.ForServer(&Server{ID: "c", NodeType: "read-replica"})
+	require.Equal(t, 5*time.Second, byType.LastContactThreshold)
+	require.Equal(t, uint64(1000), byType.MaxTrailingLogs)
+
+	// the original configuration is left alone
+	require.Equal(t, uint64(100), conf.MaxTrailingLogs)
+
+	require.Error(t, conf.Validate())
+}
+
+func TestOverriddenHealthThresholds(t *testing.T) {
+	conf := &Config{
+		LastContactThreshold: time.Second,
+		MaxTrailingLogs:      100,
+		Overrides:            []ConfigOverride{{Selector: "wan", MaxTrailingLogs: 1000}},
+	}
+
+	srv := ServerState{
+		Server: Server{ID: "a", NodeStatus: NodeAlive},
+		Stats:  ServerStats{LastTerm: 3, LastIndex: 500},
+	}
+	require.False(t, srv.isHealthy(3, 1000, conf.ForServer(&srv.Server)))
+
+	srv.Server.Meta = map[string]string{"wan": "true"}
+	require.True(t, srv.isHealthy(3, 1000, conf.ForServer(&srv.Server)))
+}
@@ -0,0 +1,233 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hashicorp/raft"
+)
+
+// PersistedServerHealth is the part of a server's health which is persisted
+// by a StatePersister.
+type PersistedServerHealth struct {
+	// Healthy is whether the server was healthy.
+	Healthy bool
+
+	// StableSince is when the server last changed its health.
+	StableSince time.Time
+}
+
+// StatePersister may optionally be implemented by the ApplicationIntegration
+// to persist the servers' health, for example within the application's own
+// store, so that it survives leadership changes and restarts. Without one a
+// new leader only considers servers stable from when it first computed the
+// state, which delays their promotion by the ServerStabilizationTime even when
+// they have been healthy for a long time.
+type StatePersister interface {
+	// PersistServerHealth is called with the health of every server after a
+	// state update in which the health of any of them changed. It is called
+	// synchronously and therefore should not block.
+	PersistServerHealth(map[raft.ServerID]PersistedServerHealth)
+
+	// LoadServerHealth returns the most recently persisted health when
+	// autopilot computes its first state. Servers whose health is unchanged
+	// retain their StableSince.
+	LoadServerHealth() map[raft.ServerID]PersistedServerHealth
+}
+
+// healthPersistence tracks what was last given to the StatePersister so that
+// it is only called when the health changes.
+type healthPersistence struct {
+	lock      sync.Mutex
+	persisted map[raft.ServerID]PersistedServerHealth
+}
+
+// changed records the health and returns whether it differs from that which
+// was last recorded.
+func (p *healthPersistence) changed(health map[raft.ServerID]PersistedServerHealth) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	same := len(health) == len(p.persisted)
+	for id, h := range health {
+		if prev, ok := p.persisted[id]; !ok || prev.Healthy != h.Healthy || !prev.StableSince.Equal(h.StableSince) {
+			same = false
+			break
+		}
+	}
+
+	p.persisted = health
+	return !same
+}
+
+// loadPersistedHealth returns the persisted health of the servers when the
+// delegate implements StatePersister and there is no current state to carry
+// the health over from.
+func (a *Autopilot) loadPersistedHealth(current *State) map[raft.ServerID]PersistedServerHealth {
+	if current != nil && len(current.Servers) > 0 {
+		return nil
+	}
+
+	persister, ok := a.delegate.(StatePersister)
+	if !ok {
+		return nil
+	}
+
+	a.countDelegateCall("LoadServerHealth")
+	return persister.LoadServerHealth()
+}
+
+// persistHealth gives the health of the state's servers to the delegate when
+// it implements StatePersister and the health has changed.
+func (a *Autopilot) persistHealth(state *State) {
+	persister, ok := a.delegate.(StatePersister)
+	if !ok {
+		return
+	}
+
+	health := make(map[raft.ServerID]PersistedServerHealth, len(state.Servers))
+	for id, srv := range state.Servers {
+		health[id] = PersistedServerHealth{
+			Healthy:     srv.Health.Healthy,
+			StableSince: srv.Health.StableSince,
+		}
+	}
+
+	if !a.persistence.changed(health) {
+		return
+	}
+
+	a.countDelegateCall("PersistServerHealth")
+	persister.PersistServerHealth(health)
+}
+
+// restoreStableSince returns when the new server became stable according to
+// the persisted health. The time of the state is returned when nothing was
+// persisted or the server's health has since changed.
+func (i *nextStateInputs) restoreStableSince(id raft.ServerID, healthy bool) time.Time {
+	persisted, ok := i.PersistedHealth[id]
+	if !ok || persisted.Healthy != healthy || persisted.StableSince.IsZero() || persisted.StableSince.After(i.Now) {
+		return i.Now
+	}
+	return persisted.StableSince
+}
+
+// PersistedEvacuation is a zone evacuation persisted by an OperationPersister.
+type PersistedEvacuation struct {
+	// Zone is the value of the configured ZoneKey being evacuated.
+	Zone string
+
+	// StartedAt is when EvacuateZone was called for the zone.
+	StartedAt time.Time
+
+	// TargetVoters is the number of voters the cluster had when the
+	// evacuation started.
+	TargetVoters int
+}
+
+// PersistedOperations are the operations in progress which are persisted by
+// an OperationPersister.
+type PersistedOperations struct {
+	// Evacuations are the zones being evacuated ordered by zone.
+	Evacuations []PersistedEvacuation
+
+	// Replacements are the server replacements in progress ordered by the ID
+	// of the server being replaced.
+	Replacements []ServerReplacement
+}
+
+// OperationPersister may optionally be implemented by the
+// ApplicationIntegration to persist the operations started through autopilot
+// which take multiple reconciliation rounds, such as zone evacuations and
+// server replacements, so that they are continued by the next leader. This is
+// typically done by replicating them through the application's Raft log.
+// Without one the operations only exist in the memory of the leader which
+// started them and are abandoned once leadership moves. Operations which would
+// move leadership are therefore refused without one.
+type OperationPersister interface {
+	// PersistOperations is called with all of the operations in progress
+	// whenever one starts, progresses or finishes. It is called synchronously
+	// and therefore should not block.
+	PersistOperations(PersistedOperations)
+
+	// LoadOperations returns the most recently persisted operations. It is
+	// called whenever autopilot sees a leader other than the one it last
+	// loaded the operations for, after which the loaded operations replace
+	// those autopilot was tracking.
+	LoadOperations() PersistedOperations
+}
+
+// operationPersistence serializes changing, persisting and loading the
+// operations in progress so that loading them never discards a change.
+type operationPersistence struct {
+	lock sync.Mutex
+
+	// loadedFor is the leader the operations were last loaded for.
+	loadedFor raft.ServerID
+}
+
+// forgetLoaded forgets which leader the operations were last loaded for so
+// that they are loaded again.
+func (p *operationPersistence) forgetLoaded() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.loadedFor = ""
+}
+
+// operationPersister returns the delegate's OperationPersister if it
+// implements one.
+func (a *Autopilot) operationPersister() (OperationPersister, bool) {
+	persister, ok := a.delegate.(OperationPersister)
+	return persister, ok
+}
+
+// loadOperations replaces the operations in progress with those persisted when
+// the delegate implements OperationPersister and the leader has changed since
+// they were last loaded, so that the operations started by a previous leader
+// are continued.
+func (a *Autopilot) loadOperations(state *State) {
+	a.operations.lock.Lock()
+	defer a.operations.lock.Unlock()
+	a.loadOperationsLocked(state)
+}
+
+// loadOperationsLocked is loadOperations for when the operations lock is held.
+func (a *Autopilot) loadOperationsLocked(state *State) {
+	persister, ok := a.operationPersister()
+	if !ok || state == nil || state.Leader == "" || state.Leader == a.operations.loadedFor {
+		return
+	}
+
+	a.countDelegateCall("LoadOperations")
+	ops := persister.LoadOperations()
+	a.evacuations.load(ops.Evacuations)
+	a.replacements.load(ops.Replacements)
+	a.operations.loadedFor = state.Leader
+}
+
+// updateOperations loads the persisted operations for the state, then calls
+// change to modify the operations in progress and, when it returns true,
+// persists them if the delegate implements OperationPersister.
+func (a *Autopilot) updateOperations(state *State, change func() bool) {
+	a.operations.lock.Lock()
+	defer a.operations.lock.Unlock()
+
+	a.loadOperationsLocked(state)
+	if !change() {
+		return
+	}
+
+	persister, ok := a.operationPersister()
+	if !ok {
+		return
+	}
+
+	a.countDelegateCall("PersistOperations")
+	persister.PersistOperations(PersistedOperations{
+		Evacuations:  a.evacuations.persisted(),
+		Replacements: a.Replacements(),
+	})
+}
@@ -0,0 +1,113 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+// persistingDelegate is an ApplicationIntegration implementing StatePersister.
+type persistingDelegate struct {
+	*MockApplicationIntegration
+	*MockStatePersister
+}
+
+func TestLoadPersistedHealth(t *testing.T) {
+	persister := NewMockStatePersister(t)
+	a := &Autopilot{
+		logger: hclog.NewNullLogger(),
+		delegate: &persistingDelegate{
+			MockApplicationIntegration: NewMockApplicationIntegration(t),
+			MockStatePersister:         persister,
+		},
+	}
+
+	health := map[raft.ServerID]PersistedServerHealth{
+		"a": {Healthy: true, StableSince: time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)},
+	}
+	persister.On("LoadServerHealth").Return(health).Twice()
+
+	// the health is loaded when there is no state to carry it over from
+	require.Equal(t, health, a.loadPersistedHealth(nil))
+	require.Equal(t, health, a.loadPersistedHealth(&State{}))
+	require.Nil(t, a.loadPersistedHealth(planTestState()))
+
+	// nothing is loaded without a StatePersister
+	a.delegate = NewMockApplicationIntegration(t)
+	require.Nil(t, a.loadPersistedHealth(nil))
+}
+
+func TestPersistHealth(t *testing.T) {
+	persister := NewMockStatePersister(t)
+	a := &Autopilot{
+		logger: hclog.NewNullLogger(),
+		delegate: &persistingDelegate{
+			MockApplicationIntegration: NewMockApplicationIntegration(t),
+			MockStatePersister:         persister,
+		},
+	}
+
+	stable := time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)
+	state := planTestState()
+	for _, srv := range state.Servers {
+		srv.Health.StableSince = stable
+	}
+
+	persister.On("PersistServerHealth", map[raft.ServerID]PersistedServerHealth{
+		"a": {Healthy: true, StableSince: stable},
+		"b": {Healthy: true, StableSince: stable},
+		"c": {Healthy: true, StableSince: stable},
+		"d": {Healthy: false, StableSince: stable},
+	}).Once()
+	a.persistHealth(state)
+
+	// the health is not persisted again until it changes
+	a.persistHealth(state)
+
+	state.Servers["d"].Health = ServerHealth{Healthy: true, StableSince: stable.Add(time.Minute)}
+	persister.On("PersistServerHealth", map[raft.ServerID]PersistedServerHealth{
+		"a": {Healthy: true, StableSince: stable},
+		"b": {Healthy: true, StableSince: stable},
+		"c": {Healthy: true, StableSince: stable},
+		"d": {Healthy: true, StableSince: stable.Add(time.Minute)},
+	}).Once()
+	a.persistHealth(state)
+
+	// as does removing a server
+	delete(state.Servers, "d")
+	persister.On("PersistServerHealth", map[raft.ServerID]PersistedServerHealth{
+		"a": {Healthy: true, StableSince: stable},
+		"b": {Healthy: true, StableSince: stable},
+		"c": {Healthy: true, StableSince: stable},
+	}).Once()
+	a.persistHealth(state)
+}
+
+func TestRestoreStableSince(t *testing.T) {
+	now := time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)
+	inputs := &nextStateInputs{
+		Now: now,
+		PersistedHealth: map[raft.ServerID]PersistedServerHealth{
+			"healthy":   {Healthy: true, StableSince: now.Add(-time.Hour)},
+			"unhealthy": {Healthy: false, StableSince: now.Add(-time.Hour)},
+			"zero":      {Healthy: true},
+			"future":    {Healthy: true, StableSince: now.Add(time.Hour)},
+		},
+	}
+
+	// the persisted time is only used while the health is unchanged
+	require.Equal(t, now.Add(-time.Hour), inputs.restoreStableSince("healthy", true))
+	require.Equal(t, now, inputs.restoreStableSince("healthy", false))
+	require.Equal(t, now.Add(-time.Hour), inputs.restoreStableSince("unhealthy", false))
+
+	// and is sensible
+	require.Equal(t, now, inputs.restoreStableSince("zero", true))
+	require.Equal(t, now, inputs.restoreStableSince("future", true))
+	require.Equal(t, now, inputs.restoreStableSince("unknown", true))
+}
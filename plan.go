@@ -0,0 +1,283 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/raft"
+)
+
+// PlannedChanges are the changes autopilot intends to make to the Raft
+// configuration. Autopilot never applies promotions, demotions and leadership
+// transfers within the same round so applying all of the planned changes may
+// take multiple rounds.
+type PlannedChanges struct {
+	// Promotions are the non-voters which would be promoted.
+	Promotions []raft.ServerID
+
+	// Demotions are the voters which would be demoted.
+	Demotions []raft.ServerID
+
+	// Leader is the server leadership would be transferred to. It is empty
+	// when leadership would not be transferred.
+	Leader raft.ServerID
+
+	// Removals are the failed and stale servers which would be removed in the
+	// order they would be removed.
+	Removals []raft.ServerID
+}
+
+// String describes the planned changes.
+func (p *PlannedChanges) String() string {
+	var parts []string
+	if len(p.Promotions) > 0 {
+		parts = append(parts, fmt.Sprintf("promote %s", joinServerIDs(p.Promotions)))
+	}
+	if len(p.Demotions) > 0 {
+		parts = append(parts, fmt.Sprintf("demote %s", joinServerIDs(p.Demotions)))
+	}
+	if p.Leader != "" {
+		parts = append(parts, fmt.Sprintf("transfer leadership to %s", p.Leader))
+	}
+	if len(p.Removals) > 0 {
+		parts = append(parts, fmt.Sprintf("remove %s", joinServerIDs(p.Removals)))
+	}
+
+	if len(parts) == 0 {
+		return "no changes"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// joinServerIDs joins the server IDs with commas.
+func joinServerIDs(ids []raft.ServerID) string {
+	strs := make([]string, len(ids))
+	for i, id := range ids {
+		strs[i] = string(id)
+	}
+	return strings.Join(strs, ", ")
+}
+
+// ReconcilePlan is a set of changes calculated by Plan which may later be
+// made with Apply. It is opaque other than for describing the changes so that
+// they may be surfaced for approval before autopilot acts upon them.
+type ReconcilePlan struct {
+	changes PlannedChanges
+
+	// raftChanges are the promotions, demotions and leadership candidates to
+	// apply after the promoter's changes were adjusted.
+	raftChanges RaftChanges
+
+	// updates are the updates to autopilot's trackers which are recorded
+	// once the plan is applied.
+	updates *trackerUpdates
+
+	// fingerprint identifies the parts of the state the plan was based on.
+	fingerprint string
+}
+
+// Changes returns the changes the plan would make.
+func (p *ReconcilePlan) Changes() PlannedChanges {
+	return p.changes
+}
+
+// String describes the changes the plan would make.
+func (p *ReconcilePlan) String() string {
+	return p.changes.String()
+}
+
+// stateFingerprint identifies the parts of the state which, when changed, would
+// invalidate a plan: the leader and whether the state is degraded along with
+// each server's Raft state, health, node status and address.
+func stateFingerprint(state *State) string {
+	ids := make([]raft.ServerID, 0, len(state.Servers))
+	for id := range state.Servers {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "leader=%s;degraded=%t", state.Leader, state.Degraded)
+	for _, id := range ids {
+		srv := state.Servers[id]
+		fmt.Fprintf(&b, ";%s=%s,%t,%s,%s", id, srv.State, srv.Health.Healthy, srv.Server.NodeStatus, srv.Server.Address)
+	}
+	return b.String()
+}
+
+// Plan calculates the promotions, demotions, leadership transfer and removals
+// autopilot would make given the current state without applying any of them.
+// Removals are only planned when CleanupDeadServers is enabled. The plan may
+// be made later with Apply. Planning does not alter what later reconciliation
+// rounds do.
+func (a *Autopilot) Plan() (*ReconcilePlan, error) {
+	defer a.beginRound(RoundPlan)()
+
+	a.countDelegateCall("AutopilotConfig")
+	conf := a.delegate.AutopilotConfig()
+	if conf == nil {
+		return nil, fmt.Errorf("delegate did not return an Autopilot configuration")
+	}
+
+	state := a.GetState()
+	if state == nil || state.Leader == "" {
+		return nil, fmt.Errorf("cannot plan changes: %w", ErrNoState)
+	}
+	a.loadOperations(state)
+
+	if state.Degraded {
+		// the servers' health is retained from before the stats outage
+		return nil, fmt.Errorf("cannot plan changes while server stats are unavailable")
+	}
+
+	changes, updates, err := a.reconcileChanges(conf, state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate promotions and demotions: %w", err)
+	}
+	planned := a.planRaftChanges(state, changes)
+
+	if conf.CleanupDeadServers {
+		planned.Removals, err = a.processRemovals(context.Background(), conf, state, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to determine the servers to remove: %w", err)
+		}
+	}
+
+	return &ReconcilePlan{
+		changes:     *planned,
+		raftChanges: changes,
+		updates:     updates,
+		fingerprint: stateFingerprint(state),
+	}, nil
+}
+
+// Apply makes the changes of a plan previously returned by Plan. An error is
+// returned without making any changes when the state has materially changed
+// since planning, such as a server's health or voting rights changing or the
+// leader changing, as the plan may no longer be safe. Plans are neither made
+// nor applied while the state is Degraded and are not applied while
+// reconciliation is disabled or the configuration is a DryRun.
+//
+// The changes are made in the same manner as autopilot's reconciliation: if
+// any promotions are made then no demotions are, and leadership is only
+// transferred when neither promotions nor demotions were made. The planned
+// removals are then made. Any changes not made will be part of a later plan.
+func (a *Autopilot) Apply(plan *ReconcilePlan) error {
+	if plan == nil {
+		return fmt.Errorf("a plan to apply is required")
+	}
+
+	defer a.beginRound(RoundApply)()
+
+	ctx := context.Background()
+
+	a.countDelegateCall("AutopilotConfig")
+	conf := a.delegate.AutopilotConfig()
+	if conf == nil {
+		return fmt.Errorf("delegate did not return an Autopilot configuration")
+	}
+
+	if !a.ReconciliationEnabled() {
+		return fmt.Errorf("cannot apply a plan while reconciliation is disabled")
+	}
+
+	if conf.DryRun {
+		return fmt.Errorf("cannot apply a plan while the configuration is a dry run")
+	}
+
+	if a.membershipChangeStuck() {
+		return fmt.Errorf("cannot apply a plan while a raft operation is unresolved")
+	}
+
+	state := a.GetState()
+	if state == nil || state.Leader == "" {
+		return fmt.Errorf("cannot apply a plan: %w", ErrNoState)
+	}
+
+	if state.Degraded {
+		return fmt.Errorf("cannot apply a plan while server stats are unavailable")
+	}
+
+	if stateFingerprint(state) != plan.fingerprint {
+		return fmt.Errorf("the autopilot state has changed since the plan was made")
+	}
+
+	if (len(plan.changes.Demotions) > 0 || plan.changes.Leader != "") && !a.inMaintenanceWindow() {
+		return fmt.Errorf("cannot apply demotions or leadership transfers outside of a maintenance window")
+	}
+
+	if len(plan.changes.Removals) > 0 {
+		// the removals are recalculated when applied so ensure that they will
+		// be the same
+		removals, err := a.processRemovals(ctx, conf, state, false)
+		if err != nil {
+			return fmt.Errorf("failed to determine the servers to remove: %w", err)
+		}
+		if !serverIDsEqual(removals, plan.changes.Removals) {
+			return fmt.Errorf("the servers to remove have changed since the plan was made")
+		}
+	}
+
+	if err := a.checkInvariants(conf, state, plan.changes); err != nil {
+		return err
+	}
+
+	a.roundLogger().Info("applying plan", "plan", plan)
+	a.recordTrackerUpdates(state, plan.updates)
+
+	done, err := a.applyPromotions(ctx, state, plan.raftChanges)
+	if !done {
+		done, err = a.applyDemotions(ctx, conf, state, plan.raftChanges)
+	}
+	if !done {
+		err = a.applyLeadershipTransfer(ctx, state, plan.raftChanges)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(plan.changes.Removals) > 0 {
+		_, err = a.processRemovals(ctx, conf, state, true)
+	}
+	return err
+}
+
+// serverIDsEqual returns whether the two lists contain the same IDs in the
+// same order.
+func serverIDsEqual(a, b []raft.ServerID) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// planRaftChanges filters the changes down to those which applyPromotions,
+// applyDemotions and applyLeadershipTransfer would act upon given the state.
+func (a *Autopilot) planRaftChanges(state *State, changes RaftChanges) *PlannedChanges {
+	plan := &PlannedChanges{}
+
+	for _, id := range changes.Promotions {
+		if a.promotionIneligibleReason(state, id) == "" {
+			plan.Promotions = append(plan.Promotions, id)
+		}
+	}
+
+	for _, id := range changes.Demotions {
+		if a.demotionIneligibleReason(state, id) == "" {
+			plan.Demotions = append(plan.Demotions, id)
+		}
+	}
+
+	plan.Leader, _, _ = chooseLeader(state, changes)
+	return plan
+}
@@ -0,0 +1,251 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func planTestState() *State {
+	state := testState([]raft.ServerID{"a", "b", "c", "d"}, "a", "a", "b")
+	state.Servers["d"].Health.Healthy = false
+	return state
+}
+
+func TestPlan(t *testing.T) {
+	state := planTestState()
+	conf := &Config{}
+
+	mdel := NewMockApplicationIntegration(t)
+	mdel.On("AutopilotConfig").Return(conf).Once()
+
+	mpromoter := NewMockPromoter(t)
+	mpromoter.On("CalculatePromotionsAndDemotions", conf, state).Return(RaftChanges{
+		Promotions: []raft.ServerID{"b", "c", "d"},
+		Demotions:  []raft.ServerID{"b", "d"},
+		Leader:     "c",
+		// c is not a voter yet so leadership goes to b
+		LeaderCandidates: []raft.ServerID{"b"},
+	}).Once()
+
+	// no raft operations should be performed
+	a := &Autopilot{
+		logger:   hclog.NewNullLogger(),
+		raft:     NewMockRaft(t),
+		delegate: mdel,
+		promoter: mpromoter,
+		state:    state,
+	}
+
+	plan, err := a.Plan()
+	require.NoError(t, err)
+	require.Equal(t, PlannedChanges{
+		Promotions: []raft.ServerID{"c"},
+		Demotions:  []raft.ServerID{"b"},
+		Leader:     "b",
+	}, plan.Changes())
+	require.Equal(t, "promote c, demote b, transfer leadership to b", plan.String())
+}
+
+func TestReconcileDryRun(t *testing.T) {
+	state := planTestState()
+	conf := &Config{DryRun: true}
+
+	mdel := NewMockApplicationIntegration(t)
+	mdel.On("AutopilotConfig").Return(conf).Once()
+
+	mpromoter := NewMockPromoter(t)
+	mpromoter.On("CalculatePromotionsAndDemotions", conf, state).Return(RaftChanges{
+		Promotions: []raft.ServerID{"c"},
+	}).Once()
+
+	var events []Event
+	a := &Autopilot{
+		logger:                hclog.NewNullLogger(),
+		raft:                  NewMockRaft(t),
+		delegate:              mdel,
+		promoter:              mpromoter,
+		state:                 state,
+		reconciliationEnabled: true,
+		eventHandlers: []EventHandler{func(e Event) {
+			events = append(events, e)
+		}},
+	}
+
+	require.NoError(t, a.reconcile(context.Background()))
+	require.Len(t, events, 1)
+	require.Equal(t, EventPlannedChanges, events[0].Type)
+	require.Equal(t, "dry run, planned changes: promote c", events[0].Message)
+}
+
+func TestPlannedChangesString(t *testing.T) {
+	require.Equal(t, "no changes", (&PlannedChanges{}).String())
+	require.Equal(t, "remove a, b", (&PlannedChanges{Removals: []raft.ServerID{"a", "b"}}).String())
+}
+
+func TestApply(t *testing.T) {
+	state := planTestState()
+	conf := &Config{}
+
+	mdel := NewMockApplicationIntegration(t)
+	mdel.On("AutopilotConfig").Return(conf)
+
+	mpromoter := NewMockPromoter(t)
+	mpromoter.On("CalculatePromotionsAndDemotions", conf, state).Return(RaftChanges{
+		Promotions: []raft.ServerID{"c"},
+		Demotions:  []raft.ServerID{"b"},
+	}).Once()
+
+	mraft := NewMockRaft(t)
+	a := &Autopilot{
+		logger:                hclog.NewNullLogger(),
+		raft:                  mraft,
+		delegate:              mdel,
+		promoter:              mpromoter,
+		state:                 state,
+		reconciliationEnabled: true,
+	}
+
+	plan, err := a.Plan()
+	require.NoError(t, err)
+
+	// the plan is refused while reconciliation is disabled or a dry run
+	a.DisableReconciliation()
+	require.ErrorContains(t, a.Apply(plan), "reconciliation is disabled")
+	a.EnableReconciliation()
+	conf.DryRun = true
+	require.ErrorContains(t, a.Apply(plan), "dry run")
+	conf.DryRun = false
+
+	// the plan is refused once the state materially changes
+	a.state = planTestState()
+	a.state.Servers["c"].Health.Healthy = false
+	require.Error(t, a.Apply(plan))
+
+	// only the promotion is applied as with reconciliation
+	a.state = planTestState()
+	mraft.On("AddVoter", raft.ServerID("c"), raft.ServerAddress(""), uint64(0), time.Duration(0)).
+		Return(&raftIndexFuture{}).Once()
+	require.NoError(t, a.Apply(plan))
+
+	require.Error(t, a.Apply(nil))
+}
+
+func TestPlanDoesNotAlterLaterRounds(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mtime := NewMockTimeProvider(t)
+	mtime.On("Now").Return(func() time.Time { return now })
+
+	state := planTestState()
+	state.Servers["a"].Health.Healthy = false
+	state.Servers["c"].Server.Version = "2.0.0"
+	conf := &Config{
+		CleanupDeadServers:           true,
+		CanarySoakPeriod:             time.Minute,
+		UnhealthyLeaderTransferDelay: time.Minute,
+		FailedVoterDemotionPeriod:    time.Minute,
+	}
+
+	mdel := NewMockApplicationIntegration(t)
+	mdel.On("AutopilotConfig").Return(conf)
+	mdel.On("KnownServers").Return(map[raft.ServerID]*Server{
+		"a": {ID: "a", NodeStatus: NodeAlive},
+		"b": {ID: "b", NodeStatus: NodeAlive},
+		"c": {ID: "c", NodeStatus: NodeAlive},
+		"d": {ID: "d", NodeStatus: NodeAlive},
+	})
+
+	mpromoter := NewMockPromoter(t)
+	mpromoter.On("CalculatePromotionsAndDemotions", conf, state).Return(RaftChanges{
+		Promotions: []raft.ServerID{"c"},
+	})
+	mpromoter.On("FilterFailedServerRemovals", conf, state, mock.Anything).Return(func(_ *Config, _ *State, failed *FailedServers) *FailedServers {
+		return failed
+	})
+	mpromoter.On("IsPotentialVoter", mock.Anything).Return(true)
+
+	mraft := NewMockRaft(t)
+	mraft.On("GetConfiguration").Return(&raftConfigFuture{config: raft.Configuration{Servers: []raft.Server{
+		{ID: "a", Suffrage: raft.Voter},
+		{ID: "b", Suffrage: raft.Voter},
+		{ID: "c", Suffrage: raft.Nonvoter},
+		{ID: "d", Suffrage: raft.Nonvoter},
+	}}})
+
+	a := &Autopilot{
+		logger:   hclog.NewNullLogger(),
+		time:     mtime,
+		raft:     mraft,
+		delegate: mdel,
+		promoter: mpromoter,
+		state:    state,
+	}
+
+	// a regression of the known servers, a recovered demoted server, a
+	// canary and an unhealthy leader would all be recorded by a round
+	a.knownServers.settleTime = time.Minute
+	a.knownServers.last = map[raft.ServerID]struct{}{"e": {}, "f": {}, "g": {}, "h": {}}
+	a.failedDemotions.demoted("e", now)
+
+	plan, err := a.Plan()
+	require.NoError(t, err)
+	require.Equal(t, []raft.ServerID{"c"}, plan.Changes().Promotions)
+
+	require.Len(t, a.knownServers.last, 4)
+	require.Contains(t, a.knownServers.last, raft.ServerID("e"))
+	require.True(t, a.knownServers.holdUntil.IsZero())
+	require.Contains(t, a.failedDemotions.demotedAt, raft.ServerID("e"))
+	require.Empty(t, a.canaries.canaries)
+	require.Equal(t, unhealthyLeader{}, a.unhealthyLeader.leader)
+
+	// the delegate calls are accounted for like any other round's
+	usage, ok := a.LastRoundUsage(RoundPlan)
+	require.True(t, ok)
+	require.NotZero(t, usage.DelegateCalls["AutopilotConfig"])
+	require.Equal(t, 1, usage.DelegateCalls["KnownServers"])
+}
+
+func TestPlanDegraded(t *testing.T) {
+	state := planTestState()
+	conf := &Config{}
+
+	mdel := NewMockApplicationIntegration(t)
+	mdel.On("AutopilotConfig").Return(conf)
+
+	mpromoter := NewMockPromoter(t)
+	mpromoter.On("CalculatePromotionsAndDemotions", conf, state).Return(RaftChanges{
+		Demotions: []raft.ServerID{"b"},
+	}).Once()
+
+	// no raft operations should be performed
+	a := &Autopilot{
+		logger:                hclog.NewNullLogger(),
+		raft:                  NewMockRaft(t),
+		delegate:              mdel,
+		promoter:              mpromoter,
+		state:                 state,
+		reconciliationEnabled: true,
+	}
+
+	plan, err := a.Plan()
+	require.NoError(t, err)
+
+	// the stats become unavailable while the servers' health is retained
+	a.state = planTestState()
+	a.state.Degraded = true
+	require.ErrorContains(t, a.Apply(plan), "server stats are unavailable")
+
+	_, err = a.Plan()
+	require.ErrorContains(t, err, "server stats are unavailable")
+
+	// the degraded state is part of what a plan is based upon
+	require.NotEqual(t, stateFingerprint(state), stateFingerprint(a.state))
+}
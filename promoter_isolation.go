@@ -0,0 +1,121 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// PromoterIsolation controls how the Config and State given to the promoter
+// are protected from being modified by it.
+type PromoterIsolation int
+
+const (
+	// PromoterIsolationNone hands the promoter autopilot's own Config and
+	// State. This is the default as it avoids copying them every round.
+	PromoterIsolationNone PromoterIsolation = iota
+
+	// PromoterIsolationCopy hands the promoter deep copies of the Config and
+	// State so that any modifications it makes cannot affect autopilot. Ext
+	// values are only copied when they implement ExtCloner.
+	PromoterIsolationCopy
+
+	// PromoterIsolationDetect hands the promoter autopilot's own Config and
+	// State but compares them against copies taken beforehand once the
+	// promoter returns. Any modification is logged along with the promoter
+	// and method responsible. It is intended for debugging promoters as
+	// copying and comparing them every round is expensive.
+	PromoterIsolationDetect
+)
+
+// WithPromoterIsolation returns an option to protect the Config and State
+// given to the promoter's CalculatePromotionsAndDemotions and
+// FilterFailedServerRemovals methods from modification.
+func WithPromoterIsolation(isolation PromoterIsolation) Option {
+	return func(a *Autopilot) {
+		a.promoterIsolation = isolation
+	}
+}
+
+// isolatePromoter wraps the promoter so that it is called according to the
+// configured PromoterIsolation. The promoter is returned as is without any
+// isolation.
+func (a *Autopilot) isolatePromoter(promoter Promoter) Promoter {
+	if a.promoterIsolation == PromoterIsolationNone {
+		return promoter
+	}
+	return &isolatedPromoter{Promoter: promoter, autopilot: a}
+}
+
+// isolatedPromoter protects the arguments of the promoter methods which are
+// only meant to read the Config and State.
+type isolatedPromoter struct {
+	Promoter
+	autopilot *Autopilot
+}
+
+func (p *isolatedPromoter) CalculatePromotionsAndDemotions(conf *Config, state *State) RaftChanges {
+	conf, state, check := p.view(conf, state)
+	defer check("CalculatePromotionsAndDemotions")
+	return p.Promoter.CalculatePromotionsAndDemotions(conf, state)
+}
+
+func (p *isolatedPromoter) FilterFailedServerRemovals(conf *Config, state *State, failed *FailedServers) *FailedServers {
+	conf, state, check := p.view(conf, state)
+	defer check("FilterFailedServerRemovals")
+	return p.Promoter.FilterFailedServerRemovals(conf, state, failed)
+}
+
+// view returns the Config and State to hand the promoter along with a
+// function to call with the method's name once it has returned.
+func (p *isolatedPromoter) view(conf *Config, state *State) (*Config, *State, func(string)) {
+	if p.autopilot.promoterIsolation == PromoterIsolationCopy {
+		return conf.clone(), state.Clone(), func(string) {}
+	}
+
+	confBefore, stateBefore := conf.clone(), state.Clone()
+	check := func(method string) {
+		var modified []string
+		if !reflect.DeepEqual(conf, confBefore) {
+			modified = append(modified, "config")
+		}
+		if !reflect.DeepEqual(state, stateBefore) {
+			modified = append(modified, "state")
+		}
+		if len(modified) > 0 {
+			p.autopilot.subsystemRoundLogger(SubsystemPromoter).Error("promoter modified its read only arguments",
+				"promoter", fmt.Sprintf("%T", p.Promoter),
+				"method", method,
+				"modified", modified,
+			)
+		}
+	}
+	return conf, state, check
+}
+
+// clone returns a deep copy of the configuration. The Ext is only copied when
+// it implements ExtCloner.
+func (c *Config) clone() *Config {
+	if c == nil {
+		return nil
+	}
+
+	clone := *c
+	if c.FailureDomainKeys != nil {
+		clone.FailureDomainKeys = append([]string{}, c.FailureDomainKeys...)
+	}
+	if c.IgnoredServerSelectors != nil {
+		clone.IgnoredServerSelectors = append([]string{}, c.IgnoredServerSelectors...)
+	}
+	clone.PreferredLeaders = cloneIDs(c.PreferredLeaders)
+	if c.SpreadConstraints != nil {
+		clone.SpreadConstraints = append([]SpreadConstraint{}, c.SpreadConstraints...)
+	}
+	if c.Overrides != nil {
+		clone.Overrides = append([]ConfigOverride{}, c.Overrides...)
+	}
+	clone.Ext = cloneExt(c.Ext)
+	return &clone
+}
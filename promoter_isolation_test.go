@@ -0,0 +1,92 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	mock "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromoterIsolation(t *testing.T) {
+	// mutatingPromoter returns a promoter which modifies its arguments
+	mutatingPromoter := func(t *testing.T) *MockPromoter {
+		mpromoter := NewMockPromoter(t)
+		mpromoter.On("CalculatePromotionsAndDemotions", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				args.Get(0).(*Config).MinQuorum = 5
+				args.Get(1).(*State).Servers["b"].State = RaftVoter
+			}).
+			Return(RaftChanges{Promotions: []raft.ServerID{"b"}}).
+			Once()
+		return mpromoter
+	}
+
+	t.Run("none", func(t *testing.T) {
+		conf, state := &Config{}, testState(sandboxServers, "a")
+		ap := New(NewMockRaft(t), NewMockApplicationIntegration(t),
+			WithLogger(testLogger(t)),
+			WithPromoter(mutatingPromoter(t)),
+		)
+
+		_, err := ap.calculatePromotionsAndDemotions(conf, state)
+		require.NoError(t, err)
+		require.Equal(t, uint(5), conf.MinQuorum)
+		require.Equal(t, RaftVoter, state.Servers["b"].State)
+	})
+
+	t.Run("copy", func(t *testing.T) {
+		conf, state := &Config{PreferredLeaders: []raft.ServerID{"a"}}, testState(sandboxServers, "a")
+		ap := New(NewMockRaft(t), NewMockApplicationIntegration(t),
+			WithLogger(testLogger(t)),
+			WithPromoter(mutatingPromoter(t)),
+			WithPromoterIsolation(PromoterIsolationCopy),
+		)
+
+		changes, err := ap.calculatePromotionsAndDemotions(conf, state)
+		require.NoError(t, err)
+		require.Equal(t, []raft.ServerID{"b"}, changes.Promotions)
+		require.Equal(t, &Config{PreferredLeaders: []raft.ServerID{"a"}}, conf)
+		require.Equal(t, testState(sandboxServers, "a"), state)
+	})
+
+	t.Run("detect", func(t *testing.T) {
+		var buf bytes.Buffer
+		conf, state := &Config{}, testState(sandboxServers, "a")
+		ap := New(NewMockRaft(t), NewMockApplicationIntegration(t),
+			WithLogger(hclog.New(&hclog.LoggerOptions{Output: &buf})),
+			WithPromoter(mutatingPromoter(t)),
+			WithPromoterIsolation(PromoterIsolationDetect),
+		)
+
+		_, err := ap.calculatePromotionsAndDemotions(conf, state)
+		require.NoError(t, err)
+		require.Contains(t, buf.String(), "promoter modified its read only arguments")
+		require.Contains(t, buf.String(), `promoter="*autopilot.MockPromoter"`)
+		require.Contains(t, buf.String(), "method=CalculatePromotionsAndDemotions")
+		require.Contains(t, buf.String(), `modified=["config", "state"]`)
+	})
+
+	t.Run("detect-unmodified", func(t *testing.T) {
+		var buf bytes.Buffer
+		mpromoter := NewMockPromoter(t)
+		mpromoter.On("FilterFailedServerRemovals", mock.Anything, mock.Anything, mock.Anything).
+			Return(&FailedServers{}).
+			Once()
+
+		ap := New(NewMockRaft(t), NewMockApplicationIntegration(t),
+			WithLogger(hclog.New(&hclog.LoggerOptions{Output: &buf})),
+			WithPromoter(mpromoter),
+			WithPromoterIsolation(PromoterIsolationDetect),
+		)
+
+		failed := ap.isolatePromoter(mpromoter).FilterFailedServerRemovals(&Config{}, testState(sandboxServers, "a"), &FailedServers{})
+		require.Equal(t, &FailedServers{}, failed)
+		require.Empty(t, buf.String())
+	})
+}
@@ -0,0 +1,122 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"fmt"
+	"time"
+)
+
+// calculatePromotionsAndDemotions has the promoter calculate the RaftChanges for
+// the given state and records the outcome with recordPromoterOutcome.
+func (a *Autopilot) calculatePromotionsAndDemotions(conf *Config, state *State) (RaftChanges, error) {
+	changes, err := a.callConfiguredPromoter(conf, state)
+	a.recordPromoterOutcome(err)
+	return changes, err
+}
+
+// callConfiguredPromoter has the configured promoter, or the DefaultPromoter
+// once fallen back to it, calculate the RaftChanges for the given state. The
+// promoter is protected against panics and when a promoter timeout is
+// configured it is also not allowed to run for longer than that. The outcome
+// is not recorded.
+func (a *Autopilot) callConfiguredPromoter(conf *Config, state *State) (RaftChanges, error) {
+	promoter := a.getPromoter()
+	if a.usingFallbackPromoter() {
+		promoter = DefaultPromoter()
+	}
+
+	changes, err := a.runPromoter(a.isolatePromoter(promoter), conf, state)
+	if err != nil {
+		a.subsystemRoundLogger(SubsystemPromoter).Error("promoter failed to calculate promotions and demotions", "error", err)
+		return RaftChanges{}, err
+	}
+	return changes, nil
+}
+
+// recordPromoterOutcome records the outcome of calling the promoter for the
+// promoter status. Any failure is emitted as an event and when the fallback
+// option is enabled then the first failure will cause the DefaultPromoter to
+// be used for all subsequent rounds until ReinstatePromoter is called.
+func (a *Autopilot) recordPromoterOutcome(err error) {
+	a.recordPromoterResult(err)
+	if err == nil {
+		return
+	}
+
+	a.emitEvent(EventPromoterFailed, "", err.Error())
+
+	a.promoterLock.Lock()
+	defer a.promoterLock.Unlock()
+	if a.promoterFallback && !a.promoterFallbackActive {
+		a.subsystemRoundLogger(SubsystemPromoter).Warn("falling back to the default promoter until the configured promoter is reinstated")
+		a.promoterFallbackActive = true
+	}
+}
+
+// runPromoter calls CalculatePromotionsAndDemotions on the given promoter. When
+// no promoter timeout is configured the promoter will be called synchronously.
+// Otherwise it is run within a separate go routine so that it can be abandoned
+// if it takes too long. Note that an abandoned promoter call cannot be stopped
+// and its go routine will continue to run until the promoter returns.
+func (a *Autopilot) runPromoter(promoter Promoter, conf *Config, state *State) (RaftChanges, error) {
+	if a.promoterTimeout <= 0 {
+		return callPromoter(promoter, conf, state)
+	}
+
+	type result struct {
+		changes RaftChanges
+		err     error
+	}
+
+	// buffered so that an abandoned go routine can still finish
+	resultCh := make(chan result, 1)
+	go func() {
+		var res result
+		res.changes, res.err = callPromoter(promoter, conf, state)
+		resultCh <- res
+	}()
+
+	timer := time.NewTimer(a.promoterTimeout)
+	defer timer.Stop()
+
+	select {
+	case res := <-resultCh:
+		return res.changes, res.err
+	case <-timer.C:
+		return RaftChanges{}, fmt.Errorf("promoter did not finish calculating promotions and demotions within %s", a.promoterTimeout)
+	}
+}
+
+// callPromoter calls CalculatePromotionsAndDemotions on the promoter and converts
+// any panic into an error.
+func callPromoter(promoter Promoter, conf *Config, state *State) (changes RaftChanges, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			changes = RaftChanges{}
+			err = fmt.Errorf("promoter panicked: %v", r)
+		}
+	}()
+
+	return promoter.CalculatePromotionsAndDemotions(conf, state), nil
+}
+
+// usingFallbackPromoter returns whether the default promoter is currently being
+// used in place of the configured one.
+func (a *Autopilot) usingFallbackPromoter() bool {
+	a.promoterLock.Lock()
+	defer a.promoterLock.Unlock()
+	return a.promoterFallbackActive
+}
+
+// ReinstatePromoter will cause the configured promoter to be used again after
+// autopilot fell back to the default promoter due to a promoter failure.
+func (a *Autopilot) ReinstatePromoter() {
+	a.promoterLock.Lock()
+	defer a.promoterLock.Unlock()
+	if a.promoterFallbackActive {
+		a.promoterFallbackActive = false
+		a.subsystemRoundLogger(SubsystemPromoter).Info("configured promoter has been reinstated")
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/raft"
+	mock "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// sandboxServers are the servers of the promoter sandbox tests' states.
+var sandboxServers = []raft.ServerID{"a", "b"}
+
+func TestPromoterSandbox(t *testing.T) {
+	t.Run("panic", func(t *testing.T) {
+		var events []Event
+		mpromoter := NewMockPromoter(t)
+		mpromoter.On("CalculatePromotionsAndDemotions", mock.Anything, mock.Anything).
+			Run(func(mock.Arguments) { panic("boom") }).
+			Once()
+
+		ap := New(NewMockRaft(t), NewMockApplicationIntegration(t),
+			WithLogger(testLogger(t)),
+			WithPromoter(mpromoter),
+			WithEventHandler(func(e Event) { events = append(events, e) }),
+		)
+
+		changes, err := ap.calculatePromotionsAndDemotions(&Config{}, testState(sandboxServers, "a"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "boom")
+		require.Equal(t, RaftChanges{}, changes)
+		require.Len(t, events, 1)
+		require.Equal(t, EventPromoterFailed, events[0].Type)
+		require.False(t, ap.usingFallbackPromoter())
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+
+		mpromoter := NewMockPromoter(t)
+		mpromoter.On("CalculatePromotionsAndDemotions", mock.Anything, mock.Anything).
+			Run(func(mock.Arguments) { <-release }).
+			Return(RaftChanges{Promotions: []raft.ServerID{"b"}}).
+			Once()
+
+		ap := New(NewMockRaft(t), NewMockApplicationIntegration(t),
+			WithLogger(testLogger(t)),
+			WithPromoter(mpromoter),
+			WithPromoterTimeout(10*time.Millisecond),
+		)
+
+		changes, err := ap.calculatePromotionsAndDemotions(&Config{}, testState(sandboxServers, "a"))
+		require.Error(t, err)
+		require.Equal(t, RaftChanges{}, changes)
+	})
+
+	t.Run("within-timeout", func(t *testing.T) {
+		expected := RaftChanges{Promotions: []raft.ServerID{"b"}}
+		mpromoter := NewMockPromoter(t)
+		mpromoter.On("CalculatePromotionsAndDemotions", mock.Anything, mock.Anything).
+			Return(expected).
+			Once()
+
+		ap := New(NewMockRaft(t), NewMockApplicationIntegration(t),
+			WithLogger(testLogger(t)),
+			WithPromoter(mpromoter),
+			WithPromoterTimeout(time.Second),
+		)
+
+		changes, err := ap.calculatePromotionsAndDemotions(&Config{}, testState(sandboxServers, "a"))
+		require.NoError(t, err)
+		require.Equal(t, expected, changes)
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		state := testState(sandboxServers, "a")
+		conf := &Config{}
+
+		mpromoter := NewMockPromoter(t)
+		mpromoter.On("CalculatePromotionsAndDemotions", conf, state).
+			Run(func(mock.Arguments) { panic("boom") }).
+			Once()
+
+		mapp := NewMockApplicationIntegration(t)
+		mapp.On("AutopilotConfig").Return(conf).Times(2)
+
+		mraft := NewMockRaft(t)
+		mraft.On("AddVoter", raft.ServerID("b"), raft.ServerAddress(""), uint64(0), time.Duration(0)).
+			Return(&raftIndexFuture{}).
+			Once()
+
+		ap := New(mraft, mapp,
+			WithLogger(testLogger(t)),
+			WithPromoter(mpromoter),
+			WithPromoterFallback(),
+		)
+		ap.state = state
+
+		// the first round fails and is skipped
+		require.Error(t, ap.reconcile(context.Background()))
+		require.True(t, ap.usingFallbackPromoter())
+
+		// the second round uses the stable promoter to promote the non-voter
+		require.NoError(t, ap.reconcile(context.Background()))
+
+		ap.ReinstatePromoter()
+		require.False(t, ap.usingFallbackPromoter())
+	})
+}
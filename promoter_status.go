@@ -0,0 +1,83 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"fmt"
+	"time"
+)
+
+// PromoterDescriber may optionally be implemented by a promoter to identify
+// itself within the State's PromoterStatus.
+type PromoterDescriber interface {
+	// Name is a short identifier of the promotion algorithm such as
+	// "stable" or "zone-aware".
+	Name() string
+
+	// Describe is a human readable description of the algorithm and its
+	// settings.
+	Describe() string
+}
+
+// PromoterStatus identifies the promoter driving the promotions and demotions
+// and reports whether it failed in the last round.
+type PromoterStatus struct {
+	// Name is the promoter's name when it implements PromoterDescriber and
+	// otherwise its Go type.
+	Name string
+
+	// Description is the promoter's description when it implements
+	// PromoterDescriber.
+	Description string
+
+	// Fallback is set when the DefaultPromoter is being used because the
+	// configured promoter failed. See WithPromoterFallback.
+	Fallback bool
+
+	// LastError is the error with which the promoter failed in the most
+	// recent reconcile round and LastErrorTime when it failed. LastError is
+	// empty when the promoter succeeded.
+	LastError     string
+	LastErrorTime time.Time
+}
+
+// promoterStatus returns the status of the promoter in use.
+func (a *Autopilot) promoterStatus() PromoterStatus {
+	promoter := a.getPromoter()
+
+	a.promoterLock.Lock()
+	status := PromoterStatus{
+		Fallback:      a.promoterFallbackActive,
+		LastError:     a.promoterLastError,
+		LastErrorTime: a.promoterLastErrorTime,
+	}
+	a.promoterLock.Unlock()
+
+	if status.Fallback {
+		promoter = DefaultPromoter()
+	}
+
+	status.Name = fmt.Sprintf("%T", promoter)
+	if describer, ok := promoter.(PromoterDescriber); ok {
+		status.Name = describer.Name()
+		status.Description = describer.Describe()
+	}
+	return status
+}
+
+// recordPromoterResult records the outcome of the promoter calculating the
+// promotions and demotions for the promoter status.
+func (a *Autopilot) recordPromoterResult(err error) {
+	a.promoterLock.Lock()
+	defer a.promoterLock.Unlock()
+
+	if err == nil {
+		a.promoterLastError = ""
+		a.promoterLastErrorTime = time.Time{}
+		return
+	}
+
+	a.promoterLastError = err.Error()
+	a.promoterLastErrorTime = a.now()
+}
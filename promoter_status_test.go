@@ -0,0 +1,52 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"testing"
+	"time"
+
+	mock "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromoterStatus(t *testing.T) {
+	now := time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)
+	mtime := NewMockTimeProvider(t)
+	mtime.On("Now").Return(now)
+
+	mpromoter := NewMockPromoter(t)
+	mpromoter.On("CalculatePromotionsAndDemotions", mock.Anything, mock.Anything).
+		Run(func(mock.Arguments) { panic("boom") }).
+		Once()
+	mpromoter.On("CalculatePromotionsAndDemotions", mock.Anything, mock.Anything).
+		Return(RaftChanges{}).
+		Once()
+
+	ap := New(NewMockRaft(t), NewMockApplicationIntegration(t),
+		WithLogger(testLogger(t)),
+		WithPromoter(mpromoter),
+		WithTimeProvider(mtime),
+		WithPromoterFallback(),
+	)
+
+	// promoters which do not describe themselves are identified by their type
+	require.Equal(t, PromoterStatus{Name: "*autopilot.MockPromoter"}, ap.promoterStatus())
+
+	_, err := ap.calculatePromotionsAndDemotions(&Config{}, testState(sandboxServers, "a"))
+	require.Error(t, err)
+	require.Equal(t, PromoterStatus{
+		Name:          "stable",
+		Description:   DefaultPromoter().(PromoterDescriber).Describe(),
+		Fallback:      true,
+		LastError:     "promoter panicked: boom",
+		LastErrorTime: now,
+	}, ap.promoterStatus())
+
+	// the error is cleared once the promoter succeeds
+	ap.ReinstatePromoter()
+	_, err = ap.calculatePromotionsAndDemotions(&Config{}, testState(sandboxServers, "a"))
+	require.NoError(t, err)
+	require.Equal(t, PromoterStatus{Name: "*autopilot.MockPromoter"}, ap.promoterStatus())
+}
@@ -0,0 +1,76 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"fmt"
+	"time"
+)
+
+// ExtMigrator may optionally be implemented by a promoter given to SetPromoter
+// to convert the Ext fields the previous promoter stored within the current
+// state into its own, so that its first round does not act upon Ext values it
+// does not understand. Without one the Ext fields are replaced by the next
+// state update.
+type ExtMigrator interface {
+	// MigrateExt is called with the previous promoter and a copy of the
+	// current state, which replaces the current state once it returns. The
+	// state is nil when there is none yet.
+	MigrateExt(previous Promoter, conf *Config, state *State)
+}
+
+// SetPromoter replaces the promoter calculating promotions and demotions, for
+// example to switch from the default promoter to a zone aware one without
+// restarting the leader. It waits for any round or state update in progress
+// to finish so that each of them uses a single promoter throughout. Any
+// fallback to the default promoter is reset. When the new promoter implements
+// ExtMigrator it migrates the Ext fields of the current state before the
+// promoter is first used. SetPromoter must not be called from within the
+// promoter or the ApplicationIntegration.
+func (a *Autopilot) SetPromoter(promoter Promoter) {
+	if promoter == nil {
+		promoter = DefaultPromoter()
+	}
+
+	a.promoterSwap.Lock()
+	defer a.promoterSwap.Unlock()
+
+	a.promoterLock.Lock()
+	previous := a.promoter
+	a.promoter = promoter
+	a.promoterFallbackActive = false
+	a.promoterLastError = ""
+	a.promoterLastErrorTime = time.Time{}
+	a.promoterLock.Unlock()
+
+	a.logger.Info("promoter replaced", "previous", fmt.Sprintf("%T", previous), "promoter", fmt.Sprintf("%T", promoter))
+
+	migrator, ok := promoter.(ExtMigrator)
+	if !ok {
+		return
+	}
+
+	state := a.GetState().Clone()
+	migrator.MigrateExt(previous, a.delegate.AutopilotConfig(), state)
+
+	a.stateLock.Lock()
+	defer a.stateLock.Unlock()
+	if state != nil {
+		a.state = state
+	}
+}
+
+// getPromoter returns the promoter set with WithPromoter or SetPromoter.
+func (a *Autopilot) getPromoter() Promoter {
+	a.promoterLock.Lock()
+	defer a.promoterLock.Unlock()
+	return a.promoter
+}
+
+// usingPromoter prevents the promoter from being replaced until the returned
+// function is called.
+func (a *Autopilot) usingPromoter() func() {
+	a.promoterSwap.RLock()
+	return a.promoterSwap.RUnlock
+}
@@ -0,0 +1,79 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// migratingPromoter is a promoter which migrates the Ext of the state.
+type migratingPromoter struct {
+	Promoter
+	previous Promoter
+}
+
+func (p *migratingPromoter) MigrateExt(previous Promoter, _ *Config, state *State) {
+	p.previous = previous
+	state.Ext = "migrated"
+}
+
+func TestSetPromoter(t *testing.T) {
+	t.Run("between-rounds", func(t *testing.T) {
+		initial, replacement := NewMockPromoter(t), NewMockPromoter(t)
+		ap := New(NewMockRaft(t), NewMockApplicationIntegration(t),
+			WithLogger(testLogger(t)),
+			WithPromoter(initial),
+		)
+		ap.promoterFallbackActive = true
+
+		end := ap.beginRound(RoundReconcile)
+		swapped := make(chan struct{})
+		go func() {
+			ap.SetPromoter(replacement)
+			close(swapped)
+		}()
+
+		// the promoter is not replaced during the round
+		select {
+		case <-swapped:
+			t.Fatal("the promoter was replaced during a round")
+		case <-time.After(20 * time.Millisecond):
+		}
+		require.Same(t, initial, ap.getPromoter())
+
+		end()
+		<-swapped
+		require.Same(t, replacement, ap.getPromoter())
+		require.False(t, ap.usingFallbackPromoter())
+
+		ap.SetPromoter(nil)
+		require.IsType(t, DefaultPromoter(), ap.getPromoter())
+	})
+
+	t.Run("migrate-ext", func(t *testing.T) {
+		conf := &Config{}
+		mdel := NewMockApplicationIntegration(t)
+		mdel.On("AutopilotConfig").Return(conf).Once()
+
+		initial := NewMockPromoter(t)
+		ap := New(NewMockRaft(t), mdel,
+			WithLogger(testLogger(t)),
+			WithPromoter(initial),
+		)
+		state := testState(sandboxServers, "a")
+		state.Ext = "initial"
+		ap.state = state
+
+		replacement := &migratingPromoter{Promoter: NewMockPromoter(t)}
+		ap.SetPromoter(replacement)
+
+		require.Same(t, initial, replacement.previous)
+		require.Equal(t, "migrated", ap.GetState().Ext)
+		// the previous state is left untouched
+		require.Equal(t, "initial", state.Ext)
+	})
+}
@@ -0,0 +1,79 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hashicorp/raft"
+)
+
+// quarantineTracker remembers the IDs and addresses of recently removed
+// servers so that a server which keeps crashing cannot oscillate between
+// joining, being promoted, failing and being removed again.
+type quarantineTracker struct {
+	lock sync.Mutex
+
+	// window is how long removed servers are quarantined for. Zero
+	// disables the quarantine entirely.
+	window time.Duration
+
+	ids   map[raft.ServerID]time.Time
+	addrs map[raft.ServerAddress]time.Time
+}
+
+// removed quarantines the given server from now on. The address may be
+// empty when it is not known.
+func (t *quarantineTracker) removed(id raft.ServerID, addr raft.ServerAddress, now time.Time) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.window <= 0 {
+		return
+	}
+
+	if t.ids == nil {
+		t.ids = make(map[raft.ServerID]time.Time)
+	}
+	if t.addrs == nil {
+		t.addrs = make(map[raft.ServerAddress]time.Time)
+	}
+
+	until := now.Add(t.window)
+	t.ids[id] = until
+	if addr != "" {
+		t.addrs[addr] = until
+	}
+}
+
+// quarantined returns whether the server's ID or address belongs to a server
+// that was removed within the quarantine window. Expired entries are
+// forgotten about. The time is only requested when something is quarantined.
+func (t *quarantineTracker) quarantined(srv *Server, now func() time.Time) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if len(t.ids) == 0 && len(t.addrs) == 0 {
+		return false
+	}
+
+	current := now()
+	for id, until := range t.ids {
+		if !current.Before(until) {
+			delete(t.ids, id)
+		}
+	}
+	for addr, until := range t.addrs {
+		if !current.Before(until) {
+			delete(t.addrs, addr)
+		}
+	}
+
+	if _, ok := t.ids[srv.ID]; ok {
+		return true
+	}
+	_, ok := t.addrs[srv.Address]
+	return ok && srv.Address != ""
+}
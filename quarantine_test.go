@@ -0,0 +1,93 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuarantineTracker(t *testing.T) {
+	start := time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)
+	now := start
+	clock := func() time.Time { return now }
+
+	tracker := quarantineTracker{window: time.Minute}
+	tracker.removed("a", "198.18.0.1:8300", start)
+	tracker.removed("b", "", start)
+
+	require.True(t, tracker.quarantined(&Server{ID: "a"}, clock))
+	require.True(t, tracker.quarantined(&Server{ID: "b"}, clock))
+	// a new ID reusing the address of a removed server is also quarantined
+	require.True(t, tracker.quarantined(&Server{ID: "c", Address: "198.18.0.1:8300"}, clock))
+	require.False(t, tracker.quarantined(&Server{ID: "c", Address: "198.18.0.3:8300"}, clock))
+	require.False(t, tracker.quarantined(&Server{ID: "c"}, clock))
+
+	now = start.Add(time.Minute)
+	require.False(t, tracker.quarantined(&Server{ID: "a", Address: "198.18.0.1:8300"}, clock))
+	require.Empty(t, tracker.ids)
+	require.Empty(t, tracker.addrs)
+
+	// a zero window disables the quarantine without requesting the time
+	var disabled quarantineTracker
+	disabled.removed("a", "198.18.0.1:8300", start)
+	require.False(t, disabled.quarantined(&Server{ID: "a"}, func() time.Time {
+		t.Fatal("time should not be requested")
+		return time.Time{}
+	}))
+}
+
+func TestApplyPromotionsQuarantine(t *testing.T) {
+	start := time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)
+	now := start
+
+	state := &State{
+		Leader: "a",
+		Servers: map[raft.ServerID]*ServerState{
+			"a": {
+				Server: Server{ID: "a", Address: "198.18.0.1:8300"},
+				State:  RaftLeader,
+				Health: ServerHealth{Healthy: true},
+			},
+			"b": {
+				Server: Server{ID: "b", Address: "198.18.0.2:8300"},
+				State:  RaftNonVoter,
+				Health: ServerHealth{Healthy: true},
+			},
+		},
+	}
+	changes := RaftChanges{Promotions: []raft.ServerID{"b"}}
+
+	mtime := NewMockTimeProvider(t)
+	mtime.On("Now").Return(func() time.Time { return now })
+	mraft := NewMockRaft(t)
+
+	a := &Autopilot{
+		logger:     hclog.NewNullLogger(),
+		time:       mtime,
+		raft:       mraft,
+		quarantine: quarantineTracker{window: time.Minute},
+	}
+	a.quarantine.removed("b", "198.18.0.2:8300", start)
+
+	done, err := a.applyPromotions(context.Background(), state, changes)
+	require.False(t, done)
+	require.NoError(t, err)
+	require.Empty(t, a.planRaftChanges(state, changes).Promotions)
+
+	// once the quarantine expires the server may be promoted again
+	now = start.Add(time.Minute)
+	mraft.On("AddVoter", raft.ServerID("b"), raft.ServerAddress("198.18.0.2:8300"), uint64(0), time.Duration(0)).
+		Return(&raftIndexFuture{}).
+		Once()
+
+	done, err = a.applyPromotions(context.Background(), state, changes)
+	require.True(t, done)
+	require.NoError(t, err)
+}
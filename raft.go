@@ -9,12 +9,33 @@ package autopilot
 //
 
 import (
+	"context"
 	"fmt"
-	"strconv"
+	"time"
 
 	"github.com/hashicorp/raft"
 )
 
+// RaftOperationTimeouts are how long autopilot allows each kind of Raft
+// membership change to take. The timeouts for adding voters, demoting voters and
+// removing servers are passed to Raft which fails the operation when it cannot
+// be started in time. As Raft does not accept a timeout for leadership
+// transfers autopilot stops waiting for them instead. Zero, the default, uses
+// Raft's behavior of waiting indefinitely.
+type RaftOperationTimeouts struct {
+	// AddVoter is the timeout for adding voters and non-voters.
+	AddVoter time.Duration
+
+	// DemoteVoter is the timeout for demoting voters.
+	DemoteVoter time.Duration
+
+	// RemoveServer is the timeout for removing servers.
+	RemoveServer time.Duration
+
+	// LeadershipTransfer is the timeout for transferring leadership.
+	LeadershipTransfer time.Duration
+}
+
 func requiredQuorum(voters int) int {
 	return (voters / 2) + 1
 }
@@ -47,9 +68,10 @@ func (a *Autopilot) NumVoters() (int, error) {
 // be required that would cause leadership loss then an error is returned
 // instead of performing any Raft configuration changes.
 func (a *Autopilot) AddServer(s *Server) error {
+	ctx := context.Background()
 	cfg, err := a.getRaftConfiguration()
 	if err != nil {
-		a.logger.Error("failed to get raft configuration", "error", err)
+		a.roundLogger().Error("failed to get raft configuration", "error", err)
 		return err
 	}
 
@@ -82,29 +104,29 @@ func (a *Autopilot) AddServer(s *Server) error {
 
 	requiredVoters := requiredQuorum(numVoters)
 	if len(voterRemovals) > numVoters-requiredVoters {
-		return fmt.Errorf("Preventing server addition that would require removal of too many servers and cause cluster instability")
+		return fmt.Errorf("Preventing server addition that would require removal of too many servers and cause cluster instability: %w", ErrQuorumRisk)
 	}
 
 	for _, id := range voterRemovals {
-		if err := a.removeServer(id); err != nil {
+		if err := a.removeServer(ctx, id); err != nil {
 			return fmt.Errorf("error removing server %q with duplicate address %q: %w", id, s.Address, err)
 		}
-		a.logger.Info("removed server with duplicate address", "address", s.Address)
+		a.roundLogger().Info("removed server with duplicate address", "address", s.Address)
 	}
 
 	for _, id := range nonVoterRemovals {
-		if err := a.removeServer(id); err != nil {
+		if err := a.removeServer(ctx, id); err != nil {
 			return fmt.Errorf("error removing server %q with duplicate address %q: %w", id, s.Address, err)
 		}
-		a.logger.Info("removed server with duplicate address", "address", s.Address)
+		a.roundLogger().Info("removed server with duplicate address", "address", s.Address)
 	}
 
 	if existingVoter {
-		if err := a.addVoter(s.ID, s.Address); err != nil {
+		if err := a.addVoter(ctx, s.ID, s.Address); err != nil {
 			return err
 		}
 	} else {
-		if err := a.addNonVoter(s.ID, s.Address); err != nil {
+		if err := a.addNonVoter(ctx, s.ID, s.Address); err != nil {
 			return err
 		}
 	}
@@ -119,14 +141,14 @@ func (a *Autopilot) AddServer(s *Server) error {
 func (a *Autopilot) RemoveServer(id raft.ServerID) error {
 	cfg, err := a.getRaftConfiguration()
 	if err != nil {
-		a.logger.Error("failed to get raft configuration", "error", err)
+		a.roundLogger().Error("failed to get raft configuration", "error", err)
 		return err
 	}
 
 	// only remove servers currently in the configuration
 	for _, server := range cfg.Servers {
 		if server.ID == id {
-			return a.removeServer(server.ID)
+			return a.removeServer(context.Background(), server.ID)
 		}
 	}
 
@@ -135,10 +157,13 @@ func (a *Autopilot) RemoveServer(id raft.ServerID) error {
 
 // addNonVoter is a wrapper around calling the AddNonVoter method on the Raft
 // interface object provided to Autopilot
-func (a *Autopilot) addNonVoter(id raft.ServerID, addr raft.ServerAddress) error {
-	addFuture := a.raft.AddNonvoter(id, addr, 0, 0)
-	if err := addFuture.Error(); err != nil {
-		a.logger.Error("failed to add raft non-voting peer", "id", id, "address", addr, "error", err)
+func (a *Autopilot) addNonVoter(ctx context.Context, id raft.ServerID, addr raft.ServerAddress) error {
+	timeout := a.raftTimeouts.AddVoter
+	err := a.waitMembershipChange(ctx, "AddNonvoter", id, timeout, func() raft.Future {
+		return a.raft.AddNonvoter(id, addr, 0, timeout)
+	})
+	if err != nil {
+		a.roundLogger().Error("failed to add raft non-voting peer", "id", id, "address", addr, "error", err)
 		return err
 	}
 	return nil
@@ -146,19 +171,25 @@ func (a *Autopilot) addNonVoter(id raft.ServerID, addr raft.ServerAddress) error
 
 // addVoter is a wrapper around calling the AddVoter method on the Raft
 // interface object provided to Autopilot
-func (a *Autopilot) addVoter(id raft.ServerID, addr raft.ServerAddress) error {
-	addFuture := a.raft.AddVoter(id, addr, 0, 0)
-	if err := addFuture.Error(); err != nil {
-		a.logger.Error("failed to add raft voting peer", "id", id, "address", addr, "error", err)
+func (a *Autopilot) addVoter(ctx context.Context, id raft.ServerID, addr raft.ServerAddress) error {
+	timeout := a.raftTimeouts.AddVoter
+	err := a.waitMembershipChange(ctx, "AddVoter", id, timeout, func() raft.Future {
+		return a.raft.AddVoter(id, addr, 0, timeout)
+	})
+	if err != nil {
+		a.roundLogger().Error("failed to add raft voting peer", "id", id, "address", addr, "error", err)
 		return err
 	}
 	return nil
 }
 
-func (a *Autopilot) demoteVoter(id raft.ServerID) error {
-	removeFuture := a.raft.DemoteVoter(id, 0, 0)
-	if err := removeFuture.Error(); err != nil {
-		a.logger.Error("failed to demote raft peer", "id", id, "error", err)
+func (a *Autopilot) demoteVoter(ctx context.Context, id raft.ServerID) error {
+	timeout := a.raftTimeouts.DemoteVoter
+	err := a.waitMembershipChange(ctx, "DemoteVoter", id, timeout, func() raft.Future {
+		return a.raft.DemoteVoter(id, 0, timeout)
+	})
+	if err != nil {
+		a.roundLogger().Error("failed to demote raft peer", "id", id, "error", err)
 		return err
 	}
 	return nil
@@ -166,17 +197,26 @@ func (a *Autopilot) demoteVoter(id raft.ServerID) error {
 
 // removeServer is a wrapper around calling the RemoveServer method on the
 // Raft interface object provided to Autopilot
-func (a *Autopilot) removeServer(id raft.ServerID) error {
-	a.logger.Debug("removing server by ID", "id", id)
-	future := a.raft.RemoveServer(id, 0, 0)
-	if err := future.Error(); err != nil {
-		a.logger.Error("failed to remove raft server",
+func (a *Autopilot) removeServer(ctx context.Context, id raft.ServerID) error {
+	if a.isLocalServer(id) {
+		a.roundLogger().Error("refusing to remove the local server", "id", id)
+		return fmt.Errorf("refusing to remove the local server %s", id)
+	}
+
+	a.roundLogger().Debug("removing server by ID", "id", id)
+	timeout := a.raftTimeouts.RemoveServer
+	err := a.waitMembershipChange(ctx, "RemoveServer", id, timeout, func() raft.Future {
+		return a.raft.RemoveServer(id, 0, timeout)
+	})
+	if err != nil {
+		a.roundLogger().Error("failed to remove raft server",
 			"id", id,
 			"error", err,
 		)
 		return err
 	}
-	a.logger.Info("removed server", "id", id)
+	a.roundLogger().Info("removed server", "id", id)
+	a.emitEvent(EventServerRemoved, id, "removed from the Raft configuration")
 	return nil
 }
 
@@ -192,13 +232,39 @@ func (a *Autopilot) getRaftConfiguration() (*raft.Configuration, error) {
 }
 
 // lastTerm will retrieve the raft stats and then pull the last term value out of it
-func (a *Autopilot) lastTerm() (uint64, error) {
-	return strconv.ParseUint(a.raft.Stats()["last_log_term"], 10, 64)
+func (a *Autopilot) leaderStats() (*LeaderStats, error) {
+	return ParseLeaderStats(a.raft.Stats())
 }
 
-// leadershipTransfer will transfer leadership to the server with the specified id and address
-func (a *Autopilot) leadershipTransfer(id raft.ServerID, address raft.ServerAddress) error {
-	a.logger.Info("Transferring leadership to new server", "id", id, "address", address)
+// leadershipTransfer will transfer leadership to the server with the specified
+// id and address. It stops waiting for the transfer when the context is
+// cancelled or the leadership transfer timeout elapses.
+func (a *Autopilot) leadershipTransfer(ctx context.Context, id raft.ServerID, address raft.ServerAddress) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("not transferring leadership to server %s: %w", id, err)
+	}
+
+	if timeout := a.raftTimeouts.LeadershipTransfer; timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	a.subsystemRoundLogger(SubsystemTransfer).Info("Transferring leadership to new server", "id", id, "address", address)
 	future := a.raft.LeadershipTransferToServer(id, address)
-	return future.Error()
+	if ctx.Done() == nil {
+		return future.Error()
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- future.Error()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("stopped waiting for the leadership transfer to server %s: %w", id, ctx.Err())
+	}
 }
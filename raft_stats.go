@@ -0,0 +1,90 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// LeaderStats are the stats Raft reports about the local server, which is the
+// leader whenever autopilot is running, parsed from Raft's Stats.
+type LeaderStats struct {
+	// Term is the current Raft term.
+	Term uint64
+
+	// LastLogIndex and LastLogTerm identify the last entry in the log.
+	LastLogIndex uint64
+	LastLogTerm  uint64
+
+	// CommitIndex is the index of the last committed entry.
+	CommitIndex uint64
+
+	// AppliedIndex is the index of the last entry applied to the FSM.
+	AppliedIndex uint64
+
+	// FSMPending is the number of committed entries waiting to be applied to
+	// the FSM.
+	FSMPending uint64
+
+	// LastSnapshotIndex and LastSnapshotTerm identify the last entry included
+	// within the latest snapshot.
+	LastSnapshotIndex uint64
+	LastSnapshotTerm  uint64
+
+	// LastContact is the time since the server was last in contact with the
+	// leader. It is zero on the leader itself and when there has never been
+	// any contact, which NeverContacted distinguishes.
+	LastContact    time.Duration
+	NeverContacted bool
+
+	// NumPeers is the number of other voters in the cluster.
+	NumPeers uint64
+}
+
+// ParseLeaderStats parses the map returned by Raft's Stats method. Stats which
+// are missing are left as the zero value but an error is returned for any
+// which cannot be parsed.
+func ParseLeaderStats(stats map[string]string) (*LeaderStats, error) {
+	parsed := &LeaderStats{}
+
+	uints := map[string]*uint64{
+		"term":                &parsed.Term,
+		"last_log_index":      &parsed.LastLogIndex,
+		"last_log_term":       &parsed.LastLogTerm,
+		"commit_index":        &parsed.CommitIndex,
+		"applied_index":       &parsed.AppliedIndex,
+		"fsm_pending":         &parsed.FSMPending,
+		"last_snapshot_index": &parsed.LastSnapshotIndex,
+		"last_snapshot_term":  &parsed.LastSnapshotTerm,
+		"num_peers":           &parsed.NumPeers,
+	}
+	for key, value := range uints {
+		raw, ok := stats[key]
+		if !ok {
+			continue
+		}
+
+		v, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the Raft %s stat %q: %w", key, raw, err)
+		}
+		*value = v
+	}
+
+	switch raw := stats["last_contact"]; raw {
+	case "", "0":
+	case "never":
+		parsed.NeverContacted = true
+	default:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the Raft last_contact stat %q: %w", raw, err)
+		}
+		parsed.LastContact = d
+	}
+
+	return parsed, nil
+}
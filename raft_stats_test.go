@@ -0,0 +1,83 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLeaderStats(t *testing.T) {
+	type testCase struct {
+		stats    map[string]string
+		expected *LeaderStats
+		err      string
+	}
+
+	cases := map[string]testCase{
+		"leader": {
+			stats: map[string]string{
+				"state":                "Leader",
+				"term":                 "4",
+				"last_log_index":       "1200",
+				"last_log_term":        "4",
+				"commit_index":         "1199",
+				"applied_index":        "1190",
+				"fsm_pending":          "9",
+				"last_snapshot_index":  "1024",
+				"last_snapshot_term":   "3",
+				"protocol_version":     "3",
+				"latest_configuration": "[{Suffrage:Voter ID:a Address:198.18.0.1:8300}]",
+				"last_contact":         "0",
+				"num_peers":            "2",
+			},
+			expected: &LeaderStats{
+				Term:              4,
+				LastLogIndex:      1200,
+				LastLogTerm:       4,
+				CommitIndex:       1199,
+				AppliedIndex:      1190,
+				FSMPending:        9,
+				LastSnapshotIndex: 1024,
+				LastSnapshotTerm:  3,
+				NumPeers:          2,
+			},
+		},
+		"last-contact": {
+			stats:    map[string]string{"last_contact": "35.5ms"},
+			expected: &LeaderStats{LastContact: 35500 * time.Microsecond},
+		},
+		"never-contacted": {
+			stats:    map[string]string{"last_contact": "never"},
+			expected: &LeaderStats{NeverContacted: true},
+		},
+		"missing": {
+			stats:    map[string]string{},
+			expected: &LeaderStats{},
+		},
+		"malformed-index": {
+			stats: map[string]string{"commit_index": "ten"},
+			err:   `failed to parse the Raft commit_index stat "ten"`,
+		},
+		"malformed-last-contact": {
+			stats: map[string]string{"last_contact": "recently"},
+			err:   `failed to parse the Raft last_contact stat "recently"`,
+		},
+	}
+
+	for name, tcase := range cases {
+		t.Run(name, func(t *testing.T) {
+			stats, err := ParseLeaderStats(tcase.stats)
+			if tcase.err != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tcase.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tcase.expected, stats)
+		})
+	}
+}
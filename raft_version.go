@@ -0,0 +1,94 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/raft"
+)
+
+// compatibleVoters checks whether the given voters are able to elect a leader
+// while they run differing Raft protocol versions. The largest group of voters
+// sharing a version must be a quorum of all the voters and no smaller than
+// Config.MinCompatibleVoters. Servers with an unknown version (zero) are not
+// counted towards any group. When all known versions are the same nothing is
+// enforced. When the voters are not compatible the reason is returned along
+// with false.
+func compatibleVoters(conf *Config, state *State, voters map[raft.ServerID]struct{}) (string, bool) {
+	versions := make(map[int]int)
+	for id := range voters {
+		srv, ok := state.Servers[id]
+		if !ok || srv.Server.RaftVersion == 0 {
+			continue
+		}
+		versions[srv.Server.RaftVersion]++
+	}
+
+	if len(versions) < 2 {
+		return "", true
+	}
+
+	largestVersion, largest := 0, 0
+	for version, count := range versions {
+		if count > largest || (count == largest && version > largestVersion) {
+			largestVersion, largest = version, count
+		}
+	}
+
+	required := requiredQuorum(len(voters))
+	if int(conf.MinCompatibleVoters) > required {
+		required = int(conf.MinCompatibleVoters)
+	}
+
+	if largest < required {
+		return fmt.Sprintf("only %d voters would share Raft protocol version %d but %d are required", largest, largestVersion, required), false
+	}
+	return "", true
+}
+
+// guardRaftVersions removes any promotions and demotions from the changes which
+// would leave voters with mixed Raft protocol versions unable to elect a leader.
+// Promotions and demotions are never applied within the same round so each is
+// checked against the current voters along with the preceding changes of the
+// same kind.
+func (a *Autopilot) guardRaftVersions(conf *Config, state *State, changes RaftChanges) RaftChanges {
+	currentVoters := func() map[raft.ServerID]struct{} {
+		voters := make(map[raft.ServerID]struct{}, len(state.Voters))
+		for _, id := range state.Voters {
+			voters[id] = struct{}{}
+		}
+		return voters
+	}
+
+	result := RaftChanges{Leader: changes.Leader, LeaderCandidates: changes.LeaderCandidates}
+
+	voters := currentVoters()
+	for _, id := range changes.Promotions {
+		if _, ok := voters[id]; !ok {
+			voters[id] = struct{}{}
+			if reason, ok := compatibleVoters(conf, state, voters); !ok {
+				a.roundLogger().Warn("Not promoting server as it would leave too few voters with a compatible Raft protocol version", "id", id, "reason", reason)
+				delete(voters, id)
+				continue
+			}
+		}
+		result.Promotions = append(result.Promotions, id)
+	}
+
+	voters = currentVoters()
+	for _, id := range changes.Demotions {
+		if _, ok := voters[id]; ok {
+			delete(voters, id)
+			if reason, ok := compatibleVoters(conf, state, voters); !ok {
+				a.roundLogger().Warn("Not demoting server as it would leave too few voters with a compatible Raft protocol version", "id", id, "reason", reason)
+				voters[id] = struct{}{}
+				continue
+			}
+		}
+		result.Demotions = append(result.Demotions, id)
+	}
+
+	return result
+}
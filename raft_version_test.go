@@ -0,0 +1,72 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGuardRaftVersions(t *testing.T) {
+	versions := map[raft.ServerID]int{
+		"a": 3,
+		"b": 3,
+		"c": 3,
+		"d": 2,
+		"e": 2,
+		"f": 0,
+	}
+	servers := []raft.ServerID{"a", "b", "c", "d", "e", "f"}
+
+	type testCase struct {
+		conf     *Config
+		voters   []raft.ServerID
+		changes  RaftChanges
+		expected RaftChanges
+	}
+
+	cases := map[string]testCase{
+		"uniform-versions": {
+			conf:     &Config{},
+			voters:   []raft.ServerID{"a", "b"},
+			changes:  RaftChanges{Promotions: []raft.ServerID{"c", "f"}, Demotions: []raft.ServerID{"a"}},
+			expected: RaftChanges{Promotions: []raft.ServerID{"c", "f"}, Demotions: []raft.ServerID{"a"}},
+		},
+		"promotion-keeps-quorum": {
+			conf:     &Config{},
+			voters:   []raft.ServerID{"a", "b"},
+			changes:  RaftChanges{Promotions: []raft.ServerID{"d", "e"}},
+			expected: RaftChanges{Promotions: []raft.ServerID{"d"}},
+		},
+		"demotion-keeps-quorum": {
+			conf:     &Config{},
+			voters:   []raft.ServerID{"a", "b", "c", "d"},
+			changes:  RaftChanges{Demotions: []raft.ServerID{"a", "b"}},
+			expected: RaftChanges{Demotions: []raft.ServerID{"a"}},
+		},
+		"demotion-of-minority-version": {
+			conf:     &Config{},
+			voters:   []raft.ServerID{"a", "b", "c", "d"},
+			changes:  RaftChanges{Demotions: []raft.ServerID{"d"}},
+			expected: RaftChanges{Demotions: []raft.ServerID{"d"}},
+		},
+		"min-compatible-voters": {
+			conf:     &Config{MinCompatibleVoters: 3},
+			voters:   []raft.ServerID{"a", "b", "c", "d"},
+			changes:  RaftChanges{Demotions: []raft.ServerID{"a"}, Leader: "b"},
+			expected: RaftChanges{Leader: "b"},
+		},
+	}
+
+	a := &Autopilot{logger: hclog.NewNullLogger()}
+	for name, tcase := range cases {
+		t.Run(name, func(t *testing.T) {
+			state := withRaftVersions(testState(servers, "", tcase.voters...), versions)
+			require.Equal(t, tcase.expected, a.guardRaftVersions(tcase.conf, state, tcase.changes))
+		})
+	}
+}
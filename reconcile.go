@@ -4,38 +4,80 @@
 package autopilot
 
 import (
+	"context"
 	"fmt"
 	"sort"
+	"strings"
+	"time"
 
+	metrics "github.com/armon/go-metrics"
 	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/raft"
 )
 
 // reconcile calculates and then applies promotions and demotions
-func (a *Autopilot) reconcile() error {
+func (a *Autopilot) reconcile(ctx context.Context) error {
 	if !a.ReconciliationEnabled() {
 		return nil
 	}
 
+	defer a.beginRound(RoundReconcile)()
+	defer a.measureReconcile(time.Now())
+
+	a.countDelegateCall("AutopilotConfig")
 	conf := a.delegate.AutopilotConfig()
 	if conf == nil {
 		return nil
 	}
 
+	if a.membershipChangeStuck() {
+		a.roundLogger().Warn("skipping reconciliation while a raft operation is unresolved")
+		return nil
+	}
+
 	// grab the current state while locked
 	state := a.GetState()
 
 	if state == nil || state.Leader == "" {
-		return fmt.Errorf("cannot reconcile Raft server voting rights without a valid autopilot state")
+		return fmt.Errorf("cannot reconcile Raft server voting rights: %w", ErrNoState)
+	}
+
+	// continue the operations started by a previous leader
+	a.loadOperations(state)
+
+	if state.Degraded {
+		a.roundLogger().Warn("skipping reconciliation while server stats are unavailable")
+		return nil
+	}
+
+	if !conf.DryRun {
+		a.advanceReplacements(ctx, conf, state)
 	}
 
-	// have the promoter calculate the required Raft changeset.
-	changes := a.promoter.CalculatePromotionsAndDemotions(conf, state)
+	changes, updates, err := a.reconcileChanges(conf, state)
+	a.recordTrackerUpdates(state, updates)
+	if err != nil {
+		return fmt.Errorf("skipping reconciliation due to a promoter failure: %w", err)
+	}
+
+	if conf.DryRun {
+		plan := a.planRaftChanges(state, changes)
+		a.roundLogger().Info("dry run, not applying planned changes", "plan", plan)
+		a.emitEvent(EventPlannedChanges, "", fmt.Sprintf("dry run, planned changes: %s", plan))
+		return nil
+	}
+
+	if a.invariantChecks {
+		plan := a.planRaftChanges(state, changes)
+		if err := a.checkInvariants(conf, state, PlannedChanges{Promotions: plan.Promotions, Demotions: plan.Demotions}); err != nil {
+			return err
+		}
+	}
 
 	// apply the promotions, if we did apply any then stop here
 	// as we do not want to apply the demotions at the same time
 	// as a means of preventing cluster instability.
-	if done, err := a.applyPromotions(state, changes); done {
+	if done, err := a.applyPromotions(ctx, state, changes); done {
 		return err
 	}
 
@@ -43,23 +85,231 @@ func (a *Autopilot) reconcile() error {
 	// as we do not want to transition leadership and do demotions
 	// at the same time. This is a preventative measure to maintain
 	// cluster stability.
-	if done, err := a.applyDemotions(state, changes); done {
+	if done, err := a.applyDemotions(ctx, conf, state, changes); done {
 		return err
 	}
 
-	// if no leadership transfer is desired then we can exit the method now.
-	if changes.Leader == "" || changes.Leader == state.Leader {
+	return a.applyLeadershipTransfer(ctx, state, changes)
+}
+
+// trackerUpdates are the updates to autopilot's trackers which result from
+// calculating a round's changes. reconcileChanges returns them rather than
+// recording them itself so that Plan can calculate the changes without
+// altering what later rounds do.
+type trackerUpdates struct {
+	// promoterErr is the outcome of calling the promoter.
+	promoterErr error
+
+	// canaries replaces the tracked canaries when not nil.
+	canaries map[string]*canary
+
+	// unhealthyLeader replaces the tracking of the leader's health when not
+	// nil.
+	unhealthyLeader *unhealthyLeader
+}
+
+// recordTrackerUpdates records the updates calculated by reconcileChanges for
+// the state.
+func (a *Autopilot) recordTrackerUpdates(state *State, updates *trackerUpdates) {
+	a.recordPromoterOutcome(updates.promoterErr)
+	if updates.canaries != nil {
+		a.canaries.record(updates.canaries)
+	}
+	if updates.unhealthyLeader != nil {
+		a.unhealthyLeader.record(*updates.unhealthyLeader)
+	}
+	a.leaderHandoff.forgetOthers(state.Leader)
+}
+
+// reconcileChanges has the promoter calculate the required Raft changeset and
+// then adjusts it for the zones being evacuated, mixed Raft protocol versions
+// and diverging terms. None of autopilot's trackers are updated, instead the
+// updates are returned to be recorded with recordTrackerUpdates.
+func (a *Autopilot) reconcileChanges(conf *Config, state *State) (RaftChanges, *trackerUpdates, error) {
+	updates := &trackerUpdates{}
+
+	changes, err := a.callConfiguredPromoter(conf, state)
+	if err != nil {
+		updates.promoterErr = err
+		return RaftChanges{}, updates, err
+	}
+
+	// keep leadership on the preferred leaders
+	changes = a.placeLeader(conf, state, changes)
+
+	// converge on the target number of voters
+	changes = a.maintainTargetVoters(conf, state, changes)
+
+	// adjust the changes to advance any server replacements
+	changes = a.replaceServers(conf, state, changes)
+
+	// adjust the changes to move voters out of any zones being evacuated
+	changes = a.evacuateZones(conf, state, changes)
+
+	// keep the voters spread out as the spread constraints require
+	changes = a.enforceSpread(conf, state, changes)
+
+	// avoid mixing the versions of the voters during rolling upgrades
+	changes = a.guardVersionSkew(conf, state, changes)
+
+	// promote a single canary of each new version until it has soaked
+	changes = a.promoteCanaries(conf, state, changes, updates)
+
+	// prevent mixed Raft protocol versions from leaving a voter set which
+	// cannot elect a leader
+	changes = a.guardRaftVersions(conf, state, changes)
+
+	// move leadership off of a leader which has been unhealthy for a while
+	changes = a.transferFromUnhealthyLeader(conf, state, changes, updates)
+
+	// avoid churning voting rights while the servers' terms are diverging
+	if state.TermsDiverged && len(changes.Demotions) > 0 {
+		a.roundLogger().Info("suppressing demotions while server Raft terms are diverging", "demotions", changes.Demotions)
+		changes.Demotions = nil
+	}
+
+	// demote voters whose health is flapping
+	changes = a.demoteFlapping(conf, state, changes)
+
+	// never demote the leader, transfer leadership away from it first
+	changes = a.protectLeader(state, changes)
+
+	// hold disruptive changes until a maintenance window opens
+	if leader, _, _ := chooseLeader(state, changes); (len(changes.Demotions) > 0 || leader != "") && !a.inMaintenanceWindow() {
+		a.roundLogger().Info("holding demotions and leadership transfers until a maintenance window opens", "demotions", changes.Demotions, "leader", changes.Leader)
+		changes.Demotions = nil
+		changes.Leader = ""
+		changes.LeaderCandidates = nil
+	}
+
+	// avoid leaving an even number of voters when there is a choice
+	changes = a.keepVotersOdd(state, changes)
+
+	return changes, updates, nil
+}
+
+// leaderCandidates returns the servers the changes nominate to become the
+// leader in order of preference without any duplicates.
+func leaderCandidates(changes RaftChanges) []raft.ServerID {
+	var candidates []raft.ServerID
+	seen := make(map[raft.ServerID]struct{})
+	for _, id := range append([]raft.ServerID{changes.Leader}, changes.LeaderCandidates...) {
+		if _, ok := seen[id]; ok || id == "" {
+			continue
+		}
+		seen[id] = struct{}{}
+		candidates = append(candidates, id)
+	}
+	return candidates
+}
+
+// leaderIneligibleReason returns why the server cannot currently be
+// transferred leadership or an empty string if it can be.
+func leaderIneligibleReason(state *State, id raft.ServerID) string {
+	srv, ok := state.Servers[id]
+	switch {
+	case !ok:
+		return "not in the autopilot state"
+	case !srv.HasVotingRights():
+		return "not a voter"
+	case !srv.Health.Healthy:
+		return "unhealthy"
+	case srv.Ignored:
+		return "ignored"
+	default:
+		return ""
+	}
+}
+
+// chooseLeader returns the first eligible server nominated by the changes
+// along with descriptions of the preferred candidates which were skipped. An
+// empty ID is returned when no transfer should be performed, either because
+// the current leader is nominated before any other eligible server or because
+// no candidates are eligible. The latter is indicated by a false bool.
+func chooseLeader(state *State, changes RaftChanges) (raft.ServerID, []string, bool) {
+	var skipped []string
+	for _, id := range leaderCandidates(changes) {
+		if id == state.Leader {
+			// the current leader is preferred over the remaining candidates
+			return "", skipped, true
+		}
+
+		if reason := leaderIneligibleReason(state, id); reason != "" {
+			skipped = append(skipped, fmt.Sprintf("%s is %s", id, reason))
+			continue
+		}
+
+		return id, skipped, true
+	}
+
+	return "", skipped, len(skipped) == 0
+}
+
+// applyLeadershipTransfer transfers leadership to the first eligible server
+// nominated by the changes. No transfer is performed when the current leader
+// is nominated before any other eligible server.
+func (a *Autopilot) applyLeadershipTransfer(ctx context.Context, state *State, changes RaftChanges) error {
+	if !a.MembershipChangesEnabled(MembershipChangeLeadershipTransfer) {
 		return nil
 	}
 
-	// lookup the server we want to transfer leadership to
-	srv, ok := state.Servers[changes.Leader]
+	retries := a.transferRetries.enabled()
+	if retries {
+		a.verifyLeadershipTransfer(state)
+	}
+
+	id, skipped, ok := chooseLeader(state, changes)
 	if !ok {
-		return fmt.Errorf("cannot transfer leadership to an unknown server with ID %s", changes.Leader)
+		return fmt.Errorf("%w as no candidates are eligible: %s", ErrLeadershipTransferFailed, strings.Join(skipped, ", "))
+	}
+
+	if len(skipped) > 0 {
+		a.subsystemRoundLogger(SubsystemTransfer).Debug("Skipped leadership transfer candidates", "skipped", skipped)
 	}
 
-	// perform the leadership transfer
-	return a.leadershipTransfer(changes.Leader, srv.Server.Address)
+	if id == "" {
+		return nil
+	}
+
+	if a.inTransferBlackout() {
+		a.subsystemRoundLogger(SubsystemTransfer).Debug("Not transferring leadership during a blackout window", "id", id)
+		return nil
+	}
+
+	if retries {
+		if reason, ok := a.transferRetries.ready(id, a.now()); !ok {
+			a.subsystemRoundLogger(SubsystemTransfer).Debug("Not transferring leadership", "id", id, "reason", reason)
+			return nil
+		}
+	}
+
+	reason := "it was the preferred candidate"
+	if len(skipped) > 0 {
+		reason = fmt.Sprintf("the preferred candidates were ineligible: %s", strings.Join(skipped, ", "))
+	}
+	if !a.approveLeadershipTransfer(&state.Servers[id].Server) || !a.takeTransferBudget(id) {
+		return nil
+	}
+
+	message := fmt.Sprintf("transferring leadership to %s as %s", id, reason)
+	a.emitEvent(EventLeadershipTransfer, id, message)
+
+	if err := a.leadershipTransfer(ctx, id, state.Servers[id].Server.Address); err != nil {
+		if ctx.Err() == nil {
+			// stopping autopilot is not a failure of the transfer
+			a.leadershipTransferFailed(id, err)
+		}
+		return fmt.Errorf("%w to server %s: %w", ErrLeadershipTransferFailed, id, err)
+	}
+	if retries {
+		a.transferRetries.attempted(state)
+	}
+	a.notifyMembershipChange(MembershipChangeLeadershipTransfer, &state.Servers[id].Server, state.Leader, message)
+	a.recordDecision(DecisionTransferLeadership, ReasonLeaderNominated, id, message, map[string]string{
+		"previous_leader": string(state.Leader),
+		"skipped":         strings.Join(skipped, ", "),
+	})
+	return nil
 }
 
 // applyPromotions will apply all the promotions in the RaftChanges parameter.
@@ -68,40 +318,50 @@ func (a *Autopilot) reconcile() error {
 // * The server isn't tracked in the provided state
 // * The server already has voting rights
 // * The server is not healthy
+// * The server no longer qualifies according to the latest autopilot state
 //
-// If any servers were promoted this function returns true for the bool value.
-func (a *Autopilot) applyPromotions(state *State, changes RaftChanges) (bool, error) {
+// If any servers were promoted, or a promotion was deferred because the
+// membership change budget is exhausted, this function returns true for the
+// bool value.
+func (a *Autopilot) applyPromotions(ctx context.Context, state *State, changes RaftChanges) (bool, error) {
+	if len(changes.Promotions) > 0 && !a.MembershipChangesEnabled(MembershipChangePromotion) {
+		a.roundLogger().Debug("Not promoting servers as promotions are disabled", "promotions", changes.Promotions)
+		return false, nil
+	}
+
 	promoted := false
 	for _, change := range changes.Promotions {
-		srv, found := state.Servers[change]
-		if !found {
-			a.logger.Debug("Ignoring promotion of server as it is not in the autopilot state", "id", change)
-			// this shouldn't be able to happen but is a nice safety measure against the
-			// delegate doing something less than desirable
+		if reason := a.promotionIneligibleReason(state, change); reason != "" {
+			a.roundLogger().Debug("Not promoting server", "id", change, "reason", reason)
 			continue
 		}
+		srv := state.Servers[change]
 
-		if srv.HasVotingRights() {
-			// There is no need to promote as this server is already a voter.
-			// No logging is needed here as this could be a very common case
-			// where the promoter just returns a lists of server ids that should
-			// be voters and non-voters without caring about which ones currently
-			// already are in that state.
-			a.logger.Debug("Not promoting server that already has voting rights", "id", change)
+		if reason, ok := a.revalidatePromotion(srv); !ok {
+			a.roundLogger().Debug("Ignoring promotion of server that no longer qualifies", "id", change, "reason", reason)
 			continue
 		}
 
-		if !srv.Health.Healthy {
-			// do not promote unhealthy servers
-			a.logger.Debug("Ignoring promotion of unhealthy server", "id", change)
-			continue
+		if !a.takeChangeBudget(MembershipChangePromotion, change) {
+			// stop here so that no demotions are made while promotions are pending
+			return true, nil
 		}
 
-		a.logger.Info("Promoting server", "id", srv.Server.ID, "address", srv.Server.Address, "name", srv.Server.Name)
+		a.roundLogger().Info("Promoting server", "id", srv.Server.ID, "address", srv.Server.Address, "name", srv.Server.Name)
 
-		if err := a.addVoter(srv.Server.ID, srv.Server.Address); err != nil {
-			return true, fmt.Errorf("failed promoting server %s: %v", srv.Server.ID, err)
+		if err := a.addVoter(ctx, srv.Server.ID, srv.Server.Address); err != nil {
+			a.lockouts.failed(srv.Server.ID, MembershipChangePromotion, a.now(), err)
+			return true, fmt.Errorf("failed promoting server %s: %w", srv.Server.ID, err)
 		}
+		a.lockouts.succeeded(srv.Server.ID)
+		a.lifecycle.promoted(a.metricsSink(), srv.Server.ID, a.now())
+		a.metricsSink().IncrCounterWithLabels(promotionsKey, 1, serverLabels(srv))
+		a.emitEvent(EventServerPromoted, srv.Server.ID, "promoted to a voter")
+		a.notifyMembershipChange(MembershipChangePromotion, &srv.Server, "", "promoted to a voter")
+		a.recordDecision(DecisionPromote, ReasonStableNonVoter, srv.Server.ID, "promoted to a voter", map[string]string{
+			"stable_since": srv.Health.StableSince.Format(time.RFC3339),
+			"last_index":   fmt.Sprint(srv.Stats.LastIndex),
+		})
 
 		promoted = true
 	}
@@ -117,35 +377,69 @@ func (a *Autopilot) applyPromotions(state *State, changes RaftChanges) (bool, er
 // IDs in the change set will be ignored if:
 // * The server isn't tracked in the provided state
 // * The server does not have voting rights
+// * The server no longer qualifies according to the latest autopilot state
 //
-// If any servers were demoted this function returns true for the bool value.
-func (a *Autopilot) applyDemotions(state *State, changes RaftChanges) (bool, error) {
+// If any servers were demoted, or a demotion was deferred because the
+// membership change budget is exhausted, this function returns true for the
+// bool value.
+func (a *Autopilot) applyDemotions(ctx context.Context, conf *Config, state *State, changes RaftChanges) (bool, error) {
+	if len(changes.Demotions) > 0 && !a.MembershipChangesEnabled(MembershipChangeDemotion) {
+		a.roundLogger().Debug("Not demoting servers as demotions are disabled", "demotions", changes.Demotions)
+		return false, nil
+	}
+
+	// the demotions requested by the promoter are checked against the
+	// MinQuorum and the healthy majority of the voters as removals are
+	checker := NewInvariantChecker(conf)
+	var made []raft.ServerID
+
 	demoted := false
 	for _, change := range changes.Demotions {
-		srv, found := state.Servers[change]
-		if !found {
-			a.logger.Debug("Ignoring demotion of server as it is not in the autopilot state", "id", change)
-			// this shouldn't be able to happen but is a nice safety measure against the
-			// delegate doing something less than desirable
+		if reason := a.demotionIneligibleReason(state, change); reason != "" {
+			a.roundLogger().Debug("Not demoting server", "id", change, "reason", reason)
+			continue
+		}
+		srv := state.Servers[change]
+
+		if err := checker.CheckChanges(state, RaftChanges{Demotions: append(made, change)}); err != nil {
+			a.roundLogger().Warn("Refusing to demote server as it would put the quorum at risk", "id", change, "error", err)
 			continue
 		}
 
-		if srv.State == RaftNonVoter {
-			// There is no need to demote as this server is already a non-voter.
-			// No logging is needed here as this could be a very common case
-			// where the promoter just returns a lists of server ids that should
-			// be voters and non-voters without caring about which ones currently
-			// already are in that state.
-			a.logger.Debug("Ignoring demotion of server that is already a non-voter", "id", change)
+		if !a.confirmDestructiveAction() {
+			// stop here as the application isn't ready for any demotions
+			return true, nil
+		}
+
+		if reason, ok := a.revalidateDemotion(srv); !ok {
+			a.roundLogger().Debug("Ignoring demotion of server that no longer qualifies", "id", change, "reason", reason)
 			continue
 		}
 
-		a.logger.Info("Demoting server", "id", srv.Server.ID, "address", srv.Server.Address, "name", srv.Server.Name)
+		if !a.approveDemotion(&srv.Server) {
+			continue
+		}
 
-		if err := a.demoteVoter(srv.Server.ID); err != nil {
-			return true, fmt.Errorf("failed demoting server %s: %v", srv.Server.ID, err)
+		if !a.takeChangeBudget(MembershipChangeDemotion, change) {
+			// stop here so that leadership is not transferred while demotions are pending
+			return true, nil
 		}
 
+		a.roundLogger().Info("Demoting server", "id", srv.Server.ID, "address", srv.Server.Address, "name", srv.Server.Name)
+
+		if err := a.demoteVoter(ctx, srv.Server.ID); err != nil {
+			a.lockouts.failed(srv.Server.ID, MembershipChangeDemotion, a.now(), err)
+			return true, fmt.Errorf("failed demoting server %s: %w", srv.Server.ID, err)
+		}
+		a.lockouts.succeeded(srv.Server.ID)
+		made = append(made, change)
+		a.metricsSink().IncrCounterWithLabels(demotionsKey, 1, serverLabels(srv))
+		a.emitEvent(EventServerDemoted, srv.Server.ID, "demoted to a non-voter")
+		a.notifyMembershipChange(MembershipChangeDemotion, &srv.Server, "", "demoted to a non-voter")
+		a.recordDecision(DecisionDemote, ReasonDemotionRequested, srv.Server.ID, "demoted to a non-voter", map[string]string{
+			"healthy": fmt.Sprint(srv.Health.Healthy),
+		})
+
 		demoted = true
 	}
 
@@ -155,11 +449,161 @@ func (a *Autopilot) applyDemotions(state *State, changes RaftChanges) (bool, err
 	return demoted, nil
 }
 
+// promotionIneligibleReason returns why the server may not be promoted given
+// the state or an empty string if it may be. It is shared by applyPromotions
+// and planRaftChanges so that plans only contain the promotions which would be
+// made.
+func (a *Autopilot) promotionIneligibleReason(state *State, id raft.ServerID) string {
+	srv, found := state.Servers[id]
+	switch {
+	case !found:
+		// this shouldn't be able to happen but is a nice safety measure against
+		// the delegate doing something less than desirable
+		return "it is not in the autopilot state"
+	case srv.HasVotingRights():
+		// this could be a very common case where the promoter just returns a
+		// lists of server ids that should be voters and non-voters without
+		// caring about which ones currently already are in that state
+		return "it already has voting rights"
+	case srv.Ignored:
+		return "autopilot is configured to ignore it"
+	case srv.Health.TermAhead:
+		// the server may be partitioned and running away with its term
+		return "its term is ahead of the leader"
+	case srv.Health.Flapping:
+		// wait for the server's health to settle down
+		return "its health is flapping"
+	case !srv.caughtUp():
+		return "its replication has not caught up with the leader"
+	case !srv.Health.Healthy:
+		return "it is unhealthy"
+	case a.lockouts.isLockedOut(id, a.now()):
+		// do not keep retrying servers that repeatedly fail to be promoted
+		return "it is locked out after repeated failures"
+	case a.quarantine.quarantined(&srv.Server, a.now):
+		// do not let crash looping servers rejoin the voters straight away
+		return "it was recently removed"
+	default:
+		return ""
+	}
+}
+
+// demotionIneligibleReason returns why the server may not be demoted given the
+// state or an empty string if it may be. It is shared by applyDemotions and
+// planRaftChanges so that plans only contain the demotions which would be made.
+// Whether the demotion would put the quorum at risk depends upon the other
+// demotions made and is checked separately.
+func (a *Autopilot) demotionIneligibleReason(state *State, id raft.ServerID) string {
+	srv, found := state.Servers[id]
+	switch {
+	case !found:
+		return "it is not in the autopilot state"
+	case srv.State == RaftNonVoter:
+		return "it is already a non-voter"
+	case srv.Ignored:
+		return "autopilot is configured to ignore it"
+	case srv.Server.Protected:
+		return "it is protected"
+	case id == state.Leader:
+		// leadership must be transferred before the server may be demoted
+		return "it is the current leader"
+	case a.lockouts.isLockedOut(id, a.now()):
+		// do not keep retrying servers that repeatedly fail to be demoted
+		return "it is locked out after repeated failures"
+	default:
+		return ""
+	}
+}
+
+// revalidatePromotion checks the server against the most recent autopilot state
+// immediately before it gets promoted. The promoter's decisions are made from a
+// state which may be several seconds old by the time they are applied, so the
+// promotion is skipped if the server has since become a voter or unhealthy, has
+// had its health change or is now ignored. When the promotion should not go
+// ahead the reason is returned along with false.
+func (a *Autopilot) revalidatePromotion(snapshot *ServerState) (string, bool) {
+	if !a.FeatureEnabled(FeatureApplyRevalidation) {
+		return "", true
+	}
+
+	current := a.GetState()
+	if current == nil {
+		// nothing newer to check against
+		return "", true
+	}
+
+	latest, ok := current.Servers[snapshot.Server.ID]
+	if !ok {
+		return "server is no longer in the autopilot state", false
+	}
+
+	if latest.HasVotingRights() {
+		return "server already has voting rights", false
+	}
+
+	if !latest.Health.Healthy {
+		return "server is no longer healthy", false
+	}
+
+	if !latest.Health.StableSince.Equal(snapshot.Health.StableSince) {
+		return "server health has changed", false
+	}
+
+	if latest.Ignored {
+		return "server is now ignored", false
+	}
+
+	return "", true
+}
+
+// revalidateDemotion checks the server against the most recent autopilot state
+// immediately before it gets demoted. The demotion is skipped if the server has
+// since lost its voting rights or is now ignored. When the demotion should not
+// go ahead the reason is returned along with false.
+func (a *Autopilot) revalidateDemotion(snapshot *ServerState) (string, bool) {
+	if !a.FeatureEnabled(FeatureApplyRevalidation) {
+		return "", true
+	}
+
+	current := a.GetState()
+	if current == nil {
+		// nothing newer to check against
+		return "", true
+	}
+
+	latest, ok := current.Servers[snapshot.Server.ID]
+	if !ok {
+		return "server is no longer in the autopilot state", false
+	}
+
+	if latest.State == RaftNonVoter {
+		return "server is already a non-voter", false
+	}
+
+	if latest.Ignored {
+		return "server is now ignored", false
+	}
+
+	if latest.Server.Protected {
+		return "server is now protected", false
+	}
+
+	return "", true
+}
+
 // getFailedServers aggregates all the information about servers that the consuming application believes are in
 // a failed/left state (indicated by the NodeStatus field on the Server type) as well as stale servers that are
 // in the raft configuration but not know to the consuming application. This function will do nothing with
-// that information and is purely to collect the data.
-func (a *Autopilot) getFailedServers() (*FailedServers, *voterRegistry, error) {
+// that information and is purely to collect the data. Servers with an unknown status will be categorized
+// as held instead of failed when the config says to do so.
+func (a *Autopilot) getFailedServers(conf *Config) (*FailedServers, *voterRegistry, error) {
+	a.countDelegateCall("KnownServers")
+	return a.categorizeServers(conf, a.delegate.KnownServers())
+}
+
+// categorizeServers aggregates the failed, stale and held servers as
+// getFailedServers does given the application's known servers.
+func (a *Autopilot) categorizeServers(conf *Config, knownServers map[raft.ServerID]*Server) (*FailedServers, *voterRegistry, error) {
 	staleRaftServers := make(map[raft.ServerID]raft.Server)
 	raftConfig, err := a.getRaftConfiguration()
 	if err != nil {
@@ -180,7 +624,7 @@ func (a *Autopilot) getFailedServers() (*FailedServers, *voterRegistry, error) {
 
 	var failed FailedServers
 
-	for id, srv := range a.delegate.KnownServers() {
+	for id, srv := range knownServers {
 		raftSrv, found := staleRaftServers[id]
 		if found {
 			delete(staleRaftServers, id)
@@ -192,9 +636,35 @@ func (a *Autopilot) getFailedServers() (*FailedServers, *voterRegistry, error) {
 
 		// Update the potential suffrage using the supplied predicate.
 		v := registry.eligibility[id]
-		v.setPotentialVoter(a.promoter.IsPotentialVoter(srv.NodeType))
+		v.setPotentialVoter(a.getPromoter().IsPotentialVoter(srv.NodeType))
+
+		if a.isIgnored(conf, srv) {
+			// ignored servers are never removed
+			continue
+		}
+
+		if srv.Protected {
+			if srv.NodeStatus != NodeAlive {
+				a.roundLogger().Debug("will not remove failed server as it is protected", "id", id, "status", srv.NodeStatus)
+			}
+			continue
+		}
+
+		if a.isLocalServer(id) {
+			if srv.NodeStatus != NodeAlive {
+				a.roundLogger().Warn("ignoring failed status reported for the local server", "id", id, "status", srv.NodeStatus)
+			}
+			continue
+		}
+
+		if conf.holdsServer(srv.NodeStatus) {
+			failed.HeldServers = append(failed.HeldServers, srv)
+		} else if srv.NodeStatus != NodeAlive {
+			if !a.failedForGracePeriod(conf, id) {
+				a.roundLogger().Debug("will not remove failed server until it has been failed for the grace period", "id", id, "grace_period", conf.DeadServerRemovalGracePeriod)
+				continue
+			}
 
-		if srv.NodeStatus != NodeAlive {
 			if found && raftSrv.Suffrage == raft.Voter {
 				failed.FailedVoters = append(failed.FailedVoters, srv)
 			} else if found {
@@ -204,6 +674,11 @@ func (a *Autopilot) getFailedServers() (*FailedServers, *voterRegistry, error) {
 	}
 
 	for id, srv := range staleRaftServers {
+		if a.isLocalServer(id) {
+			a.roundLogger().Warn("ignoring the local server missing from the known servers", "id", id)
+			continue
+		}
+
 		if srv.Suffrage == raft.Voter {
 			failed.StaleVoters = append(failed.StaleVoters, id)
 		} else {
@@ -217,16 +692,71 @@ func (a *Autopilot) getFailedServers() (*FailedServers, *voterRegistry, error) {
 	sort.Slice(failed.StaleVoters, func(i, j int) bool {
 		return failed.StaleVoters[i] < failed.StaleVoters[j]
 	})
-	sort.Slice(failed.FailedNonVoters, func(i, j int) bool {
-		return failed.FailedNonVoters[i].ID < failed.FailedNonVoters[j].ID
-	})
-	sort.Slice(failed.FailedVoters, func(i, j int) bool {
-		return failed.FailedVoters[i].ID < failed.FailedVoters[j].ID
+	sortFailedServers(failed.FailedNonVoters)
+	sortFailedServers(failed.FailedVoters)
+	sort.Slice(failed.HeldServers, func(i, j int) bool {
+		return failed.HeldServers[i].ID < failed.HeldServers[j].ID
 	})
 
 	return &failed, registry, nil
 }
 
+// sortFailedServers orders failed servers by the precedence in which they should
+// be removed. Servers with a higher RemovalPriority come first, then those with
+// a lower Value and remaining ties are broken by the server ID to keep the ordering
+// deterministic.
+func sortFailedServers(servers []*Server) {
+	sort.Slice(servers, func(i, j int) bool {
+		if servers[i].RemovalPriority != servers[j].RemovalPriority {
+			return servers[i].RemovalPriority > servers[j].RemovalPriority
+		}
+		if servers[i].Value != servers[j].Value {
+			return servers[i].Value < servers[j].Value
+		}
+		return servers[i].ID < servers[j].ID
+	})
+}
+
+// balanceFailedVoters reorders the failed voters, already sorted by
+// sortFailedServers, so that among those of equal RemovalPriority the ones
+// within the zones with the most voters are removed first. Otherwise removing
+// only some of the failed voters could leave the surviving voters concentrated
+// within a single zone. The zones are identified by the ZoneKey.
+func balanceFailedVoters(conf *Config, state *State, servers []*Server) []*Server {
+	if conf.ZoneKey == "" || state == nil || len(servers) < 2 {
+		return servers
+	}
+
+	zoneVoters := make(map[string]int)
+	for _, id := range state.Voters {
+		if srv, ok := state.Servers[id]; ok {
+			zoneVoters[srv.Server.Meta[conf.ZoneKey]]++
+		}
+	}
+
+	remaining := append([]*Server(nil), servers...)
+	result := make([]*Server, 0, len(servers))
+	for len(remaining) > 0 {
+		// take the first server from the zone with the most voters, never
+		// passing over a server with a higher removal priority
+		best := 0
+		for i, srv := range remaining {
+			if srv.RemovalPriority != remaining[0].RemovalPriority {
+				break
+			}
+			if zoneVoters[srv.Meta[conf.ZoneKey]] > zoneVoters[remaining[best].Meta[conf.ZoneKey]] {
+				best = i
+			}
+		}
+
+		srv := remaining[best]
+		remaining = append(remaining[:best], remaining[best+1:]...)
+		result = append(result, srv)
+		zoneVoters[srv.Meta[conf.ZoneKey]]--
+	}
+	return result
+}
+
 // pruneDeadServers will find stale raft servers and failed servers as indicated by the consuming application
 // and remove them. For stale raft servers this means removing them from the Raft configuration. For failed
 // servers this means issuing RemoveFailedNode calls to the delegate. All stale/failed non-voters will be
@@ -234,74 +764,236 @@ func (a *Autopilot) getFailedServers() (*FailedServers, *voterRegistry, error) {
 // cap the number removed so that we do not remove too many at a time and do not remove nodes to the
 // point where the number of voters would be below the MinQuorum value from the autopilot config.
 // Additionally, the delegate will be consulted to determine if all the removals should be done and
-// can filter the failed servers listings if need be.
-func (a *Autopilot) pruneDeadServers() error {
+// can filter the failed servers listings if need be. A failed removal does not prevent the remaining
+// removals from being attempted, all the errors are returned together.
+func (a *Autopilot) pruneDeadServers(ctx context.Context) error {
 	if !a.ReconciliationEnabled() {
 		return nil
 	}
 
+	defer a.beginRound(RoundPrune)()
+
+	a.countDelegateCall("AutopilotConfig")
 	conf := a.delegate.AutopilotConfig()
 	if conf == nil || !conf.CleanupDeadServers {
 		return nil
 	}
 
+	if !a.MembershipChangesEnabled(MembershipChangeRemoval) {
+		a.roundLogger().Debug("not removing dead servers as removals are disabled")
+		return nil
+	}
+
+	if a.membershipChangeStuck() {
+		a.roundLogger().Warn("skipping dead server removal while a raft operation is unresolved")
+		return nil
+	}
+
 	state := a.GetState()
 
-	failed, vr, err := a.getFailedServers()
+	if conf.DryRun {
+		removals, err := a.processRemovals(ctx, conf, state, false)
+		if err != nil {
+			return err
+		}
+		plan := &PlannedChanges{Removals: removals}
+		a.roundLogger().Info("dry run, not applying planned changes", "plan", plan)
+		a.emitEvent(EventPlannedChanges, "", fmt.Sprintf("dry run, planned changes: %s", plan))
+		return nil
+	}
+
+	if a.invariantChecks {
+		removals, err := a.processRemovals(ctx, conf, state, false)
+		if err != nil {
+			return err
+		}
+		if err := a.checkInvariants(conf, state, PlannedChanges{Removals: removals}); err != nil {
+			return err
+		}
+	}
+
+	_, err := a.processRemovals(ctx, conf, state, true)
+	return err
+}
+
+// processRemovals determines which failed and stale servers may be removed and
+// returns them in the order they would be removed. When apply is true the
+// servers are also removed. Failed voters which are demoted rather than
+// removed, due to the FailedVoterDemotionPeriod, are returned as well.
+func (a *Autopilot) processRemovals(ctx context.Context, conf *Config, state *State, apply bool) ([]raft.ServerID, error) {
+	if !a.inMaintenanceWindow() {
+		a.roundLogger().Debug("holding removals until a maintenance window opens")
+		return nil, nil
+	}
+
+	a.countDelegateCall("KnownServers")
+	knownServers := a.delegate.KnownServers()
+	if apply {
+		// only rounds which make the removals may start holding them so that
+		// planning them does not alter what later rounds do
+		a.observeKnownServers(a.now(), knownServers)
+	}
+
+	failed, vr, err := a.categorizeServers(conf, knownServers)
 	if err != nil || failed == nil {
-		return err
+		return nil, err
+	}
+
+	failed.FailedVoters = balanceFailedVoters(conf, state, failed.FailedVoters)
+
+	if conf.FailedVoterDemotionPeriod > 0 {
+		if apply {
+			a.failedDemotions.forgetRecovered(failed.FailedNonVoters)
+		}
+		failed.FailedNonVoters = a.failedDemotions.removable(failed.FailedNonVoters, conf.FailedVoterDemotionPeriod, a.now())
+	}
+
+	failed = a.isolatePromoter(a.getPromoter()).FilterFailedServerRemovals(conf, state, failed)
+
+	var removals []raft.ServerID
+	var result error
+	seq := &removalSequence{}
+
+	// removeStage removes the given servers, records them and updates the
+	// registry. It returns false when no further stages should be processed.
+	// Failed removals do not stop later stages, instead their errors are
+	// aggregated. The servers which failed to be removed are still treated as
+	// removed by the registry which only makes the later stages more cautious.
+	removeStage := func(toRemove []raft.ServerID, remove func([]raft.ServerID) error) bool {
+		toRemove = a.limitRemovals(conf, toRemove, len(removals), apply)
+		if apply && len(toRemove) > 0 {
+			if !a.confirmDestructiveAction() {
+				return false
+			}
+			if err := remove(toRemove); err != nil {
+				result = multierror.Append(result, err)
+			}
+		}
+
+		removals = append(removals, toRemove...)
+		vr.remove(toRemove...)
+		return true
 	}
 
-	failed = a.promoter.FilterFailedServerRemovals(conf, state, failed)
+	removeStale := func(toRemove []raft.ServerID) error {
+		return a.removeStaleServers(ctx, seq, toRemove)
+	}
+
+	removeFailed := func(voters bool) func([]raft.ServerID) error {
+		return func(toRemove []raft.ServerID) error {
+			a.removeFailedServers(failed.getFailed(toRemove, voters))
+			return nil
+		}
+	}
 
 	// Remove servers in order of increasing precedence (and update the registry)
 	// Rules:
 	// 1. Deal with non-voters first as their removal shouldn't impact cluster stability.
 	// 2. Handle 'stale' before 'failed' in order to make progress towards the applications desired server set.
+	// 3. Within the failed servers, those with a higher RemovalPriority are handled first
+	//    followed by those with a lower Value.
 
 	// remove stale non-voters
-	toRemove := a.adjudicateRemoval(failed.StaleNonVoters, vr)
-	if err = a.removeStaleServers(toRemove); err != nil {
-		return err
+	if !removeStage(a.adjudicateRemoval(a.withoutLeader(state, failed.StaleNonVoters, apply), vr, apply), removeStale) {
+		return removals, result
 	}
-	vr.remove(toRemove...)
 
 	// Remove stale voters
-	toRemove = a.adjudicateRemoval(failed.StaleVoters, vr)
-	if err = a.removeStaleServers(toRemove); err != nil {
-		return err
+	if !removeStage(a.adjudicateRemoval(a.withoutLeader(state, failed.StaleVoters, apply), vr, apply), removeStale) {
+		return removals, result
 	}
-	vr.remove(toRemove...)
 
 	// remove failed non-voters
-	failedNonVoters := vr.filter(failed.FailedNonVoters)
-	toRemove = a.adjudicateRemoval(failedNonVoters, vr)
-	a.removeFailedServers(failed.getFailed(toRemove, false))
-	vr.remove(toRemove...)
+	if !removeStage(a.adjudicateRemoval(a.withoutLeader(state, vr.filter(failed.FailedNonVoters), apply), vr, apply), removeFailed(false)) {
+		return removals, result
+	}
 
-	// remove failed voters
-	failedVoters := vr.filter(failed.FailedVoters)
-	toRemove = a.adjudicateRemoval(failedVoters, vr)
-	a.removeFailedServers(failed.getFailed(toRemove, true))
-	vr.remove(toRemove...)
+	// remove failed voters, or demote them first when configured to
+	if conf.FailedVoterDemotionPeriod > 0 {
+		removeFailedVoters := func(toDemote []raft.ServerID) error {
+			return a.demoteFailedVoters(ctx, failed.getFailed(toDemote, true))
+		}
+		removeStage(a.adjudicateRemoval(a.withoutLeader(state, vr.filter(failed.FailedVoters), apply), vr, apply), removeFailedVoters)
+		return removals, result
+	}
+	removeStage(a.adjudicateRemoval(a.withoutLeader(state, vr.filter(failed.FailedVoters), apply), vr, apply), removeFailed(true))
+	return removals, result
+}
 
-	return nil
+// limitRemovals returns as many of the servers as may still be removed within
+// the round's MaxRemovalsPerRound given the number already removed. When apply
+// is true the skipped removals are recorded as decisions.
+func (a *Autopilot) limitRemovals(conf *Config, ids []raft.ServerID, removed int, apply bool) []raft.ServerID {
+	if conf.MaxRemovalsPerRound == 0 {
+		return ids
+	}
+
+	allowed := int(conf.MaxRemovalsPerRound) - removed
+	if allowed < 0 {
+		allowed = 0
+	}
+	if len(ids) <= allowed {
+		return ids
+	}
+
+	for _, id := range ids[allowed:] {
+		a.roundLogger().Debug("will not remove server node as the maximum removals for the round have been made", "id", id, "max", conf.MaxRemovalsPerRound)
+		if apply {
+			a.recordDecision(DecisionSkipRemoval, ReasonRemovalLimit, id, "the maximum removals for the round have been made", map[string]string{
+				"max_removals": fmt.Sprint(conf.MaxRemovalsPerRound),
+			})
+		}
+	}
+	return ids[:allowed]
+}
+
+// failedForGracePeriod returns whether the server has been continuously failed
+// for at least the configured DeadServerRemovalGracePeriod. Servers whose
+// failure has not yet been observed by a state update have not been failed for
+// any time at all.
+func (a *Autopilot) failedForGracePeriod(conf *Config, id raft.ServerID) bool {
+	if conf == nil || conf.DeadServerRemovalGracePeriod <= 0 {
+		return true
+	}
+
+	since, ok := a.lifecycle.failedSince(id)
+	return ok && a.now().Sub(since) >= conf.DeadServerRemovalGracePeriod
 }
 
-func (a *Autopilot) adjudicateRemoval(ids []raft.ServerID, vr *voterRegistry) []raft.ServerID {
+// adjudicateRemoval returns the servers which may be removed without putting
+// the cluster at risk. When apply is true the skipped removals are recorded as
+// decisions.
+func (a *Autopilot) adjudicateRemoval(ids []raft.ServerID, vr *voterRegistry, apply bool) []raft.ServerID {
 	var result []raft.ServerID
 	initialPotentialVoters := vr.potentialVoters()
 	removedPotentialVoters := 0
 	maxRemoval := (initialPotentialVoters - 1) / 2
+	a.countDelegateCall("AutopilotConfig")
 	minQuorum := a.delegate.AutopilotConfig().MinQuorum
 
 	for _, id := range ids {
 		v := vr.eligibility[id]
 
+		if a.lockouts.isLockedOut(id, a.now()) {
+			a.roundLogger().Debug("will not remove server node as it is locked out after repeated failures", "id", id)
+			continue
+		}
+
 		if v != nil && v.isPotentialVoter() && initialPotentialVoters-removedPotentialVoters-1 < int(minQuorum) {
-			a.logger.Debug("will not remove server node as it would leave less voters than the minimum number allowed", "id", id, "min", minQuorum)
+			a.roundLogger().Debug("will not remove server node as it would leave less voters than the minimum number allowed", "id", id, "min", minQuorum)
+			if apply {
+				a.recordDecision(DecisionSkipRemoval, ReasonBelowMinQuorum, id, "removal would leave less voters than the minimum number allowed", map[string]string{
+					"min_quorum":       fmt.Sprint(minQuorum),
+					"potential_voters": fmt.Sprint(initialPotentialVoters - removedPotentialVoters),
+				})
+			}
 		} else if v.isCurrentVoter() && maxRemoval < 1 {
-			a.logger.Debug("will not remove server node as removal of a majority of voting servers is not safe", "id", id)
+			a.roundLogger().Debug("will not remove server node as removal of a majority of voting servers is not safe", "id", id)
+			if apply {
+				a.recordDecision(DecisionSkipRemoval, ReasonQuorumRisk, id, "removal of a majority of voting servers is not safe", map[string]string{
+					"potential_voters": fmt.Sprint(initialPotentialVoters),
+				})
+			}
 		} else if v != nil && v.isPotentialVoter() {
 			maxRemoval--
 			// We need to track how many voters we have removed from the registry
@@ -316,24 +1008,98 @@ func (a *Autopilot) adjudicateRemoval(ids []raft.ServerID, vr *voterRegistry) []
 	return result
 }
 
-func (a *Autopilot) removeStaleServer(id raft.ServerID) error {
-	a.logger.Debug("removing server by ID", "id", id)
-	future := a.raft.RemoveServer(id, 0, 0)
-	if err := future.Error(); err != nil {
-		a.logger.Error("failed to remove raft server", "id", id, "error", err)
+func (a *Autopilot) removeStaleServer(ctx context.Context, id raft.ServerID, prevIndex uint64) error {
+	return a.removeRaftServer(ctx, id, prevIndex, ReasonStaleServer, "removed as the application no longer knows about it")
+}
+
+// removeRaftServer removes the server from the Raft configuration, recording
+// the removal, or its failure, with the given reason and message.
+func (a *Autopilot) removeRaftServer(ctx context.Context, id raft.ServerID, prevIndex uint64, reason DecisionReason, message string) error {
+	if a.isLocalServer(id) {
+		a.roundLogger().Error("refusing to remove the local server", "id", id)
+		return fmt.Errorf("refusing to remove the local server %s", id)
+	}
+
+	a.roundLogger().Debug("removing server by ID", "id", id)
+	timeout := a.raftTimeouts.RemoveServer
+	err := a.waitMembershipChange(ctx, "RemoveServer", id, timeout, func() raft.Future {
+		return a.raft.RemoveServer(id, prevIndex, timeout)
+	})
+	if err != nil {
+		a.roundLogger().Error("failed to remove raft server", "id", id, "error", err)
+		a.lockouts.failed(id, MembershipChangeRemoval, a.now(), err)
+		a.recordDecision(DecisionRemovalFailed, reason, id, "failed to remove from the Raft configuration", map[string]string{
+			"error": err.Error(),
+		})
 		return err
 	}
-	a.logger.Info("removed server", "id", id)
+	a.roundLogger().Info("removed server", "id", id)
+	a.lockouts.succeeded(id)
+	now := a.now()
+	a.lifecycle.removed(a.metricsSink(), id, now)
+	a.quarantine.removed(id, "", now)
+	a.metricsSink().IncrCounterWithLabels(removalsKey, 1, []metrics.Label{{Name: "id", Value: string(id)}})
+	a.emitEvent(EventServerRemoved, id, message)
+	a.notifyMembershipChange(MembershipChangeRemoval, a.knownServer(id), "", message)
+	a.recordDecision(DecisionRemove, reason, id, message, nil)
 	return nil
 }
 
-func (a *Autopilot) removeStaleServers(toRemove []raft.ServerID) error {
+// removalSequence tracks the removals from the Raft configuration made within
+// a single pass so that each one after the first may be made against the
+// configuration the earlier removals resulted in.
+type removalSequence struct {
+	issued bool
+}
+
+// nextRemoval returns the index of the Raft configuration to remove the server
+// from. The first removal of a pass is made against whatever the latest
+// configuration is. Waiting upon a removal's future only returns once the new
+// configuration has committed, so later removals re-read the configuration and
+// are made against its index rather than issued on top of stale assumptions.
+// False is returned when the server is no longer in the configuration.
+func (a *Autopilot) nextRemoval(seq *removalSequence, id raft.ServerID) (uint64, bool, error) {
+	if !seq.issued {
+		return 0, true, nil
+	}
+
+	future := a.raft.GetConfiguration()
+	if err := future.Error(); err != nil {
+		return 0, false, fmt.Errorf("failed to read the Raft configuration before removing server %s: %w", id, err)
+	}
+	for _, srv := range future.Configuration().Servers {
+		if srv.ID == id {
+			return future.Index(), true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+func (a *Autopilot) removeStaleServers(ctx context.Context, seq *removalSequence, toRemove []raft.ServerID) error {
+	if !a.MembershipChangesEnabled(MembershipChangeRemoval) {
+		return nil
+	}
+
 	var result error
 
 	for _, id := range toRemove {
-		err := a.removeStaleServer(id)
+		if !a.approveRemoval(a.knownServer(id)) || !a.takeChangeBudget(MembershipChangeRemoval, id) {
+			continue
+		}
+
+		prevIndex, ok, err := a.nextRemoval(seq, id)
 		if err != nil {
 			result = multierror.Append(result, err)
+			continue
+		}
+		if !ok {
+			a.roundLogger().Debug("not removing server as it has already left the Raft configuration", "id", id)
+			continue
+		}
+
+		seq.issued = true
+		if err := a.removeStaleServer(ctx, id, prevIndex); err != nil {
+			result = multierror.Append(result, err)
 		}
 	}
 
@@ -341,7 +1107,36 @@ func (a *Autopilot) removeStaleServers(toRemove []raft.ServerID) error {
 }
 
 func (a *Autopilot) removeFailedServers(toRemove []*Server) {
+	if !a.MembershipChangesEnabled(MembershipChangeRemoval) {
+		return
+	}
+
 	for _, srv := range toRemove {
-		a.delegate.RemoveFailedServer(srv)
+		if a.isLocalServer(srv.ID) {
+			a.roundLogger().Error("refusing to remove the local server", "id", srv.ID)
+			continue
+		}
+
+		if !a.approveRemoval(srv) || !a.takeChangeBudget(MembershipChangeRemoval, srv.ID) {
+			continue
+		}
+
+		if remover, ok := a.delegate.(RoundAwareRemover); ok {
+			a.countDelegateCall("RemoveFailedServerInRound")
+			remover.RemoveFailedServerInRound(a.currentRound(), srv)
+		} else {
+			a.countDelegateCall("RemoveFailedServer")
+			a.delegate.RemoveFailedServer(srv)
+		}
+		now := a.now()
+		a.lifecycle.removed(a.metricsSink(), srv.ID, now)
+		a.quarantine.removed(srv.ID, srv.Address, now)
+		a.metricsSink().IncrCounterWithLabels(removalsKey, 1, []metrics.Label{{Name: "id", Value: string(srv.ID)}})
+		message := fmt.Sprintf("removed as its node status is %q", srv.NodeStatus)
+		a.emitEvent(EventServerRemoved, srv.ID, message)
+		a.notifyMembershipChange(MembershipChangeRemoval, srv, "", message)
+		a.recordDecision(DecisionRemove, ReasonFailedServer, srv.ID, message, map[string]string{
+			"node_status": string(srv.NodeStatus),
+		})
 	}
 }
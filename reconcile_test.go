@@ -4,6 +4,7 @@
 package autopilot
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -355,7 +356,7 @@ func TestReconcile(t *testing.T) {
 				tcase.setupExpectations(mraft)
 			}
 
-			err := a.reconcile()
+			err := a.reconcile(context.Background())
 			require.NoError(t, err)
 		})
 	}
@@ -529,9 +530,22 @@ func TestPruneDeadServers(t *testing.T) {
 					uint64(0),
 					time.Duration(0),
 				).Return(&raftIndexFuture{}).Once()
+				// the configuration is re-read once the first removal commits
+				mraft.On("GetConfiguration").Return(&raftConfigFuture{
+					config: raft.Configuration{
+						Servers: []raft.Server{
+							{
+								Suffrage: raft.Voter,
+								ID:       "0aacc844-1d0a-4ba7-bbc2-bd88d51cb231",
+								Address:  "198.18.0.5:8300",
+							},
+						},
+					},
+					index: 12,
+				}).Once()
 				mraft.On("RemoveServer",
 					raft.ServerID("0aacc844-1d0a-4ba7-bbc2-bd88d51cb231"),
-					uint64(0),
+					uint64(12),
 					time.Duration(0),
 				).Return(&raftIndexFuture{}).Once()
 				mapp.On("RemoveFailedServer", &Server{
@@ -720,9 +734,22 @@ func TestPruneDeadServers(t *testing.T) {
 					uint64(0),
 					time.Duration(0),
 				).Return(&raftIndexFuture{}).Once()
+				// the configuration is re-read once the first removal commits
+				mraft.On("GetConfiguration").Return(&raftConfigFuture{
+					config: raft.Configuration{
+						Servers: []raft.Server{
+							{
+								Suffrage: raft.Voter,
+								ID:       "0aacc844-1d0a-4ba7-bbc2-bd88d51cb231",
+								Address:  "198.18.0.5:8300",
+							},
+						},
+					},
+					index: 12,
+				}).Once()
 				mraft.On("RemoveServer",
 					raft.ServerID("0aacc844-1d0a-4ba7-bbc2-bd88d51cb231"),
-					uint64(0),
+					uint64(12),
 					time.Duration(0),
 				).Return(&raftIndexFuture{}).Once()
 				mapp.On("RemoveFailedServer", &Server{
@@ -1037,7 +1064,7 @@ func TestPruneDeadServers(t *testing.T) {
 				tcase.setupExpectations(mraft, mapp)
 			}
 
-			err := a.pruneDeadServers()
+			err := a.pruneDeadServers(context.Background())
 			require.NoError(t, err)
 		})
 	}
@@ -1047,12 +1074,622 @@ func TestReconcileDisabled(t *testing.T) {
 	ap := New(NewMockRaft(t), NewMockApplicationIntegration(t),
 		WithLogger(testLogger(t)),
 		WithReconciliationDisabled())
-	require.NoError(t, ap.reconcile())
+	require.NoError(t, ap.reconcile(context.Background()))
 }
 
 func TestPruneDeadServersDisabled(t *testing.T) {
 	ap := New(NewMockRaft(t), NewMockApplicationIntegration(t),
 		WithLogger(testLogger(t)),
 		WithReconciliationDisabled())
-	require.NoError(t, ap.pruneDeadServers())
+	require.NoError(t, ap.pruneDeadServers(context.Background()))
+}
+
+func TestGetFailedServersRemovalPriority(t *testing.T) {
+	raftConfig := raft.Configuration{
+		Servers: []raft.Server{
+			{Suffrage: raft.Voter, ID: "a", Address: "198.18.0.1:8300"},
+			{Suffrage: raft.Voter, ID: "b", Address: "198.18.0.2:8300"},
+			{Suffrage: raft.Voter, ID: "c", Address: "198.18.0.3:8300"},
+			{Suffrage: raft.Voter, ID: "d", Address: "198.18.0.4:8300"},
+			{Suffrage: raft.Nonvoter, ID: "e", Address: "198.18.0.5:8300"},
+			{Suffrage: raft.Nonvoter, ID: "f", Address: "198.18.0.6:8300"},
+		},
+	}
+
+	knownServers := map[raft.ServerID]*Server{
+		"a": {ID: "a", NodeStatus: NodeAlive, NodeType: NodeVoter},
+		"b": {ID: "b", NodeStatus: NodeFailed, NodeType: NodeVoter},
+		"c": {ID: "c", NodeStatus: NodeFailed, NodeType: NodeVoter, RemovalPriority: 10},
+		"d": {ID: "d", NodeStatus: NodeFailed, NodeType: NodeVoter},
+		"e": {ID: "e", NodeStatus: NodeFailed, NodeType: NodeVoter, RemovalPriority: -1},
+		"f": {ID: "f", NodeStatus: NodeFailed, NodeType: NodeVoter},
+	}
+
+	mpromoter := NewMockPromoter(t)
+	mpromoter.On("IsPotentialVoter", NodeVoter).Return(true)
+	mapp := NewMockApplicationIntegration(t)
+	mapp.On("KnownServers").Return(knownServers).Once()
+	mraft := NewMockRaft(t)
+	mraft.On("GetConfiguration").Return(&raftConfigFuture{config: raftConfig}).Once()
+
+	a := &Autopilot{
+		logger:   hclog.NewNullLogger(),
+		raft:     mraft,
+		delegate: mapp,
+		promoter: mpromoter,
+	}
+
+	failed, _, err := a.getFailedServers(nil)
+	require.NoError(t, err)
+
+	var voters []raft.ServerID
+	for _, srv := range failed.FailedVoters {
+		voters = append(voters, srv.ID)
+	}
+	var nonVoters []raft.ServerID
+	for _, srv := range failed.FailedNonVoters {
+		nonVoters = append(nonVoters, srv.ID)
+	}
+
+	require.Equal(t, []raft.ServerID{"c", "b", "d"}, voters)
+	require.Equal(t, []raft.ServerID{"f", "e"}, nonVoters)
+}
+
+func TestGetFailedServersUnknownStatus(t *testing.T) {
+	raftConfig := raft.Configuration{
+		Servers: []raft.Server{
+			{Suffrage: raft.Voter, ID: "a", Address: "198.18.0.1:8300"},
+			{Suffrage: raft.Voter, ID: "b", Address: "198.18.0.2:8300"},
+			{Suffrage: raft.Nonvoter, ID: "c", Address: "198.18.0.3:8300"},
+		},
+	}
+
+	knownServers := map[raft.ServerID]*Server{
+		"a": {ID: "a", NodeStatus: NodeAlive, NodeType: NodeVoter},
+		"b": {ID: "b", NodeStatus: NodeUnknown, NodeType: NodeVoter},
+		"c": {ID: "c", NodeStatus: NodeUnknown, NodeType: NodeVoter},
+	}
+
+	cases := map[string]struct {
+		treatment UnknownStatusTreatment
+		expected  FailedServers
+	}{
+		"default": {
+			expected: FailedServers{
+				FailedVoters:    []*Server{knownServers["b"]},
+				FailedNonVoters: []*Server{knownServers["c"]},
+			},
+		},
+		"failed": {
+			treatment: UnknownStatusFailed,
+			expected: FailedServers{
+				FailedVoters:    []*Server{knownServers["b"]},
+				FailedNonVoters: []*Server{knownServers["c"]},
+			},
+		},
+		"hold": {
+			treatment: UnknownStatusHold,
+			expected: FailedServers{
+				HeldServers: []*Server{knownServers["b"], knownServers["c"]},
+			},
+		},
+	}
+
+	for name, tcase := range cases {
+		t.Run(name, func(t *testing.T) {
+			mpromoter := NewMockPromoter(t)
+			mpromoter.On("IsPotentialVoter", NodeVoter).Return(true)
+			mapp := NewMockApplicationIntegration(t)
+			mapp.On("KnownServers").Return(knownServers).Once()
+			mraft := NewMockRaft(t)
+			mraft.On("GetConfiguration").Return(&raftConfigFuture{config: raftConfig}).Once()
+
+			a := &Autopilot{
+				logger:   hclog.NewNullLogger(),
+				raft:     mraft,
+				delegate: mapp,
+				promoter: mpromoter,
+			}
+
+			failed, _, err := a.getFailedServers(&Config{UnknownStatusTreatment: tcase.treatment})
+			require.NoError(t, err)
+			require.Equal(t, &tcase.expected, failed)
+		})
+	}
+}
+
+func TestGetFailedServersGracePeriod(t *testing.T) {
+	start := time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)
+	now := start
+
+	raftConfig := raft.Configuration{
+		Servers: []raft.Server{
+			{Suffrage: raft.Voter, ID: "a", Address: "198.18.0.1:8300"},
+			{Suffrage: raft.Voter, ID: "b", Address: "198.18.0.2:8300"},
+			{Suffrage: raft.Nonvoter, ID: "c", Address: "198.18.0.3:8300"},
+		},
+	}
+
+	knownServers := map[raft.ServerID]*Server{
+		"a": {ID: "a", NodeStatus: NodeAlive, NodeType: NodeVoter},
+		"b": {ID: "b", NodeStatus: NodeFailed, NodeType: NodeVoter},
+		"c": {ID: "c", NodeStatus: NodeFailed, NodeType: NodeVoter},
+	}
+
+	mtime := NewMockTimeProvider(t)
+	mtime.On("Now").Return(func() time.Time { return now })
+	mpromoter := NewMockPromoter(t)
+	mpromoter.On("IsPotentialVoter", NodeVoter).Return(true)
+	mapp := NewMockApplicationIntegration(t)
+	mapp.On("KnownServers").Return(knownServers)
+	mraft := NewMockRaft(t)
+	mraft.On("GetConfiguration").Return(&raftConfigFuture{config: raftConfig})
+
+	a := &Autopilot{
+		logger:   hclog.NewNullLogger(),
+		time:     mtime,
+		raft:     mraft,
+		delegate: mapp,
+		promoter: mpromoter,
+	}
+
+	// b has been failed for a while whereas c only just failed
+	a.lifecycle.observe(start, &State{Servers: map[raft.ServerID]*ServerState{
+		"b": {Server: *knownServers["b"]},
+	}})
+	now = start.Add(time.Minute)
+	a.lifecycle.observe(now, &State{Servers: map[raft.ServerID]*ServerState{
+		"b": {Server: *knownServers["b"]},
+		"c": {Server: *knownServers["c"]},
+	}})
+
+	conf := &Config{DeadServerRemovalGracePeriod: time.Minute}
+	failed, _, err := a.getFailedServers(conf)
+	require.NoError(t, err)
+	require.Equal(t, &FailedServers{FailedVoters: []*Server{knownServers["b"]}}, failed)
+
+	now = start.Add(2 * time.Minute)
+	failed, _, err = a.getFailedServers(conf)
+	require.NoError(t, err)
+	require.Equal(t, &FailedServers{
+		FailedVoters:    []*Server{knownServers["b"]},
+		FailedNonVoters: []*Server{knownServers["c"]},
+	}, failed)
+}
+
+func TestReconcileTermsDiverged(t *testing.T) {
+	conf := &Config{}
+	state := &State{
+		Leader:        "a",
+		TermsDiverged: true,
+		Servers: map[raft.ServerID]*ServerState{
+			"a": {
+				Server: Server{ID: "a", Address: "198.18.0.1:8300"},
+				State:  RaftLeader,
+				Health: ServerHealth{Healthy: true},
+			},
+			"b": {
+				Server: Server{ID: "b", Address: "198.18.0.2:8300"},
+				State:  RaftVoter,
+				Health: ServerHealth{Healthy: true},
+			},
+		},
+	}
+
+	mdel := NewMockApplicationIntegration(t)
+	mdel.On("AutopilotConfig").Return(conf).Once()
+
+	mpromoter := NewMockPromoter(t)
+	mpromoter.On("CalculatePromotionsAndDemotions", conf, state).
+		Return(RaftChanges{Demotions: []raft.ServerID{"b"}}).
+		Once()
+
+	// no demotion is expected on the mocked raft
+	a := &Autopilot{
+		logger:                testLogger(t),
+		raft:                  NewMockRaft(t),
+		delegate:              mdel,
+		promoter:              mpromoter,
+		state:                 state,
+		reconciliationEnabled: true,
+	}
+
+	require.NoError(t, a.reconcile(context.Background()))
+}
+
+func TestLocalServerNeverRemoved(t *testing.T) {
+	raftConfig := raft.Configuration{
+		Servers: []raft.Server{
+			{Suffrage: raft.Voter, ID: "a", Address: "198.18.0.1:8300"},
+			{Suffrage: raft.Voter, ID: "b", Address: "198.18.0.2:8300"},
+			{Suffrage: raft.Voter, ID: "c", Address: "198.18.0.3:8300"},
+			{Suffrage: raft.Nonvoter, ID: "d", Address: "198.18.0.4:8300"},
+		},
+	}
+
+	// the local server "a" is misreported as failed and the local server
+	// "d" is missing from the known servers
+	knownServers := map[raft.ServerID]*Server{
+		"a": {ID: "a", NodeStatus: NodeFailed, NodeType: NodeVoter},
+		"b": {ID: "b", NodeStatus: NodeAlive, NodeType: NodeVoter},
+		"c": {ID: "c", NodeStatus: NodeFailed, NodeType: NodeVoter},
+	}
+
+	for _, local := range []raft.ServerID{"a", "d"} {
+		t.Run(string(local), func(t *testing.T) {
+			mpromoter := NewMockPromoter(t)
+			mpromoter.On("IsPotentialVoter", NodeVoter).Return(true)
+			mapp := NewMockApplicationIntegration(t)
+			mapp.On("KnownServers").Return(knownServers).Once()
+			mraft := NewMockRaft(t)
+			mraft.On("GetConfiguration").Return(&raftConfigFuture{config: raftConfig}).Once()
+
+			a := &Autopilot{
+				logger:        hclog.NewNullLogger(),
+				raft:          mraft,
+				delegate:      mapp,
+				promoter:      mpromoter,
+				localServerID: local,
+			}
+
+			failed, _, err := a.getFailedServers(&Config{})
+			require.NoError(t, err)
+			for _, srv := range failed.FailedVoters {
+				require.NotEqual(t, local, srv.ID)
+			}
+			require.NotContains(t, failed.StaleNonVoters, local)
+
+			// the lower level removal functions refuse as well
+			require.Error(t, a.removeServer(context.Background(), local))
+			require.Error(t, a.removeStaleServer(context.Background(), local, 0))
+			a.removeFailedServers([]*Server{{ID: local}})
+		})
+	}
+}
+
+func TestSortFailedServersValue(t *testing.T) {
+	servers := []*Server{
+		{ID: "a", Value: 10},
+		{ID: "b"},
+		{ID: "c", Value: 10, RemovalPriority: 1},
+		{ID: "d", Value: -5},
+		{ID: "e"},
+	}
+
+	sortFailedServers(servers)
+
+	var ids []raft.ServerID
+	for _, srv := range servers {
+		ids = append(ids, srv.ID)
+	}
+	require.Equal(t, []raft.ServerID{"c", "d", "b", "e", "a"}, ids)
+}
+
+func TestApplyLeadershipTransferCandidates(t *testing.T) {
+	state := &State{
+		Leader: "a",
+		Servers: map[raft.ServerID]*ServerState{
+			"a": {Server: Server{ID: "a", Address: "198.18.0.1:8300"}, State: RaftLeader, Health: ServerHealth{Healthy: true}},
+			"b": {Server: Server{ID: "b", Address: "198.18.0.2:8300"}, State: RaftVoter},
+			"c": {Server: Server{ID: "c", Address: "198.18.0.3:8300"}, State: RaftNonVoter, Health: ServerHealth{Healthy: true}},
+			"d": {Server: Server{ID: "d", Address: "198.18.0.4:8300"}, State: RaftVoter, Health: ServerHealth{Healthy: true}},
+		},
+	}
+
+	t.Run("first-eligible", func(t *testing.T) {
+		var events []Event
+		mraft := NewMockRaft(t)
+		a := &Autopilot{
+			logger: hclog.NewNullLogger(),
+			raft:   mraft,
+			eventHandlers: []EventHandler{func(e Event) {
+				events = append(events, e)
+			}},
+		}
+
+		mraft.On("LeadershipTransferToServer", raft.ServerID("d"), raft.ServerAddress("198.18.0.4:8300")).
+			Return(&raftIndexFuture{}).Once()
+
+		err := a.applyLeadershipTransfer(context.Background(), state, RaftChanges{
+			Leader:           "b",
+			LeaderCandidates: []raft.ServerID{"b", "e", "c", "d", "a"},
+		})
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		require.Equal(t, EventLeadershipTransfer, events[0].Type)
+		require.Equal(t, raft.ServerID("d"), events[0].ServerID)
+		require.Equal(t, "transferring leadership to d as the preferred candidates were ineligible: b is unhealthy, e is not in the autopilot state, c is not a voter", events[0].Message)
+	})
+
+	t.Run("leader-preferred", func(t *testing.T) {
+		a := &Autopilot{logger: hclog.NewNullLogger(), raft: NewMockRaft(t)}
+		require.NoError(t, a.applyLeadershipTransfer(context.Background(), state, RaftChanges{
+			LeaderCandidates: []raft.ServerID{"b", "a", "d"},
+		}))
+	})
+
+	t.Run("none-eligible", func(t *testing.T) {
+		a := &Autopilot{logger: hclog.NewNullLogger(), raft: NewMockRaft(t)}
+		require.Error(t, a.applyLeadershipTransfer(context.Background(), state, RaftChanges{
+			Leader:           "b",
+			LeaderCandidates: []raft.ServerID{"c"},
+		}))
+	})
+}
+
+func TestRemoveStaleServersSequence(t *testing.T) {
+	mraft := NewMockRaft(t)
+	mraft.On("RemoveServer", raft.ServerID("b"), uint64(0), time.Duration(0)).Return(&raftIndexFuture{}).Once()
+	// after b's removal commits c has already left and d remains
+	mraft.On("GetConfiguration").Return(&raftConfigFuture{
+		config: raft.Configuration{
+			Servers: []raft.Server{
+				{Suffrage: raft.Voter, ID: "a", Address: "198.18.0.1:8300"},
+				{Suffrage: raft.Nonvoter, ID: "d", Address: "198.18.0.4:8300"},
+			},
+		},
+		index: 7,
+	}).Twice()
+	mraft.On("RemoveServer", raft.ServerID("d"), uint64(7), time.Duration(0)).Return(&raftIndexFuture{}).Once()
+
+	a := &Autopilot{
+		logger: testLogger(t),
+		raft:   mraft,
+		state:  &State{},
+	}
+
+	seq := &removalSequence{}
+	require.NoError(t, a.removeStaleServers(context.Background(), seq, []raft.ServerID{"b", "c"}))
+	require.NoError(t, a.removeStaleServers(context.Background(), seq, []raft.ServerID{"d"}))
+}
+
+func TestApplyDemotionsSkipsProtected(t *testing.T) {
+	state := &State{
+		Leader: "a",
+		Servers: map[raft.ServerID]*ServerState{
+			"a": {
+				Server: Server{ID: "a", Address: "198.18.0.1:8300"},
+				State:  RaftLeader,
+				Health: ServerHealth{Healthy: true},
+			},
+			"b": {
+				Server: Server{ID: "b", Address: "198.18.0.2:8300", Protected: true},
+				State:  RaftVoter,
+				Health: ServerHealth{Healthy: true},
+			},
+		},
+	}
+
+	// no demotion is expected on the mocked raft
+	a := &Autopilot{
+		logger: testLogger(t),
+		raft:   NewMockRaft(t),
+		state:  state,
+	}
+
+	demoted, err := a.applyDemotions(context.Background(), nil, state, RaftChanges{Demotions: []raft.ServerID{"b"}})
+	require.NoError(t, err)
+	require.False(t, demoted)
+}
+
+func TestApplyDemotionsQuorumSafety(t *testing.T) {
+	voter := func(id raft.ServerID, healthy bool) *ServerState {
+		return &ServerState{
+			Server: Server{ID: id, Address: raft.ServerAddress("198.18.0.1:8300")},
+			State:  RaftVoter,
+			Health: ServerHealth{Healthy: healthy},
+		}
+	}
+
+	type testCase struct {
+		conf     *Config
+		healthy  map[raft.ServerID]bool
+		demote   []raft.ServerID
+		expected []raft.ServerID
+	}
+
+	cases := map[string]testCase{
+		"min-quorum": {
+			conf:     &Config{MinQuorum: 3},
+			healthy:  map[raft.ServerID]bool{"b": true, "c": true, "d": true},
+			demote:   []raft.ServerID{"c", "d"},
+			expected: []raft.ServerID{"c"},
+		},
+		"healthy-majority": {
+			conf:     &Config{},
+			healthy:  map[raft.ServerID]bool{"b": true, "c": true, "d": false, "e": false},
+			demote:   []raft.ServerID{"b", "d"},
+			expected: []raft.ServerID{"d"},
+		},
+	}
+
+	for name, tcase := range cases {
+		t.Run(name, func(t *testing.T) {
+			state := &State{
+				Leader: "a",
+				Servers: map[raft.ServerID]*ServerState{
+					"a": {
+						Server: Server{ID: "a", Address: "198.18.0.1:8300"},
+						State:  RaftLeader,
+						Health: ServerHealth{Healthy: true},
+					},
+				},
+			}
+			for id, healthy := range tcase.healthy {
+				state.Servers[id] = voter(id, healthy)
+			}
+
+			mraft := NewMockRaft(t)
+			for _, id := range tcase.expected {
+				mraft.On("DemoteVoter", id, uint64(0), time.Duration(0)).Return(&raftIndexFuture{}).Once()
+			}
+
+			a := &Autopilot{
+				logger: testLogger(t),
+				raft:   mraft,
+				state:  state,
+			}
+
+			demoted, err := a.applyDemotions(context.Background(), tcase.conf, state, RaftChanges{Demotions: tcase.demote})
+			require.NoError(t, err)
+			require.True(t, demoted)
+		})
+	}
+}
+
+func TestBalanceFailedVoters(t *testing.T) {
+	zones := map[raft.ServerID]string{
+		"a": "zone-2",
+		"b": "zone-1",
+		"c": "zone-1",
+		"d": "zone-1",
+		"e": "zone-1",
+		"f": "zone-2",
+	}
+
+	state := &State{Servers: make(map[raft.ServerID]*ServerState)}
+	for id, zone := range zones {
+		state.Voters = append(state.Voters, id)
+		state.Servers[id] = &ServerState{
+			Server: Server{ID: id, Meta: map[string]string{"zone": zone}},
+			State:  RaftVoter,
+		}
+	}
+
+	failed := func(id raft.ServerID, priority int) *Server {
+		return &Server{ID: id, Meta: map[string]string{"zone": zones[id]}, RemovalPriority: priority}
+	}
+	ids := func(servers []*Server) []raft.ServerID {
+		var result []raft.ServerID
+		for _, srv := range servers {
+			result = append(result, srv.ID)
+		}
+		return result
+	}
+
+	conf := &Config{ZoneKey: "zone"}
+
+	// zone-1 keeps the most voters until two of its voters are removed
+	servers := []*Server{failed("a", 0), failed("b", 0), failed("c", 0)}
+	require.Equal(t, []raft.ServerID{"b", "c", "a"}, ids(balanceFailedVoters(conf, state, servers)))
+
+	// the removal priority still comes first
+	servers = []*Server{failed("a", 1), failed("b", 0), failed("c", 0)}
+	require.Equal(t, []raft.ServerID{"a", "b", "c"}, ids(balanceFailedVoters(conf, state, servers)))
+
+	// without zones the order is left alone
+	servers = []*Server{failed("a", 0), failed("b", 0), failed("c", 0)}
+	require.Equal(t, []raft.ServerID{"a", "b", "c"}, ids(balanceFailedVoters(&Config{}, state, servers)))
+}
+
+func TestGetFailedServersProtected(t *testing.T) {
+	raftConfig := raft.Configuration{
+		Servers: []raft.Server{
+			{Suffrage: raft.Voter, ID: "a", Address: "198.18.0.1:8300"},
+			{Suffrage: raft.Voter, ID: "b", Address: "198.18.0.2:8300"},
+			{Suffrage: raft.Nonvoter, ID: "c", Address: "198.18.0.3:8300"},
+			{Suffrage: raft.Nonvoter, ID: "d", Address: "198.18.0.4:8300"},
+		},
+	}
+
+	knownServers := map[raft.ServerID]*Server{
+		"a": {ID: "a", NodeStatus: NodeAlive, NodeType: NodeVoter},
+		"b": {ID: "b", NodeStatus: NodeFailed, NodeType: NodeVoter, Protected: true},
+		"c": {ID: "c", NodeStatus: NodeLeft, NodeType: NodeVoter, Protected: true},
+		"d": {ID: "d", NodeStatus: NodeFailed, NodeType: NodeVoter},
+	}
+
+	mpromoter := NewMockPromoter(t)
+	mpromoter.On("IsPotentialVoter", NodeVoter).Return(true)
+	mapp := NewMockApplicationIntegration(t)
+	mapp.On("KnownServers").Return(knownServers).Once()
+	mraft := NewMockRaft(t)
+	mraft.On("GetConfiguration").Return(&raftConfigFuture{config: raftConfig}).Once()
+
+	a := &Autopilot{
+		logger:   hclog.NewNullLogger(),
+		raft:     mraft,
+		delegate: mapp,
+		promoter: mpromoter,
+	}
+
+	// protected servers are never removed however they have failed
+	failed, _, err := a.getFailedServers(&Config{})
+	require.NoError(t, err)
+	require.Equal(t, &FailedServers{
+		FailedNonVoters: []*Server{knownServers["d"]},
+	}, failed)
+}
+
+func TestRevalidateDemotionProtected(t *testing.T) {
+	state := planTestState()
+	a := &Autopilot{
+		logger:   hclog.NewNullLogger(),
+		state:    state,
+		features: map[Feature]bool{FeatureApplyRevalidation: true},
+	}
+
+	snapshot := *state.Servers["b"]
+	_, ok := a.revalidateDemotion(&snapshot)
+	require.True(t, ok)
+
+	// a server protected after the changes were calculated is not demoted
+	state.Servers["b"].Server.Protected = true
+	reason, ok := a.revalidateDemotion(&snapshot)
+	require.False(t, ok)
+	require.Equal(t, "server is now protected", reason)
+}
+
+func TestLimitRemovals(t *testing.T) {
+	type testCase struct {
+		max      uint
+		removed  int
+		expected []raft.ServerID
+	}
+
+	ids := []raft.ServerID{"a", "b", "c"}
+
+	cases := map[string]testCase{
+		"unlimited": {
+			expected: ids,
+		},
+		"within-limit": {
+			max:      3,
+			expected: ids,
+		},
+		"over-limit": {
+			max:      2,
+			expected: []raft.ServerID{"a", "b"},
+		},
+		"partly-used": {
+			max:      2,
+			removed:  1,
+			expected: []raft.ServerID{"a"},
+		},
+		"used-up": {
+			max:      2,
+			removed:  3,
+			expected: []raft.ServerID{},
+		},
+	}
+
+	for name, tcase := range cases {
+		t.Run(name, func(t *testing.T) {
+			a := &Autopilot{logger: hclog.NewNullLogger()}
+			a.decisions.size = 10
+
+			conf := &Config{MaxRemovalsPerRound: tcase.max}
+			require.Equal(t, tcase.expected, a.limitRemovals(conf, ids, tcase.removed, false))
+			require.Empty(t, a.Decisions())
+
+			// the skipped removals are recorded when applied
+			require.Equal(t, tcase.expected, a.limitRemovals(conf, ids, tcase.removed, true))
+			decisions := a.Decisions()
+			require.Len(t, decisions, len(ids)-len(tcase.expected))
+			for _, d := range decisions {
+				require.Equal(t, DecisionSkipRemoval, d.Type)
+				require.Equal(t, ReasonRemovalLimit, d.Reason)
+			}
+		})
+	}
 }
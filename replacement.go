@@ -0,0 +1,334 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/raft"
+)
+
+// ReplacementPhase is the step a server replacement is currently waiting on.
+type ReplacementPhase string
+
+const (
+	// ReplacementWaiting is when the new server has not yet been healthy for
+	// the server stabilization time.
+	ReplacementWaiting ReplacementPhase = "waiting"
+	// ReplacementPromoting is when the new server is being promoted.
+	ReplacementPromoting ReplacementPhase = "promoting"
+	// ReplacementTransferring is when leadership is being moved off of the
+	// old server so that it can be demoted.
+	ReplacementTransferring ReplacementPhase = "transferring-leadership"
+	// ReplacementDemoting is when the old server is being demoted.
+	ReplacementDemoting ReplacementPhase = "demoting"
+	// ReplacementRemoving is when the old server is being removed.
+	ReplacementRemoving ReplacementPhase = "removing"
+)
+
+// ServerReplacement reports the progress of replacing one voter with another.
+type ServerReplacement struct {
+	// OldID is the voter being replaced.
+	OldID raft.ServerID
+
+	// NewID is the server replacing it.
+	NewID raft.ServerID
+
+	// Remove is whether the old server will be removed from the Raft
+	// configuration once demoted.
+	Remove bool
+
+	// StartedAt is when ReplaceServer was called.
+	StartedAt time.Time
+
+	// Phase is the step the replacement is currently waiting on.
+	Phase ReplacementPhase
+}
+
+// replacementTracker tracks the server replacements in progress keyed by the
+// ID of the server being replaced.
+type replacementTracker struct {
+	lock         sync.Mutex
+	replacements map[raft.ServerID]*ServerReplacement
+}
+
+// inProgress returns whether any servers are being replaced.
+func (t *replacementTracker) inProgress() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return len(t.replacements) > 0
+}
+
+// load replaces the replacements in progress with the persisted ones.
+func (t *replacementTracker) load(replacements []ServerReplacement) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.replacements = make(map[raft.ServerID]*ServerReplacement, len(replacements))
+	for _, r := range replacements {
+		r := r
+		t.replacements[r.OldID] = &r
+	}
+}
+
+// ReplaceServer starts replacing the voter oldID with newID. Over the following
+// reconciliation rounds autopilot will wait for newID to be healthy for the
+// server stabilization time, promote it, transfer leadership away from oldID if
+// it is the leader, demote oldID and, when remove is true, finally remove oldID
+// from the Raft configuration. The new server may not have joined the cluster
+// yet. Progress is reported with events and by Replacements.
+//
+// The promoter is prevented from promoting oldID and demoting newID while the
+// replacement is in progress. Once complete without removal the promoter is
+// free to promote oldID again.
+func (a *Autopilot) ReplaceServer(oldID, newID raft.ServerID, remove bool) error {
+	if oldID == "" || newID == "" || oldID == newID {
+		return fmt.Errorf("replacing a server requires two different server IDs")
+	}
+
+	if a.isLocalServer(oldID) && remove {
+		return fmt.Errorf("refusing to remove the local server %s", oldID)
+	}
+
+	state := a.GetState()
+	if state == nil {
+		return fmt.Errorf("cannot replace a server: %w", ErrNoState)
+	}
+
+	old, ok := state.Servers[oldID]
+	if !ok {
+		return fmt.Errorf("cannot replace server %s: %w", oldID, ErrUnknownServer)
+	}
+	if !old.HasVotingRights() {
+		return fmt.Errorf("server %s is not a voter", oldID)
+	}
+
+	if srv, ok := state.Servers[newID]; ok && srv.HasVotingRights() {
+		return fmt.Errorf("server %s is already a voter", newID)
+	}
+
+	if _, ok := a.operationPersister(); !ok && oldID == state.Leader {
+		return fmt.Errorf("refusing to replace the leader %s as the next leader would not continue the replacement without an OperationPersister", oldID)
+	}
+
+	var err error
+	a.updateOperations(state, func() bool {
+		a.replacements.lock.Lock()
+		defer a.replacements.lock.Unlock()
+
+		for _, r := range a.replacements.replacements {
+			if r.OldID == oldID || r.NewID == oldID || r.OldID == newID || r.NewID == newID {
+				err = fmt.Errorf("server %s is already being replaced by server %s", r.OldID, r.NewID)
+				return false
+			}
+		}
+
+		if a.replacements.replacements == nil {
+			a.replacements.replacements = make(map[raft.ServerID]*ServerReplacement)
+		}
+		a.replacements.replacements[oldID] = &ServerReplacement{
+			OldID:     oldID,
+			NewID:     newID,
+			Remove:    remove,
+			StartedAt: a.now(),
+			Phase:     ReplacementWaiting,
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	a.logger.Info("replacing server", "old", oldID, "new", newID, "remove", remove)
+	a.emitEvent(EventReplacementProgress, oldID, fmt.Sprintf("replacing server %s with %s, waiting for %s to be stable", oldID, newID, newID))
+	return nil
+}
+
+// Replacements returns the progress of all server replacements in progress
+// ordered by the ID of the server being replaced.
+func (a *Autopilot) Replacements() []ServerReplacement {
+	a.replacements.lock.Lock()
+	defer a.replacements.lock.Unlock()
+
+	var replacements []ServerReplacement
+	for _, r := range a.replacements.replacements {
+		replacements = append(replacements, *r)
+	}
+
+	sort.Slice(replacements, func(i, j int) bool {
+		return replacements[i].OldID < replacements[j].OldID
+	})
+	return replacements
+}
+
+// setReplacementPhase updates the phase of the replacement and emits an event
+// when it changed. It returns whether the phase changed.
+func (a *Autopilot) setReplacementPhase(r *ServerReplacement, phase ReplacementPhase, message string) bool {
+	if r.Phase == phase {
+		return false
+	}
+
+	r.Phase = phase
+	a.roundLogger().Info("server replacement progressed", "old", r.OldID, "new", r.NewID, "phase", phase)
+	a.emitEvent(EventReplacementProgress, r.OldID, message)
+	return true
+}
+
+// completeReplacement stops tracking the replacement and emits an event. The
+// replacements lock must be held.
+func (a *Autopilot) completeReplacement(r *ServerReplacement) {
+	delete(a.replacements.replacements, r.OldID)
+	a.roundLogger().Info("server replacement complete", "old", r.OldID, "new", r.NewID)
+	a.emitEvent(EventReplacementComplete, r.OldID, fmt.Sprintf("server %s has been replaced by %s", r.OldID, r.NewID))
+}
+
+// replacementPhase determines the step the replacement is waiting on given
+// the state. An empty phase is returned once the replacement is complete.
+func replacementPhase(r *ServerReplacement, state *State, conf *Config, now time.Time) ReplacementPhase {
+	old, oldFound := state.Servers[r.OldID]
+	srv, newFound := state.Servers[r.NewID]
+
+	switch {
+	case !oldFound:
+		// the old server has left the configuration one way or another
+		return ""
+	case !newFound:
+		return ReplacementWaiting
+	case !srv.HasVotingRights():
+		if !srv.Health.IsStable(now, state.serverStabilizationTimeAt(conf.ForServer(&srv.Server), now)) {
+			return ReplacementWaiting
+		}
+		return ReplacementPromoting
+	case r.OldID == state.Leader:
+		return ReplacementTransferring
+	case old.HasVotingRights():
+		return ReplacementDemoting
+	case r.Remove:
+		return ReplacementRemoving
+	default:
+		return ""
+	}
+}
+
+// advanceReplacements updates the phase of every server replacement in
+// progress, emitting events as they progress, and removes old servers which
+// have been demoted when requested. The replacements are persisted when any of
+// them progress. The removals are made without holding the replacements lock.
+func (a *Autopilot) advanceReplacements(ctx context.Context, conf *Config, state *State) {
+	now := a.now()
+
+	var removing []ServerReplacement
+	a.updateOperations(state, func() bool {
+		a.replacements.lock.Lock()
+		defer a.replacements.lock.Unlock()
+
+		changed := false
+		for _, r := range a.replacements.replacements {
+			phase := replacementPhase(r, state, conf, now)
+			switch phase {
+			case "":
+				a.completeReplacement(r)
+				changed = true
+			case ReplacementWaiting:
+				changed = a.setReplacementPhase(r, phase, fmt.Sprintf("waiting for %s to be stable", r.NewID)) || changed
+			case ReplacementPromoting:
+				changed = a.setReplacementPhase(r, phase, fmt.Sprintf("promoting %s", r.NewID)) || changed
+			case ReplacementTransferring:
+				changed = a.setReplacementPhase(r, phase, fmt.Sprintf("transferring leadership from %s to %s", r.OldID, r.NewID)) || changed
+			case ReplacementDemoting:
+				changed = a.setReplacementPhase(r, phase, fmt.Sprintf("demoting %s", r.OldID)) || changed
+			case ReplacementRemoving:
+				changed = a.setReplacementPhase(r, phase, fmt.Sprintf("removing %s", r.OldID)) || changed
+				removing = append(removing, *r)
+			}
+		}
+		return changed
+	})
+
+	sort.Slice(removing, func(i, j int) bool { return removing[i].OldID < removing[j].OldID })
+	for _, r := range removing {
+		if !a.MembershipChangesEnabled(MembershipChangeRemoval) || a.lockouts.isLockedOut(r.OldID, a.now()) || !a.inMaintenanceWindow() || !a.confirmDestructiveAction() ||
+			!a.approveRemoval(a.knownServer(r.OldID)) || !a.takeChangeBudget(MembershipChangeRemoval, r.OldID) {
+			continue
+		}
+		if err := a.removeRaftServer(ctx, r.OldID, 0, ReasonServerReplaced, fmt.Sprintf("removed as it has been replaced by %s", r.NewID)); err != nil {
+			continue
+		}
+
+		a.updateOperations(state, func() bool {
+			a.replacements.lock.Lock()
+			defer a.replacements.lock.Unlock()
+
+			// the replacement may have been cancelled or replaced meanwhile
+			current, ok := a.replacements.replacements[r.OldID]
+			if !ok || current.NewID != r.NewID {
+				return false
+			}
+			a.completeReplacement(current)
+			return true
+		})
+	}
+}
+
+// replaceServers modifies the promoter's changes to advance all the server
+// replacements in progress. The new servers are promoted once stable, then
+// leadership is moved off of the old servers before they are demoted. The
+// promoter may not promote the old servers or demote the new ones meanwhile.
+func (a *Autopilot) replaceServers(conf *Config, state *State, changes RaftChanges) RaftChanges {
+	a.replacements.lock.Lock()
+	defer a.replacements.lock.Unlock()
+
+	if len(a.replacements.replacements) == 0 {
+		return changes
+	}
+
+	now := a.now()
+
+	blockPromotion := make(map[raft.ServerID]struct{})
+	blockDemotion := make(map[raft.ServerID]struct{})
+	var promotions, demotions []raft.ServerID
+
+	for _, r := range a.replacements.replacements {
+		blockPromotion[r.OldID] = struct{}{}
+		blockDemotion[r.NewID] = struct{}{}
+
+		switch replacementPhase(r, state, conf, now) {
+		case ReplacementPromoting:
+			promotions = append(promotions, r.NewID)
+			blockPromotion[r.NewID] = struct{}{}
+		case ReplacementTransferring:
+			changes.Leader = r.NewID
+		case ReplacementDemoting:
+			demotions = append(demotions, r.OldID)
+			blockDemotion[r.OldID] = struct{}{}
+		}
+	}
+
+	result := RaftChanges{
+		Leader:           changes.Leader,
+		LeaderCandidates: changes.LeaderCandidates,
+	}
+
+	for _, id := range changes.Promotions {
+		if _, ok := blockPromotion[id]; !ok {
+			result.Promotions = append(result.Promotions, id)
+		}
+	}
+	sortByValue(promotions, state)
+	result.Promotions = append(result.Promotions, promotions...)
+
+	for _, id := range changes.Demotions {
+		if _, ok := blockDemotion[id]; !ok {
+			result.Demotions = append(result.Demotions, id)
+		}
+	}
+	sort.Slice(demotions, func(i, j int) bool { return demotions[i] < demotions[j] })
+	result.Demotions = append(result.Demotions, demotions...)
+
+	return result
+}
@@ -0,0 +1,118 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// replacementServers are the servers of the server replacement tests.
+var replacementServers = []raft.ServerID{"a", "b", "c", "d"}
+
+func TestReplaceServerValidation(t *testing.T) {
+	a := &Autopilot{
+		logger: hclog.NewNullLogger(),
+		state:  testState(replacementServers, "a", "a", "b", "c"),
+	}
+
+	require.Error(t, a.ReplaceServer("a", "a", false))
+	require.Error(t, a.ReplaceServer("d", "a", false))
+	require.Error(t, a.ReplaceServer("a", "b", false))
+
+	// the leader is only replaced when the replacement is persisted
+	require.Error(t, a.ReplaceServer("a", "d", true))
+	delegate := &operationPersistingDelegate{MockApplicationIntegration: NewMockApplicationIntegration(t)}
+	a.delegate = delegate
+	require.NoError(t, a.ReplaceServer("a", "d", true))
+	require.Error(t, a.ReplaceServer("b", "d", true))
+
+	replacements := a.Replacements()
+	require.Len(t, replacements, 1)
+	require.Equal(t, raft.ServerID("a"), replacements[0].OldID)
+	require.Equal(t, raft.ServerID("d"), replacements[0].NewID)
+	require.Equal(t, ReplacementWaiting, replacements[0].Phase)
+	require.Equal(t, replacements, delegate.operations.Replacements)
+}
+
+func TestReplaceServerProgress(t *testing.T) {
+	conf := &Config{}
+	mraft := NewMockRaft(t)
+	delegate := &operationPersistingDelegate{MockApplicationIntegration: NewMockApplicationIntegration(t)}
+
+	var events []EventType
+	newAutopilot := func(state *State) *Autopilot {
+		return &Autopilot{
+			logger:               hclog.NewNullLogger(),
+			raft:                 mraft,
+			delegate:             delegate,
+			state:                state,
+			firstActionConfirmed: true,
+			eventHandlers: []EventHandler{func(e Event) {
+				events = append(events, e.Type)
+			}},
+		}
+	}
+
+	a := newAutopilot(testState(replacementServers, "a", "a", "b", "c"))
+	require.NoError(t, a.ReplaceServer("a", "d", true))
+
+	// the promoter may not promote the old server or demote the new one
+	promoterChanges := RaftChanges{
+		Promotions: []raft.ServerID{"a"},
+		Demotions:  []raft.ServerID{"d"},
+	}
+
+	// d is promoted first
+	state := testState(replacementServers, "a", "a", "b", "c")
+	a.advanceReplacements(context.Background(), conf, state)
+	require.Equal(t, RaftChanges{Promotions: []raft.ServerID{"d"}}, a.replaceServers(conf, state, promoterChanges))
+
+	// then leadership is moved to d
+	state = testState(replacementServers, "a", "a", "b", "c", "d")
+	a.advanceReplacements(context.Background(), conf, state)
+	require.Equal(t, RaftChanges{Leader: "d"}, a.replaceServers(conf, state, promoterChanges))
+	require.Equal(t, ReplacementTransferring, delegate.operations.Replacements[0].Phase)
+
+	// d is now the leader and continues the replacement by demoting a
+	state = testState(replacementServers, "d", "a", "b", "c", "d")
+	a = newAutopilot(state)
+	a.advanceReplacements(context.Background(), conf, state)
+	require.Equal(t, RaftChanges{Demotions: []raft.ServerID{"a"}}, a.replaceServers(conf, state, promoterChanges))
+
+	// and finally removes it without holding the replacements lock
+	state = testState(replacementServers, "d", "b", "c", "d")
+	mraft.On("RemoveServer", raft.ServerID("a"), uint64(0), time.Duration(0)).Return(&raftIndexFuture{}).Once().
+		Run(func(mock.Arguments) {
+			require.Len(t, a.Replacements(), 1)
+		})
+	WithDecisionLog(10)(a)
+	a.advanceReplacements(context.Background(), conf, state)
+	require.Empty(t, a.Replacements())
+	require.Empty(t, delegate.operations.Replacements)
+
+	// the removal is recorded as any other removal would be
+	decisions := a.Decisions()
+	require.Len(t, decisions, 1)
+	require.Equal(t, DecisionRemove, decisions[0].Type)
+	require.Equal(t, ReasonServerReplaced, decisions[0].Reason)
+	require.Equal(t, raft.ServerID("a"), decisions[0].ServerID)
+	require.Equal(t, "removed as it has been replaced by d", decisions[0].Message)
+
+	require.Equal(t, []EventType{
+		EventReplacementProgress, // started
+		EventReplacementProgress, // promoting
+		EventReplacementProgress, // transferring leadership
+		EventReplacementProgress, // demoting
+		EventReplacementProgress, // removing
+		EventServerRemoved,
+		EventReplacementComplete,
+	}, events)
+}
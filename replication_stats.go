@@ -0,0 +1,105 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/raft"
+)
+
+// WithReplicationStats returns an option to derive the servers' stats from the
+// leader's own view of replication rather than fetching them from every
+// server, avoiding the fan out of the delegate's FetchServerStats. It replaces
+// any StatsFetcher and requires WithRaftObservations as Raft only reports the
+// followers it fails to heartbeat through its observations.
+//
+// Followers which Raft is heartbeating successfully are reported as being in
+// contact and up to date with the leader. Those it fails to heartbeat report
+// the time since their last contact and the leader's last index when the
+// failures began. The stats are therefore coarser than those fetched from the
+// servers themselves and a follower which is in contact but lagging behind
+// will not be judged unhealthy. They are only available on the leader.
+func WithReplicationStats() Option {
+	return func(a *Autopilot) {
+		a.replication = &replicationStats{raft: a.raft, now: a.now}
+		a.statsFetch.fetcher = a.replication
+	}
+}
+
+// failedHeartbeat records a follower which Raft is failing to heartbeat.
+type failedHeartbeat struct {
+	// lastContact is when the follower was last in contact with the leader.
+	lastContact time.Time
+
+	// lastIndex is the leader's last index when the failures began.
+	lastIndex uint64
+}
+
+// replicationStats is a StatsFetcher deriving the servers' stats from Raft's
+// observations of the heartbeats it sends them.
+type replicationStats struct {
+	raft Raft
+	now  func() time.Time
+
+	lock    sync.Mutex
+	failing map[raft.ServerID]failedHeartbeat
+}
+
+var _ StatsFetcher = (*replicationStats)(nil)
+
+// observe tracks the followers which are failing heartbeats.
+func (r *replicationStats) observe(o raft.Observation) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	switch data := o.Data.(type) {
+	case raft.FailedHeartbeatObservation:
+		if r.failing == nil {
+			r.failing = make(map[raft.ServerID]failedHeartbeat)
+		}
+		if _, ok := r.failing[data.PeerID]; !ok {
+			r.failing[data.PeerID] = failedHeartbeat{lastContact: data.LastContact, lastIndex: r.raft.LastIndex()}
+		}
+	case raft.ResumedHeartbeatObservation:
+		delete(r.failing, data.PeerID)
+	case raft.PeerObservation:
+		if data.Removed {
+			delete(r.failing, data.Peer.ID)
+		}
+	case raft.LeaderObservation:
+		// heartbeats are only sent by the leader
+		r.failing = nil
+	}
+}
+
+// FetchStats returns the stats of the server as seen by the leader.
+func (r *replicationStats) FetchStats(_ context.Context, srv *Server) (*ServerStats, error) {
+	if r.raft.State() != raft.Leader {
+		return nil, fmt.Errorf("replication stats are only available on the leader")
+	}
+
+	leader, err := ParseLeaderStats(r.raft.Stats())
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine the last Raft term: %w", err)
+	}
+
+	stats := &ServerStats{
+		LastTerm:  leader.LastLogTerm,
+		LastIndex: r.raft.LastIndex(),
+	}
+
+	r.lock.Lock()
+	failed, ok := r.failing[srv.ID]
+	r.lock.Unlock()
+
+	if ok {
+		stats.LastContact = r.now().Sub(failed.lastContact)
+		stats.LastIndex = failed.lastIndex
+	}
+	return stats, nil
+}
@@ -0,0 +1,62 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplicationStats(t *testing.T) {
+	now := time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)
+
+	mtime := NewMockTimeProvider(t)
+	mtime.On("Now").Return(func() time.Time { return now })
+
+	lastIndex := uint64(100)
+	mraft := NewMockRaft(t)
+	mraft.On("State").Return(raft.Leader)
+	mraft.On("Stats").Return(map[string]string{"last_log_term": "3"})
+	mraft.On("LastIndex").Return(func() uint64 { return lastIndex })
+
+	a := New(mraft, nil, WithTimeProvider(mtime), WithReplicationStats())
+	require.Same(t, a.replication, a.statsFetch.fetcher)
+
+	ctx := context.Background()
+	srv := &Server{ID: "b"}
+
+	// followers being heartbeated are up to date
+	stats, err := a.replication.FetchStats(ctx, srv)
+	require.NoError(t, err)
+	require.Equal(t, &ServerStats{LastTerm: 3, LastIndex: 100}, stats)
+
+	// those failing heartbeats report their last contact and the index when
+	// the failures began
+	a.replication.observe(raft.Observation{Data: raft.FailedHeartbeatObservation{PeerID: "b", LastContact: now.Add(-5 * time.Second)}})
+	lastIndex = 120
+	a.replication.observe(raft.Observation{Data: raft.FailedHeartbeatObservation{PeerID: "b", LastContact: now.Add(-5 * time.Second)}})
+	now = now.Add(time.Second)
+
+	stats, err = a.replication.FetchStats(ctx, srv)
+	require.NoError(t, err)
+	require.Equal(t, &ServerStats{LastContact: 6 * time.Second, LastTerm: 3, LastIndex: 100}, stats)
+
+	a.replication.observe(raft.Observation{Data: raft.ResumedHeartbeatObservation{PeerID: "b"}})
+	stats, err = a.replication.FetchStats(ctx, srv)
+	require.NoError(t, err)
+	require.Equal(t, &ServerStats{LastTerm: 3, LastIndex: 120}, stats)
+}
+
+func TestReplicationStatsNotLeader(t *testing.T) {
+	mraft := NewMockRaft(t)
+	mraft.On("State").Return(raft.Follower).Once()
+
+	a := New(mraft, nil, WithReplicationStats())
+	_, err := a.replication.FetchStats(context.Background(), &Server{ID: "b"})
+	require.Error(t, err)
+}
@@ -0,0 +1,144 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyPromotionsRevalidation(t *testing.T) {
+	stable := time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)
+	servers := []raft.ServerID{"a", "b"}
+	snapshot := testState(servers, "a")
+	snapshot.Servers["b"].Health.StableSince = stable
+	changes := RaftChanges{Promotions: []raft.ServerID{"b"}}
+
+	type testCase struct {
+		modify   func(latest *State)
+		noState  bool
+		expected bool
+	}
+
+	cases := map[string]testCase{
+		"unchanged": {
+			expected: true,
+		},
+		"no-latest-state": {
+			noState:  true,
+			expected: true,
+		},
+		"removed": {
+			modify: func(latest *State) {
+				delete(latest.Servers, "b")
+			},
+		},
+		"now-voter": {
+			modify: func(latest *State) {
+				latest.Servers["b"].State = RaftVoter
+			},
+		},
+		"now-unhealthy": {
+			modify: func(latest *State) {
+				latest.Servers["b"].Health = ServerHealth{Healthy: false, StableSince: stable.Add(time.Second)}
+			},
+		},
+		"health-flapped": {
+			modify: func(latest *State) {
+				latest.Servers["b"].Health.StableSince = stable.Add(time.Second)
+			},
+		},
+	}
+
+	for name, tcase := range cases {
+		t.Run(name, func(t *testing.T) {
+			mraft := NewMockRaft(t)
+			if tcase.expected {
+				mraft.On("AddVoter", raft.ServerID("b"), raft.ServerAddress(""), uint64(0), time.Duration(0)).
+					Return(&raftIndexFuture{}).
+					Once()
+			}
+
+			var latest *State
+			if !tcase.noState {
+				latest = testState(servers, "a")
+				latest.Servers["b"].Health.StableSince = stable
+				if tcase.modify != nil {
+					tcase.modify(latest)
+				}
+			}
+
+			a := &Autopilot{
+				logger:   hclog.NewNullLogger(),
+				raft:     mraft,
+				state:    latest,
+				features: map[Feature]bool{FeatureApplyRevalidation: true},
+			}
+
+			promoted, err := a.applyPromotions(context.Background(), snapshot, changes)
+			require.NoError(t, err)
+			require.Equal(t, tcase.expected, promoted)
+		})
+	}
+}
+
+func TestApplyDemotionsRevalidation(t *testing.T) {
+	servers := []raft.ServerID{"a", "b"}
+	snapshot := testState(servers, "a", "a", "b")
+	changes := RaftChanges{Demotions: []raft.ServerID{"b"}}
+
+	type testCase struct {
+		modify   func(latest *State)
+		expected bool
+	}
+
+	cases := map[string]testCase{
+		"unchanged": {
+			expected: true,
+		},
+		"now-non-voter": {
+			modify: func(latest *State) {
+				latest.Servers["b"].State = RaftNonVoter
+			},
+		},
+		"removed": {
+			modify: func(latest *State) {
+				delete(latest.Servers, "b")
+			},
+		},
+	}
+
+	for name, tcase := range cases {
+		t.Run(name, func(t *testing.T) {
+			mraft := NewMockRaft(t)
+			if tcase.expected {
+				mraft.On("DemoteVoter", raft.ServerID("b"), uint64(0), time.Duration(0)).
+					Return(&raftIndexFuture{}).
+					Once()
+			}
+
+			latest := testState(servers, "a", "a", "b")
+			if tcase.modify != nil {
+				tcase.modify(latest)
+			}
+
+			a := &Autopilot{
+				logger:   hclog.NewNullLogger(),
+				raft:     mraft,
+				delegate: NewMockApplicationIntegration(t),
+				state:    latest,
+				features: map[Feature]bool{FeatureApplyRevalidation: true},
+			}
+
+			demoted, err := a.applyDemotions(context.Background(), nil, snapshot, changes)
+			require.NoError(t, err)
+			require.Equal(t, tcase.expected, demoted)
+		})
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync/atomic"
+
+	hclog "github.com/hashicorp/go-hclog"
+)
+
+// roundCounter is used to generate round IDs if random bytes are unavailable.
+var roundCounter uint64
+
+// RoundAwareRemover may optionally be implemented by the ApplicationIntegration
+// to receive the ID of the round in which a failed server is being removed. The
+// round ID is also attached to all log lines and events of that round so that
+// related actions can be grouped and retried notifications deduplicated. When
+// implemented it is called instead of RemoveFailedServer.
+type RoundAwareRemover interface {
+	RemoveFailedServerInRound(round string, srv *Server)
+}
+
+// newRoundID generates a unique ID for a reconcile or prune round.
+func newRoundID() string {
+	var buf [8]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return fmt.Sprintf("round-%d", atomic.AddUint64(&roundCounter, 1))
+	}
+	return hex.EncodeToString(buf[:])
+}
+
+// beginRound generates a new round ID which will be attached to all log lines
+// and events until the returned function is called to end the round. The
+// resources used by the round are measured and recorded when it ends and the
+// promoter cannot be replaced while it is in progress.
+func (a *Autopilot) beginRound(kind RoundKind) func() {
+	donePromoter := a.usingPromoter()
+	id := newRoundID()
+	usage := startRoundUsage(id, kind)
+
+	a.roundLock.Lock()
+	a.round = id
+	a.roundUsage = usage
+	a.roundLock.Unlock()
+
+	return func() {
+		a.roundLock.Lock()
+		a.round = ""
+		a.roundUsage = nil
+		a.roundLock.Unlock()
+
+		a.recordRoundUsage(usage.finish())
+		donePromoter()
+	}
+}
+
+// currentRound returns the ID of the round in progress or an empty string
+// when there is none.
+func (a *Autopilot) currentRound() string {
+	a.roundLock.RLock()
+	defer a.roundLock.RUnlock()
+	return a.round
+}
+
+// roundLogger returns the logger to use for output related to reconciliation.
+// This is the logger of the prune subsystem during prune rounds and of the
+// reconcile subsystem otherwise. While a round is in progress the round ID
+// will be included.
+func (a *Autopilot) roundLogger() hclog.Logger {
+	a.roundLock.RLock()
+	subsystem := SubsystemReconcile
+	if a.roundUsage != nil && a.roundUsage.usage.Kind == RoundPrune {
+		subsystem = SubsystemPrune
+	}
+	a.roundLock.RUnlock()
+
+	return a.subsystemRoundLogger(subsystem)
+}
+
+// subsystemRoundLogger returns the logger of the given subsystem which will
+// include the round ID while a round is in progress.
+func (a *Autopilot) subsystemRoundLogger(s Subsystem) hclog.Logger {
+	logger := a.subsystemLogger(s)
+	if round := a.currentRound(); round != "" {
+		return logger.With("round", round)
+	}
+	return logger
+}
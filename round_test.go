@@ -0,0 +1,56 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+)
+
+type roundAwareDelegate struct {
+	*MockApplicationIntegration
+	*MockRoundAwareRemover
+}
+
+func TestRounds(t *testing.T) {
+	require.NotEqual(t, newRoundID(), newRoundID())
+
+	var events []Event
+	mdel := &roundAwareDelegate{
+		MockApplicationIntegration: NewMockApplicationIntegration(t),
+		MockRoundAwareRemover:      NewMockRoundAwareRemover(t),
+	}
+
+	a := &Autopilot{
+		logger:   hclog.NewNullLogger(),
+		delegate: mdel,
+		eventHandlers: []EventHandler{func(e Event) {
+			events = append(events, e)
+		}},
+	}
+
+	// outside of a round nothing is attached
+	require.Empty(t, a.currentRound())
+	a.emitEvent(EventPromoterFailed, "", "outside")
+
+	end := a.beginRound(RoundPrune)
+	round := a.currentRound()
+	require.NotEmpty(t, round)
+
+	srv := &Server{ID: "a"}
+	mdel.MockRoundAwareRemover.On("RemoveFailedServerInRound", round, srv).Once()
+	a.removeFailedServers([]*Server{srv})
+	a.emitEvent(EventPromoterFailed, "", "inside")
+
+	end()
+	require.Empty(t, a.currentRound())
+
+	require.Len(t, events, 3)
+	require.Empty(t, events[0].Round)
+	require.Equal(t, EventServerRemoved, events[1].Type)
+	require.Equal(t, round, events[1].Round)
+	require.Equal(t, round, events[2].Round)
+}
@@ -5,7 +5,10 @@ package autopilot
 
 import (
 	"context"
+	"fmt"
 	"time"
+
+	"github.com/hashicorp/go-multierror"
 )
 
 // Start will launch the go routines in the background to perform Autopilot.
@@ -20,6 +23,13 @@ func (a *Autopilot) Start(ctx context.Context) {
 		return
 	}
 
+	// take over from standby keeping the state it has built up, the new
+	// go routine waits for the standby one to release the leader lock
+	if a.execution != nil && a.execution.status == Standby {
+		a.execution.shutdown()
+		a.execution.status = ShuttingDown
+	}
+
 	ctx, shutdown := context.WithCancel(ctx)
 
 	exec := &execInfo{
@@ -47,6 +57,33 @@ func (a *Autopilot) Start(ctx context.Context) {
 	return
 }
 
+// Step performs a single iteration of autopilot's loop synchronously: the
+// state is updated, the current state is reconciled with the desired state and
+// then dead servers are pruned. Enrichers are not run. It allows autopilot to
+// be driven deterministically, such as by a simulation using a virtual clock,
+// and returns an error without doing anything while autopilot is running.
+func (a *Autopilot) Step(ctx context.Context) error {
+	if status, _ := a.IsRunning(); status != NotRunning {
+		return fmt.Errorf("cannot step autopilot while it is running")
+	}
+
+	if err := a.leaderLock.TryLock(ctx); err != nil {
+		return fmt.Errorf("failed to gain the autopilot leader lock: %w", err)
+	}
+	defer a.leaderLock.Unlock()
+
+	a.updateState(ctx)
+
+	var result error
+	if err := a.reconcile(ctx); err != nil {
+		result = multierror.Append(result, fmt.Errorf("failed to reconcile current state with the desired state: %w", err))
+	}
+	if err := a.pruneDeadServers(ctx); err != nil {
+		result = multierror.Append(result, fmt.Errorf("failed to prune dead servers: %w", err))
+	}
+	return result
+}
+
 // Stop will terminate the go routines being executed to perform autopilot.
 func (a *Autopilot) Stop() <-chan struct{} {
 	a.execLock.Lock()
@@ -66,7 +103,8 @@ func (a *Autopilot) Stop() <-chan struct{} {
 
 // IsRunning returns the current execution status of the autopilot
 // go routines as well as a chan which will be closed when the
-// routines are no longer running
+// routines are no longer running. The features enabled for those
+// routines are returned by Features.
 func (a *Autopilot) IsRunning() (ExecutionStatus, <-chan struct{}) {
 	a.execLock.Lock()
 	defer a.execLock.Unlock()
@@ -145,6 +183,10 @@ func (a *Autopilot) beginExecution(ctx context.Context, exec *execInfo) {
 		defer a.stateLock.Unlock()
 		a.state = &State{}
 
+		// another leader may change the operations in progress before we are
+		// the leader again so they must be loaded once more
+		a.operations.forgetLoaded()
+
 		a.finishExecution(exec)
 		a.leaderLock.Unlock()
 	}()
@@ -157,18 +199,25 @@ func (a *Autopilot) beginExecution(ctx context.Context, exec *execInfo) {
 		case <-ctx.Done():
 			return
 		case <-reconcileTicker.C:
-			if err := a.reconcile(); err != nil {
+			if err := a.reconcile(ctx); err != nil {
 				a.logger.Error("Failed to reconcile current state with the desired state",
 					"error", err)
 			}
 
-			if err := a.pruneDeadServers(); err != nil {
+			if err := a.pruneDeadServers(ctx); err != nil {
 				a.logger.Error("Failed to prune dead servers", "error", err)
 			}
 		case <-a.removeDeadCh:
-			if err := a.pruneDeadServers(); err != nil {
+			if err := a.pruneDeadServers(ctx); err != nil {
 				a.logger.Error("Failed to prune dead servers", "error", err)
 			}
+		case req := <-a.onDemandCh:
+			a.runOnDemandRound(ctx, req)
+		case <-a.observedCh:
+			if err := a.reconcile(ctx); err != nil {
+				a.logger.Error("Failed to reconcile current state with the desired state",
+					"error", err)
+			}
 		}
 	}
 }
@@ -178,6 +227,9 @@ func (a *Autopilot) beginExecution(ctx context.Context, exec *execInfo) {
 func (a *Autopilot) runStateUpdater(ctx context.Context, done chan struct{}) {
 	a.logger.Debug("state update routine is now running")
 	defer func() {
+		// enrichers are given our context so wait for them to notice its
+		// cancellation before reporting that we are done
+		a.enrichment.running.Wait()
 		a.logger.Debug("state update routine is now stopped")
 		close(done)
 	}()
@@ -185,12 +237,26 @@ func (a *Autopilot) runStateUpdater(ctx context.Context, done chan struct{}) {
 	ticker := time.NewTicker(a.updateInterval)
 	defer ticker.Stop()
 
+	observations := a.observations
+
+	a.runEnrichers(ctx)
+
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
 			a.updateState(ctx)
+			a.runEnrichers(ctx)
+		case <-a.refreshCh:
+			a.updateState(ctx)
+		case o, ok := <-observations:
+			if !ok {
+				// stop selecting on the closed channel
+				observations = nil
+				continue
+			}
+			a.handleObservation(ctx, o)
 		}
 	}
 }
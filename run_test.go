@@ -109,9 +109,15 @@ func TestRunLifeCycle(t *testing.T) {
 	// now validate the initial state
 	genExpected := func(ts time.Time) *State {
 		return &State{
-			firstStateTime:   ts,
-			Healthy:          true,
-			FailureTolerance: 1,
+			firstStateTime:             ts,
+			Healthy:                    true,
+			FailureTolerance:           1,
+			OptimisticFailureTolerance: 1,
+			FailureToleranceInputs: FailureToleranceInputs{
+				Voters:         3,
+				HealthyVoters:  3,
+				RequiredQuorum: 2,
+			},
 			Servers: map[raft.ServerID]*ServerState{
 				"7875975d-d54b-49c1-a400-9fefcc706c67": {
 					Server: Server{
@@ -124,9 +130,10 @@ func TestRunLifeCycle(t *testing.T) {
 						NodeType:    NodeVoter,
 						IsLeader:    true,
 					},
-					State:  RaftLeader,
-					Stats:  *serverStats["7875975d-d54b-49c1-a400-9fefcc706c67"],
-					Health: ServerHealth{Healthy: true, StableSince: ts},
+					State:          RaftLeader,
+					Stats:          *serverStats["7875975d-d54b-49c1-a400-9fefcc706c67"],
+					Health:         ServerHealth{Healthy: true, StableSince: ts},
+					statsFetchedAt: ts,
 				},
 				"ecfc5237-63c3-4b09-94b9-d5682d9ae5b1": {
 					Server: Server{
@@ -138,9 +145,10 @@ func TestRunLifeCycle(t *testing.T) {
 						RaftVersion: 3,
 						NodeType:    NodeVoter,
 					},
-					State:  RaftVoter,
-					Stats:  *serverStats["ecfc5237-63c3-4b09-94b9-d5682d9ae5b1"],
-					Health: ServerHealth{Healthy: true, StableSince: ts},
+					State:          RaftVoter,
+					Stats:          *serverStats["ecfc5237-63c3-4b09-94b9-d5682d9ae5b1"],
+					Health:         ServerHealth{Healthy: true, StableSince: ts},
+					statsFetchedAt: ts,
 				},
 				"e72eb8da-604d-47cd-bd7f-69ec120ea2b7": {
 					Server: Server{
@@ -152,9 +160,10 @@ func TestRunLifeCycle(t *testing.T) {
 						RaftVersion: 3,
 						NodeType:    NodeVoter,
 					},
-					State:  RaftVoter,
-					Stats:  *serverStats["e72eb8da-604d-47cd-bd7f-69ec120ea2b7"],
-					Health: ServerHealth{Healthy: true, StableSince: ts},
+					State:          RaftVoter,
+					Stats:          *serverStats["e72eb8da-604d-47cd-bd7f-69ec120ea2b7"],
+					Health:         ServerHealth{Healthy: true, StableSince: ts},
+					statsFetchedAt: ts,
 				},
 			},
 			Leader: "7875975d-d54b-49c1-a400-9fefcc706c67",
@@ -163,6 +172,8 @@ func TestRunLifeCycle(t *testing.T) {
 				"e72eb8da-604d-47cd-bd7f-69ec120ea2b7",
 				"ecfc5237-63c3-4b09-94b9-d5682d9ae5b1",
 			},
+			LeaderStats: &LeaderStats{LastLogTerm: 3},
+			Promoter:    stablePromoterStatus(),
 		}
 	}
 
@@ -273,3 +284,19 @@ func TestRunLifeCycle(t *testing.T) {
 		return chanIsSelectable(done)
 	}, time.Second, 50*time.Millisecond)
 }
+
+func TestStepWhileRunning(t *testing.T) {
+	ap := &Autopilot{
+		execution:  &execInfo{status: Running},
+		leaderLock: newMutex(),
+	}
+	require.EqualError(t, ap.Step(context.Background()), "cannot step autopilot while it is running")
+
+	// the leader lock is held by another step or a previous execution which
+	// is still shutting down
+	ap.execution = nil
+	ap.leaderLock.Lock()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(t, ap.Step(ctx), context.Canceled)
+}
@@ -0,0 +1,95 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"fmt"
+	"strings"
+)
+
+// selectorOp is the comparison a serverSelector performs.
+type selectorOp int
+
+const (
+	selectorExists selectorOp = iota
+	selectorEquals
+	selectorNotEquals
+)
+
+// serverSelector matches servers by the value of a single Server.Meta key.
+type serverSelector struct {
+	key   string
+	value string
+	op    selectorOp
+}
+
+// parseServerSelector parses a label selector style expression. The supported
+// forms are "key=value", "key!=value" and "key", the last of which matches all
+// servers that have any value for the key.
+func parseServerSelector(expr string) (serverSelector, error) {
+	var sel serverSelector
+
+	if idx := strings.Index(expr, "!="); idx >= 0 {
+		sel.key, sel.value, sel.op = expr[:idx], expr[idx+2:], selectorNotEquals
+	} else if idx := strings.Index(expr, "="); idx >= 0 {
+		sel.key, sel.value, sel.op = expr[:idx], expr[idx+1:], selectorEquals
+	} else {
+		sel.key, sel.op = expr, selectorExists
+	}
+
+	sel.key = strings.TrimSpace(sel.key)
+	sel.value = strings.TrimSpace(sel.value)
+
+	if sel.key == "" {
+		return sel, fmt.Errorf("server selector %q does not specify a meta key", expr)
+	}
+
+	return sel, nil
+}
+
+// matches returns whether the server meta satisfies the selector.
+func (s serverSelector) matches(meta map[string]string) bool {
+	value, ok := meta[s.key]
+
+	switch s.op {
+	case selectorEquals:
+		return ok && value == s.value
+	case selectorNotEquals:
+		return !ok || value != s.value
+	default:
+		return ok
+	}
+}
+
+// matchesAnySelector returns whether the server meta satisfies any of the
+// given selector expressions. An error is returned for the first expression
+// that cannot be parsed.
+func matchesAnySelector(exprs []string, meta map[string]string) (bool, error) {
+	for _, expr := range exprs {
+		sel, err := parseServerSelector(expr)
+		if err != nil {
+			return false, err
+		}
+
+		if sel.matches(meta) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// isIgnored returns whether the server matches any of the configured
+// IgnoredServerSelectors and therefore should not be managed by autopilot.
+func (a *Autopilot) isIgnored(conf *Config, srv *Server) bool {
+	if conf == nil || len(conf.IgnoredServerSelectors) == 0 {
+		return false
+	}
+
+	ignored, err := matchesAnySelector(conf.IgnoredServerSelectors, srv.Meta)
+	if err != nil {
+		a.logger.Warn("invalid ignored server selector", "error", err)
+	}
+	return ignored
+}
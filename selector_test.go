@@ -0,0 +1,135 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseServerSelector(t *testing.T) {
+	cases := map[string]struct {
+		expr     string
+		expected serverSelector
+		err      bool
+	}{
+		"equals":       {expr: "pool=build-agents", expected: serverSelector{key: "pool", value: "build-agents", op: selectorEquals}},
+		"not-equals":   {expr: "pool != core", expected: serverSelector{key: "pool", value: "core", op: selectorNotEquals}},
+		"exists":       {expr: "maintenance", expected: serverSelector{key: "maintenance", op: selectorExists}},
+		"empty-value":  {expr: "pool=", expected: serverSelector{key: "pool", op: selectorEquals}},
+		"missing-key":  {expr: "=core", err: true},
+		"empty":        {expr: "", err: true},
+		"only-spacing": {expr: "  != x", err: true},
+	}
+
+	for name, tcase := range cases {
+		t.Run(name, func(t *testing.T) {
+			sel, err := parseServerSelector(tcase.expr)
+			if tcase.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tcase.expected, sel)
+		})
+	}
+}
+
+func TestServerSelectorMatches(t *testing.T) {
+	meta := map[string]string{"pool": "build-agents", "maintenance": ""}
+
+	cases := map[string]bool{
+		"pool=build-agents":  true,
+		"pool=core":          false,
+		"pool!=core":         true,
+		"pool!=build-agents": false,
+		"zone!=us-east-1a":   true,
+		"maintenance":        true,
+		"zone":               false,
+	}
+
+	for expr, expected := range cases {
+		t.Run(expr, func(t *testing.T) {
+			matches, err := matchesAnySelector([]string{expr}, meta)
+			require.NoError(t, err)
+			require.Equal(t, expected, matches)
+		})
+	}
+
+	_, err := matchesAnySelector([]string{"=bad"}, meta)
+	require.Error(t, err)
+}
+
+func TestIgnoredServers(t *testing.T) {
+	conf := &Config{IgnoredServerSelectors: []string{"pool=build-agents"}}
+
+	raftConfig := raft.Configuration{
+		Servers: []raft.Server{
+			{Suffrage: raft.Voter, ID: "a", Address: "198.18.0.1:8300"},
+			{Suffrage: raft.Voter, ID: "b", Address: "198.18.0.2:8300"},
+			{Suffrage: raft.Nonvoter, ID: "c", Address: "198.18.0.3:8300"},
+		},
+	}
+
+	knownServers := map[raft.ServerID]*Server{
+		"a": {ID: "a", NodeStatus: NodeAlive, NodeType: NodeVoter},
+		"b": {ID: "b", NodeStatus: NodeFailed, NodeType: NodeVoter},
+		"c": {ID: "c", NodeStatus: NodeFailed, NodeType: NodeVoter, Meta: map[string]string{"pool": "build-agents"}},
+	}
+
+	mpromoter := NewMockPromoter(t)
+	mpromoter.On("IsPotentialVoter", NodeVoter).Return(true)
+	mapp := NewMockApplicationIntegration(t)
+	mapp.On("KnownServers").Return(knownServers).Once()
+	mraft := NewMockRaft(t)
+	mraft.On("GetConfiguration").Return(&raftConfigFuture{config: raftConfig}).Once()
+
+	a := &Autopilot{
+		logger:   hclog.NewNullLogger(),
+		raft:     mraft,
+		delegate: mapp,
+		promoter: mpromoter,
+	}
+
+	// the ignored server is neither failed nor stale
+	failed, _, err := a.getFailedServers(conf)
+	require.NoError(t, err)
+	require.Equal(t, &FailedServers{FailedVoters: []*Server{knownServers["b"]}}, failed)
+
+	// and its stats would not be fetched
+	require.Equal(t, map[raft.ServerID]*Server{
+		"a": knownServers["a"],
+		"b": knownServers["b"],
+	}, a.managedServers(conf, knownServers))
+
+	// ignored servers are not promoted or demoted
+	state := &State{
+		Servers: map[raft.ServerID]*ServerState{
+			"c": {
+				Server:  *knownServers["c"],
+				State:   RaftNonVoter,
+				Health:  ServerHealth{Healthy: true},
+				Ignored: true,
+			},
+			"d": {
+				Server:  Server{ID: "d"},
+				State:   RaftVoter,
+				Health:  ServerHealth{Healthy: true},
+				Ignored: true,
+			},
+		},
+	}
+
+	done, err := a.applyPromotions(context.Background(), state, RaftChanges{Promotions: []raft.ServerID{"c"}})
+	require.False(t, done)
+	require.NoError(t, err)
+
+	done, err = a.applyDemotions(context.Background(), nil, state, RaftChanges{Demotions: []raft.ServerID{"d"}})
+	require.False(t, done)
+	require.NoError(t, err)
+}
@@ -0,0 +1,164 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"math"
+	"sort"
+
+	"github.com/hashicorp/raft"
+)
+
+// SpreadConstraint limits how many voters may share the same value of a
+// Server.Meta key, such as no more than two voters within the same rack.
+// Servers without a value for the key are not limited.
+type SpreadConstraint struct {
+	// Key is the Server.Meta key whose values are constrained.
+	Key string
+
+	// MaxVoters is the most voters which may share a value of the key.
+	MaxVoters int
+}
+
+// SpreadViolation describes voters sharing a value of a SpreadConstraint's
+// key beyond its MaxVoters.
+type SpreadViolation struct {
+	// Key is the Server.Meta key of the constraint.
+	Key string
+
+	// Value is the value of the key the voters share.
+	Value string
+
+	// Voters are the voters sharing the value.
+	Voters []raft.ServerID
+
+	// MaxVoters is the most voters the constraint allows to share the value.
+	MaxVoters int
+}
+
+// spreadViolations returns the violations of the configured spread
+// constraints by the state's voters, in the order the constraints are
+// configured and then by value.
+func spreadViolations(conf *Config, s *State) []SpreadViolation {
+	if conf == nil || len(conf.SpreadConstraints) == 0 {
+		return nil
+	}
+
+	var result []SpreadViolation
+	for _, c := range conf.SpreadConstraints {
+		shared := make(map[string][]raft.ServerID)
+		for _, id := range s.Voters {
+			srv, ok := s.Servers[id]
+			if !ok {
+				continue
+			}
+			if value := srv.Server.Meta[c.Key]; value != "" {
+				shared[value] = append(shared[value], id)
+			}
+		}
+
+		var violations []SpreadViolation
+		for value, voters := range shared {
+			if len(voters) > c.MaxVoters {
+				violations = append(violations, SpreadViolation{
+					Key:       c.Key,
+					Value:     value,
+					Voters:    voters,
+					MaxVoters: c.MaxVoters,
+				})
+			}
+		}
+		sort.Slice(violations, func(i, j int) bool {
+			return violations[i].Value < violations[j].Value
+		})
+		result = append(result, violations...)
+	}
+	return result
+}
+
+// enforceSpread filters the promotions so that no promotion takes the voters
+// sharing a value of a SpreadConstraint's key beyond its MaxVoters. The
+// promotions are reordered so that those leaving the most room within their
+// most constrained value are made first. Demotions are left alone.
+func (a *Autopilot) enforceSpread(conf *Config, state *State, changes RaftChanges) RaftChanges {
+	if len(conf.SpreadConstraints) == 0 || len(changes.Promotions) == 0 {
+		return changes
+	}
+
+	demoting := make(map[raft.ServerID]struct{})
+	for _, id := range changes.Demotions {
+		demoting[id] = struct{}{}
+	}
+
+	// count the voters sharing each value once the demotions are made
+	shared := make([]map[string]int, len(conf.SpreadConstraints))
+	for i, c := range conf.SpreadConstraints {
+		shared[i] = make(map[string]int)
+		for _, id := range state.Voters {
+			srv, ok := state.Servers[id]
+			if !ok {
+				continue
+			}
+			if _, ok := demoting[id]; ok {
+				continue
+			}
+			if value := srv.Server.Meta[c.Key]; value != "" {
+				shared[i][value]++
+			}
+		}
+	}
+
+	// headroom returns how many more voters may share the most constrained
+	// of the server's values. Servers unknown to the state are unconstrained
+	// as their promotion will not be made.
+	headroom := func(id raft.ServerID) int {
+		srv, ok := state.Servers[id]
+		if !ok {
+			return math.MaxInt
+		}
+
+		room := math.MaxInt
+		for i, c := range conf.SpreadConstraints {
+			value := srv.Server.Meta[c.Key]
+			if value == "" {
+				continue
+			}
+			if r := c.MaxVoters - shared[i][value]; r < room {
+				room = r
+			}
+		}
+		return room
+	}
+
+	candidates := append([]raft.ServerID(nil), changes.Promotions...)
+	var promotions []raft.ServerID
+	for len(candidates) > 0 {
+		best := 0
+		for i, id := range candidates {
+			if headroom(id) > headroom(candidates[best]) {
+				best = i
+			}
+		}
+
+		id := candidates[best]
+		if headroom(id) <= 0 {
+			// every remaining candidate would violate a constraint
+			a.roundLogger().Info("not promoting servers as it would violate a spread constraint", "promotions", candidates)
+			break
+		}
+
+		candidates = append(candidates[:best], candidates[best+1:]...)
+		promotions = append(promotions, id)
+		if srv, ok := state.Servers[id]; ok {
+			for i, c := range conf.SpreadConstraints {
+				if value := srv.Server.Meta[c.Key]; value != "" {
+					shared[i][value]++
+				}
+			}
+		}
+	}
+
+	changes.Promotions = promotions
+	return changes
+}
@@ -0,0 +1,55 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"testing"
+
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+// spreadTestState returns a state with voters a, b and c within rack-1 and
+// non-voters d within rack-1, e within rack-2 and f without a rack.
+func spreadTestState() *State {
+	racks := map[raft.ServerID]string{"a": "rack-1", "b": "rack-1", "c": "rack-1", "d": "rack-1", "e": "rack-2", "f": ""}
+	return withMeta(testState([]raft.ServerID{"a", "b", "c", "d", "e", "f"}, "a", "a", "b", "c"), "rack", racks)
+}
+
+func TestSpreadViolations(t *testing.T) {
+	state := spreadTestState()
+
+	require.Nil(t, spreadViolations(&Config{}, state))
+	require.Empty(t, spreadViolations(&Config{SpreadConstraints: []SpreadConstraint{{Key: "rack", MaxVoters: 3}}}, state))
+
+	conf := &Config{SpreadConstraints: []SpreadConstraint{{Key: "rack", MaxVoters: 2}}}
+	require.Equal(t, []SpreadViolation{{
+		Key:       "rack",
+		Value:     "rack-1",
+		Voters:    []raft.ServerID{"a", "b", "c"},
+		MaxVoters: 2,
+	}}, spreadViolations(conf, state))
+}
+
+func TestEnforceSpread(t *testing.T) {
+	state := spreadTestState()
+	a := &Autopilot{logger: testLogger(t)}
+	conf := &Config{SpreadConstraints: []SpreadConstraint{{Key: "rack", MaxVoters: 3}}}
+
+	// d would be a fourth voter within rack-1 while f has no rack
+	changes := a.enforceSpread(conf, state, RaftChanges{Promotions: []raft.ServerID{"d", "e", "f"}})
+	require.Equal(t, []raft.ServerID{"f", "e"}, changes.Promotions)
+
+	// demoting a voter within rack-1 makes room for d
+	changes = a.enforceSpread(conf, state, RaftChanges{
+		Promotions: []raft.ServerID{"d"},
+		Demotions:  []raft.ServerID{"c"},
+	})
+	require.Equal(t, []raft.ServerID{"d"}, changes.Promotions)
+	require.Equal(t, []raft.ServerID{"c"}, changes.Demotions)
+
+	// without constraints the promotions are left alone
+	changes = a.enforceSpread(&Config{}, state, RaftChanges{Promotions: []raft.ServerID{"d", "e"}})
+	require.Equal(t, []raft.ServerID{"d", "e"}, changes.Promotions)
+}
@@ -40,22 +40,32 @@ func (_ *StablePromoter) FilterFailedServerRemovals(_ *Config, _ *State, failed
 
 // CalculatePromotionsAndDemotions will return a list of all promotions and demotions to be done as well as the server id of
 // the desired leader. This particular interface implementation maintains a stable leader and will promote healthy servers
-// to voting status. It will never change the leader ID nor will it perform demotions.
+// to voting status. It will never change the leader ID nor will it perform demotions. Promotions are ordered so that servers
+// with a higher Value are promoted first.
 func (_ *StablePromoter) CalculatePromotionsAndDemotions(c *Config, s *State) RaftChanges {
 	var changes RaftChanges
 
 	now := time.Now()
-	minStableDuration := s.ServerStabilizationTime(c)
 	for id, server := range s.Servers {
+		minStableDuration := s.serverStabilizationTimeAt(c.ForServer(&server.Server), now)
 		// ignore staging state as they are not ready yet
 		if server.State == RaftNonVoter && server.Health.IsStable(now, minStableDuration) {
 			changes.Promotions = append(changes.Promotions, id)
 		}
 	}
 
+	sortByValue(changes.Promotions, s)
 	return changes
 }
 
+func (_ *StablePromoter) Name() string {
+	return "stable"
+}
+
+func (_ *StablePromoter) Describe() string {
+	return "promotes every stable, healthy non-voter and never demotes voters"
+}
+
 func (_ *StablePromoter) IsPotentialVoter(nodeType NodeType) bool {
 	return nodeType == NodeVoter
 }
@@ -93,3 +93,21 @@ func TestStablePromoter_CalculatePromotionsAndDemotions(t *testing.T) {
 	conf := &Config{ServerStabilizationTime: 10 * time.Second}
 	require.Equal(t, expected, promoter.CalculatePromotionsAndDemotions(conf, state))
 }
+
+func TestStablePromoter_PromotionValueOrder(t *testing.T) {
+	state := &State{
+		Servers: make(map[raft.ServerID]*ServerState),
+	}
+
+	values := map[raft.ServerID]int{"a": 0, "b": 5, "c": 0, "d": -1, "e": 10}
+	for id, value := range values {
+		state.Servers[id] = &ServerState{
+			Server: Server{ID: id, Value: value},
+			State:  RaftNonVoter,
+			Health: ServerHealth{Healthy: true},
+		}
+	}
+
+	changes := new(StablePromoter).CalculatePromotionsAndDemotions(&Config{}, state)
+	require.Equal(t, []raft.ServerID{"e", "b", "a", "c", "d"}, changes.Promotions)
+}
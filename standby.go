@@ -0,0 +1,59 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"context"
+)
+
+// StartStandby launches the go routine which periodically updates the
+// autopilot state without ever reconciling it or pruning dead servers. It is
+// meant to be called on followers so that if they become the leader, the
+// subsequent call to Start finds warm health and stability data rather than
+// waiting for servers to stabilize again. The delegate must be able to fetch
+// server stats and will be notified of states while in standby. Nothing is
+// done when autopilot is already running or in standby. Stop ends standby
+// while Start takes over from it.
+func (a *Autopilot) StartStandby(ctx context.Context) {
+	a.execLock.Lock()
+	defer a.execLock.Unlock()
+
+	if a.execution != nil && (a.execution.status == Running || a.execution.status == Standby) {
+		return
+	}
+
+	ctx, shutdown := context.WithCancel(ctx)
+
+	exec := &execInfo{
+		status:   Standby,
+		shutdown: shutdown,
+		done:     make(chan struct{}),
+	}
+
+	go a.beginStandby(ctx, exec)
+	a.execution = exec
+}
+
+// beginStandby updates the state until the context is cancelled. Unlike
+// beginExecution the state is kept once stopped so that it may be used when
+// autopilot starts running.
+func (a *Autopilot) beginStandby(ctx context.Context, exec *execInfo) {
+	if err := a.leaderLock.TryLock(ctx); err != nil {
+		a.finishExecution(exec)
+		return
+	}
+
+	a.logger.Debug("autopilot is now in standby")
+	a.updateState(ctx)
+
+	stateUpdaterDone := make(chan struct{})
+	go a.runStateUpdater(ctx, stateUpdaterDone)
+
+	<-ctx.Done()
+	<-stateUpdaterDone
+
+	a.logger.Debug("autopilot is no longer in standby")
+	a.finishExecution(exec)
+	a.leaderLock.Unlock()
+}
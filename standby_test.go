@@ -0,0 +1,56 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+func TestStandby(t *testing.T) {
+	t.Cleanup(func() { goleak.VerifyNone(t) })
+
+	mraft := NewMockRaft(t)
+	mdel := NewMockApplicationIntegration(t)
+	mtime := NewMockTimeProvider(t)
+
+	// the state updates fail without a configuration, leaving the state as is
+	mtime.On("Now").Return(time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC))
+	mdel.On("AutopilotConfig").Return(nil)
+
+	a := New(mraft, mdel, WithLogger(testLogger(t)), WithTimeProvider(mtime))
+	state := planTestState()
+	a.state = state
+
+	a.StartStandby(context.Background())
+	status, _ := a.IsRunning()
+	require.Equal(t, Standby, status)
+
+	// starting standby again does nothing
+	exec := a.execution
+	a.StartStandby(context.Background())
+	require.Same(t, exec, a.execution)
+
+	// stopping standby keeps the state for when autopilot starts running
+	<-a.Stop()
+	status, _ = a.IsRunning()
+	require.Equal(t, NotRunning, status)
+	require.Same(t, state, a.GetState())
+
+	// starting takes over from standby and standby is not entered while
+	// running
+	a.StartStandby(context.Background())
+	a.Start(context.Background())
+	status, _ = a.IsRunning()
+	require.Equal(t, Running, status)
+
+	exec = a.execution
+	a.StartStandby(context.Background())
+	require.Same(t, exec, a.execution)
+	<-a.Stop()
+}
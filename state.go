@@ -27,6 +27,21 @@ func aliveServers(servers map[raft.ServerID]*Server) map[raft.ServerID]*Server {
 	return serverMap
 }
 
+// managedServers will filter the input map of servers and output one with
+// all the servers that autopilot has been configured to ignore removed.
+func (a *Autopilot) managedServers(conf *Config, servers map[raft.ServerID]*Server) map[raft.ServerID]*Server {
+	serverMap := make(map[raft.ServerID]*Server)
+	for id, server := range servers {
+		if a.isIgnored(conf, server) {
+			continue
+		}
+
+		serverMap[id] = server
+	}
+
+	return serverMap
+}
+
 // nextStateInputs is the collection of values that can influence
 // creation of the next State.
 type nextStateInputs struct {
@@ -41,6 +56,16 @@ type nextStateInputs struct {
 	LeaderID       raft.ServerID
 	IsLeader       bool // this will be true when the server running the autopilot code is the leader
 	CurrentState   *State
+	ClockJump      time.Duration // how far the wall clock jumped since the previous state
+
+	StatsOutageSince time.Time // when stats stopped being fetched for every server
+	Degraded         bool      // whether the servers' previous health should be retained
+
+	PersistedHealth map[raft.ServerID]PersistedServerHealth // the health persisted before the first state
+
+	LeaderStats *LeaderStats // the parsed Raft stats of the local server
+
+	Promoter PromoterStatus // the identity and health of the promoter in use
 }
 
 func (i *nextStateInputs) getCurrentServerState(id raft.ServerID) (*ServerState, bool) {
@@ -88,8 +113,20 @@ func (a *Autopilot) gatherNextStateInputs(ctx context.Context) (*nextStateInputs
 		firstStateTime = a.state.firstStateTime
 	}
 
+	// detect wall clock jumps so that times recorded in previous states can
+	// be adjusted to not reset or inflate stability windows
+	var clockJump time.Duration
+	if a.FeatureEnabled(FeatureClockJumpProtection) {
+		clockJump = a.clock.observe(now)
+	}
+	if clockJump != 0 {
+		a.subsystemLogger(SubsystemState).Warn("detected a wall clock jump", "jump", clockJump)
+		a.emitEvent(EventClockJump, "", fmt.Sprintf("the wall clock jumped by %s, adjusting previously recorded times", clockJump))
+	}
+
 	// firstStateTime will be the zero value if we are in the process of generating
 	// the first state. In that case we set it to the now time.
+	firstStateTime = adjustForClock(firstStateTime, now, clockJump)
 	if firstStateTime.IsZero() {
 		firstStateTime = now
 	}
@@ -98,6 +135,7 @@ func (a *Autopilot) gatherNextStateInputs(ctx context.Context) (*nextStateInputs
 		Now:            now,
 		FirstStateTime: firstStateTime,
 		CurrentState:   currentState,
+		ClockJump:      clockJump,
 	}
 
 	// grab the latest autopilot configuration
@@ -116,6 +154,10 @@ func (a *Autopilot) gatherNextStateInputs(ctx context.Context) (*nextStateInputs
 
 	// get the known servers which may include left/failed ones
 	inputs.KnownServers = a.delegate.KnownServers()
+	a.observeKnownServers(now, inputs.KnownServers)
+
+	// restore the servers' health when there is none to carry over
+	inputs.PersistedHealth = a.loadPersistedHealth(currentState)
 
 	// Try to retrieve leader id from the delegate.
 	for id, srv := range inputs.KnownServers {
@@ -147,11 +189,13 @@ func (a *Autopilot) gatherNextStateInputs(ctx context.Context) (*nextStateInputs
 	// latest index of the leader and use that.
 	inputs.IsLeader = a.raft.State() == raft.Leader
 
-	term, err := a.lastTerm()
+	stats, err := a.leaderStats()
 	if err != nil {
 		return nil, fmt.Errorf("failed to determine the last Raft term: %w", err)
 	}
-	inputs.LastTerm = term
+	inputs.LastTerm = stats.LastLogTerm
+	inputs.LeaderStats = stats
+	inputs.Promoter = a.promoterStatus()
 
 	// getting the raft configuration could block for a while so now is a good
 	// time to check for context cancellation
@@ -174,7 +218,17 @@ func (a *Autopilot) gatherNextStateInputs(ctx context.Context) (*nextStateInputs
 	fetchCtx, cancel := context.WithDeadline(ctx, d)
 	defer cancel()
 
-	inputs.FetchedStats = a.delegate.FetchServerStats(fetchCtx, aliveServers(inputs.KnownServers))
+	toFetch := a.managedServers(config, aliveServers(inputs.KnownServers))
+	inputs.FetchedStats = a.fetchServerStats(fetchCtx, toFetch)
+
+	// detect the stats being unavailable for every server
+	if len(toFetch) > 0 && len(inputs.FetchedStats) == 0 {
+		inputs.StatsOutageSince = now
+		if currentState != nil && !currentState.StatsOutageSince.IsZero() {
+			inputs.StatsOutageSince = adjustForClock(currentState.StatsOutageSince, now, clockJump)
+		}
+		inputs.Degraded = now.Sub(inputs.StatsOutageSince) < config.StatsOutageGracePeriod
+	}
 
 	// it might be nil but we propagate the ctx.Err just in case our context was
 	// cancelled since the last time we checked.
@@ -209,9 +263,13 @@ func (a *Autopilot) nextStateWithInputs(inputs *nextStateInputs) *State {
 	// time up until the time we generated the first state becomes far enough
 	// in the past. Until that point in time all servers are considered stable.
 	newState := &State{
-		firstStateTime: inputs.FirstStateTime,
-		Healthy:        true,
-		Servers:        nextServers,
+		firstStateTime:   inputs.FirstStateTime,
+		Healthy:          true,
+		Servers:          nextServers,
+		StatsOutageSince: inputs.StatsOutageSince,
+		Degraded:         inputs.Degraded,
+		LeaderStats:      inputs.LeaderStats,
+		Promoter:         inputs.Promoter,
 	}
 
 	voterCount := 0
@@ -223,11 +281,15 @@ func (a *Autopilot) nextStateWithInputs(inputs *nextStateInputs) *State {
 	//   3. Count the number of healthy voters in the cluster
 	//   4. Detect unhealthy servers and mark the overall health as false
 	for id, srv := range nextServers {
-		if !srv.Health.Healthy {
-			// any unhealthiness results in overall unhealthiness
+		if !srv.Health.Healthy && !srv.Ignored {
+			// any unhealthiness of managed servers results in overall unhealthiness
 			newState.Healthy = false
 		}
 
+		if inputs.Config.holdsServer(srv.Server.NodeStatus) {
+			newState.HeldServers = append(newState.HeldServers, id)
+		}
+
 		switch srv.State {
 		case RaftLeader:
 			newState.Leader = id
@@ -249,8 +311,21 @@ func (a *Autopilot) nextStateWithInputs(inputs *nextStateInputs) *State {
 		newState.FailureTolerance = healthyVoters - requiredQuorum
 	}
 
+	newState.FailureDomains = failureDomainTolerances(inputs.Config, newState)
+	newState.SpreadViolations = spreadViolations(inputs.Config, newState)
+	if a.FeatureEnabled(FeatureTermDivergenceGuard) {
+		newState.TermsDiverged = termsDiverged(inputs, newState)
+	}
+
+	// compute how much longer each healthy non-voter must remain stable
+	// before it could be promoted
+	for _, srv := range nextServers {
+		minStableDuration := newState.serverStabilizationTimeAt(inputs.Config.ForServer(&srv.Server), inputs.Now)
+		srv.SecondsUntilEligible = secondsUntilEligible(srv, inputs.Now, minStableDuration)
+	}
+
 	// update any promoter specific overall state
-	if newExt := a.promoter.GetStateExt(inputs.Config, newState); newExt != nil {
+	if newExt := a.getPromoter().GetStateExt(inputs.Config, newState); newExt != nil {
 		newState.Ext = newExt
 	}
 
@@ -259,12 +334,21 @@ func (a *Autopilot) nextStateWithInputs(inputs *nextStateInputs) *State {
 	// each server as some promotion algorithms may want to keep certain
 	// servers as non-voters for reasons. The node type then can be used
 	// to indicate why that might be happening.
-	for id, typ := range a.promoter.GetNodeTypes(inputs.Config, newState) {
+	for id, typ := range a.getPromoter().GetNodeTypes(inputs.Config, newState) {
 		if srv, ok := newState.Servers[id]; ok {
 			srv.Server.NodeType = typ
 		}
 	}
 
+	// the promotable non-voters depend upon the node types
+	newState.FailureToleranceInputs = FailureToleranceInputs{
+		Voters:              voterCount,
+		HealthyVoters:       healthyVoters,
+		RequiredQuorum:      requiredQuorum,
+		PromotableNonVoters: a.promotableNonVoters(newState),
+	}
+	newState.OptimisticFailureTolerance = optimisticFailureTolerance(newState.FailureToleranceInputs)
+
 	// Sort the voters list to keep the output stable. This is done near the end
 	// as SortServers may use other parts of the state that were created in
 	// this method and populated in the newState. Requiring output stability
@@ -272,6 +356,7 @@ func (a *Autopilot) nextStateWithInputs(inputs *nextStateInputs) *State {
 	// the state periodically you shouldn't see things change unless there
 	// are real changes to server health or overall configuration.
 	SortServers(newState.Voters, newState)
+	SortServers(newState.HeldServers, newState)
 
 	return newState
 }
@@ -289,10 +374,14 @@ func (a *Autopilot) nextServers(inputs *nextStateInputs) map[raft.ServerID]*Serv
 		// update any promoter specific information. This isn't done within
 		// buildServerState to keep that function "pure" and not require
 		// mocking for tests
-		if newExt := a.promoter.GetServerExt(inputs.Config, &state); newExt != nil {
+		if newExt := a.getPromoter().GetServerExt(inputs.Config, &state); newExt != nil {
 			state.Server.Ext = newExt
 		}
 
+		state.Lockout = a.lockouts.get(srv.ID)
+		state.Enrichments = a.enrichment.get(srv.ID, inputs.Now)
+		state.Ignored = a.isIgnored(inputs.Config, &state.Server)
+
 		newServers[srv.ID] = &state
 	}
 
@@ -335,9 +424,11 @@ func buildServerState(inputs *nextStateInputs, srv raft.Server) ServerState {
 
 	// copy some state from an existing server into the new state - most of this
 	// should be overridden soon but at this point we are just building the base.
-	if existing, found := inputs.getCurrentServerState(srv.ID); found {
+	existing, found := inputs.getCurrentServerState(srv.ID)
+	if found {
 		state.Stats = existing.Stats
 		state.Health = existing.Health
+		state.Health.StableSince = adjustForClock(state.Health.StableSince, inputs.Now, inputs.ClockJump)
 		previousHealthy = &state.Health.Healthy
 
 		// it is important to note that the map values we retrieved this from are
@@ -371,8 +462,13 @@ func buildServerState(inputs *nextStateInputs, srv raft.Server) ServerState {
 	}
 
 	// override the Stats if any were in the fetched results
-	if stats, found := inputs.FetchedStats[srv.ID]; found {
+	stats, fetched := inputs.FetchedStats[srv.ID]
+	if fetched {
 		state.Stats = *stats
+		state.statsFetchedAt = inputs.Now
+	} else if found && !existing.statsFetchedAt.IsZero() {
+		state.statsFetchedAt = adjustForClock(existing.statsFetchedAt, inputs.Now, inputs.ClockJump)
+		state.StatsAge = inputs.Now.Sub(state.statsFetchedAt)
 	}
 
 	var leaderLastIndex uint64
@@ -387,32 +483,178 @@ func buildServerState(inputs *nextStateInputs, srv raft.Server) ServerState {
 		leaderLastTerm = leader.LastTerm
 	} // else - we have no leader and will keep the term/index at 0 to indicate this
 
+	// track the replication progress of non-voters. Without freshly fetched
+	// stats the previous progress is retained as there is nothing new to go on.
+	if inputs.Config.CatchUpMaxLag > 0 && !state.HasVotingRights() {
+		var prev *CatchUpProgress
+		if found {
+			prev = existing.CatchUp
+		}
+		if fetched {
+			state.CatchUp = nextCatchUpProgress(inputs.Config, prev, state.Stats.LastIndex, leaderLastIndex)
+		} else if prev != nil {
+			state.CatchUp = prev
+		} else {
+			state.CatchUp = &CatchUpProgress{}
+		}
+	}
+
+	// while degraded the previous health verdict is retained as the stats
+	// it would be judged with are unavailable
+	if inputs.Degraded && found {
+		return state
+	}
+
+	// the previous health verdict of an alive server is also retained while
+	// its cached stats are within the TTL so that a transient failure to
+	// fetch them does not flip its health
+	if ttl := inputs.Config.StatsCacheTTL; ttl > 0 && found && !fetched && !state.statsFetchedAt.IsZero() &&
+		state.Server.NodeStatus == NodeAlive && state.StatsAge <= ttl {
+		return state
+	}
+
+	// the node type is assigned by the promoter after health is determined so
+	// match any overrides against the previous classification
+	target := state.Server
+	if target.NodeType == "" && found {
+		target.NodeType = existing.Server.NodeType
+	}
+
 	// now populate the healthy field given the stats
-	state.Health.Healthy = state.isHealthy(leaderLastTerm, leaderLastIndex, inputs.Config)
+	state.Health.Reasons = state.unhealthyReasons(leaderLastTerm, leaderLastIndex, inputs.Config.ForServer(&target))
+	state.Health.Healthy = len(state.Health.Reasons) == 0
+	if found {
+		state.Health.applyHysteresis(inputs.Config, &existing.Health)
+	}
+	state.Health.TermAhead = leaderLastTerm != 0 && state.Stats.LastTerm > leaderLastTerm
+
+	// track how often the health changes to detect flapping servers
+	if inputs.Config.FlapThreshold > 0 {
+		state.Health.trackFlapping(inputs.Config, found && existing.Health.Healthy != state.Health.Healthy, inputs.Now)
+	} else {
+		state.Health.Flapping, state.Health.transitions, state.Health.lastTransition = false, nil, time.Time{}
+	}
+
 	// overwrite the StableSince field if this is a new server or when
 	// the health status changes. No need for an else as we previously set
 	// it when we overwrote the whole Health structure when finding a
 	// server in the existing state
-	if previousHealthy == nil || *previousHealthy != state.Health.Healthy {
+	if previousHealthy == nil {
+		state.Health.StableSince = inputs.restoreStableSince(srv.ID, state.Health.Healthy)
+	} else if *previousHealthy != state.Health.Healthy {
 		state.Health.StableSince = inputs.Now
 	}
 
 	return state
 }
 
+// secondsUntilEligible returns the number of whole seconds, rounded up, until a
+// healthy non-voter will have been stable for the given duration. Zero is
+// returned for servers that are already eligible or that are not healthy
+// non-voters.
+func secondsUntilEligible(srv *ServerState, now time.Time, minStableDuration time.Duration) int {
+	if srv.State != RaftNonVoter || !srv.Health.Healthy {
+		return 0
+	}
+
+	remaining := minStableDuration - now.Sub(srv.Health.StableSince)
+	if remaining <= 0 {
+		return 0
+	}
+
+	return int((remaining + time.Second - 1) / time.Second)
+}
+
+// termsDiverged returns whether at least two servers, and at least half of the
+// non-leader servers with fetched stats, report a last log term which differs
+// from the leader's. Only servers autopilot manages are taken into account.
+func termsDiverged(inputs *nextStateInputs, state *State) bool {
+	var leaderTerm uint64
+	if inputs.IsLeader {
+		leaderTerm = inputs.LastTerm
+	} else if leader, ok := inputs.FetchedStats[inputs.LeaderID]; ok {
+		leaderTerm = leader.LastTerm
+	} else {
+		// without a leader term there is nothing to compare against
+		return false
+	}
+
+	followers := 0
+	diverged := 0
+	for id, srv := range state.Servers {
+		if id == state.Leader || srv.Ignored {
+			continue
+		}
+
+		stats, ok := inputs.FetchedStats[id]
+		if !ok {
+			continue
+		}
+
+		followers++
+		if stats.LastTerm != leaderTerm {
+			diverged++
+		}
+	}
+
+	return diverged >= 2 && diverged*2 >= followers
+}
+
 // updateState will compute the nextState, set it on the Autopilot instance and
 // then notify the delegate of the update.
 func (a *Autopilot) updateState(ctx context.Context) {
-	newState, err := a.nextState(ctx)
+	defer a.usingPromoter()()
+
+	inputs, err := a.gatherNextStateInputs(ctx)
 	if err != nil {
-		a.logger.Error("Error when computing next state", "error", err)
+		a.subsystemLogger(SubsystemState).Error("Error when computing next state", "error", err)
 		return
 	}
 
+	newState := a.nextStateWithInputs(inputs)
+	a.lifecycle.observe(inputs.Now, newState)
+	a.history.record(inputs.Now, newState)
+	a.observeFailureTolerance(inputs.Now, newState)
+	a.emitStateMetrics(newState)
+	a.emitHealthChanges(inputs.CurrentState, newState)
+
+	a.observeStatsOutage(inputs.CurrentState, newState)
+	a.observeTermsAhead(inputs.CurrentState, newState)
+
+	if prev := inputs.CurrentState; prev == nil || prev.TermsDiverged != newState.TermsDiverged {
+		if newState.TermsDiverged {
+			a.subsystemLogger(SubsystemState).Warn("servers report diverging Raft terms, suppressing demotions until they converge")
+			a.emitEvent(EventTermsDiverged, "", "servers report diverging Raft terms, demotions are suppressed and the server stabilization time is doubled until they converge")
+		} else if prev != nil {
+			a.subsystemLogger(SubsystemState).Info("server Raft terms have converged")
+			a.emitEvent(EventTermsConverged, "", "server Raft terms have converged, resuming normal operation")
+		}
+	}
+
 	a.stateLock.Lock()
 	defer a.stateLock.Unlock()
 	a.state = newState
-	a.delegate.NotifyState(newState)
+	a.notifyState(inputs.CurrentState, newState)
+	a.notifyStateDiff(inputs.CurrentState, newState)
+	a.persistHealth(newState)
+}
+
+// sortByValue orders the servers with the highest Value first. Ties are broken
+// by the server ID.
+func sortByValue(ids []raft.ServerID, s *State) {
+	value := func(id raft.ServerID) int {
+		if srv, ok := s.Servers[id]; ok {
+			return srv.Server.Value
+		}
+		return 0
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		if vi, vj := value(ids[i]), value(ids[j]); vi != vj {
+			return vi > vj
+		}
+		return ids[i] < ids[j]
+	})
 }
 
 // SortServers will take a list of raft ServerIDs and sort it using
@@ -469,3 +711,50 @@ func ServerLessThan(id1 raft.ServerID, id2 raft.ServerID, s *State) bool {
 	// as we know that server J is healthy and thus should come before server I.
 	return srvI.Health.Healthy
 }
+
+// observeStatsOutage logs and emits events when a stats outage begins or ends.
+func (a *Autopilot) observeStatsOutage(prev, next *State) {
+	wasOutage := prev != nil && !prev.StatsOutageSince.IsZero()
+	isOutage := !next.StatsOutageSince.IsZero()
+
+	switch {
+	case isOutage && !wasOutage:
+		if next.Degraded {
+			a.subsystemLogger(SubsystemState).Warn("stats are unavailable for every server, retaining their previous health and suspending voting changes")
+			a.emitEvent(EventStatsOutage, "", "stats are unavailable for every server, autopilot is degraded and retaining their previous health")
+		} else {
+			a.subsystemLogger(SubsystemState).Warn("stats are unavailable for every server")
+			a.emitEvent(EventStatsOutage, "", "stats are unavailable for every server")
+		}
+	case wasOutage && !isOutage:
+		a.subsystemLogger(SubsystemState).Info("stats are available again")
+		a.emitEvent(EventStatsRestored, "", "stats are available again")
+	case wasOutage && prev.Degraded && !next.Degraded:
+		a.subsystemLogger(SubsystemState).Warn("stats outage exceeded the grace period, judging server health without stats")
+	}
+}
+
+// observeTermsAhead logs and emits events for the servers which have started
+// reporting a term ahead of the leader's. An early state refresh is requested
+// so that an election in progress is noticed sooner.
+func (a *Autopilot) observeTermsAhead(prev, next *State) {
+	ahead := false
+	for id, srv := range next.Servers {
+		if !srv.Health.TermAhead {
+			continue
+		}
+		if prev != nil {
+			if prevSrv, ok := prev.Servers[id]; ok && prevSrv.Health.TermAhead {
+				continue
+			}
+		}
+
+		ahead = true
+		a.subsystemLogger(SubsystemState).Warn("server reports a term ahead of the leader", "id", id, "term", srv.Stats.LastTerm)
+		a.emitEvent(EventServerTermAhead, id, fmt.Sprintf("last term %d is ahead of the leader's, an election may be in progress or the server may be partitioned", srv.Stats.LastTerm))
+	}
+
+	if ahead && a.FeatureEnabled(FeatureTermAheadRefresh) {
+		a.requestRefresh()
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"github.com/hashicorp/raft"
+)
+
+// StateDelta is what changed in the autopilot state since the delegate was
+// last notified. The full state is available on demand with State.
+type StateDelta struct {
+	// Diff describes the servers added and removed along with the changes to
+	// their health, voting rights and the leader.
+	Diff *StateDiff
+
+	// Servers holds the new state of every server which was added or whose
+	// Raft state, health, node status, address, name or version changed.
+	// Removed servers are only within the Diff.
+	Servers map[raft.ServerID]*ServerState
+
+	state *State
+}
+
+// State returns the full autopilot state the delta leads to. Like the state
+// given to NotifyState it should not be modified.
+func (d *StateDelta) State() *State {
+	return d.state
+}
+
+// StateDeltaNotifier may optionally be implemented by the
+// ApplicationIntegration to be notified of only what changed after each state
+// update, rather than with the full state, which is cheaper for the
+// application to process for large clusters. When implemented NotifyState is
+// no longer called and state updates which changed nothing the StateDelta
+// describes are not notified. It is called synchronously and therefore should
+// not block.
+type StateDeltaNotifier interface {
+	NotifyStateDelta(*StateDelta)
+}
+
+// notifyState notifies the delegate of the new state, either with the full
+// state or with a StateDelta when the delegate implements StateDeltaNotifier.
+func (a *Autopilot) notifyState(older, newer *State) {
+	notifier, ok := a.delegate.(StateDeltaNotifier)
+	if !ok {
+		a.delegate.NotifyState(newer)
+		return
+	}
+
+	delta := &StateDelta{
+		Diff:    Diff(older, newer),
+		Servers: make(map[raft.ServerID]*ServerState),
+		state:   newer,
+	}
+
+	var previous map[raft.ServerID]*ServerState
+	if older != nil {
+		previous = older.Servers
+	}
+	for id, srv := range newer.Servers {
+		if prev, ok := previous[id]; !ok || serverStateChanged(prev, srv) {
+			delta.Servers[id] = srv
+		}
+	}
+
+	if delta.Diff.Empty() && len(delta.Servers) == 0 {
+		return
+	}
+	notifier.NotifyStateDelta(delta)
+}
+
+// serverStateChanged returns whether any of the parts of the server's state
+// which a StateDelta tracks differ.
+func serverStateChanged(older, newer *ServerState) bool {
+	return older.State != newer.State ||
+		older.Health.Healthy != newer.Health.Healthy ||
+		older.Server.NodeStatus != newer.Server.NodeStatus ||
+		older.Server.Address != newer.Server.Address ||
+		older.Server.Name != newer.Server.Name ||
+		older.Server.Version != newer.Server.Version
+}
@@ -0,0 +1,58 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"testing"
+
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+// deltaNotifyingDelegate records the deltas given to NotifyStateDelta.
+type deltaNotifyingDelegate struct {
+	*MockApplicationIntegration
+	deltas []*StateDelta
+}
+
+func (d *deltaNotifyingDelegate) NotifyStateDelta(delta *StateDelta) {
+	d.deltas = append(d.deltas, delta)
+}
+
+func TestNotifyStateDelta(t *testing.T) {
+	delegate := &deltaNotifyingDelegate{MockApplicationIntegration: NewMockApplicationIntegration(t)}
+	a := &Autopilot{logger: testLogger(t), delegate: delegate}
+
+	older := &State{
+		Leader: "a",
+		Servers: map[raft.ServerID]*ServerState{
+			"a": diffTestServer("a", RaftLeader, true),
+			"b": diffTestServer("b", RaftVoter, true),
+		},
+	}
+
+	// the first notification includes every server
+	a.notifyState(nil, older)
+	require.Len(t, delegate.deltas, 1)
+	require.Len(t, delegate.deltas[0].Servers, 2)
+	require.Same(t, older, delegate.deltas[0].State())
+
+	// stats changes alone are not notified
+	newer := &State{
+		Leader: "a",
+		Servers: map[raft.ServerID]*ServerState{
+			"a": diffTestServer("a", RaftLeader, true),
+			"b": diffTestServer("b", RaftVoter, true),
+		},
+	}
+	newer.Servers["b"].Stats.LastIndex = 10
+	a.notifyState(older, newer)
+	require.Len(t, delegate.deltas, 1)
+
+	newer.Servers["b"].Server.Version = "1.2.0"
+	a.notifyState(older, newer)
+	require.Len(t, delegate.deltas, 2)
+	require.True(t, delegate.deltas[1].Diff.Empty())
+	require.Equal(t, map[raft.ServerID]*ServerState{"b": newer.Servers["b"]}, delegate.deltas[1].Servers)
+}
@@ -0,0 +1,51 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"github.com/hashicorp/raft"
+)
+
+// testState returns a state made up of healthy servers with the given IDs in
+// which the leader and voters have voting rights and the remaining servers are
+// non-voters.
+func testState(servers []raft.ServerID, leader raft.ServerID, voters ...raft.ServerID) *State {
+	state := &State{
+		Leader:  leader,
+		Voters:  voters,
+		Servers: make(map[raft.ServerID]*ServerState, len(servers)),
+	}
+
+	for _, id := range servers {
+		state.Servers[id] = &ServerState{
+			Server: Server{ID: id, NodeType: NodeVoter},
+			State:  RaftNonVoter,
+			Health: ServerHealth{Healthy: true},
+		}
+	}
+
+	for _, id := range voters {
+		state.Servers[id].State = RaftVoter
+	}
+	if srv, ok := state.Servers[leader]; ok {
+		srv.State = RaftLeader
+	}
+	return state
+}
+
+// withMeta sets the metadata key of the state's servers to their values.
+func withMeta(state *State, key string, values map[raft.ServerID]string) *State {
+	for id, value := range values {
+		state.Servers[id].Server.Meta = map[string]string{key: value}
+	}
+	return state
+}
+
+// withRaftVersions sets the Raft protocol version of the state's servers.
+func withRaftVersions(state *State, versions map[raft.ServerID]int) *State {
+	for id, version := range versions {
+		state.Servers[id].Server.RaftVersion = version
+	}
+	return state
+}
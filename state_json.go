@@ -0,0 +1,241 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/raft"
+)
+
+// StateSchemaVersion is the version of the JSON schema of the State. It is
+// to be incremented whenever a change is made to the schema that older
+// versions of this package would not be able to decode. Adding fields does
+// not require a new version as unknown fields are ignored when decoding.
+//
+// The schema of version 1 uses the same field names as the reflection based
+// encoding of earlier versions of this package so that states encoded by them,
+// which lack a SchemaVersion, can still be decoded.
+const StateSchemaVersion = 1
+
+// stateJSON is the JSON schema of the State. Fields must never be renamed or
+// removed without incrementing the StateSchemaVersion.
+type stateJSON struct {
+	SchemaVersion              int
+	Healthy                    bool
+	FailureTolerance           int
+	Servers                    map[raft.ServerID]*ServerState
+	Leader                     raft.ServerID
+	Voters                     []raft.ServerID
+	OptimisticFailureTolerance int
+	FailureToleranceInputs     FailureToleranceInputs
+	FailureDomains             map[string]*FailureDomainTolerance
+	HeldServers                []raft.ServerID
+	SpreadViolations           []SpreadViolation
+	TermsDiverged              bool
+	StatsOutageSince           time.Time
+	Degraded                   bool
+	LeaderStats                *LeaderStats
+	Promoter                   PromoterStatus
+
+	// FirstStateTime is when the first state was generated, which the
+	// effective server stabilization time depends upon. It is omitted when
+	// unknown.
+	FirstStateTime *time.Time `json:",omitempty"`
+
+	Ext interface{}
+}
+
+// MarshalJSON encodes the State with the schema of the StateSchemaVersion.
+func (s State) MarshalJSON() ([]byte, error) {
+	enc := stateJSON{
+		SchemaVersion:              StateSchemaVersion,
+		Healthy:                    s.Healthy,
+		FailureTolerance:           s.FailureTolerance,
+		Servers:                    s.Servers,
+		Leader:                     s.Leader,
+		Voters:                     s.Voters,
+		OptimisticFailureTolerance: s.OptimisticFailureTolerance,
+		FailureToleranceInputs:     s.FailureToleranceInputs,
+		FailureDomains:             s.FailureDomains,
+		HeldServers:                s.HeldServers,
+		SpreadViolations:           s.SpreadViolations,
+		TermsDiverged:              s.TermsDiverged,
+		StatsOutageSince:           s.StatsOutageSince,
+		Degraded:                   s.Degraded,
+		LeaderStats:                s.LeaderStats,
+		Promoter:                   s.Promoter,
+		Ext:                        s.Ext,
+	}
+	if !s.firstStateTime.IsZero() {
+		enc.FirstStateTime = &s.firstStateTime
+	}
+	return json.Marshal(&enc)
+}
+
+// UnmarshalJSON decodes a State encoded by MarshalJSON. An error is returned
+// when it was encoded with a newer schema than this package supports.
+func (s *State) UnmarshalJSON(data []byte) error {
+	var dec stateJSON
+	if err := json.Unmarshal(data, &dec); err != nil {
+		return err
+	}
+
+	if dec.SchemaVersion < 0 || dec.SchemaVersion > StateSchemaVersion {
+		return fmt.Errorf("unsupported autopilot state schema version %d", dec.SchemaVersion)
+	}
+
+	*s = State{
+		Healthy:                    dec.Healthy,
+		FailureTolerance:           dec.FailureTolerance,
+		Servers:                    dec.Servers,
+		Leader:                     dec.Leader,
+		Voters:                     dec.Voters,
+		OptimisticFailureTolerance: dec.OptimisticFailureTolerance,
+		FailureToleranceInputs:     dec.FailureToleranceInputs,
+		FailureDomains:             dec.FailureDomains,
+		HeldServers:                dec.HeldServers,
+		SpreadViolations:           dec.SpreadViolations,
+		TermsDiverged:              dec.TermsDiverged,
+		StatsOutageSince:           dec.StatsOutageSince,
+		Degraded:                   dec.Degraded,
+		LeaderStats:                dec.LeaderStats,
+		Promoter:                   dec.Promoter,
+		Ext:                        dec.Ext,
+	}
+	if dec.FirstStateTime != nil {
+		s.firstStateTime = *dec.FirstStateTime
+	}
+	return nil
+}
+
+// serverStateJSON is the JSON schema of the ServerState.
+type serverStateJSON struct {
+	Server               Server
+	State                RaftState
+	Stats                ServerStats
+	Health               ServerHealth
+	StatsAge             time.Duration
+	CatchUp              *CatchUpProgress
+	Lockout              *ServerLockout
+	Ignored              bool
+	SecondsUntilEligible int
+	Enrichments          map[string]Enrichment
+}
+
+// MarshalJSON encodes the ServerState with the schema of the
+// StateSchemaVersion.
+func (s ServerState) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&serverStateJSON{
+		Server:               s.Server,
+		State:                s.State,
+		Stats:                s.Stats,
+		Health:               s.Health,
+		StatsAge:             s.StatsAge,
+		CatchUp:              s.CatchUp,
+		Lockout:              s.Lockout,
+		Ignored:              s.Ignored,
+		SecondsUntilEligible: s.SecondsUntilEligible,
+		Enrichments:          s.Enrichments,
+	})
+}
+
+// UnmarshalJSON decodes a ServerState encoded by MarshalJSON.
+func (s *ServerState) UnmarshalJSON(data []byte) error {
+	var dec serverStateJSON
+	if err := json.Unmarshal(data, &dec); err != nil {
+		return err
+	}
+
+	*s = ServerState{
+		Server:               dec.Server,
+		State:                dec.State,
+		Stats:                dec.Stats,
+		Health:               dec.Health,
+		StatsAge:             dec.StatsAge,
+		CatchUp:              dec.CatchUp,
+		Lockout:              dec.Lockout,
+		Ignored:              dec.Ignored,
+		SecondsUntilEligible: dec.SecondsUntilEligible,
+		Enrichments:          dec.Enrichments,
+	}
+	return nil
+}
+
+// serverHealthJSON is the JSON schema of the ServerHealth. The flapping and
+// observation tracking is internal and not encoded.
+type serverHealthJSON struct {
+	Healthy     bool
+	StableSince time.Time
+	Reasons     []string
+	TermAhead   bool
+	Flapping    bool
+}
+
+// MarshalJSON encodes the ServerHealth with the schema of the
+// StateSchemaVersion.
+func (h ServerHealth) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&serverHealthJSON{
+		Healthy:     h.Healthy,
+		StableSince: h.StableSince,
+		Reasons:     h.Reasons,
+		TermAhead:   h.TermAhead,
+		Flapping:    h.Flapping,
+	})
+}
+
+// UnmarshalJSON decodes a ServerHealth encoded by MarshalJSON.
+func (h *ServerHealth) UnmarshalJSON(data []byte) error {
+	var dec serverHealthJSON
+	if err := json.Unmarshal(data, &dec); err != nil {
+		return err
+	}
+
+	*h = ServerHealth{
+		Healthy:     dec.Healthy,
+		StableSince: dec.StableSince,
+		Reasons:     dec.Reasons,
+		TermAhead:   dec.TermAhead,
+		Flapping:    dec.Flapping,
+	}
+	return nil
+}
+
+// serverStatsJSON is the JSON schema of the ServerStats. The LastContact is
+// encoded in nanoseconds.
+type serverStatsJSON struct {
+	LastContact time.Duration
+	LastTerm    uint64
+	LastIndex   uint64
+	Ext         interface{}
+}
+
+// MarshalJSON encodes the ServerStats with the schema of the
+// StateSchemaVersion.
+func (s ServerStats) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&serverStatsJSON{
+		LastContact: s.LastContact,
+		LastTerm:    s.LastTerm,
+		LastIndex:   s.LastIndex,
+		Ext:         s.Ext,
+	})
+}
+
+// UnmarshalJSON decodes a ServerStats encoded by MarshalJSON.
+func (s *ServerStats) UnmarshalJSON(data []byte) error {
+	var dec serverStatsJSON
+	if err := json.Unmarshal(data, &dec); err != nil {
+		return err
+	}
+
+	*s = ServerStats{
+		LastContact: dec.LastContact,
+		LastTerm:    dec.LastTerm,
+		LastIndex:   dec.LastIndex,
+		Ext:         dec.Ext,
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateJSON(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		state := testState([]raft.ServerID{"a"}, "a", "a")
+		state.firstStateTime = time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)
+		srv := state.Servers[state.Leader]
+		srv.Stats.LastContact = 15 * time.Millisecond
+		srv.Health.Reasons = []string{"node status is \"failed\""}
+		srv.Health.pendingObservations = 2
+		state.LeaderStats = &LeaderStats{Term: 3, LastLogIndex: 1024, LastLogTerm: 3}
+
+		data, err := json.Marshal(state)
+		require.NoError(t, err)
+
+		var fields map[string]interface{}
+		require.NoError(t, json.Unmarshal(data, &fields))
+		require.Equal(t, float64(StateSchemaVersion), fields["SchemaVersion"])
+		require.Equal(t, "2020-11-02T12:00:00Z", fields["FirstStateTime"])
+
+		var decoded State
+		require.NoError(t, json.Unmarshal(data, &decoded))
+
+		// the observation tracking is internal and not encoded
+		srv.Health.pendingObservations = 0
+		require.Equal(t, state, &decoded)
+	})
+
+	t.Run("unversioned", func(t *testing.T) {
+		// states encoded before the schema was versioned have no version
+		var decoded State
+		require.NoError(t, json.Unmarshal([]byte(`{"Healthy":true,"Leader":"a","Servers":{"a":{"State":"leader","Stats":{"LastContact":0,"LastTerm":2}}}}`), &decoded))
+		require.True(t, decoded.Healthy)
+		require.Equal(t, RaftLeader, decoded.Servers["a"].State)
+		require.Equal(t, uint64(2), decoded.Servers["a"].Stats.LastTerm)
+		require.True(t, decoded.firstStateTime.IsZero())
+	})
+
+	t.Run("newer version", func(t *testing.T) {
+		var decoded State
+		err := json.Unmarshal([]byte(`{"SchemaVersion":2}`), &decoded)
+		require.EqualError(t, err, "unsupported autopilot state schema version 2")
+	})
+}
@@ -0,0 +1,87 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/raft"
+)
+
+// StateReader provides read-only access to an autopilot State on servers which
+// are not running autopilot. The leader's Autopilot.ExportState output can be
+// pushed to followers using the application's own transport and fed into a
+// StateReader on each of them. UIs and APIs on any server may then show the
+// same autopilot data without forwarding every request to the leader.
+type StateReader struct {
+	lock      sync.RWMutex
+	state     *State
+	conf      *Config
+	updatedAt time.Time
+}
+
+// NewStateReader creates a StateReader without any state. GetState will return
+// nil until Update has succeeded.
+func NewStateReader() *StateReader {
+	return &StateReader{}
+}
+
+// Update replaces the reader's state and config with those read from r, which
+// must be in the format written by ExportState. The existing state is retained
+// when an error is returned.
+func (r *StateReader) Update(reader io.Reader) error {
+	export, err := decodeStateExport(reader)
+	if err != nil {
+		return err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.state = export.State
+	r.conf = export.Config
+	r.updatedAt = export.ExportedAt
+	return nil
+}
+
+// GetState returns the most recently received State. The returned State should
+// not be modified.
+func (r *StateReader) GetState() *State {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.state
+}
+
+// GetServerHealth returns the ServerHealth for a given server from the most
+// recently received State or nil if the server is unknown.
+func (r *StateReader) GetServerHealth(id raft.ServerID) *ServerHealth {
+	state := r.GetState()
+	if state == nil {
+		return nil
+	}
+
+	srv, ok := state.Servers[id]
+	if ok {
+		return &srv.Health
+	}
+
+	return nil
+}
+
+// Config returns the Config which was in effect when the most recently received
+// State was exported.
+func (r *StateReader) Config() *Config {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.conf
+}
+
+// UpdatedAt returns the time at which the most recently received State was
+// exported by the leader. It may be used to detect a stale reader.
+func (r *StateReader) UpdatedAt() time.Time {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.updatedAt
+}
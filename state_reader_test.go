@@ -0,0 +1,41 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateReader(t *testing.T) {
+	r := NewStateReader()
+	require.Nil(t, r.GetState())
+	require.Nil(t, r.Config())
+	require.Nil(t, r.GetServerHealth("a"))
+	require.True(t, r.UpdatedAt().IsZero())
+
+	state := testState([]raft.ServerID{"a"}, "a", "a")
+	state.firstStateTime = time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)
+	conf := &Config{MinQuorum: 3}
+
+	var buf bytes.Buffer
+	require.NoError(t, ExportState(&buf, state, conf))
+	require.NoError(t, r.Update(&buf))
+
+	require.Equal(t, state, r.GetState())
+	require.Equal(t, conf, r.Config())
+	require.False(t, r.UpdatedAt().IsZero())
+	require.Equal(t, &state.Servers["a"].Health,
+		r.GetServerHealth("a"))
+	require.Nil(t, r.GetServerHealth("unknown"))
+
+	// a failed update retains the previous state
+	require.Error(t, r.Update(strings.NewReader(`{`)))
+	require.Equal(t, state, r.GetState())
+}
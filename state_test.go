@@ -12,6 +12,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/raft"
 	mock "github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -49,6 +50,12 @@ func readGolden(t *testing.T, name string) string {
 	return loadGolden(t, name, "", false)
 }
 
+// stablePromoterStatus is the PromoterStatus of the default promoter.
+func stablePromoterStatus() PromoterStatus {
+	p := DefaultPromoter().(PromoterDescriber)
+	return PromoterStatus{Name: p.Name(), Description: p.Describe()}
+}
+
 func golden(t *testing.T, name, got string) string {
 	t.Helper()
 	return loadGolden(t, name, got, *update)
@@ -169,6 +176,8 @@ func TestGatherNextStateInputsLeaderFromDelegate(t *testing.T) {
 		KnownServers:   servers,
 		LatestIndex:    lastIndex,
 		LastTerm:       lastTerm,
+		LeaderStats:    &LeaderStats{LastLogTerm: lastTerm},
+		Promoter:       stablePromoterStatus(),
 		FetchedStats:   serverStats,
 		LeaderID:       leaderID,
 		IsLeader:       true,
@@ -303,6 +312,8 @@ func TestGatherNextStateInputs(t *testing.T) {
 				KnownServers:   servers,
 				LatestIndex:    lastIndex,
 				LastTerm:       lastTerm,
+				LeaderStats:    &LeaderStats{LastLogTerm: lastTerm},
+				Promoter:       stablePromoterStatus(),
 				FetchedStats:   serverStats,
 				LeaderID:       leaderID,
 				IsLeader:       isLeader,
@@ -425,6 +436,8 @@ func TestNextStateWithInputs(t *testing.T) {
 			mprom := NewMockPromoter(t)
 
 			tcase.setupPromoter(t, mprom)
+			// used to count the non-voters which could be promoted
+			mprom.On("IsPotentialVoter", NodeVoter).Return(true).Maybe()
 
 			var inputs nextStateInputs
 
@@ -447,3 +460,253 @@ func TestNextStateWithInputs(t *testing.T) {
 		})
 	}
 }
+
+func TestSecondsUntilEligible(t *testing.T) {
+	now := time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)
+
+	type testCase struct {
+		srv      ServerState
+		expected int
+	}
+
+	cases := map[string]testCase{
+		"voter": {
+			srv: ServerState{
+				State:  RaftVoter,
+				Health: ServerHealth{Healthy: true, StableSince: now},
+			},
+		},
+		"unhealthy": {
+			srv: ServerState{
+				State:  RaftNonVoter,
+				Health: ServerHealth{Healthy: false, StableSince: now},
+			},
+		},
+		"just-stable": {
+			srv: ServerState{
+				State:  RaftNonVoter,
+				Health: ServerHealth{Healthy: true, StableSince: now},
+			},
+			expected: 10,
+		},
+		"partial-seconds-round-up": {
+			srv: ServerState{
+				State:  RaftNonVoter,
+				Health: ServerHealth{Healthy: true, StableSince: now.Add(-2500 * time.Millisecond)},
+			},
+			expected: 8,
+		},
+		"already-eligible": {
+			srv: ServerState{
+				State:  RaftNonVoter,
+				Health: ServerHealth{Healthy: true, StableSince: now.Add(-time.Minute)},
+			},
+		},
+	}
+
+	for name, tcase := range cases {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tcase.expected, secondsUntilEligible(&tcase.srv, now, 10*time.Second))
+		})
+	}
+}
+
+func TestTermsDiverged(t *testing.T) {
+	type testCase struct {
+		terms    map[raft.ServerID]uint64
+		isLeader bool
+		expected bool
+	}
+
+	cases := map[string]testCase{
+		"converged": {
+			terms:    map[raft.ServerID]uint64{"a": 5, "b": 5, "c": 5, "d": 5},
+			isLeader: true,
+		},
+		"single-server": {
+			terms:    map[raft.ServerID]uint64{"a": 5, "b": 4, "c": 5, "d": 5},
+			isLeader: true,
+		},
+		"many-servers": {
+			terms:    map[raft.ServerID]uint64{"a": 5, "b": 4, "c": 3, "d": 5},
+			isLeader: true,
+			expected: true,
+		},
+		"leader-from-stats": {
+			terms:    map[raft.ServerID]uint64{"a": 6, "b": 4, "c": 3, "d": 5},
+			expected: true,
+		},
+	}
+
+	for name, tcase := range cases {
+		t.Run(name, func(t *testing.T) {
+			inputs := &nextStateInputs{
+				LastTerm:     5,
+				IsLeader:     tcase.isLeader,
+				LeaderID:     "a",
+				FetchedStats: make(map[raft.ServerID]*ServerStats),
+			}
+			state := &State{
+				Leader:  "a",
+				Servers: make(map[raft.ServerID]*ServerState),
+			}
+
+			for id, term := range tcase.terms {
+				inputs.FetchedStats[id] = &ServerStats{LastTerm: term}
+				state.Servers[id] = &ServerState{Server: Server{ID: id}}
+			}
+
+			require.Equal(t, tcase.expected, termsDiverged(inputs, state))
+		})
+	}
+}
+
+func TestBuildServerStateDegraded(t *testing.T) {
+	now := time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)
+	stableSince := now.Add(-time.Hour)
+
+	inputs := &nextStateInputs{
+		Now:         now,
+		Config:      &Config{LastContactThreshold: time.Second, MaxTrailingLogs: 10},
+		IsLeader:    true,
+		LatestIndex: 1000,
+		LastTerm:    5,
+		KnownServers: map[raft.ServerID]*Server{
+			"a": {ID: "a", NodeStatus: NodeAlive},
+		},
+		CurrentState: &State{
+			Servers: map[raft.ServerID]*ServerState{
+				"a": {
+					Server: Server{ID: "a", NodeStatus: NodeAlive},
+					Stats:  ServerStats{LastTerm: 5, LastIndex: 500},
+					Health: ServerHealth{Healthy: true, StableSince: stableSince},
+				},
+			},
+		},
+		StatsOutageSince: now,
+		Degraded:         true,
+	}
+
+	// the previous health is retained while degraded
+	state := buildServerState(inputs, raft.Server{ID: "a", Suffrage: raft.Voter})
+	require.Equal(t, ServerHealth{Healthy: true, StableSince: stableSince}, state.Health)
+
+	// once the grace period has passed the stale stats make it unhealthy
+	inputs.Degraded = false
+	state = buildServerState(inputs, raft.Server{ID: "a", Suffrage: raft.Voter})
+	require.False(t, state.Health.Healthy)
+	require.NotEmpty(t, state.Health.Reasons)
+}
+
+func TestBuildServerStateCachedStats(t *testing.T) {
+	now := time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)
+	stableSince := now.Add(-time.Hour)
+	fetchedAt := now.Add(-20 * time.Second)
+
+	inputs := &nextStateInputs{
+		Now:         now,
+		Config:      &Config{LastContactThreshold: time.Second, MaxTrailingLogs: 10, StatsCacheTTL: 30 * time.Second},
+		IsLeader:    true,
+		LatestIndex: 1000,
+		LastTerm:    5,
+		KnownServers: map[raft.ServerID]*Server{
+			"a": {ID: "a", NodeStatus: NodeAlive},
+		},
+		CurrentState: &State{
+			Servers: map[raft.ServerID]*ServerState{
+				"a": {
+					Server:         Server{ID: "a", NodeStatus: NodeAlive},
+					Stats:          ServerStats{LastTerm: 5, LastIndex: 500},
+					Health:         ServerHealth{Healthy: true, StableSince: stableSince},
+					statsFetchedAt: fetchedAt,
+				},
+			},
+		},
+	}
+
+	// the previous health is retained while the cached stats are within the TTL
+	state := buildServerState(inputs, raft.Server{ID: "a", Suffrage: raft.Voter})
+	require.Equal(t, ServerHealth{Healthy: true, StableSince: stableSince}, state.Health)
+	require.Equal(t, ServerStats{LastTerm: 5, LastIndex: 500}, state.Stats)
+	require.Equal(t, 20*time.Second, state.StatsAge)
+
+	// a failed server is judged as usual
+	inputs.KnownServers["a"].NodeStatus = NodeFailed
+	state = buildServerState(inputs, raft.Server{ID: "a", Suffrage: raft.Voter})
+	require.False(t, state.Health.Healthy)
+	inputs.KnownServers["a"].NodeStatus = NodeAlive
+
+	// once the cached stats are older than the TTL they make it unhealthy
+	inputs.Now = now.Add(15 * time.Second)
+	state = buildServerState(inputs, raft.Server{ID: "a", Suffrage: raft.Voter})
+	require.Equal(t, 35*time.Second, state.StatsAge)
+	require.False(t, state.Health.Healthy)
+
+	// freshly fetched stats have no age
+	inputs.FetchedStats = map[raft.ServerID]*ServerStats{"a": {LastTerm: 5, LastIndex: 1000}}
+	state = buildServerState(inputs, raft.Server{ID: "a", Suffrage: raft.Voter})
+	require.Zero(t, state.StatsAge)
+	require.True(t, state.Health.Healthy)
+}
+
+func TestObserveStatsOutage(t *testing.T) {
+	var events []EventType
+	a := &Autopilot{
+		logger: hclog.NewNullLogger(),
+		eventHandlers: []EventHandler{func(e Event) {
+			events = append(events, e.Type)
+		}},
+	}
+
+	now := time.Now()
+	healthy := &State{}
+	outage := &State{StatsOutageSince: now, Degraded: true}
+	expired := &State{StatsOutageSince: now}
+
+	a.observeStatsOutage(nil, healthy)
+	a.observeStatsOutage(healthy, outage)
+	a.observeStatsOutage(outage, outage)
+	a.observeStatsOutage(outage, expired)
+	a.observeStatsOutage(expired, healthy)
+
+	require.Equal(t, []EventType{EventStatsOutage, EventStatsRestored}, events)
+}
+
+func TestObserveTermsAhead(t *testing.T) {
+	var events []Event
+	a := &Autopilot{
+		logger:    hclog.NewNullLogger(),
+		refreshCh: make(chan struct{}, 1),
+		features:  map[Feature]bool{FeatureTermAheadRefresh: true},
+		eventHandlers: []EventHandler{func(e Event) {
+			events = append(events, e)
+		}},
+	}
+
+	prev := &State{Servers: map[raft.ServerID]*ServerState{
+		"a": {Server: Server{ID: "a"}},
+		"b": {Server: Server{ID: "b"}, Health: ServerHealth{TermAhead: true}},
+	}}
+	next := &State{Servers: map[raft.ServerID]*ServerState{
+		"a": {Server: Server{ID: "a"}, Stats: ServerStats{LastTerm: 7}, Health: ServerHealth{TermAhead: true}},
+		"b": {Server: Server{ID: "b"}, Stats: ServerStats{LastTerm: 7}, Health: ServerHealth{TermAhead: true}},
+	}}
+
+	// only servers newly ahead of the leader are reported
+	a.observeTermsAhead(prev, next)
+	require.Len(t, events, 1)
+	require.Equal(t, EventServerTermAhead, events[0].Type)
+	require.Equal(t, raft.ServerID("a"), events[0].ServerID)
+	require.Len(t, a.refreshCh, 1)
+
+	// no refresh is requested when the feature is disabled
+	<-a.refreshCh
+	a.setFeatures([]Feature{FeatureTermAheadRefresh}, false)
+	a.observeTermsAhead(prev, next)
+	require.Len(t, events, 2)
+	require.Len(t, a.refreshCh, 0)
+
+	// nothing is reported once the servers were already ahead
+	a.observeTermsAhead(next, next)
+	require.Len(t, events, 2)
+}
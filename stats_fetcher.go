@@ -0,0 +1,114 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/raft"
+)
+
+// DefaultStatsFetchParallelism is the number of servers whose stats are
+// fetched concurrently when a StatsFetcher is given without a limit.
+const DefaultStatsFetchParallelism = 8
+
+// StatsFetcher fetches the stats of a single server. When one is registered
+// with the WithStatsFetcher option autopilot fans the calls out across all the
+// servers itself and the delegate's FetchServerStats method is not called.
+type StatsFetcher interface {
+	// FetchStats returns the stats of the given server. The context will be
+	// cancelled once the per server timeout or the deadline of the whole
+	// fetch passes. Servers for which an error is returned have no stats.
+	FetchStats(ctx context.Context, srv *Server) (*ServerStats, error)
+}
+
+// statsFetchConfig is how the servers' stats are fetched with a StatsFetcher.
+type statsFetchConfig struct {
+	fetcher StatsFetcher
+
+	// parallelism is the maximum number of concurrent FetchStats calls.
+	parallelism int
+
+	// timeout limits each FetchStats call. Zero only limits the calls by the
+	// deadline of the whole fetch.
+	timeout time.Duration
+}
+
+// fetchServerStats fetches the stats of the given servers. The registered
+// StatsFetcher is used when there is one and the delegate otherwise.
+func (a *Autopilot) fetchServerStats(ctx context.Context, servers map[raft.ServerID]*Server) map[raft.ServerID]*ServerStats {
+	if a.statsFetch.fetcher == nil {
+		return a.delegate.FetchServerStats(ctx, servers)
+	}
+
+	parallelism := a.statsFetch.parallelism
+	if parallelism < 1 {
+		parallelism = DefaultStatsFetchParallelism
+	}
+
+	type result struct {
+		id    raft.ServerID
+		stats *ServerStats
+		err   error
+	}
+
+	// buffered so that calls still running once the fetch is abandoned can
+	// finish without blocking
+	resultCh := make(chan result, len(servers))
+	sem := make(chan struct{}, parallelism)
+
+	for id, srv := range servers {
+		go func(id raft.ServerID, srv *Server) {
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				resultCh <- result{id: id, err: ctx.Err()}
+				return
+			}
+
+			stats, err := a.fetchStats(ctx, srv)
+			resultCh <- result{id: id, stats: stats, err: err}
+		}(id, srv)
+	}
+
+	fetched := make(map[raft.ServerID]*ServerStats)
+	for range servers {
+		select {
+		case res := <-resultCh:
+			if res.err != nil {
+				a.subsystemLogger(SubsystemState).Debug("failed to fetch server stats", "id", res.id, "error", res.err)
+				continue
+			}
+			if res.stats != nil {
+				fetched[res.id] = res.stats
+			}
+		case <-ctx.Done():
+			// servers which have not responded by the deadline have no stats
+			return fetched
+		}
+	}
+	return fetched
+}
+
+// fetchStats calls the StatsFetcher for a single server within the per server
+// timeout and converts any panic into an error.
+func (a *Autopilot) fetchStats(ctx context.Context, srv *Server) (stats *ServerStats, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			stats = nil
+			err = fmt.Errorf("stats fetcher panicked: %v", r)
+		}
+	}()
+
+	if a.statsFetch.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.statsFetch.timeout)
+		defer cancel()
+	}
+
+	return a.statsFetch.fetcher.FetchStats(ctx, srv)
+}
@@ -0,0 +1,117 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+// testStatsFetcher returns the stats it was given for each server and records
+// the peak number of concurrent calls.
+type testStatsFetcher struct {
+	lock    sync.Mutex
+	running int
+	peak    int
+
+	delay time.Duration
+	stats map[raft.ServerID]*ServerStats
+}
+
+func (f *testStatsFetcher) FetchStats(ctx context.Context, srv *Server) (*ServerStats, error) {
+	f.lock.Lock()
+	f.running++
+	if f.running > f.peak {
+		f.peak = f.running
+	}
+	f.lock.Unlock()
+
+	defer func() {
+		f.lock.Lock()
+		f.running--
+		f.lock.Unlock()
+	}()
+
+	if srv.ID == "panic" {
+		panic("injected panic")
+	}
+
+	select {
+	case <-time.After(f.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	stats, ok := f.stats[srv.ID]
+	if !ok {
+		return nil, errors.New("no stats")
+	}
+	return stats, nil
+}
+
+func TestFetchServerStatsParallel(t *testing.T) {
+	fetcher := &testStatsFetcher{
+		delay: 10 * time.Millisecond,
+		stats: map[raft.ServerID]*ServerStats{
+			"a": {LastIndex: 1},
+			"b": {LastIndex: 2},
+			"c": {LastIndex: 3},
+			"d": {LastIndex: 4},
+		},
+	}
+
+	a := &Autopilot{logger: hclog.NewNullLogger()}
+	WithStatsFetcher(fetcher, 2, 0)(a)
+
+	servers := map[raft.ServerID]*Server{
+		"a":       {ID: "a"},
+		"b":       {ID: "b"},
+		"c":       {ID: "c"},
+		"d":       {ID: "d"},
+		"missing": {ID: "missing"},
+		"panic":   {ID: "panic"},
+	}
+
+	stats := a.fetchServerStats(context.Background(), servers)
+	require.Equal(t, map[raft.ServerID]*ServerStats{
+		"a": {LastIndex: 1},
+		"b": {LastIndex: 2},
+		"c": {LastIndex: 3},
+		"d": {LastIndex: 4},
+	}, stats)
+	require.LessOrEqual(t, fetcher.peak, 2)
+}
+
+func TestFetchServerStatsTimeout(t *testing.T) {
+	fetcher := &testStatsFetcher{
+		delay: time.Second,
+		stats: map[raft.ServerID]*ServerStats{"a": {LastIndex: 1}},
+	}
+
+	a := &Autopilot{logger: hclog.NewNullLogger()}
+	WithStatsFetcher(fetcher, 0, 10*time.Millisecond)(a)
+
+	start := time.Now()
+	stats := a.fetchServerStats(context.Background(), map[raft.ServerID]*Server{"a": {ID: "a"}})
+	require.Empty(t, stats)
+	require.Less(t, time.Since(start), time.Second)
+}
+
+func TestFetchServerStatsDelegate(t *testing.T) {
+	servers := map[raft.ServerID]*Server{"a": {ID: "a"}}
+	expected := map[raft.ServerID]*ServerStats{"a": {LastIndex: 1}}
+
+	mapp := NewMockApplicationIntegration(t)
+	mapp.On("FetchServerStats", context.Background(), servers).Return(expected).Once()
+
+	a := &Autopilot{logger: hclog.NewNullLogger(), delegate: mapp}
+	require.Equal(t, expected, a.fetchServerStats(context.Background(), servers))
+}
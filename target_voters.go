@@ -0,0 +1,167 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"github.com/hashicorp/raft"
+)
+
+// maintainTargetVoters modifies the promoter's changes so that the cluster
+// converges on Config.TargetVoters voters. While below the target the
+// promoter's promotions, followed by any other stable non-voters the promoter
+// considers potential voters, are promoted favouring the zones with the fewest
+// voters. Promotions beyond the target are dropped. While above the target
+// healthy voters are demoted from the zones with the most voters, provided a
+// majority of the remaining voters are healthy and MinQuorum is respected.
+// Surplus voters are left alone while servers are being replaced or zones
+// evacuated as those temporarily require an extra voter.
+func (a *Autopilot) maintainTargetVoters(conf *Config, state *State, changes RaftChanges) RaftChanges {
+	target := int(conf.TargetVoters)
+	if target == 0 {
+		return changes
+	}
+
+	zoneOf := func(id raft.ServerID) string {
+		if conf.ZoneKey == "" {
+			return ""
+		}
+		return state.Servers[id].Server.Meta[conf.ZoneKey]
+	}
+
+	demoting := make(map[raft.ServerID]struct{})
+	for _, id := range changes.Demotions {
+		demoting[id] = struct{}{}
+	}
+
+	// count the voters remaining once the promoter's demotions are made
+	zoneVoters := make(map[string]int)
+	voters := 0
+	for _, id := range state.Voters {
+		if _, ok := state.Servers[id]; !ok {
+			continue
+		}
+		if _, ok := demoting[id]; ok {
+			continue
+		}
+		zoneVoters[zoneOf(id)]++
+		voters++
+	}
+
+	result := RaftChanges{
+		Demotions:        changes.Demotions,
+		Leader:           changes.Leader,
+		LeaderCandidates: changes.LeaderCandidates,
+	}
+
+	if voters < target {
+		candidates := a.targetVoterCandidates(conf, state, changes)
+		for voters < target && len(candidates) > 0 {
+			// take the first candidate from the zone with the fewest voters
+			best := 0
+			for i, id := range candidates {
+				if zoneVoters[zoneOf(id)] < zoneVoters[zoneOf(candidates[best])] {
+					best = i
+				}
+			}
+
+			id := candidates[best]
+			candidates = append(candidates[:best], candidates[best+1:]...)
+			result.Promotions = append(result.Promotions, id)
+			zoneVoters[zoneOf(id)]++
+			voters++
+		}
+		return result
+	}
+
+	if len(changes.Promotions) > 0 {
+		a.roundLogger().Debug("Not promoting servers as the target number of voters has been reached", "target", target, "promotions", changes.Promotions)
+	}
+
+	if voters == target || a.replacements.inProgress() || a.evacuations.inProgress() {
+		return result
+	}
+
+	// demote the surplus voters
+	var healthy []raft.ServerID
+	for _, id := range state.Voters {
+		srv, ok := state.Servers[id]
+		if !ok || !srv.Health.Healthy {
+			continue
+		}
+		if _, ok := demoting[id]; !ok {
+			healthy = append(healthy, id)
+		}
+	}
+
+	candidates := make([]raft.ServerID, 0, len(healthy))
+	for _, id := range healthy {
+		if srv := state.Servers[id]; id != state.Leader && !srv.Ignored && !srv.Server.Protected {
+			candidates = append(candidates, id)
+		}
+	}
+	// least valuable voters are demoted first
+	sortByValue(candidates, state)
+	for i, j := 0, len(candidates)-1; i < j; i, j = i+1, j-1 {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	}
+
+	healthyVoters := len(healthy)
+	for voters > target && len(candidates) > 0 {
+		remaining := voters - 1
+		if remaining < int(conf.MinQuorum) || healthyVoters-1 < remaining/2+1 {
+			a.roundLogger().Debug("Not demoting surplus voters as it would leave too few healthy voters", "target", target)
+			break
+		}
+
+		// take the first candidate from the zone with the most voters
+		best := 0
+		for i, id := range candidates {
+			if zoneVoters[zoneOf(id)] > zoneVoters[zoneOf(candidates[best])] {
+				best = i
+			}
+		}
+
+		id := candidates[best]
+		candidates = append(candidates[:best], candidates[best+1:]...)
+		result.Demotions = append(result.Demotions, id)
+		zoneVoters[zoneOf(id)]--
+		healthyVoters--
+		voters--
+	}
+
+	return result
+}
+
+// targetVoterCandidates returns the non-voters which may be promoted to reach
+// the target number of voters in order of preference. The promoter's
+// promotions come first followed by the other stable non-voters of a node type
+// the promoter considers a potential voter, ordered by their Value.
+func (a *Autopilot) targetVoterCandidates(conf *Config, state *State, changes RaftChanges) []raft.ServerID {
+	now := a.now()
+	eligible := func(id raft.ServerID) bool {
+		srv, ok := state.Servers[id]
+		return ok && srv.State == RaftNonVoter && !srv.Ignored && srv.Health.Healthy && !srv.Health.Flapping && srv.caughtUp() && !a.lockouts.isLockedOut(id, now) && !a.quarantine.quarantined(&srv.Server, a.now)
+	}
+
+	var candidates []raft.ServerID
+	seen := make(map[raft.ServerID]struct{})
+	for _, id := range changes.Promotions {
+		if _, ok := seen[id]; ok || !eligible(id) {
+			continue
+		}
+		seen[id] = struct{}{}
+		candidates = append(candidates, id)
+	}
+
+	var others []raft.ServerID
+	for id, srv := range state.Servers {
+		if _, ok := seen[id]; ok || !a.promotionEligible(conf, state, srv, now) {
+			continue
+		}
+		others = append(others, id)
+	}
+	sortByValue(others, state)
+
+	return append(candidates, others...)
+}
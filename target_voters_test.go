@@ -0,0 +1,113 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintainTargetVoters(t *testing.T) {
+	// a, b and c are in zone 1 while d, e and f are in zone 2
+	state := func(voters ...raft.ServerID) *State {
+		s := &State{
+			Leader:  "a",
+			Servers: make(map[raft.ServerID]*ServerState),
+		}
+		for i, id := range []raft.ServerID{"a", "b", "c", "d", "e", "f"} {
+			zone := "1"
+			if i >= 3 {
+				zone = "2"
+			}
+			s.Servers[id] = &ServerState{
+				Server: Server{ID: id, NodeType: NodeVoter, Meta: map[string]string{"zone": zone}},
+				State:  RaftNonVoter,
+				Health: ServerHealth{Healthy: true},
+			}
+		}
+		for _, id := range voters {
+			s.Servers[id].State = RaftVoter
+			s.Voters = append(s.Voters, id)
+		}
+		s.Servers["a"].State = RaftLeader
+		return s
+	}
+
+	type testCase struct {
+		conf     Config
+		state    *State
+		changes  RaftChanges
+		expected RaftChanges
+	}
+
+	cases := map[string]testCase{
+		"disabled": {
+			state:    state("a"),
+			changes:  RaftChanges{Promotions: []raft.ServerID{"b", "c", "d", "e", "f"}},
+			expected: RaftChanges{Promotions: []raft.ServerID{"b", "c", "d", "e", "f"}},
+		},
+		"promotions-capped-and-zone-balanced": {
+			conf:     Config{TargetVoters: 3, ZoneKey: "zone"},
+			state:    state("a"),
+			changes:  RaftChanges{Promotions: []raft.ServerID{"b", "c", "d", "e", "f"}},
+			expected: RaftChanges{Promotions: []raft.ServerID{"d", "b"}},
+		},
+		"stable-non-voters-promoted": {
+			conf:     Config{TargetVoters: 3},
+			state:    state("a"),
+			expected: RaftChanges{Promotions: []raft.ServerID{"b", "c"}},
+		},
+		"at-target": {
+			conf:     Config{TargetVoters: 3},
+			state:    state("a", "b", "c"),
+			changes:  RaftChanges{Promotions: []raft.ServerID{"d"}},
+			expected: RaftChanges{},
+		},
+		"surplus-demoted-from-largest-zone": {
+			conf:     Config{TargetVoters: 3, ZoneKey: "zone"},
+			state:    state("a", "b", "c", "d", "e"),
+			expected: RaftChanges{Demotions: []raft.ServerID{"c", "e"}},
+		},
+		"surplus-respects-min-quorum": {
+			conf:     Config{TargetVoters: 3, MinQuorum: 4},
+			state:    state("a", "b", "c", "d", "e"),
+			expected: RaftChanges{Demotions: []raft.ServerID{"e"}},
+		},
+	}
+
+	for name, tcase := range cases {
+		t.Run(name, func(t *testing.T) {
+			a := &Autopilot{
+				logger:   hclog.NewNullLogger(),
+				promoter: DefaultPromoter(),
+			}
+			require.Equal(t, tcase.expected, a.maintainTargetVoters(&tcase.conf, tcase.state, tcase.changes))
+		})
+	}
+}
+
+func TestMaintainTargetVotersHoldsDuringReplacement(t *testing.T) {
+	s := &State{
+		Leader: "a",
+		Voters: []raft.ServerID{"a", "b", "c", "d"},
+		Servers: map[raft.ServerID]*ServerState{
+			"a": {Server: Server{ID: "a"}, State: RaftLeader, Health: ServerHealth{Healthy: true}},
+			"b": {Server: Server{ID: "b"}, State: RaftVoter, Health: ServerHealth{Healthy: true}},
+			"c": {Server: Server{ID: "c"}, State: RaftVoter, Health: ServerHealth{Healthy: true}},
+			"d": {Server: Server{ID: "d"}, State: RaftVoter, Health: ServerHealth{Healthy: true}},
+		},
+	}
+
+	a := &Autopilot{
+		logger:   hclog.NewNullLogger(),
+		promoter: DefaultPromoter(),
+		replacements: replacementTracker{replacements: map[raft.ServerID]*ServerReplacement{
+			"b": {OldID: "b", NewID: "d"},
+		}},
+	}
+	require.Equal(t, RaftChanges{}, a.maintainTargetVoters(&Config{TargetVoters: 3}, s, RaftChanges{}))
+}
@@ -0,0 +1,191 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/raft"
+)
+
+// failureToleranceRecoveryTime is how long the failure tolerance must remain
+// above zero before it is considered to have recovered. This prevents alerts
+// from flapping while servers go in and out of health.
+const failureToleranceRecoveryTime = 30 * time.Second
+
+var (
+	// failureToleranceExhaustedKey is the metric key incremented whenever the
+	// failure tolerance drops to zero.
+	failureToleranceExhaustedKey = []string{"autopilot", "failure_tolerance", "exhausted"}
+
+	// failureToleranceRestoredKey is the metric key incremented whenever the
+	// failure tolerance recovers after having dropped to zero.
+	failureToleranceRestoredKey = []string{"autopilot", "failure_tolerance", "restored"}
+)
+
+// promotableNonVoters returns how many non-voters could be promoted to replace
+// failed voters. These are the healthy non-voters of a node type the promoter
+// considers a potential voter which have caught up with the leader.
+func (a *Autopilot) promotableNonVoters(s *State) int {
+	count := 0
+	for _, srv := range s.Servers {
+		if srv.State != RaftNonVoter || srv.Ignored || !srv.Health.Healthy || srv.Health.TermAhead || srv.Health.Flapping || !srv.caughtUp() {
+			continue
+		}
+		if a.getPromoter().IsPotentialVoter(srv.Server.NodeType) {
+			count++
+		}
+	}
+	return count
+}
+
+// optimisticFailureTolerance returns how many voters could fail one at a time
+// if a promotable non-voter replaced each of them before the next failure.
+func optimisticFailureTolerance(inputs FailureToleranceInputs) int {
+	tolerance := inputs.HealthyVoters + inputs.PromotableNonVoters - inputs.RequiredQuorum
+	if tolerance < 0 {
+		return 0
+	}
+	return tolerance
+}
+
+// failureToleranceTracker detects when the failure tolerance of the cluster
+// is exhausted and when it is restored again.
+type failureToleranceTracker struct {
+	lock sync.Mutex
+
+	// exhausted is whether the failure tolerance has dropped to zero and
+	// not yet recovered.
+	exhausted bool
+
+	// recoveringSince is when the failure tolerance rose above zero again
+	// after being exhausted. It is the zero value while still exhausted.
+	recoveringSince time.Time
+}
+
+// observe updates the tracker with the given state and returns the type of
+// event to emit, if any. Exhaustion is reported immediately while restoration
+// is only reported once the failure tolerance has remained above zero for the
+// failureToleranceRecoveryTime.
+func (t *failureToleranceTracker) observe(now time.Time, s *State) (EventType, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if s.Leader == "" || len(s.Voters) == 0 {
+		// without a leader the failure tolerance is meaningless
+		return "", false
+	}
+
+	if s.FailureTolerance < 1 {
+		t.recoveringSince = time.Time{}
+		if t.exhausted {
+			return "", false
+		}
+		t.exhausted = true
+		return EventFailureToleranceExhausted, true
+	}
+
+	if !t.exhausted {
+		return "", false
+	}
+
+	if t.recoveringSince.IsZero() {
+		t.recoveringSince = now
+	}
+
+	if now.Sub(t.recoveringSince) < failureToleranceRecoveryTime {
+		return "", false
+	}
+
+	t.exhausted = false
+	t.recoveringSince = time.Time{}
+	return EventFailureToleranceRestored, true
+}
+
+// observeFailureTolerance emits events and metrics when the failure tolerance
+// of the given state is exhausted or restored.
+func (a *Autopilot) observeFailureTolerance(now time.Time, s *State) {
+	if !a.FeatureEnabled(FeatureFailureToleranceEvents) {
+		return
+	}
+
+	typ, ok := a.failureTolerance.observe(now, s)
+	if !ok {
+		return
+	}
+
+	switch typ {
+	case EventFailureToleranceExhausted:
+		a.metricsSink().IncrCounter(failureToleranceExhaustedKey, 1)
+
+		actions := restoreToleranceActions(s)
+		message := "the cluster cannot tolerate the failure of any voter"
+		if len(actions) > 0 {
+			message = fmt.Sprintf("%s, to restore failure tolerance: %s", message, strings.Join(actions, ", "))
+		}
+		a.logger.Warn("failure tolerance exhausted", "actions", actions)
+		a.emitEvent(typ, "", message)
+	case EventFailureToleranceRestored:
+		a.metricsSink().IncrCounter(failureToleranceRestoredKey, 1)
+		a.logger.Info("failure tolerance restored", "failure_tolerance", s.FailureTolerance)
+		a.emitEvent(typ, "", fmt.Sprintf("the cluster can tolerate the failure of %d voters", s.FailureTolerance))
+	}
+}
+
+// restoreToleranceActions computes the smallest set of promotions of healthy
+// non-voters and demotions of unhealthy voters that would give the cluster a
+// failure tolerance of at least one. Fewer demotions are preferred when there
+// are multiple equally sized sets. Nil is returned when no such set exists.
+func restoreToleranceActions(s *State) []string {
+	var promotable, demotable []raft.ServerID
+	voters, healthy := 0, 0
+	for id, srv := range s.Servers {
+		if srv.Ignored {
+			continue
+		}
+
+		switch {
+		case srv.HasVotingRights():
+			voters++
+			if srv.Health.Healthy {
+				healthy++
+			} else if id != s.Leader {
+				demotable = append(demotable, id)
+			}
+		case srv.State == RaftNonVoter && srv.Health.Healthy:
+			promotable = append(promotable, id)
+		}
+	}
+
+	sort.Slice(promotable, func(i, j int) bool { return promotable[i] < promotable[j] })
+	sort.Slice(demotable, func(i, j int) bool { return demotable[i] < demotable[j] })
+
+	for total := 1; total <= len(promotable)+len(demotable); total++ {
+		for demotions := 0; demotions <= total && demotions <= len(demotable); demotions++ {
+			promotions := total - demotions
+			if promotions > len(promotable) {
+				continue
+			}
+
+			if healthy+promotions-requiredQuorum(voters+promotions-demotions) < 1 {
+				continue
+			}
+
+			var actions []string
+			for _, id := range promotable[:promotions] {
+				actions = append(actions, fmt.Sprintf("promote server %s", id))
+			}
+			for _, id := range demotable[:demotions] {
+				actions = append(actions, fmt.Sprintf("demote server %s", id))
+			}
+			return actions
+		}
+	}
+
+	return nil
+}
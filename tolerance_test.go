@@ -0,0 +1,150 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+func toleranceTestState(healthy map[raft.ServerID]bool, voters ...raft.ServerID) *State {
+	var ids []raft.ServerID
+	for id := range healthy {
+		ids = append(ids, id)
+	}
+
+	state := testState(ids, voters[0], voters...)
+	healthyVoters := 0
+	for id, h := range healthy {
+		state.Servers[id].Health.Healthy = h
+		if h && state.Servers[id].HasVotingRights() {
+			healthyVoters++
+		}
+	}
+
+	if tolerance := healthyVoters - requiredQuorum(len(voters)); tolerance > 0 {
+		state.FailureTolerance = tolerance
+	}
+	return state
+}
+
+func TestRestoreToleranceActions(t *testing.T) {
+	type testCase struct {
+		healthy  map[raft.ServerID]bool
+		voters   []raft.ServerID
+		expected []string
+	}
+
+	cases := map[string]testCase{
+		"demote-unhealthy": {
+			healthy:  map[raft.ServerID]bool{"a": true, "b": true, "c": true, "d": false},
+			voters:   []raft.ServerID{"a", "b", "c", "d"},
+			expected: []string{"demote server d"},
+		},
+		"promote-healthy": {
+			healthy:  map[raft.ServerID]bool{"a": true, "b": true, "c": true, "d": true},
+			voters:   []raft.ServerID{"a", "b"},
+			expected: []string{"promote server c"},
+		},
+		"promote-and-demote": {
+			healthy:  map[raft.ServerID]bool{"a": true, "b": true, "c": false, "d": true},
+			voters:   []raft.ServerID{"a", "b", "c"},
+			expected: []string{"promote server d", "demote server c"},
+		},
+		"impossible": {
+			healthy: map[raft.ServerID]bool{"a": true},
+			voters:  []raft.ServerID{"a"},
+		},
+	}
+
+	for name, tcase := range cases {
+		t.Run(name, func(t *testing.T) {
+			state := toleranceTestState(tcase.healthy, tcase.voters...)
+			require.Equal(t, tcase.expected, restoreToleranceActions(state))
+		})
+	}
+}
+
+func TestObserveFailureTolerance(t *testing.T) {
+	sink := testMetricsSink(t)
+
+	var events []Event
+	a := &Autopilot{
+		logger:   hclog.NewNullLogger(),
+		features: map[Feature]bool{FeatureFailureToleranceEvents: true},
+		eventHandlers: []EventHandler{func(e Event) {
+			events = append(events, e)
+		}},
+	}
+
+	tolerant := toleranceTestState(map[raft.ServerID]bool{"a": true, "b": true, "c": true}, "a", "b", "c")
+	exhausted := toleranceTestState(map[raft.ServerID]bool{"a": true, "b": true, "c": false, "d": true}, "a", "b", "c")
+	now := time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)
+
+	a.observeFailureTolerance(now, tolerant)
+	require.Empty(t, events)
+
+	a.observeFailureTolerance(now, exhausted)
+	require.Len(t, events, 1)
+	require.Equal(t, EventFailureToleranceExhausted, events[0].Type)
+	require.Contains(t, events[0].Message, "promote server d, demote server c")
+
+	// no further event while still exhausted
+	a.observeFailureTolerance(now.Add(time.Second), exhausted)
+	require.Len(t, events, 1)
+
+	// recovering briefly is not enough
+	a.observeFailureTolerance(now.Add(2*time.Second), tolerant)
+	a.observeFailureTolerance(now.Add(3*time.Second), exhausted)
+	a.observeFailureTolerance(now.Add(4*time.Second), tolerant)
+	a.observeFailureTolerance(now.Add(4*time.Second+failureToleranceRecoveryTime/2), tolerant)
+	require.Len(t, events, 1)
+
+	a.observeFailureTolerance(now.Add(4*time.Second+failureToleranceRecoveryTime), tolerant)
+	require.Len(t, events, 2)
+	require.Equal(t, EventFailureToleranceRestored, events[1].Type)
+
+	intervals := sink.Data()
+	require.NotEmpty(t, intervals)
+	require.Equal(t, 1, intervals[0].Counters["autopilot.failure_tolerance.exhausted"].Count)
+	require.Equal(t, 1, intervals[0].Counters["autopilot.failure_tolerance.restored"].Count)
+}
+
+func TestOptimisticFailureTolerance(t *testing.T) {
+	state := &State{
+		Servers: map[raft.ServerID]*ServerState{
+			"a": {Server: Server{ID: "a", NodeType: NodeVoter}, State: RaftLeader, Health: ServerHealth{Healthy: true}},
+			"b": {Server: Server{ID: "b", NodeType: NodeVoter}, State: RaftVoter, Health: ServerHealth{Healthy: true}},
+			"c": {Server: Server{ID: "c", NodeType: NodeVoter}, State: RaftVoter},
+			"d": {Server: Server{ID: "d", NodeType: NodeVoter}, State: RaftNonVoter, Health: ServerHealth{Healthy: true}},
+			"e": {Server: Server{ID: "e", NodeType: NodeVoter}, State: RaftNonVoter, Health: ServerHealth{Healthy: true}},
+			// not promotable
+			"f": {Server: Server{ID: "f", NodeType: NodeVoter}, State: RaftNonVoter},
+			"g": {Server: Server{ID: "g", NodeType: "read-replica"}, State: RaftNonVoter, Health: ServerHealth{Healthy: true}},
+			"h": {Server: Server{ID: "h", NodeType: NodeVoter}, State: RaftNonVoter, Health: ServerHealth{Healthy: true}, Ignored: true},
+			"i": {Server: Server{ID: "i", NodeType: NodeVoter}, State: RaftNonVoter, Health: ServerHealth{Healthy: true}, CatchUp: &CatchUpProgress{}},
+		},
+	}
+
+	a := &Autopilot{promoter: DefaultPromoter()}
+	require.Equal(t, 2, a.promotableNonVoters(state))
+
+	// with 2 of the 3 voters healthy there is no failure tolerance but the
+	// two non-voters could replace failing voters one at a time
+	require.Equal(t, 2, optimisticFailureTolerance(FailureToleranceInputs{
+		Voters:              3,
+		HealthyVoters:       2,
+		RequiredQuorum:      2,
+		PromotableNonVoters: 2,
+	}))
+	require.Equal(t, 0, optimisticFailureTolerance(FailureToleranceInputs{
+		Voters:         3,
+		HealthyVoters:  1,
+		RequiredQuorum: 2,
+	}))
+}
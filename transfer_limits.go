@@ -0,0 +1,63 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/raft"
+)
+
+// WithLeadershipTransferLimit returns an option to limit the leadership
+// transfers autopilot makes to at most max within any window of the given
+// duration. This prevents a promoter which keeps nominating a "better" leader
+// from churning leadership. Transfers beyond the limit are deferred until a
+// later round. A max of zero, the default, places no limit on transfers.
+func WithLeadershipTransferLimit(max int, window time.Duration) Option {
+	return func(a *Autopilot) {
+		a.transferBudget.max = max
+		a.transferBudget.window = window
+	}
+}
+
+// WithLeadershipTransferBlackouts returns an option to prevent leadership
+// transfers while any of the given windows is open, such as during the daily
+// peak of client traffic. Unlike maintenance windows, which permit disruptive
+// changes while open, these forbid transfers while open. Transfers nominated
+// during a blackout are deferred until a later round.
+func WithLeadershipTransferBlackouts(windows ...MaintenanceWindow) Option {
+	return func(a *Autopilot) {
+		a.transferBlackouts = append(a.transferBlackouts, windows...)
+	}
+}
+
+// inTransferBlackout returns whether leadership transfers are currently
+// forbidden. It is always false when no blackout windows are configured.
+func (a *Autopilot) inTransferBlackout() bool {
+	if len(a.transferBlackouts) == 0 {
+		return false
+	}
+
+	now := a.now()
+	for i := range a.transferBlackouts {
+		if a.transferBlackouts[i].Contains(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// takeTransferBudget returns whether leadership may be transferred to the
+// server within the configured limit. Deferred transfers are logged and
+// counted.
+func (a *Autopilot) takeTransferBudget(id raft.ServerID) bool {
+	if a.transferBudget.take(a.now) {
+		return true
+	}
+
+	a.subsystemRoundLogger(SubsystemTransfer).Debug("deferring leadership transfer as the transfer limit has been reached", "id", id)
+	a.metricsSink().IncrCounterWithLabels(changesDeferredKey, 1, []metrics.Label{{Name: "change", Value: string(MembershipChangeLeadershipTransfer)}})
+	return false
+}
@@ -0,0 +1,54 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeadershipTransferLimits(t *testing.T) {
+	// a Monday
+	start := time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)
+	now := start
+
+	mtime := NewMockTimeProvider(t)
+	mtime.On("Now").Return(func() time.Time { return now })
+
+	mraft := NewMockRaft(t)
+	a := &Autopilot{
+		logger: hclog.NewNullLogger(),
+		raft:   mraft,
+		time:   mtime,
+	}
+	WithLeadershipTransferLimit(1, time.Hour)(a)
+	WithLeadershipTransferBlackouts(MaintenanceWindow{Start: 12 * time.Hour, Duration: time.Hour})(a)
+
+	ctx := context.Background()
+	changes := RaftChanges{Leader: "b"}
+	servers := []raft.ServerID{"a", "b", "c"}
+
+	// nothing is transferred during the blackout
+	require.NoError(t, a.applyLeadershipTransfer(ctx, testState(servers, "a", servers...), changes))
+
+	// one transfer is allowed once it closes
+	now = start.Add(time.Hour)
+	mraft.On("LeadershipTransferToServer", raft.ServerID("b"), raft.ServerAddress("")).
+		Return(&raftIndexFuture{}).Once()
+	require.NoError(t, a.applyLeadershipTransfer(ctx, testState(servers, "a", servers...), changes))
+
+	// but no more until the limit's window has passed
+	now = start.Add(90 * time.Minute)
+	require.NoError(t, a.applyLeadershipTransfer(ctx, testState(servers, "b", servers...), RaftChanges{Leader: "c"}))
+
+	now = start.Add(2 * time.Hour)
+	mraft.On("LeadershipTransferToServer", raft.ServerID("c"), raft.ServerAddress("")).
+		Return(&raftIndexFuture{}).Once()
+	require.NoError(t, a.applyLeadershipTransfer(ctx, testState(servers, "b", servers...), RaftChanges{Leader: "c"}))
+}
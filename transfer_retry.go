@@ -0,0 +1,156 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/raft"
+)
+
+// transferRetryTracker tracks the most recent leadership transfer so that it
+// can be verified against later autopilot states and retried with backoff
+// when leadership did not move to the intended server.
+type transferRetryTracker struct {
+	lock sync.Mutex
+
+	// retries is the number of times a failed transfer is retried. Zero
+	// disables the verification and retries entirely.
+	retries int
+	backoff time.Duration
+
+	// target is the server leadership was last transferred to.
+	target raft.ServerID
+
+	// attemptState is the state the transfer to the target was attempted
+	// with. Only a state computed afterwards can verify it.
+	attemptState *State
+
+	// verifying is true while a transfer which did not return an error has
+	// not yet been observed to have moved leadership.
+	verifying bool
+
+	// failures is the number of consecutive failed transfers to the target.
+	failures int
+
+	// next is when the transfer to the target may be retried.
+	next time.Time
+
+	// gaveUp is true once the transfers to the target have permanently
+	// failed. No further transfers to it are attempted until another server
+	// is nominated.
+	gaveUp bool
+}
+
+func (t *transferRetryTracker) enabled() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.retries > 0
+}
+
+// ready returns whether leadership may be transferred to the server. When it
+// may not be the reason is returned.
+func (t *transferRetryTracker) ready(id raft.ServerID, now time.Time) (string, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if id != t.target {
+		// a different server was nominated so start afresh
+		t.target, t.attemptState, t.verifying, t.failures, t.next, t.gaveUp = id, nil, false, 0, time.Time{}, false
+		return "", true
+	}
+
+	switch {
+	case t.gaveUp:
+		return "previous transfers permanently failed", false
+	case t.verifying:
+		return "the previous transfer has not yet been verified", false
+	case now.Before(t.next):
+		return fmt.Sprintf("backing off until %s after %d failed transfers", t.next.Format(time.RFC3339), t.failures), false
+	default:
+		return "", true
+	}
+}
+
+// attempted records that the transfer to the target did not return an error
+// and now needs to be verified.
+func (t *transferRetryTracker) attempted(state *State) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.attemptState = state
+	t.verifying = true
+}
+
+// observe verifies a pending transfer against the state. It returns the
+// target and whether leadership moved to it. The bool is false and the target
+// empty when there is nothing to verify yet.
+func (t *transferRetryTracker) observe(state *State) (raft.ServerID, bool, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if !t.verifying || state == t.attemptState {
+		return "", false, false
+	}
+
+	t.verifying = false
+	if state.Leader == t.target {
+		t.failures, t.next = 0, time.Time{}
+		return t.target, true, true
+	}
+	return t.target, false, true
+}
+
+// failed records a failed transfer to the target and returns whether it has
+// now permanently failed along with the number of consecutive failures.
+func (t *transferRetryTracker) failed(now time.Time) (bool, int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.verifying = false
+	t.failures++
+	if t.failures > t.retries {
+		t.gaveUp = true
+		return true, t.failures
+	}
+
+	t.next = now.Add(t.backoff << (t.failures - 1))
+	return false, t.failures
+}
+
+// verifyLeadershipTransfer checks whether the previous leadership transfer
+// moved leadership to the intended server now that a newer state has been
+// observed.
+func (a *Autopilot) verifyLeadershipTransfer(state *State) {
+	target, moved, ok := a.transferRetries.observe(state)
+	if !ok {
+		return
+	}
+
+	if moved {
+		a.subsystemRoundLogger(SubsystemTransfer).Info("verified that leadership was transferred", "id", target)
+		return
+	}
+
+	a.leadershipTransferFailed(target, fmt.Errorf("leadership moved to %q rather than %q", state.Leader, target))
+}
+
+// leadershipTransferFailed records the failed transfer to the server and emits
+// an event when it will no longer be retried.
+func (a *Autopilot) leadershipTransferFailed(id raft.ServerID, err error) {
+	if !a.transferRetries.enabled() {
+		return
+	}
+
+	permanent, failures := a.transferRetries.failed(a.now())
+	if !permanent {
+		a.subsystemRoundLogger(SubsystemTransfer).Warn("leadership transfer failed, it will be retried", "id", id, "failures", failures, "error", err)
+		return
+	}
+
+	message := fmt.Sprintf("giving up transferring leadership to %s after %d failed attempts: %v", id, failures, err)
+	a.subsystemRoundLogger(SubsystemTransfer).Error("leadership transfer permanently failed", "id", id, "failures", failures, "error", err)
+	a.emitEvent(EventLeadershipTransferFailed, id, message)
+}
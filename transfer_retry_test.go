@@ -0,0 +1,96 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeadershipTransferRetries(t *testing.T) {
+	start := time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)
+	now := start
+
+	mtime := NewMockTimeProvider(t)
+	mtime.On("Now").Return(func() time.Time { return now })
+
+	mraft := NewMockRaft(t)
+	var events []Event
+	a := &Autopilot{
+		logger: hclog.NewNullLogger(),
+		raft:   mraft,
+		time:   mtime,
+	}
+	WithLeadershipTransferRetries(1, 10*time.Second)(a)
+	WithEventHandler(func(e Event) {
+		if e.Type == EventLeadershipTransferFailed {
+			events = append(events, e)
+		}
+	})(a)
+
+	ctx := context.Background()
+	changes := RaftChanges{Leader: "b"}
+	servers := []raft.ServerID{"a", "b", "c"}
+	state := testState(servers, "a", servers...)
+
+	// a failed transfer is retried after the backoff
+	mraft.On("LeadershipTransferToServer", raft.ServerID("b"), raft.ServerAddress("")).
+		Return(&raftIndexFuture{err: fmt.Errorf("timed out")}).Once()
+	require.ErrorIs(t, a.applyLeadershipTransfer(ctx, state, changes), ErrLeadershipTransferFailed)
+	now = start.Add(9 * time.Second)
+	require.NoError(t, a.applyLeadershipTransfer(ctx, state, changes))
+
+	// a transfer which succeeds is not retried until it has been verified by
+	// a newer state
+	now = start.Add(10 * time.Second)
+	mraft.On("LeadershipTransferToServer", raft.ServerID("b"), raft.ServerAddress("")).
+		Return(&raftIndexFuture{}).Once()
+	require.NoError(t, a.applyLeadershipTransfer(ctx, state, changes))
+	require.NoError(t, a.applyLeadershipTransfer(ctx, state, changes))
+	require.Empty(t, events)
+
+	// leadership not moving exhausts the retries
+	now = start.Add(time.Hour)
+	require.NoError(t, a.applyLeadershipTransfer(ctx, testState(servers, "a", servers...), changes))
+	require.Len(t, events, 1)
+	require.Equal(t, raft.ServerID("b"), events[0].ServerID)
+
+	// another server may still be transferred to and the transfer verified
+	mraft.On("LeadershipTransferToServer", raft.ServerID("c"), raft.ServerAddress("")).
+		Return(&raftIndexFuture{}).Once()
+	require.NoError(t, a.applyLeadershipTransfer(ctx, state, RaftChanges{Leader: "c"}))
+	require.NoError(t, a.applyLeadershipTransfer(ctx, testState(servers, "c", servers...), RaftChanges{Leader: "c"}))
+	require.Len(t, events, 1)
+}
+
+func TestTransferRetryTracker(t *testing.T) {
+	now := time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)
+	tracker := transferRetryTracker{retries: 3, backoff: time.Second}
+
+	_, ok := tracker.ready("b", now)
+	require.True(t, ok)
+
+	// the backoff doubles with each failure
+	for i, backoff := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+		permanent, failures := tracker.failed(now)
+		require.False(t, permanent)
+		require.Equal(t, i+1, failures)
+
+		_, ok = tracker.ready("b", now.Add(backoff-time.Nanosecond))
+		require.False(t, ok)
+		_, ok = tracker.ready("b", now.Add(backoff))
+		require.True(t, ok)
+	}
+
+	permanent, _ := tracker.failed(now)
+	require.True(t, permanent)
+	_, ok = tracker.ready("b", now.Add(time.Hour))
+	require.False(t, ok)
+}
@@ -4,7 +4,7 @@
 package autopilot
 
 import (
-	"context"
+	"fmt"
 	"time"
 
 	"github.com/hashicorp/raft"
@@ -44,6 +44,21 @@ const (
 	NodeLeft    NodeStatus = "left"
 )
 
+// UnknownStatusTreatment determines how autopilot treats servers that the
+// application reports with the NodeUnknown status.
+type UnknownStatusTreatment string
+
+const (
+	// UnknownStatusFailed treats servers with an unknown status the same as
+	// failed servers which makes them eligible for removal. This is the
+	// default treatment.
+	UnknownStatusFailed UnknownStatusTreatment = "failed"
+
+	// UnknownStatusHold holds servers with an unknown status. No action will
+	// be taken to remove them until their status is known.
+	UnknownStatusHold UnknownStatusTreatment = "hold"
+)
+
 type NodeType string
 
 const (
@@ -73,6 +88,160 @@ type Config struct {
 	// applicable with Raft protocol version 3 or higher.
 	ServerStabilizationTime time.Duration
 
+	// FailureDomainKeys are the Server.Meta keys (such as a zone or host group)
+	// whose values identify failure domains. For each key autopilot will report
+	// whether the cluster can survive the loss of all voters within a domain.
+	FailureDomainKeys []string
+
+	// UnknownStatusTreatment controls whether servers with the NodeUnknown
+	// status are treated as failed or are held without any action being
+	// taken. When unset UnknownStatusFailed is used.
+	UnknownStatusTreatment UnknownStatusTreatment
+
+	// IgnoredServerSelectors are label selector style expressions evaluated
+	// against Server.Meta such as "pool=build-agents", "pool!=core" or
+	// "maintenance". Servers matching any of them are excluded from all
+	// autopilot management. They will not be promoted, demoted or removed and
+	// autopilot will not request that their stats be fetched.
+	IgnoredServerSelectors []string
+
+	// ZoneKey is the Server.Meta key whose value identifies the zone a server
+	// is located in. It must be set in order to evacuate zones. When set,
+	// failed voters are removed from the zones with the most voters first.
+	ZoneKey string
+
+	// MinCompatibleVoters is the minimum number of voters which must share
+	// the same Raft protocol version while servers with differing versions
+	// are voters, such as during an upgrade. Regardless of this setting a
+	// promotion or demotion is never made if it would leave no Raft protocol
+	// version shared by a quorum of the voters.
+	MinCompatibleVoters uint
+
+	// MaxVersionSkew limits how much the Version of a server may differ from
+	// the leader's for it to be promoted, preventing a voter set of mixed
+	// versions during rolling upgrades. Promoters migrating the voters to a
+	// new version may lift it by implementing UpgradeMigrator. When unset any
+	// version may be promoted.
+	MaxVersionSkew VersionSkew
+
+	// CanarySoakPeriod enables canary promotions when non-zero. Only a single
+	// server with a Version which no voter has is promoted, as a canary, and
+	// further servers with that version are only promoted once the canary has
+	// been a healthy voter for this long.
+	CanarySoakPeriod time.Duration
+
+	// DryRun causes autopilot to calculate the promotions, demotions,
+	// leadership transfers and removals it would make without applying them.
+	// The planned changes are reported with EventPlannedChanges events.
+	DryRun bool
+
+	// StatsOutageGracePeriod is how long the servers' health is frozen when
+	// stats cannot be fetched for any server, such as during an outage of
+	// the stats endpoint. During this period the State is marked Degraded
+	// and voting rights are left alone. Afterwards servers will be judged
+	// unhealthy as usual. Zero disables the grace period.
+	StatsOutageGracePeriod time.Duration
+
+	// StatsCacheTTL is how long the last stats fetched for an alive server
+	// are relied upon when fetching them fails while stats for other servers
+	// can still be fetched. Within the TTL the server's previous health is
+	// retained rather than flipping on a transient RPC failure. Zero disables
+	// the cache.
+	StatsCacheTTL time.Duration
+
+	// TargetVoters is the number of voters autopilot maintains when non-zero.
+	// Stable non-voters are promoted while there are fewer voters and surplus
+	// healthy voters are demoted, balancing the voters across the zones
+	// identified by ZoneKey. When zero the number of voters is left to the
+	// promoter.
+	TargetVoters uint
+
+	// DeadServerRemovalGracePeriod is how long a server must have been
+	// continuously failed before it will be removed. This prevents a brief
+	// flap of the status the application reports from causing a removal.
+	// Zero removes failed servers immediately.
+	DeadServerRemovalGracePeriod time.Duration
+
+	// PreferredLeaders are the servers which should hold leadership in order
+	// of preference. Whenever the leader is not one of them leadership is
+	// transferred to the first which is a healthy and stable voter.
+	PreferredLeaders []raft.ServerID
+
+	// PreferredLeaderZone is the zone, identified by ZoneKey, whose voters
+	// should hold leadership when PreferredLeaders is empty.
+	PreferredLeaderZone string
+
+	// UnhealthyLeaderTransferDelay is how long the leader must have been
+	// unhealthy before autopilot transfers leadership to the most up to date
+	// healthy and stable voter rather than waiting for a Raft election. Zero
+	// disables these transfers.
+	UnhealthyLeaderTransferDelay time.Duration
+
+	// CatchUpMaxLag is how many log entries a non-voter may trail the leader
+	// by and still be considered caught up. When non-zero non-voters are only
+	// eligible for promotion once their lag is within it and has been making
+	// progress for CatchUpObservations state updates, not merely once they
+	// are stable. Zero disables the tracking of catch up progress.
+	CatchUpMaxLag uint64
+
+	// CatchUpObservations is the number of consecutive state updates a
+	// non-voter's lag must have been shrinking or within the CatchUpMaxLag
+	// before it is considered caught up. Values below one are treated as one.
+	CatchUpObservations int
+
+	// UnhealthyObservations is the number of consecutive state updates a
+	// healthy server must be judged unhealthy in before it is considered
+	// unhealthy. This prevents a single noisy stats sample from resetting
+	// its StableSince. Values below two change the health immediately.
+	UnhealthyObservations int
+
+	// HealthyObservations is the number of consecutive state updates an
+	// unhealthy server must be judged healthy in before it is considered
+	// healthy again. Values below two change the health immediately.
+	HealthyObservations int
+
+	// FlapThreshold is the number of health changes within the FlapWindow
+	// above which a server is considered to be flapping. Zero disables the
+	// detection of flapping servers.
+	FlapThreshold int
+
+	// FlapWindow is the window over which a server's health changes are
+	// counted against the FlapThreshold.
+	FlapWindow time.Duration
+
+	// FlapDampingPeriod is how long the health of a flapping server must not
+	// change before it is no longer considered to be flapping.
+	FlapDampingPeriod time.Duration
+
+	// SpreadConstraints limit how many voters may share the same value of a
+	// Server.Meta key. Promotions which would violate them are not made and
+	// the voters violating them, for example after a server's metadata
+	// changed, are reported within the State.
+	SpreadConstraints []SpreadConstraint
+
+	// DemoteFlapping causes voters which are flapping to be demoted, one per
+	// round, as long as MinQuorum voters remain.
+	DemoteFlapping bool
+
+	// MaxRemovalsPerRound limits how many stale or failed servers may be
+	// removed within a single round so that the application mistakenly
+	// reporting many servers as failed cannot cause a mass removal. Servers
+	// are removed in the usual precedence order until the limit is reached.
+	// Zero places no limit on the removals.
+	MaxRemovalsPerRound uint
+
+	// FailedVoterDemotionPeriod causes failed voters to be demoted to
+	// non-voters rather than removed when non-zero. They are only removed
+	// once they have remained failed for this long after their demotion,
+	// limiting the damage done when a server is mistakenly reported as
+	// failed. Zero removes failed voters immediately.
+	FailedVoterDemotionPeriod time.Duration
+
+	// Overrides replace the LastContactThreshold, MaxTrailingLogs and
+	// ServerStabilizationTime for the servers they match by NodeType or
+	// Server.Meta. See ConfigOverride.
+	Overrides []ConfigOverride
+
 	Ext interface{}
 }
 
@@ -90,6 +259,23 @@ type Server struct {
 	RaftVersion int
 	IsLeader    bool
 
+	// RemovalPriority is an optional hint used to order the removal of failed
+	// servers. When not all failed servers may be removed at once, those with
+	// a higher priority (such as spot instances) will be removed before those
+	// with a lower priority. Servers of equal priority are ordered by ID.
+	RemovalPriority int
+
+	// Value is an optional hint of how valuable the server is as a member
+	// of the cluster, such as a reserved instance compared to a cheaper spot
+	// instance. Servers with a higher value are promoted first and, among
+	// failed servers of equal RemovalPriority, removed last.
+	Value int
+
+	// Protected prevents autopilot from ever demoting or removing the server,
+	// such as a seed server or one undergoing manual recovery. The server may
+	// still be promoted.
+	Protected bool
+
 	// The remaining fields are those that the promoter
 	// will fill in
 
@@ -102,6 +288,40 @@ type ServerState struct {
 	State  RaftState
 	Stats  ServerStats
 	Health ServerHealth
+
+	// StatsAge is how long ago the Stats were fetched. It is zero when they
+	// were fetched for this state and otherwise the Stats are the last ones
+	// fetched for the server.
+	StatsAge time.Duration
+
+	// statsFetchedAt is when the Stats were fetched.
+	statsFetchedAt time.Time
+
+	// CatchUp is the replication progress of a non-voter. It is nil for
+	// voters and when no CatchUpMaxLag is configured.
+	CatchUp *CatchUpProgress
+
+	// Lockout holds the failed operations autopilot has recently attempted
+	// against this server, including the kind of the last failed operation
+	// and when it failed. When these have caused the server to be locked out
+	// autopilot will not try to promote, demote or remove it until the
+	// lockout expires. It is nil when no operations have failed.
+	Lockout *ServerLockout
+
+	// Ignored is true when the server matches one of the configured
+	// IgnoredServerSelectors and is therefore not managed by autopilot.
+	Ignored bool
+
+	// SecondsUntilEligible is how many more seconds a healthy non-voter must
+	// remain stable before it has been stable for the effective server
+	// stabilization time and is eligible for promotion. It is zero for all
+	// other servers.
+	SecondsUntilEligible int
+
+	// Enrichments holds the most recent data each registered Enricher
+	// returned for this server keyed by the enricher's name. It is nil when
+	// no enricher has returned data for the server.
+	Enrichments map[string]Enrichment
 }
 
 func (s *ServerState) HasVotingRights() bool {
@@ -111,32 +331,46 @@ func (s *ServerState) HasVotingRights() bool {
 // isHealthy determines whether this ServerState is considered healthy
 // based on the given Autopilot config
 func (s *ServerState) isHealthy(lastTerm uint64, leaderLastIndex uint64, conf *Config) bool {
+	return len(s.unhealthyReasons(lastTerm, leaderLastIndex, conf)) == 0
+}
+
+// unhealthyReasons returns a description of every reason the server is not
+// healthy. Nil is returned for healthy servers.
+func (s *ServerState) unhealthyReasons(lastTerm uint64, leaderLastIndex uint64, conf *Config) []string {
 	// Raft hasn't been bootstrapped yet so nothing is healthy
 	if leaderLastIndex == 0 || lastTerm == 0 {
-		return false
+		return []string{"the leader's last log index and term are unknown"}
 	}
 
+	var reasons []string
+
 	// Check that the application still thinks the server is alive and well.
 	if s.Server.NodeStatus != NodeAlive {
-		return false
+		reasons = append(reasons, fmt.Sprintf("node status is %q", s.Server.NodeStatus))
 	}
 
 	// Check to ensure that the server was contacted recently enough.
-	if s.Stats.LastContact > conf.LastContactThreshold || s.Stats.LastContact < 0 {
-		return false
+	if s.Stats.LastContact < 0 {
+		reasons = append(reasons, "last contact is unknown")
+	} else if s.Stats.LastContact > conf.LastContactThreshold {
+		reasons = append(reasons, fmt.Sprintf("last contact %s exceeds the %s threshold", s.Stats.LastContact, conf.LastContactThreshold))
 	}
 
-	// Check if the server has a different Raft term from the leader
-	if s.Stats.LastTerm != lastTerm {
-		return false
+	// Check if the server has a different Raft term from the leader. A term
+	// ahead of the leader's means an election is in progress or the server
+	// has been partitioned and keeps starting elections.
+	if s.Stats.LastTerm > lastTerm {
+		reasons = append(reasons, fmt.Sprintf("last term %d is ahead of the leader's term %d", s.Stats.LastTerm, lastTerm))
+	} else if s.Stats.LastTerm != lastTerm {
+		reasons = append(reasons, fmt.Sprintf("last term %d does not match the leader's term %d", s.Stats.LastTerm, lastTerm))
 	}
 
 	// Check if the server has fallen behind more than the configured max trailing logs value
 	if s.Stats.LastIndex+conf.MaxTrailingLogs < leaderLastIndex {
-		return false
+		reasons = append(reasons, fmt.Sprintf("trailing %d logs exceeds the maximum of %d", leaderLastIndex-s.Stats.LastIndex, conf.MaxTrailingLogs))
 	}
 
-	return true
+	return reasons
 }
 
 type ServerHealth struct {
@@ -146,6 +380,31 @@ type ServerHealth struct {
 
 	// StableSince is the last time this server's Healthy value changed.
 	StableSince time.Time
+
+	// Reasons describes why the server is not healthy, such as its last
+	// contact exceeding the threshold or it trailing too many logs. It is
+	// empty for healthy servers.
+	Reasons []string
+
+	// TermAhead is set when the server reports a last log term greater than
+	// the leader's. Such servers are never promoted.
+	TermAhead bool
+
+	// Flapping is set when the server's health has changed more than the
+	// FlapThreshold within the FlapWindow. Flapping servers are never
+	// promoted, and may be demoted, until their health has not changed for
+	// the FlapDampingPeriod.
+	Flapping bool
+
+	// transitions are the times the health changed within the FlapWindow and
+	// lastTransition the most recent of them. They are only tracked when a
+	// FlapThreshold is configured.
+	transitions    []time.Time
+	lastTransition time.Time
+
+	// pendingObservations is the number of consecutive state updates which
+	// judged the server's health differently from its current verdict.
+	pendingObservations int
 }
 
 // IsStable returns true if the ServerState shows a stable, passing state
@@ -176,6 +435,31 @@ type ServerStats struct {
 
 	// LastIndex is the last log index this server has a record of in its Raft log.
 	LastIndex uint64
+
+	// Ext holds any additional signals the delegate fetched alongside the Raft
+	// stats, such as the apply latency, free disk space or age of the latest
+	// snapshot. Autopilot does not interpret it but carries it into the State
+	// so that Promoters can act upon it. Like the other Ext fields its type
+	// should be registered with RegisterExtType to survive serialization.
+	Ext interface{}
+}
+
+// FailureToleranceInputs are the counts of servers which the FailureTolerance
+// and OptimisticFailureTolerance of a State were calculated from.
+type FailureToleranceInputs struct {
+	// Voters is the number of voters including the leader.
+	Voters int
+
+	// HealthyVoters is the number of voters which are healthy.
+	HealthyVoters int
+
+	// RequiredQuorum is the number of voters required for a quorum.
+	RequiredQuorum int
+
+	// PromotableNonVoters is the number of healthy non-voters of a node type
+	// the promoter considers a potential voter which have caught up with the
+	// leader. These could be promoted to replace failed voters.
+	PromotableNonVoters int
 }
 
 type State struct {
@@ -185,16 +469,67 @@ type State struct {
 	Servers          map[raft.ServerID]*ServerState
 	Leader           raft.ServerID
 	Voters           []raft.ServerID
-	Ext              interface{}
+	// OptimisticFailureTolerance is how many voters could fail, one at a
+	// time, without an outage assuming a healthy non-voter is promoted to
+	// replace each failed voter. It is at least the FailureTolerance.
+	OptimisticFailureTolerance int
+	// FailureToleranceInputs are the counts the failure tolerances were
+	// calculated from.
+	FailureToleranceInputs FailureToleranceInputs
+	// FailureDomains holds the failure tolerance broken down by each of
+	// the Config.FailureDomainKeys.
+	FailureDomains map[string]*FailureDomainTolerance
+	// HeldServers are the servers with an unknown status which autopilot
+	// will take no action on because of the UnknownStatusHold treatment.
+	HeldServers []raft.ServerID
+	// SpreadViolations are the voters sharing a value of a
+	// Config.SpreadConstraints key beyond its MaxVoters.
+	SpreadViolations []SpreadViolation
+	// TermsDiverged is set when many servers report a last log term other
+	// than the leader's, as typically happens while a network partition is
+	// healing. While set, demotions are suppressed and the effective server
+	// stabilization time is doubled.
+	TermsDiverged bool
+	// StatsOutageSince is when the stats stopped being available for every
+	// server. It is the zero time while stats are available.
+	StatsOutageSince time.Time
+	// Degraded is set during the first StatsOutageGracePeriod of a stats
+	// outage. While set, the servers' previous health is retained rather
+	// than marking every server unhealthy and no promotions, demotions or
+	// leadership transfers are made.
+	Degraded bool
+	// LeaderStats are the parsed Raft stats of the leader as of when the
+	// state was built so that promoters need not parse them again.
+	LeaderStats *LeaderStats
+	// Promoter identifies the promoter driving the promotions and demotions
+	// and whether it failed in the last round.
+	Promoter PromoterStatus
+	Ext      interface{}
+}
+
+// holdsServer returns whether a server with the given status should be held
+// instead of being treated as failed.
+func (c *Config) holdsServer(status NodeStatus) bool {
+	return c != nil && status == NodeUnknown && c.UnknownStatusTreatment == UnknownStatusHold
 }
 
 func (s *State) ServerStabilizationTime(c *Config) time.Duration {
+	return s.serverStabilizationTimeAt(c, time.Now())
+}
+
+// serverStabilizationTimeAt returns the effective server stabilization time as
+// of the given time.
+func (s *State) serverStabilizationTimeAt(c *Config, now time.Time) time.Duration {
 	// Only use the configured stabilization time when autopilot has
 	// been running for at least as long as when the first state was
 	// generated. If it hasn't been running that long then we would
 	// guarantee that all checks against the stabilization time will
 	// fail which will result in excessive leader elections.
-	if time.Since(s.firstStateTime) > c.ServerStabilizationTime {
+	if now.Sub(s.firstStateTime) > c.ServerStabilizationTime {
+		if s.TermsDiverged {
+			// be more cautious about promotions until the terms converge
+			return 2 * c.ServerStabilizationTime
+		}
 		return c.ServerStabilizationTime
 	}
 
@@ -218,31 +553,28 @@ type Raft interface {
 	State() raft.RaftState
 }
 
+// ApplicationIntegration is the delegate through which autopilot interacts
+// with the application. It is composed of the capability interfaces so that
+// applications which do not need all of them may compose one from those they
+// implement with an Integration.
 type ApplicationIntegration interface {
-	// AutopilotConfig is used to retrieve the latest configuration from the delegate
-	AutopilotConfig() *Config
-
-	// NotifyState will be called when the autopilot state is updated. The application may choose to emit metrics
-	// or perform other actions based on this information.
-	NotifyState(*State)
-
-	// FetchServerStats will be called to request the application fetch the ServerStats out of band. Usually this
-	// will require an RPC to each server.
-	FetchServerStats(context.Context, map[raft.ServerID]*Server) map[raft.ServerID]*ServerStats
-
-	// KnownServers fetchs the list of servers as known to the application
-	KnownServers() map[raft.ServerID]*Server
-
-	// RemoveFailedServer notifies the application to forcefully remove the server in the failed state
-	// It is expected that this returns nearly immediately so if a longer running operation needs to be
-	// performed then the Delegate implementation should spawn a go routine itself.
-	RemoveFailedServer(*Server)
+	ConfigProvider
+	StateObserver
+	ServerStatsFetcher
+	ServerSource
+	FailedServerRemover
 }
 
 type RaftChanges struct {
 	Promotions []raft.ServerID
 	Demotions  []raft.ServerID
 	Leader     raft.ServerID
+
+	// LeaderCandidates are further servers to transfer leadership to, in
+	// order of preference, when Leader is not eligible to become the leader.
+	// The first eligible server out of Leader followed by these candidates
+	// will be chosen.
+	LeaderCandidates []raft.ServerID
 }
 
 type FailedServers struct {
@@ -259,6 +591,10 @@ type FailedServers struct {
 	// FailedVoters are the servers without voting rights in the cluster that the
 	// delegate has indicated are in a failed state
 	FailedVoters []*Server
+
+	// HeldServers are the servers the delegate has given an unknown status
+	// which will not be removed due to the configured UnknownStatusTreatment
+	HeldServers []*Server
 }
 
 func (f *FailedServers) getFailed(ids []raft.ServerID, isVoter bool) []*Server {
@@ -290,7 +626,8 @@ func (f *FailedServers) getFailed(ids []raft.ServerID, isVoter bool) []*Server {
 //
 // Note that all parameters passed to these functions should be considered read-only and
 // their modification could result in undefined behavior of the core autopilot routines
-// including potential crashes.
+// including potential crashes. The WithPromoterIsolation option may be used to protect
+// against or detect such modifications.
 type Promoter interface {
 	// GetServerExt returns some object that should be stored in the Ext field of the Server
 	// This value will not be used by the code in this repo but may be used by the other
@@ -146,6 +146,43 @@ func TestServerIsHealthy(t *testing.T) {
 	}
 }
 
+func TestServerUnhealthyReasons(t *testing.T) {
+	conf := &Config{
+		MaxTrailingLogs:      200,
+		LastContactThreshold: 100 * time.Millisecond,
+	}
+
+	healthy := ServerState{
+		Server: Server{NodeStatus: NodeAlive},
+		Stats: ServerStats{
+			LastContact: 99 * time.Millisecond,
+			LastTerm:    5,
+			LastIndex:   801,
+		},
+	}
+	require.Nil(t, healthy.unhealthyReasons(5, 1000, conf))
+
+	unhealthy := ServerState{
+		Server: Server{NodeStatus: NodeLeft},
+		Stats: ServerStats{
+			LastContact: 12 * time.Second,
+			LastTerm:    4,
+			LastIndex:   500,
+		},
+	}
+	require.Equal(t, []string{
+		`node status is "left"`,
+		"last contact 12s exceeds the 100ms threshold",
+		"last term 4 does not match the leader's term 5",
+		"trailing 500 logs exceeds the maximum of 200",
+	}, unhealthy.unhealthyReasons(5, 1000, conf))
+
+	require.Equal(t, []string{"the leader's last log index and term are unknown"}, healthy.unhealthyReasons(0, 0, conf))
+
+	// a term ahead of the leader's is reported distinctly
+	require.Equal(t, []string{"last term 5 is ahead of the leader's term 4"}, healthy.unhealthyReasons(4, 1000, conf))
+}
+
 func TestServerIsStable(t *testing.T) {
 	type testCase struct {
 		health            *ServerHealth
@@ -215,3 +252,18 @@ func TestServerStabilizationTime(t *testing.T) {
 	}, 500*time.Millisecond, 50*time.Millisecond)
 
 }
+
+func TestServerStabilizationTimeTermsDiverged(t *testing.T) {
+	conf := &Config{
+		ServerStabilizationTime: 10 * time.Second,
+	}
+
+	now := time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)
+	s := &State{
+		firstStateTime: now.Add(-time.Hour),
+	}
+	require.Equal(t, 10*time.Second, s.serverStabilizationTimeAt(conf, now))
+
+	s.TermsDiverged = true
+	require.Equal(t, 20*time.Second, s.serverStabilizationTimeAt(conf, now))
+}
@@ -0,0 +1,120 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/raft"
+)
+
+// unhealthyLeader is the leader which is unhealthy and since when.
+type unhealthyLeader struct {
+	id    raft.ServerID
+	since time.Time
+}
+
+// unhealthyLeaderTracker tracks how long the current leader has been
+// unhealthy.
+type unhealthyLeaderTracker struct {
+	lock   sync.Mutex
+	leader unhealthyLeader
+}
+
+// observed returns the tracking updated for whether the leader is healthy,
+// without recording it, along with how long the leader has been unhealthy
+// for. Zero is returned for healthy leaders.
+func (t *unhealthyLeaderTracker) observed(id raft.ServerID, healthy bool, now time.Time) (unhealthyLeader, time.Duration) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if healthy || id == "" {
+		return unhealthyLeader{}, 0
+	}
+
+	leader := t.leader
+	if leader.id != id {
+		leader = unhealthyLeader{id: id, since: now}
+	}
+	return leader, now.Sub(leader.since)
+}
+
+// record replaces the tracking with that calculated by a round.
+func (t *unhealthyLeaderTracker) record(leader unhealthyLeader) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.leader = leader
+}
+
+// transferFromUnhealthyLeader modifies the changes to move leadership off of
+// a leader which has been unhealthy for at least the configured
+// UnhealthyLeaderTransferDelay rather than waiting for Raft to hold an
+// election. The healthy and stable voters are nominated with the most up to
+// date first, ahead of any candidates nominated by the promoter. The updated
+// tracking of the leader's health is set within the updates rather than
+// recorded.
+func (a *Autopilot) transferFromUnhealthyLeader(conf *Config, state *State, changes RaftChanges, updates *trackerUpdates) RaftChanges {
+	if conf.UnhealthyLeaderTransferDelay <= 0 {
+		return changes
+	}
+
+	leader, ok := state.Servers[state.Leader]
+	if !ok {
+		return changes
+	}
+
+	now := a.now()
+	tracked, unhealthyFor := a.unhealthyLeader.observed(state.Leader, leader.Health.Healthy, now)
+	updates.unhealthyLeader = &tracked
+	if leader.Health.Healthy || unhealthyFor < conf.UnhealthyLeaderTransferDelay {
+		return changes
+	}
+
+	var candidates []raft.ServerID
+	for _, id := range state.Voters {
+		srv, ok := state.Servers[id]
+		if !ok || id == state.Leader || srv.Ignored {
+			continue
+		}
+
+		minStable := state.serverStabilizationTimeAt(conf.ForServer(&srv.Server), now)
+		if srv.Health.IsStable(now, minStable) {
+			candidates = append(candidates, id)
+		}
+	}
+
+	if len(candidates) == 0 {
+		a.subsystemRoundLogger(SubsystemTransfer).Warn("leader has been unhealthy but there are no healthy stable voters to transfer leadership to",
+			"leader", state.Leader, "unhealthy_for", unhealthyFor)
+		return changes
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		ci, cj := state.Servers[candidates[i]], state.Servers[candidates[j]]
+		if ci.Stats.LastIndex != cj.Stats.LastIndex {
+			return ci.Stats.LastIndex > cj.Stats.LastIndex
+		}
+		if ci.Server.Value != cj.Server.Value {
+			return ci.Server.Value > cj.Server.Value
+		}
+		return ci.Server.ID < cj.Server.ID
+	})
+
+	a.subsystemRoundLogger(SubsystemTransfer).Warn("leader has been unhealthy, transferring leadership away from it",
+		"leader", state.Leader, "unhealthy_for", unhealthyFor, "reasons", leader.Health.Reasons, "candidates", candidates)
+
+	// keep any other server the promoter nominated but never the current
+	// leader as that would prevent the transfer
+	for _, id := range leaderCandidates(changes) {
+		if id != state.Leader {
+			candidates = append(candidates, id)
+		}
+	}
+
+	changes.Leader = candidates[0]
+	changes.LeaderCandidates = candidates[1:]
+	return changes
+}
@@ -0,0 +1,69 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+// transferFromUnhealthyLeaderRound modifies the changes and records the
+// leader's health as a reconciliation round would.
+func transferFromUnhealthyLeaderRound(a *Autopilot, conf *Config, state *State, changes RaftChanges) RaftChanges {
+	updates := &trackerUpdates{}
+	changes = a.transferFromUnhealthyLeader(conf, state, changes, updates)
+	a.recordTrackerUpdates(state, updates)
+	return changes
+}
+
+func TestTransferFromUnhealthyLeader(t *testing.T) {
+	start := time.Date(2020, 11, 2, 12, 0, 0, 0, time.UTC)
+	now := start
+
+	mtime := NewMockTimeProvider(t)
+	mtime.On("Now").Return(func() time.Time { return now })
+
+	state := &State{
+		Leader: "a",
+		Voters: []raft.ServerID{"a", "b", "c", "d"},
+		Servers: map[raft.ServerID]*ServerState{
+			"a": {Server: Server{ID: "a"}, State: RaftLeader, Health: ServerHealth{Reasons: []string{`node status is "failed"`}}},
+			"b": {Server: Server{ID: "b"}, State: RaftVoter, Stats: ServerStats{LastIndex: 10}, Health: ServerHealth{Healthy: true}},
+			"c": {Server: Server{ID: "c"}, State: RaftVoter, Stats: ServerStats{LastIndex: 12}, Health: ServerHealth{Healthy: true}},
+			"d": {Server: Server{ID: "d"}, State: RaftVoter, Stats: ServerStats{LastIndex: 20}},
+		},
+	}
+
+	conf := &Config{UnhealthyLeaderTransferDelay: 30 * time.Second}
+	a := &Autopilot{
+		logger: hclog.NewNullLogger(),
+		time:   mtime,
+	}
+
+	// nothing happens until the leader has been unhealthy for the delay
+	require.Equal(t, RaftChanges{}, transferFromUnhealthyLeaderRound(a, conf, state, RaftChanges{}))
+	now = start.Add(29 * time.Second)
+	require.Equal(t, RaftChanges{}, transferFromUnhealthyLeaderRound(a, conf, state, RaftChanges{}))
+
+	// the most up to date healthy voter is preferred
+	now = start.Add(30 * time.Second)
+	require.Equal(t, RaftChanges{
+		Leader:           "c",
+		LeaderCandidates: []raft.ServerID{"b"},
+	}, transferFromUnhealthyLeaderRound(a, conf, state, RaftChanges{Leader: "a"}))
+
+	// recovering resets the delay
+	state.Servers["a"].Health.Healthy = true
+	require.Equal(t, RaftChanges{}, transferFromUnhealthyLeaderRound(a, conf, state, RaftChanges{}))
+	state.Servers["a"].Health.Healthy = false
+	require.Equal(t, RaftChanges{}, transferFromUnhealthyLeaderRound(a, conf, state, RaftChanges{}))
+
+	// disabled without a delay
+	now = start.Add(time.Hour)
+	require.Equal(t, RaftChanges{}, transferFromUnhealthyLeaderRound(a, &Config{}, state, RaftChanges{}))
+}
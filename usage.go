@@ -0,0 +1,180 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	runtimemetrics "runtime/metrics"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+)
+
+// RoundKind identifies what a round was performing.
+type RoundKind string
+
+const (
+	// RoundReconcile is a round of promotions, demotions and leadership
+	// transfers.
+	RoundReconcile RoundKind = "reconcile"
+	// RoundPrune is a round of failed and stale server removals.
+	RoundPrune RoundKind = "prune"
+	// RoundPlan is a round calculating a plan for Plan.
+	RoundPlan RoundKind = "plan"
+	// RoundApply is a round applying a plan given to Apply.
+	RoundApply RoundKind = "apply"
+)
+
+const (
+	// allocBytesMetric and allocObjectsMetric are the runtime metrics with
+	// the cumulative heap allocations of the process.
+	allocBytesMetric   = "/gc/heap/allocs:bytes"
+	allocObjectsMetric = "/gc/heap/allocs:objects"
+)
+
+// RoundUsage is the resources consumed by a reconcile, prune or apply round.
+// The CPU time and allocations are measured for the whole process so they
+// include the work of any go routines running concurrently with the round and
+// should be treated as upper bound estimates.
+type RoundUsage struct {
+	// ID is the ID of the round.
+	ID string
+
+	// Kind is what the round was performing.
+	Kind RoundKind
+
+	// Start is when the round started.
+	Start time.Time
+
+	// WallTime is how long the round took.
+	WallTime time.Duration
+
+	// CPUTime is the user and system CPU time consumed during the round. It
+	// is zero on platforms where it cannot be measured.
+	CPUTime time.Duration
+
+	// AllocatedBytes is the number of bytes allocated on the heap during the
+	// round.
+	AllocatedBytes uint64
+
+	// Allocations is the number of heap objects allocated during the round.
+	Allocations uint64
+
+	// DelegateCalls counts the calls made to the ApplicationIntegration
+	// during the round keyed by the method name.
+	DelegateCalls map[string]int
+}
+
+// roundUsage accumulates the resource usage of the round in progress.
+type roundUsage struct {
+	usage RoundUsage
+
+	cpuStart     time.Duration
+	bytesStart   uint64
+	objectsStart uint64
+}
+
+// readAllocations returns the cumulative heap allocations of the process in
+// bytes and objects.
+func readAllocations() (uint64, uint64) {
+	samples := []runtimemetrics.Sample{
+		{Name: allocBytesMetric},
+		{Name: allocObjectsMetric},
+	}
+	runtimemetrics.Read(samples)
+
+	var values [2]uint64
+	for i, s := range samples {
+		if s.Value.Kind() == runtimemetrics.KindUint64 {
+			values[i] = s.Value.Uint64()
+		}
+	}
+	return values[0], values[1]
+}
+
+// startRoundUsage begins measuring the resources used by a round.
+func startRoundUsage(id string, kind RoundKind) *roundUsage {
+	u := &roundUsage{
+		usage: RoundUsage{
+			ID:            id,
+			Kind:          kind,
+			Start:         time.Now(),
+			DelegateCalls: make(map[string]int),
+		},
+		cpuStart: processCPUTime(),
+	}
+	u.bytesStart, u.objectsStart = readAllocations()
+	return u
+}
+
+// finish completes the measurements and returns the round's usage.
+func (u *roundUsage) finish() RoundUsage {
+	usage := u.usage
+	usage.WallTime = time.Since(usage.Start)
+
+	if cpu := processCPUTime(); cpu > u.cpuStart {
+		usage.CPUTime = cpu - u.cpuStart
+	}
+
+	bytes, objects := readAllocations()
+	if bytes > u.bytesStart {
+		usage.AllocatedBytes = bytes - u.bytesStart
+	}
+	if objects > u.objectsStart {
+		usage.Allocations = objects - u.objectsStart
+	}
+	return usage
+}
+
+// countDelegateCall records a call to the ApplicationIntegration method
+// against the round in progress. Calls outside of a round are not counted.
+func (a *Autopilot) countDelegateCall(method string) {
+	a.roundLock.Lock()
+	defer a.roundLock.Unlock()
+
+	if a.roundUsage != nil {
+		a.roundUsage.usage.DelegateCalls[method]++
+	}
+}
+
+// recordRoundUsage stores the usage of a completed round and emits it as
+// metrics.
+func (a *Autopilot) recordRoundUsage(usage RoundUsage) {
+	a.roundLock.Lock()
+	if a.lastRoundUsage == nil {
+		a.lastRoundUsage = make(map[RoundKind]RoundUsage)
+	}
+	a.lastRoundUsage[usage.Kind] = usage
+	a.roundLock.Unlock()
+
+	sink := a.metricsSink()
+	labels := []metrics.Label{{Name: "kind", Value: string(usage.Kind)}}
+
+	sink.AddSampleWithLabels(roundWallTimeKey, durationMillis(usage.WallTime), labels)
+	sink.AddSampleWithLabels(roundCPUTimeKey, durationMillis(usage.CPUTime), labels)
+	sink.AddSampleWithLabels(roundAllocBytesKey, float32(usage.AllocatedBytes), labels)
+	sink.AddSampleWithLabels(roundAllocationsKey, float32(usage.Allocations), labels)
+
+	for method, calls := range usage.DelegateCalls {
+		sink.IncrCounterWithLabels(roundDelegateCallsKey, float32(calls), append(labels, metrics.Label{Name: "method", Value: method}))
+	}
+}
+
+// LastRoundUsage returns the resources consumed by the most recently completed
+// round of the given kind. False is returned when no such round has completed.
+func (a *Autopilot) LastRoundUsage(kind RoundKind) (RoundUsage, bool) {
+	a.roundLock.RLock()
+	defer a.roundLock.RUnlock()
+
+	usage, ok := a.lastRoundUsage[kind]
+	if !ok {
+		return RoundUsage{}, false
+	}
+
+	calls := make(map[string]int, len(usage.DelegateCalls))
+	for method, n := range usage.DelegateCalls {
+		calls[method] = n
+	}
+	usage.DelegateCalls = calls
+	return usage, true
+}
@@ -0,0 +1,14 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package autopilot
+
+import "time"
+
+// processCPUTime returns zero as the CPU time of the process cannot be
+// measured on this platform.
+func processCPUTime() time.Duration {
+	return 0
+}
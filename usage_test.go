@@ -0,0 +1,61 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"testing"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundUsage(t *testing.T) {
+	sink := metrics.NewInmemSink(time.Minute, time.Minute)
+
+	mdel := NewMockApplicationIntegration(t)
+	mdel.On("RemoveFailedServer", &Server{ID: "a"}).Once()
+
+	a := &Autopilot{
+		logger:     hclog.NewNullLogger(),
+		delegate:   mdel,
+		metricSink: sink,
+	}
+
+	_, ok := a.LastRoundUsage(RoundPrune)
+	require.False(t, ok)
+
+	// calls outside of a round are not counted
+	a.countDelegateCall("AutopilotConfig")
+
+	end := a.beginRound(RoundPrune)
+	round := a.currentRound()
+	a.countDelegateCall("AutopilotConfig")
+	a.countDelegateCall("AutopilotConfig")
+	a.removeFailedServers([]*Server{{ID: "a"}})
+	end()
+
+	usage, ok := a.LastRoundUsage(RoundPrune)
+	require.True(t, ok)
+	require.Equal(t, round, usage.ID)
+	require.Equal(t, RoundPrune, usage.Kind)
+	require.False(t, usage.Start.IsZero())
+	require.Greater(t, usage.WallTime, time.Duration(0))
+	require.Equal(t, map[string]int{
+		"AutopilotConfig":    2,
+		"RemoveFailedServer": 1,
+	}, usage.DelegateCalls)
+
+	_, ok = a.LastRoundUsage(RoundReconcile)
+	require.False(t, ok)
+
+	intervals := sink.Data()
+	require.NotEmpty(t, intervals)
+	_, ok = intervals[0].Samples["autopilot.round.wall_time;kind=prune"]
+	require.True(t, ok)
+	calls, ok := intervals[0].Counters["autopilot.round.delegate_calls;kind=prune;method=AutopilotConfig"]
+	require.True(t, ok)
+	require.Equal(t, float64(2), calls.Sum)
+}
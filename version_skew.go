@@ -0,0 +1,131 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/raft"
+)
+
+// VersionSkew is how much the Version of a server being promoted may differ
+// from the leader's. Versions are compared as major.minor.patch semantic
+// versions, ignoring any "v" prefix, pre-release and build metadata.
+type VersionSkew string
+
+const (
+	// VersionSkewAny places no limit on the versions of promoted servers.
+	// This is the default.
+	VersionSkewAny VersionSkew = ""
+
+	// VersionSkewMinor allows the minor and patch versions to differ but
+	// requires the major version to match.
+	VersionSkewMinor VersionSkew = "minor"
+
+	// VersionSkewPatch allows only the patch version to differ.
+	VersionSkewPatch VersionSkew = "patch"
+
+	// VersionSkewNone requires the versions to match.
+	VersionSkewNone VersionSkew = "none"
+)
+
+// UpgradeMigrator may optionally be implemented by a Promoter which migrates
+// the voters to a new version during rolling upgrades. While it reports that
+// a migration is in progress its promotions are not limited by the
+// Config.MaxVersionSkew.
+type UpgradeMigrator interface {
+	UpgradeMigrationInProgress(conf *Config, state *State) bool
+}
+
+// semanticVersion is a parsed major.minor.patch version.
+type semanticVersion [3]uint64
+
+// parseSemanticVersion parses a version such as "1.9.0", "v1.10" or
+// "1.9.0-beta1+ent". Missing minor and patch versions are zero.
+func parseSemanticVersion(v string) (semanticVersion, error) {
+	var parsed semanticVersion
+
+	core := strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(core, "-+"); i >= 0 {
+		core = core[:i]
+	}
+
+	parts := strings.Split(core, ".")
+	if core == "" || len(parts) > len(parsed) {
+		return parsed, fmt.Errorf("invalid version %q", v)
+	}
+	for i, part := range parts {
+		n, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return parsed, fmt.Errorf("invalid version %q", v)
+		}
+		parsed[i] = n
+	}
+	return parsed, nil
+}
+
+// allows returns whether the skew allows the versions to differ as they do.
+func (s VersionSkew) allows(a, b semanticVersion) bool {
+	switch s {
+	case VersionSkewMinor:
+		return a[0] == b[0]
+	case VersionSkewPatch:
+		return a[0] == b[0] && a[1] == b[1]
+	case VersionSkewNone:
+		return a == b
+	default:
+		return true
+	}
+}
+
+// guardVersionSkew removes the promotions of servers whose Version differs
+// from the leader's by more than the configured MaxVersionSkew so that the
+// voters are not left with mixed versions during a rolling upgrade. The
+// promoter may lift the guard while it is migrating the voters to the new
+// version by implementing UpgradeMigrator.
+func (a *Autopilot) guardVersionSkew(conf *Config, state *State, changes RaftChanges) RaftChanges {
+	if conf.MaxVersionSkew == VersionSkewAny || len(changes.Promotions) == 0 {
+		return changes
+	}
+
+	if migrator, ok := a.getPromoter().(UpgradeMigrator); ok && migrator.UpgradeMigrationInProgress(conf, state) {
+		return changes
+	}
+
+	leader, ok := state.Servers[state.Leader]
+	if !ok {
+		return changes
+	}
+	leaderVersion, err := parseSemanticVersion(leader.Server.Version)
+	if err != nil {
+		a.roundLogger().Warn("unable to limit the version skew of promoted servers", "leader", state.Leader, "error", err)
+		return changes
+	}
+
+	var promotions []raft.ServerID
+	for _, id := range changes.Promotions {
+		srv, ok := state.Servers[id]
+		if !ok {
+			promotions = append(promotions, id)
+			continue
+		}
+
+		version, err := parseSemanticVersion(srv.Server.Version)
+		if err != nil {
+			a.roundLogger().Warn("Not promoting server as its version cannot be compared with the leader's", "id", id, "error", err)
+			continue
+		}
+		if !conf.MaxVersionSkew.allows(leaderVersion, version) {
+			a.roundLogger().Info("Not promoting server as its version differs too much from the leader's",
+				"id", id, "version", srv.Server.Version, "leader_version", leader.Server.Version, "max_skew", conf.MaxVersionSkew)
+			continue
+		}
+		promotions = append(promotions, id)
+	}
+
+	changes.Promotions = promotions
+	return changes
+}
@@ -0,0 +1,80 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"testing"
+
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSemanticVersion(t *testing.T) {
+	cases := map[string]semanticVersion{
+		"1.9.0":          {1, 9, 0},
+		"v1.10":          {1, 10, 0},
+		"2":              {2, 0, 0},
+		"1.9.3-beta1":    {1, 9, 3},
+		"1.9.3+ent":      {1, 9, 3},
+		" 1.9.3-rc1+ent": {1, 9, 3},
+	}
+	for v, expected := range cases {
+		parsed, err := parseSemanticVersion(v)
+		require.NoError(t, err, v)
+		require.Equal(t, expected, parsed, v)
+	}
+
+	for _, v := range []string{"", "v", "1.x", "1.2.3.4", "latest"} {
+		_, err := parseSemanticVersion(v)
+		require.Error(t, err, v)
+	}
+}
+
+// upgradeMigrator is a promoter reporting whether an upgrade migration is in
+// progress.
+type upgradeMigrator struct {
+	Promoter
+	migrating bool
+}
+
+func (p *upgradeMigrator) UpgradeMigrationInProgress(*Config, *State) bool {
+	return p.migrating
+}
+
+func TestGuardVersionSkew(t *testing.T) {
+	versions := map[raft.ServerID]string{
+		"a": "1.9.2",
+		"b": "1.9.5",
+		"c": "1.10.0",
+		"d": "2.0.0",
+		"e": "unknown",
+	}
+	state := &State{Leader: "a", Servers: make(map[raft.ServerID]*ServerState)}
+	for id, version := range versions {
+		state.Servers[id] = &ServerState{Server: Server{ID: id, Version: version}}
+	}
+	promotions := []raft.ServerID{"b", "c", "d", "e"}
+
+	cases := map[VersionSkew][]raft.ServerID{
+		VersionSkewAny:   {"b", "c", "d", "e"},
+		VersionSkewMinor: {"b", "c"},
+		VersionSkewPatch: {"b"},
+		VersionSkewNone:  nil,
+	}
+	for skew, expected := range cases {
+		a := &Autopilot{logger: testLogger(t), promoter: DefaultPromoter()}
+		changes := a.guardVersionSkew(&Config{MaxVersionSkew: skew}, state, RaftChanges{Promotions: promotions})
+		require.Equal(t, expected, changes.Promotions, skew)
+	}
+
+	// an upgrade migration lifts the guard, even within a composed promoter
+	migrator := &upgradeMigrator{Promoter: DefaultPromoter(), migrating: true}
+	a := &Autopilot{logger: testLogger(t), promoter: NewComposedPromoter(DefaultPromoter(), migrator)}
+	changes := a.guardVersionSkew(&Config{MaxVersionSkew: VersionSkewNone}, state, RaftChanges{Promotions: promotions})
+	require.Equal(t, promotions, changes.Promotions)
+
+	migrator.migrating = false
+	changes = a.guardVersionSkew(&Config{MaxVersionSkew: VersionSkewNone}, state, RaftChanges{Promotions: promotions})
+	require.Empty(t, changes.Promotions)
+}
@@ -0,0 +1,208 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/raft"
+)
+
+// DefaultRaftFutureWatchdog is the suggested duration for which autopilot
+// waits for a Raft membership change to resolve before considering it stuck
+// when enabled with WithRaftFutureWatchdog.
+const DefaultRaftFutureWatchdog = time.Minute
+
+// watchdogTimeoutMargin is how much longer than an operation's timeout the
+// watchdog waits so that Raft has a chance to fail the operation itself.
+const watchdogTimeoutMargin = 5 * time.Second
+
+// StuckOperation describes a Raft membership change which did not resolve
+// within the watchdog duration.
+type StuckOperation struct {
+	// Operation is the Raft operation that was issued, such as "AddVoter".
+	Operation string
+
+	// ServerID is the server the operation was issued for.
+	ServerID raft.ServerID
+
+	// Since is when the operation was issued.
+	Since time.Time
+}
+
+// stuckFuture is the internal record of a stuck operation.
+type stuckFuture struct {
+	op StuckOperation
+
+	// leader is the leader when the operation was issued. A change of leader
+	// means the operation can no longer complete.
+	leader raft.ServerID
+}
+
+// futureWatchdog tracks Raft membership changes which have not resolved.
+type futureWatchdog struct {
+	lock sync.Mutex
+
+	// timeout is how long to wait for a future before considering it stuck.
+	// Zero disables the watchdog and futures will be waited on indefinitely.
+	timeout time.Duration
+
+	stuck *stuckFuture
+}
+
+// current returns the stuck operation, if any.
+func (w *futureWatchdog) current() *stuckFuture {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.stuck
+}
+
+// record sets the stuck operation.
+func (w *futureWatchdog) record(stuck *stuckFuture) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.stuck = stuck
+}
+
+// clear removes the given stuck operation and returns whether it was still
+// recorded.
+func (w *futureWatchdog) clear(stuck *stuckFuture) bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.stuck != stuck {
+		return false
+	}
+	w.stuck = nil
+	return true
+}
+
+// StuckOperation returns the Raft membership change which has not resolved
+// within the watchdog duration or nil if there is none. While an operation is
+// stuck autopilot will not issue further membership changes.
+func (a *Autopilot) StuckOperation() *StuckOperation {
+	stuck := a.watchdog.current()
+	if stuck == nil {
+		return nil
+	}
+
+	op := stuck.op
+	return &op
+}
+
+// membershipChangeStuck returns whether a previous membership change is still
+// stuck. Stuck operations are forgotten once the leader changes as they can no
+// longer complete.
+func (a *Autopilot) membershipChangeStuck() bool {
+	stuck := a.watchdog.current()
+	if stuck == nil {
+		return false
+	}
+
+	if state := a.GetState(); state != nil && state.Leader != stuck.leader {
+		if a.watchdog.clear(stuck) {
+			a.logger.Info("forgetting stuck raft operation after a leadership change",
+				"operation", stuck.op.Operation,
+				"id", stuck.op.ServerID,
+			)
+			a.changes.abandon()
+		}
+		return false
+	}
+
+	return true
+}
+
+// waitMembershipChange issues a Raft membership change and waits for it to
+// resolve. If it does not resolve within the watchdog duration the operation
+// is recorded as stuck, an event is emitted and an error returned. No further
+// membership changes will be issued until the stuck future resolves or the
+// leader changes. Cancelling the context stops waiting for the future, without
+// considering it stuck, so that a shutting down application is not blocked. A
+// change still queued behind others when the context is cancelled is never
+// issued.
+// The watchdog waits at least a margin beyond the operation's timeout, given
+// to Raft when issuing it, so that Raft fails timed out operations first.
+// Changes are made by the change executor, one at a time, so the time waited
+// includes any time the change spent queued behind others.
+func (a *Autopilot) waitMembershipChange(ctx context.Context, op string, id raft.ServerID, timeout time.Duration, issue func() raft.Future) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("not issuing %s for server %s: %w", op, id, err)
+	}
+
+	if a.membershipChangeStuck() {
+		stuck := a.watchdog.current()
+		return fmt.Errorf("not issuing %s for server %s while %s for server %s is unresolved",
+			op, id, stuck.op.Operation, stuck.op.ServerID)
+	}
+
+	deadline := a.watchdog.timeout
+	if deadline > 0 && timeout > 0 && deadline < timeout+watchdogTimeoutMargin {
+		deadline = timeout + watchdogTimeoutMargin
+	}
+
+	// the change is made by the executor so that it is never interleaved
+	// with any other
+	errCh, err := a.changes.submit(ctx, a.metricsSink(), a.now, op, id, issue)
+	if err != nil {
+		return err
+	}
+
+	if deadline <= 0 && ctx.Done() == nil {
+		return <-errCh
+	}
+
+	since := a.now()
+
+	// a nil channel never fires, leaving only the context when the watchdog
+	// is disabled
+	var expired <-chan time.Time
+	if deadline > 0 {
+		timer := time.NewTimer(deadline)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		a.roundLogger().Warn("stopped waiting for raft operation to resolve", "operation", op, "id", id, "error", ctx.Err())
+		return fmt.Errorf("stopped waiting for %s for server %s: %w", op, id, ctx.Err())
+	case <-expired:
+	}
+
+	stuck := &stuckFuture{
+		op: StuckOperation{
+			Operation: op,
+			ServerID:  id,
+			Since:     since,
+		},
+	}
+	if state := a.GetState(); state != nil {
+		stuck.leader = state.Leader
+	}
+	a.watchdog.record(stuck)
+
+	a.roundLogger().Error("raft operation has not resolved, holding further membership changes",
+		"operation", op,
+		"id", id,
+		"waited", deadline,
+	)
+	a.emitEvent(EventRaftOperationStuck, id,
+		fmt.Sprintf("%s has not resolved after %s, no further membership changes will be made until it does", op, deadline))
+
+	go func() {
+		err := <-errCh
+		if a.watchdog.clear(stuck) {
+			a.logger.Info("stuck raft operation resolved", "operation", op, "id", id, "error", err)
+			a.emitEvent(EventRaftOperationResolved, id, fmt.Sprintf("%s has resolved", op))
+		}
+	}()
+
+	return fmt.Errorf("%s for server %s did not resolve within %s", op, id, deadline)
+}
@@ -0,0 +1,172 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package autopilot
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingFuture is a raft future whose Error method blocks until release is
+// closed.
+type blockingFuture struct {
+	release chan struct{}
+}
+
+func (f *blockingFuture) Index() uint64 {
+	return 0
+}
+
+func (f *blockingFuture) Error() error {
+	<-f.release
+	return nil
+}
+
+func TestRaftFutureWatchdog(t *testing.T) {
+	var lock sync.Mutex
+	var events []EventType
+
+	mraft := NewMockRaft(t)
+	a := &Autopilot{
+		logger:   hclog.NewNullLogger(),
+		raft:     mraft,
+		state:    &State{Leader: "a"},
+		watchdog: futureWatchdog{timeout: 10 * time.Millisecond},
+		eventHandlers: []EventHandler{func(e Event) {
+			lock.Lock()
+			defer lock.Unlock()
+			events = append(events, e.Type)
+		}},
+	}
+
+	future := &blockingFuture{release: make(chan struct{})}
+	mraft.On("DemoteVoter", raft.ServerID("b"), uint64(0), time.Duration(0)).Return(future).Once()
+
+	require.Error(t, a.demoteVoter(context.Background(), "b"))
+	stuck := a.StuckOperation()
+	require.NotNil(t, stuck)
+	require.Equal(t, "DemoteVoter", stuck.Operation)
+	require.Equal(t, raft.ServerID("b"), stuck.ServerID)
+
+	// further membership changes are not issued to raft
+	require.Error(t, a.addVoter(context.Background(), "c", "198.18.0.3:8300"))
+
+	close(future.release)
+	require.Eventually(t, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(events) == 2
+	}, time.Second, 5*time.Millisecond)
+
+	require.Nil(t, a.StuckOperation())
+	require.Equal(t, []EventType{EventRaftOperationStuck, EventRaftOperationResolved}, events)
+}
+
+func TestRaftFutureWatchdogLeaderChange(t *testing.T) {
+	mraft := NewMockRaft(t)
+	a := &Autopilot{
+		logger:   hclog.NewNullLogger(),
+		raft:     mraft,
+		state:    &State{Leader: "a"},
+		watchdog: futureWatchdog{timeout: 10 * time.Millisecond},
+	}
+
+	future := &blockingFuture{release: make(chan struct{})}
+	defer close(future.release)
+	mraft.On("RemoveServer", raft.ServerID("b"), uint64(0), time.Duration(0)).Return(future).Once()
+
+	require.Error(t, a.removeServer(context.Background(), "b"))
+	require.True(t, a.membershipChangeStuck())
+
+	// a new leader means the stuck operation can no longer complete
+	a.state = &State{Leader: "c"}
+	require.False(t, a.membershipChangeStuck())
+	require.Nil(t, a.StuckOperation())
+
+	mraft.On("RemoveServer", raft.ServerID("d"), uint64(0), time.Duration(0)).Return(&raftIndexFuture{}).Once()
+	require.NoError(t, a.removeServer(context.Background(), "d"))
+}
+
+func TestRaftOperationCancellation(t *testing.T) {
+	mraft := NewMockRaft(t)
+	a := &Autopilot{
+		logger: hclog.NewNullLogger(),
+		raft:   mraft,
+		state:  &State{Leader: "a"},
+	}
+
+	// nothing is issued once the context has been cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(t, a.addVoter(ctx, "b", "198.18.0.2:8300"), context.Canceled)
+	require.ErrorIs(t, a.leadershipTransfer(ctx, "b", "198.18.0.2:8300"), context.Canceled)
+
+	// cancelling the context stops waiting on futures even without the
+	// watchdog and without the operation being considered stuck
+	future := &blockingFuture{release: make(chan struct{})}
+	defer close(future.release)
+	mraft.On("DemoteVoter", raft.ServerID("b"), uint64(0), time.Duration(0)).Return(future).Once()
+	mraft.On("LeadershipTransferToServer", raft.ServerID("c"), raft.ServerAddress("198.18.0.3:8300")).Return(future).Once()
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, a.demoteVoter(ctx, "b"), context.DeadlineExceeded)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, a.leadershipTransfer(ctx, "c", "198.18.0.3:8300"), context.DeadlineExceeded)
+	require.Nil(t, a.StuckOperation())
+}
+
+func TestRaftOperationTimeouts(t *testing.T) {
+	mraft := NewMockRaft(t)
+	a := &Autopilot{
+		logger:   hclog.NewNullLogger(),
+		raft:     mraft,
+		state:    &State{Leader: "a"},
+		watchdog: futureWatchdog{timeout: 10 * time.Millisecond},
+	}
+	WithRaftOperationTimeouts(RaftOperationTimeouts{
+		AddVoter:           time.Second,
+		DemoteVoter:        2 * time.Second,
+		RemoveServer:       3 * time.Second,
+		LeadershipTransfer: 10 * time.Millisecond,
+	})(a)
+
+	ctx := context.Background()
+	mraft.On("AddVoter", raft.ServerID("b"), raft.ServerAddress("198.18.0.2:8300"), uint64(0), time.Second).Return(&raftIndexFuture{}).Once()
+	mraft.On("AddNonvoter", raft.ServerID("c"), raft.ServerAddress("198.18.0.3:8300"), uint64(0), time.Second).Return(&raftIndexFuture{}).Once()
+	mraft.On("RemoveServer", raft.ServerID("d"), uint64(0), 3*time.Second).Return(&raftIndexFuture{}).Once()
+	require.NoError(t, a.addVoter(ctx, "b", "198.18.0.2:8300"))
+	require.NoError(t, a.addNonVoter(ctx, "c", "198.18.0.3:8300"))
+	require.NoError(t, a.removeServer(ctx, "d"))
+
+	// the watchdog waits beyond the operation's timeout rather than its own
+	future := &blockingFuture{release: make(chan struct{})}
+	mraft.On("DemoteVoter", raft.ServerID("b"), uint64(0), 2*time.Second).Return(future).Once()
+	time.AfterFunc(50*time.Millisecond, func() { close(future.release) })
+	require.NoError(t, a.demoteVoter(ctx, "b"))
+	require.Nil(t, a.StuckOperation())
+
+	// raft does not time out leadership transfers so autopilot stops waiting
+	blocked := &blockingFuture{release: make(chan struct{})}
+	defer close(blocked.release)
+	mraft.On("LeadershipTransferToServer", raft.ServerID("b"), raft.ServerAddress("198.18.0.2:8300")).Return(blocked).Once()
+	require.ErrorIs(t, a.leadershipTransfer(ctx, "b", "198.18.0.2:8300"), context.DeadlineExceeded)
+}
+
+func TestRaftFutureWatchdogOption(t *testing.T) {
+	// futures are waited on indefinitely unless configured
+	a := New(nil, nil)
+	require.Zero(t, a.watchdog.timeout)
+
+	a = New(nil, nil, WithRaftFutureWatchdog(DefaultRaftFutureWatchdog))
+	require.Equal(t, DefaultRaftFutureWatchdog, a.watchdog.timeout)
+}